@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reply 指令回覆；平台支援時以卡片與按鈕呈現，不支援時使用 PlainText
+type Reply struct {
+	Text  string
+	Cards []Card
+	Rows  []Row
+	// Files 附加檔案（例如圖表）；不支援附件的平台只顯示文字與卡片
+	Files []File
+	// Ephemeral 只讓發出指令的人看到（Discord），其他平台照常回覆
+	Ephemeral bool
+	// Update 按鈕動作的回覆：取代按鈕所在的訊息，而不是另外發一則
+	Update bool
+}
+
+// Card 卡片（Discord embed）
+type Card struct {
+	Title       string
+	Description string
+	Color       int
+	Fields      []Field
+	Footer      string
+	// Image 卡片內的圖片，引用附件時使用 "attachment://檔名"
+	Image string
+}
+
+// File 回覆附帶的檔案
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Row 一列按鈕或一個選單
+type Row struct {
+	Buttons []Button
+	Menu    *Menu
+}
+
+// Button 按下後以 Data 呼叫 Router.RunAction
+type Button struct {
+	Label    string
+	Emoji    string
+	Data     string
+	Primary  bool
+	Disabled bool
+}
+
+// Menu 選單，選擇後以 Data + ":" + Option.Value 呼叫 Router.RunAction
+type Menu struct {
+	Data        string
+	Placeholder string
+	Options     []Option
+}
+
+type Option struct {
+	Label       string
+	Description string
+	Value       string
+	Emoji       string
+}
+
+// Text 純文字回覆
+func Text(msg string) Reply {
+	return Reply{Text: msg}
+}
+
+// Textf 以格式字串產生純文字回覆
+func Textf(format string, a ...interface{}) Reply {
+	return Reply{Text: fmt.Sprintf(format, a...)}
+}
+
+// Empty 沒有任何內容（例如過期或格式錯誤的按鈕），平台不需要回覆
+func (r Reply) Empty() bool {
+	return r.Text == "" && len(r.Cards) == 0 && len(r.Files) == 0
+}
+
+// PlainText 將文字與卡片合併成 Markdown 文字，供不支援卡片的平台使用
+func (r Reply) PlainText() string {
+	parts := make([]string, 0, len(r.Cards)+1)
+	if r.Text != "" {
+		parts = append(parts, r.Text)
+	}
+	for _, card := range r.Cards {
+		var b strings.Builder
+		if card.Title != "" {
+			b.WriteString("**" + card.Title + "**\n")
+		}
+		if card.Description != "" {
+			b.WriteString(card.Description + "\n")
+		}
+		for _, f := range card.Fields {
+			b.WriteString("\n**" + f.Name + "**\n" + f.Value + "\n")
+		}
+		if card.Footer != "" {
+			b.WriteString("\n" + card.Footer)
+		}
+		parts = append(parts, strings.TrimRight(b.String(), "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
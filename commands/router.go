@@ -0,0 +1,355 @@
+// Package commands 聊天機器人的指令路由，與 Discord、Telegram 等平台無關
+//
+// 指令只需註冊一次（名稱、別名、參數定義、說明與處理函式），各平台的轉接層負責
+// 把斜線指令或文字訊息轉成 Context、呼叫 Router，再把 Reply 轉成平台的訊息格式。
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ArgType 參數型別
+type ArgType int
+
+const (
+	String ArgType = iota
+	Integer
+	Number
+	Boolean
+)
+
+// Arg 指令參數定義
+type Arg struct {
+	Name        string
+	Description string
+	Type        ArgType
+	Required    bool
+	Choices     []string // 固定選項（不分大小寫）
+	Min         *float64 // Integer、Number 的範圍，nil 表示不限
+	Max         *float64
+	// Autocomplete 由平台提供自動完成（例如機場代碼），不可與 Choices 同時使用
+	Autocomplete bool
+	// Rest 文字指令中收集剩下的所有字（例如多字地名），只能是最後一個位置參數
+	Rest bool
+}
+
+// Bound 產生 Arg.Min / Arg.Max 使用的指標
+func Bound(v float64) *float64 {
+	return &v
+}
+
+// Args 已解析的參數，值一律以字串保存，取值時再轉型
+type Args map[string]string
+
+func (a Args) String(name string) string {
+	return a[name]
+}
+
+func (a Args) Int(name string) int {
+	n, _ := strconv.Atoi(a[name])
+	return n
+}
+
+func (a Args) Float(name string) float64 {
+	f, _ := strconv.ParseFloat(a[name], 64)
+	return f
+}
+
+func (a Args) Bool(name string) bool {
+	b, _ := strconv.ParseBool(a[name])
+	return b
+}
+
+// Context 一次指令呼叫的資訊
+type Context struct {
+	Platform string // discord、telegram ...
+	Prefix   string // 顯示用法時的指令前綴，例如 "/" 或 "!"
+	// BotName 機器人的帳號名稱（Telegram 的 username），指令帶 @ 時必須與它相符
+	BotName   string
+	Command   string
+	Args      Args
+	UserID    string
+	ChannelID string
+	// GuildID 伺服器 ID，私訊或不分伺服器的平台為空
+	GuildID string
+	// Admin 可以變更伺服器層級的設定（Discord 的「管理伺服器」權限）
+	Admin bool
+}
+
+// Handler 指令處理函式
+type Handler func(ctx *Context) Reply
+
+// ActionHandler 按鈕或選單的處理函式，args 為 Data 以 ":" 分隔後去掉前綴的部分
+type ActionHandler func(ctx *Context, args []string) Reply
+
+// Command 指令定義
+type Command struct {
+	Name        string
+	Aliases     []string
+	Emoji       string
+	Description string
+	Args        []Arg
+	Example     string // 文字指令範例（不含指令名稱），例如 "TPE NRT 2026-03-01"
+	// Slow 需要查詢外部服務，平台應先回覆「處理中」（例如 Discord 的延遲回覆）
+	Slow bool
+	// ParseText 自訂文字指令的參數解析，nil 時依 Args 順序對應位置參數並接受 name=value
+	ParseText func(words []string) (Args, error)
+	Handler   Handler
+}
+
+// Usage 例如 "/price <origin> <destination> <date>"，選填參數以 [] 表示
+func (c *Command) Usage(prefix string) string {
+	var b strings.Builder
+	b.WriteString(prefix + c.Name)
+	for _, arg := range c.Args {
+		if arg.Required {
+			b.WriteString(" <" + arg.Name + ">")
+		} else {
+			b.WriteString(" [" + arg.Name + "]")
+		}
+	}
+	return b.String()
+}
+
+// ParseWords 將文字指令的參數轉成 Args
+func (c *Command) ParseWords(words []string) (Args, error) {
+	if c.ParseText != nil {
+		return c.ParseText(words)
+	}
+
+	args := make(Args)
+	known := make(map[string]bool, len(c.Args))
+	for _, arg := range c.Args {
+		known[arg.Name] = true
+	}
+
+	next := 0
+	for i, word := range words {
+		if key, value, ok := strings.Cut(word, "="); ok && known[strings.ToLower(key)] {
+			args[strings.ToLower(key)] = value
+			continue
+		}
+		// 跳過已用 name=value 指定的參數
+		for next < len(c.Args) && args[c.Args[next].Name] != "" {
+			next++
+		}
+		if next >= len(c.Args) {
+			return nil, Errorf("router.too_many", "參數太多：%s", word)
+		}
+		arg := c.Args[next]
+		if arg.Rest {
+			args[arg.Name] = strings.Join(words[i:], " ")
+			return args, nil
+		}
+		args[arg.Name] = word
+		next++
+	}
+	return args, nil
+}
+
+// Validate 檢查必填、型別、固定選項與範圍，並將固定選項統一成定義的大小寫
+func (c *Command) Validate(args Args) error {
+	for _, arg := range c.Args {
+		value := strings.TrimSpace(args[arg.Name])
+		if value == "" {
+			if arg.Required {
+				return Errorf("router.missing", "缺少參數 %[1]s（%[2]s）", arg.Name, arg.Description)
+			}
+			delete(args, arg.Name)
+			continue
+		}
+
+		switch arg.Type {
+		case Integer, Number:
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil || (arg.Type == Integer && n != float64(int64(n))) {
+				return Errorf("router.not_number", "%s 必須是數字", arg.Name)
+			}
+			if (arg.Min != nil && n < *arg.Min) || (arg.Max != nil && n > *arg.Max) {
+				return rangeError(arg)
+			}
+		case Boolean:
+			if _, err := strconv.ParseBool(value); err != nil {
+				return Errorf("router.not_bool", "%s 必須是 true 或 false", arg.Name)
+			}
+		}
+
+		if len(arg.Choices) > 0 {
+			matched := ""
+			for _, choice := range arg.Choices {
+				if strings.EqualFold(choice, value) {
+					matched = choice
+				}
+			}
+			if matched == "" {
+				return Errorf("router.choices", "%s 只能是 %s", arg.Name, strings.Join(arg.Choices, "、"))
+			}
+			value = matched
+		}
+		args[arg.Name] = value
+	}
+	return nil
+}
+
+func rangeError(arg Arg) error {
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	switch {
+	case arg.Min != nil && arg.Max != nil:
+		return Errorf("router.range", "%s 超出範圍（%s 到 %s）", arg.Name, format(*arg.Min), format(*arg.Max))
+	case arg.Min != nil:
+		return Errorf("router.range_min", "%s 超出範圍（最小 %s）", arg.Name, format(*arg.Min))
+	}
+	return Errorf("router.range_max", "%s 超出範圍（最大 %s）", arg.Name, format(*arg.Max))
+}
+
+// Router 指令與按鈕動作的註冊表
+type Router struct {
+	// Translate 翻譯路由本身的提示（用法、參數錯誤、說明）；指令說明的 key 為 "help.<指令名稱>"
+	Translate Translator
+
+	commands []*Command
+	byName   map[string]*Command
+	actions  map[string]ActionHandler
+}
+
+func NewRouter() *Router {
+	return &Router{
+		byName:  make(map[string]*Command),
+		actions: make(map[string]ActionHandler),
+	}
+}
+
+// Register 註冊指令；與 http.ServeMux 相同，名稱或別名重複屬於程式錯誤，直接 panic
+func (r *Router) Register(cmd *Command) {
+	if cmd.Name == "" || cmd.Handler == nil {
+		panic("commands: 指令需要名稱與處理函式")
+	}
+	rest := false
+	for _, arg := range cmd.Args {
+		if rest {
+			panic(fmt.Sprintf("commands: 指令 %s 的 Rest 參數必須是最後一個", cmd.Name))
+		}
+		rest = arg.Rest
+	}
+	for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+		name = strings.ToLower(name)
+		if _, exists := r.byName[name]; exists {
+			panic(fmt.Sprintf("commands: 指令名稱重複: %s", name))
+		}
+		r.byName[name] = cmd
+	}
+	r.commands = append(r.commands, cmd)
+}
+
+// Handle 註冊按鈕/選單動作，prefix 為 Data 的第一段
+func (r *Router) Handle(prefix string, handler ActionHandler) {
+	if _, exists := r.actions[prefix]; exists {
+		panic(fmt.Sprintf("commands: 動作重複: %s", prefix))
+	}
+	r.actions[prefix] = handler
+}
+
+// Commands 依註冊順序回傳所有指令
+func (r *Router) Commands() []*Command {
+	return r.commands
+}
+
+// Lookup 依名稱或別名找指令（不分大小寫）
+func (r *Router) Lookup(name string) *Command {
+	return r.byName[strings.ToLower(name)]
+}
+
+// Run 驗證參數並執行指令；找不到指令或參數錯誤時回傳提示訊息
+func (r *Router) Run(ctx *Context) Reply {
+	cmd := r.Lookup(ctx.Command)
+	if cmd == nil {
+		return Reply{Text: r.text(ctx, "router.unknown", "❓ 不認識的指令 `%[1]s%[2]s`，輸入 `%[1]shelp` 查看所有指令", ctx.Prefix, ctx.Command), Ephemeral: true}
+	}
+	if ctx.Args == nil {
+		ctx.Args = make(Args)
+	}
+	if err := cmd.Validate(ctx.Args); err != nil {
+		return r.usageError(ctx, cmd, err)
+	}
+	ctx.Command = cmd.Name
+	return cmd.Handler(ctx)
+}
+
+// RunText 解析並執行一則文字訊息，例如 "!price TPE NRT 2026-03-01"
+// 不是以 prefix 開頭或找不到指令時 ok 為 false，呼叫端應忽略這則訊息
+func (r *Router) RunText(ctx *Context, text string) (reply Reply, ok bool) {
+	cmd, words, ok := r.Match(text, ctx.Prefix, ctx.BotName)
+	if !ok {
+		return Reply{}, false
+	}
+	args, err := cmd.ParseWords(words)
+	if err != nil {
+		return r.usageError(ctx, cmd, err), true
+	}
+	ctx.Command = cmd.Name
+	ctx.Args = args
+	return r.Run(ctx), true
+}
+
+// Match 找出文字訊息對應的指令；Telegram 群組中的 "/price@MyBot" 只有 @ 後面是 botName（不分大小寫）才會回應，
+// 指名其他機器人或 botName 未知時視為不是給這個機器人的指令
+func (r *Router) Match(text, prefix, botName string) (*Command, []string, bool) {
+	words := strings.Fields(text)
+	if len(words) == 0 || !strings.HasPrefix(words[0], prefix) {
+		return nil, nil, false
+	}
+	name, mention, addressed := strings.Cut(strings.TrimPrefix(words[0], prefix), "@")
+	if addressed && (botName == "" || !strings.EqualFold(mention, botName)) {
+		return nil, nil, false
+	}
+	cmd := r.Lookup(name)
+	if cmd == nil {
+		return nil, nil, false
+	}
+	return cmd, words[1:], true
+}
+
+// RunAction 執行按鈕/選單動作，data 例如 "price:page:abc:1:duration"
+func (r *Router) RunAction(ctx *Context, data string) (Reply, bool) {
+	parts := strings.Split(data, ":")
+	handler, ok := r.actions[parts[0]]
+	if !ok {
+		return Reply{}, false
+	}
+	return handler(ctx, parts[1:]), true
+}
+
+func (r *Router) usageError(ctx *Context, cmd *Command, err error) Reply {
+	msg := "⚠️ " + r.errorText(ctx, err) + "\n" + r.text(ctx, "router.usage", "用法：`%s`", cmd.Usage(ctx.Prefix))
+	if cmd.Example != "" {
+		msg += "\n" + r.example(ctx, cmd)
+	}
+	return Reply{Text: msg, Ephemeral: true}
+}
+
+func (r *Router) example(ctx *Context, cmd *Command) string {
+	return r.text(ctx, "router.example", "範例：`%s`", ctx.Prefix+cmd.Name+" "+cmd.Example)
+}
+
+// Help 依註冊的指令產生說明，使用 ctx 的指令前綴與 Translate 的語系
+func (r *Router) Help(ctx *Context, title string) string {
+	prefix := ctx.Prefix
+	var b strings.Builder
+	b.WriteString(title)
+	for _, cmd := range r.commands {
+		description := r.text(ctx, "help."+cmd.Name, cmd.Description)
+		b.WriteString(fmt.Sprintf("\n\n%s **%s**\n`%s`", cmd.Emoji, description, cmd.Usage(prefix)))
+		if len(cmd.Aliases) > 0 {
+			aliases := append([]string(nil), cmd.Aliases...)
+			sort.Strings(aliases)
+			b.WriteString(r.text(ctx, "router.aliases", "（也可用 %s）", prefix+strings.Join(aliases, "、"+prefix)))
+		}
+		if cmd.Example != "" {
+			b.WriteString("\n" + r.example(ctx, cmd))
+		}
+	}
+	return b.String()
+}
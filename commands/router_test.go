@@ -0,0 +1,229 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func testRouter() *Router {
+	r := NewRouter()
+	r.Register(&Command{
+		Name:        "echo",
+		Aliases:     []string{"say"},
+		Emoji:       "🔁",
+		Description: "重複輸入的文字",
+		Args: []Arg{
+			{Name: "times", Description: "次數", Type: Integer, Required: true, Min: Bound(1), Max: Bound(3)},
+			{Name: "mode", Description: "大小寫", Choices: []string{"upper", "lower"}},
+			{Name: "text", Description: "文字", Required: true, Rest: true},
+		},
+		Example: "2 hello world",
+		Handler: func(ctx *Context) Reply {
+			text := ctx.Args.String("text")
+			if ctx.Args.String("mode") == "upper" {
+				text = strings.ToUpper(text)
+			}
+			return Text(strings.Repeat(text+";", ctx.Args.Int("times")))
+		},
+	})
+	r.Handle("echo", func(ctx *Context, args []string) Reply {
+		return Reply{Text: strings.Join(args, ","), Update: true}
+	})
+	return r
+}
+
+func TestCommand_ParseWords(t *testing.T) {
+	cmd := testRouter().Lookup("echo")
+	tests := []struct {
+		name    string
+		words   []string
+		want    Args
+		wantErr bool
+	}{
+		{name: "位置參數與多字的 Rest", words: []string{"2", "hello", "world"}, want: Args{"times": "2", "mode": "hello", "text": "world"}},
+		{name: "name=value 不受順序限制", words: []string{"mode=upper", "2", "hello", "world"}, want: Args{"times": "2", "mode": "upper", "text": "hello world"}},
+		{name: "全部具名", words: []string{"text=hi", "times=1"}, want: Args{"times": "1", "text": "hi"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := cmd.ParseWords(tt.words)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(args) != len(tt.want) {
+				t.Fatalf("參數 = %v, 預期 %v", args, tt.want)
+			}
+			for k, v := range tt.want {
+				if args[k] != v {
+					t.Errorf("%s = %q, 預期 %q", k, args[k], v)
+				}
+			}
+		})
+	}
+
+	noRest := &Command{Name: "pair", Args: []Arg{{Name: "a"}, {Name: "b"}}}
+	if _, err := noRest.ParseWords([]string{"1", "2", "3"}); err == nil {
+		t.Error("參數太多應回傳錯誤")
+	}
+}
+
+func TestCommand_Validate(t *testing.T) {
+	cmd := testRouter().Lookup("echo")
+	tests := []struct {
+		name    string
+		args    Args
+		wantErr string
+	}{
+		{name: "合法", args: Args{"times": "2", "text": "hi", "mode": "UPPER"}},
+		{name: "缺少必填", args: Args{"text": "hi"}, wantErr: "缺少參數 times"},
+		{name: "不是整數", args: Args{"times": "1.5", "text": "hi"}, wantErr: "times 必須是數字"},
+		{name: "超出範圍", args: Args{"times": "9", "text": "hi"}, wantErr: "（1 到 3）"},
+		{name: "不在選項內", args: Args{"times": "1", "text": "hi", "mode": "title"}, wantErr: "mode 只能是 upper、lower"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cmd.Validate(tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("不應有錯誤: %v", err)
+				}
+				if tt.args["mode"] != "upper" {
+					t.Errorf("固定選項應統一成定義的大小寫: %q", tt.args["mode"])
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("錯誤 = %v, 預期包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRouter_RunText(t *testing.T) {
+	r := testRouter()
+	ctx := func() *Context { return &Context{Platform: "test", Prefix: "/", BotName: "TravelBot"} }
+
+	tests := []struct {
+		text          string
+		wantOK        bool
+		wantText      string
+		wantEphemeral bool
+	}{
+		{text: "/echo 2 upper hi there", wantOK: true, wantText: "HI THERE;HI THERE;"},
+		{text: "/SAY 1 lower hi", wantOK: true, wantText: "hi;"},
+		{text: "/echo@TravelBot 1 lower hi", wantOK: true, wantText: "hi;"},
+		{text: "/echo@travelbot 1 lower hi", wantOK: true, wantText: "hi;"},
+		{text: "/echo@OtherBot 1 lower hi", wantOK: false},
+		{text: "/echo 5 lower hi", wantOK: true, wantText: "用法：`/echo <times> [mode] <text>`", wantEphemeral: true},
+		{text: "/unknown", wantOK: false},
+		{text: "echo 1 lower hi", wantOK: false},
+		{text: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		reply, ok := r.RunText(ctx(), tt.text)
+		if ok != tt.wantOK || !strings.Contains(reply.Text, tt.wantText) || reply.Ephemeral != tt.wantEphemeral {
+			t.Errorf("RunText(%q) = %+v, %v", tt.text, reply, ok)
+		}
+	}
+
+	if reply := r.Run(&Context{Prefix: "!", Command: "nope"}); !reply.Ephemeral || !strings.Contains(reply.Text, "`!help`") {
+		t.Errorf("不認識的指令應提示 help: %+v", reply)
+	}
+}
+
+func TestRouter_RunAction(t *testing.T) {
+	r := testRouter()
+	reply, ok := r.RunAction(&Context{}, "echo:page:abc:2")
+	if !ok || reply.Text != "page,abc,2" || !reply.Update {
+		t.Errorf("動作參數應去掉前綴後傳入: %+v %v", reply, ok)
+	}
+	if _, ok := r.RunAction(&Context{}, "other:page"); ok {
+		t.Error("未註冊的動作應回傳 false")
+	}
+}
+
+func TestRouter_Help(t *testing.T) {
+	help := testRouter().Help(&Context{Prefix: "!"}, "標題")
+	for _, want := range []string{"標題", "🔁 **重複輸入的文字**", "`!echo <times> [mode] <text>`", "（也可用 !say）", "範例：`!echo 2 hello world`"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("說明缺少 %q:\n%s", want, help)
+		}
+	}
+}
+
+func TestRouter_Translate(t *testing.T) {
+	r := testRouter()
+	r.Translate = func(ctx *Context, key string) string {
+		if ctx.UserID != "en" {
+			return ""
+		}
+		return map[string]string{
+			"router.missing": "Missing argument %[1]s",
+			"router.usage":   "Usage: `%s`",
+			"router.example": "Example: `%s`",
+			"router.aliases": " (also %s)",
+			"help.echo":      "Repeat the text",
+		}[key]
+	}
+
+	tests := []struct {
+		name string
+		user string
+		text string
+		want []string
+	}{
+		{"翻譯錯誤與用法", "en", "!echo", []string{"Missing argument times\nUsage: `!echo <times> [mode] <text>`", "Example: `!echo 2 hello world`"}},
+		{"沒有翻譯時使用中文", "zh", "!echo", []string{"缺少參數 times（次數）", "用法：", "範例："}},
+		{"沒有翻譯的 key 使用中文", "en", "!echo 9 hi", []string{"times 超出範圍（1 到 3）", "Usage:"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, _ := r.RunText(&Context{Prefix: "!", UserID: tt.user}, tt.text)
+			for _, want := range tt.want {
+				if !strings.Contains(reply.Text, want) {
+					t.Errorf("回覆缺少 %q: %q", want, reply.Text)
+				}
+			}
+		})
+	}
+
+	help := r.Help(&Context{Prefix: "!", UserID: "en"}, "Title")
+	for _, want := range []string{"🔁 **Repeat the text**", " (also !say)", "Example: `!echo 2 hello world`"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("說明缺少 %q:\n%s", want, help)
+		}
+	}
+}
+
+func TestRouter_RegisterPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  *Command
+	}{
+		{name: "名稱重複", cmd: &Command{Name: "echo", Handler: func(*Context) Reply { return Reply{} }}},
+		{name: "別名與既有指令重複", cmd: &Command{Name: "shout", Aliases: []string{"SAY"}, Handler: func(*Context) Reply { return Reply{} }}},
+		{name: "沒有處理函式", cmd: &Command{Name: "empty"}},
+		{name: "Rest 不是最後一個", cmd: &Command{Name: "bad", Args: []Arg{{Name: "a", Rest: true}, {Name: "b"}}, Handler: func(*Context) Reply { return Reply{} }}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("應 panic")
+				}
+			}()
+			testRouter().Register(tt.cmd)
+		})
+	}
+}
+
+func TestReply_PlainText(t *testing.T) {
+	reply := Reply{Text: "結果", Cards: []Card{{Title: "標題", Description: "說明", Fields: []Field{{Name: "欄位", Value: "值"}}, Footer: "頁尾"}}}
+	want := "結果\n\n**標題**\n說明\n\n**欄位**\n值\n\n頁尾"
+	if got := reply.PlainText(); got != want {
+		t.Errorf("PlainText() = %q, 預期 %q", got, want)
+	}
+	if !(Reply{}).Empty() || (Reply{Cards: []Card{{}}}).Empty() {
+		t.Error("Empty 判斷錯誤")
+	}
+}
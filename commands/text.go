@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Translator 依 Context（例如使用者設定的語系）取得 key 對應的格式字串，
+// 回傳空字串時使用呼叫端提供的中文
+type Translator func(ctx *Context, key string) string
+
+// Error 可翻譯的參數錯誤：Key 交給 Router.Translate 翻譯，Format 為沒有翻譯時使用的中文
+// 自訂的 ParseText 也可以回傳 Errorf 產生的錯誤，用法提示就會以使用者的語系顯示
+type Error struct {
+	Key    string
+	Format string
+	Args   []interface{}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf(e.Format, e.Args...)
+}
+
+// Errorf 建立可翻譯的錯誤
+func Errorf(key, format string, a ...interface{}) error {
+	return &Error{Key: key, Format: format, Args: a}
+}
+
+// text 取得翻譯後的文字，沒有設定 Translate 或沒有翻譯時使用 format
+func (r *Router) text(ctx *Context, key, format string, a ...interface{}) string {
+	if r.Translate != nil {
+		if translated := r.Translate(ctx, key); translated != "" {
+			format = translated
+		}
+	}
+	if len(a) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, a...)
+}
+
+// errorText 翻譯 Error；其他錯誤直接使用 Error()
+func (r *Router) errorText(ctx *Context, err error) string {
+	var e *Error
+	if !errors.As(err, &e) {
+		return err.Error()
+	}
+	return r.text(ctx, e.Key, e.Format, e.Args...)
+}
@@ -2,34 +2,60 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
 )
 
 type Config struct {
-	AmadeusAPIKey      string
-	AmadeusAPISecret   string
-	AmadeusBaseURL     string
-	WeatherAPIKey      string
-	ExchangeRateAPIKey string
-	FoursquareAPIKey   string
-	DiscordBotToken    string // [修改] 改用 Discord Token
-	ServerPort         string
-	Environment        string
-	LogLevel           string
+	AmadeusAPIKey       string
+	AmadeusAPISecret    string
+	AmadeusBaseURL      string
+	WeatherAPIKey       string
+	WeatherForecastDays int    // 天氣預報最多請求天數（WeatherAPI 上限 14）
+	AdviceRulesPath     string // 自訂旅行建議規則檔（JSON），空值使用內建規則
+	GeocoderUserAgent   string // Nominatim 要求可識別的 User-Agent（建議附聯絡方式）
+	ExchangeRateAPIKey  string
+	FoursquareAPIKey    string
+	DiscordBotToken     string // [修改] 改用 Discord Token
+	DiscordGuildID      string // 只在指定伺服器註冊斜線指令（開發用，立即生效）
+	DiscordTextCommands bool   // 是否接受 ! 開頭的文字指令（需要 MessageContent intent）
+	TelegramBotToken    string
+	// 通知：自訂訊息範本（JSON）與 SMTP 寄信設定，SMTP_HOST 為空時不啟用 Email
+	NotificationTemplatesPath string
+	SMTPHost                  string
+	SMTPPort                  int
+	SMTPUsername              string
+	SMTPPassword              string
+	SMTPFrom                  string
+	ServerPort                string
+	Environment               string
+	LogLevel                  string
 }
 
 func LoadConfig() *Config {
 	return &Config{
-		AmadeusAPIKey:      getEnv("AMADEUS_API_KEY", ""),
-		AmadeusAPISecret:   getEnv("AMADEUS_API_SECRET", ""),
-		AmadeusBaseURL:     getEnv("AMADEUS_BASE_URL", "https://test.api.amadeus.com/v2"),
-		WeatherAPIKey:      getEnv("WEATHER_API_KEY", ""),
-		ExchangeRateAPIKey: getEnv("EXCHANGE_RATE_API_KEY", ""),
-		FoursquareAPIKey:   getEnv("FOURSQUARE_API_KEY", ""),
-		DiscordBotToken:    getEnv("DISCORD_BOT_TOKEN", ""), // [修改] 讀取 Discord 環境變數
-		ServerPort:         getEnv("PORT", "8080"),
-		Environment:        getEnv("ENVIRONMENT", "development"),
-		LogLevel:           getEnv("LOG_LEVEL", "info"),
+		AmadeusAPIKey:             getEnv("AMADEUS_API_KEY", ""),
+		AmadeusAPISecret:          getEnv("AMADEUS_API_SECRET", ""),
+		AmadeusBaseURL:            getEnv("AMADEUS_BASE_URL", "https://test.api.amadeus.com/v2"),
+		WeatherAPIKey:             getEnv("WEATHER_API_KEY", ""),
+		WeatherForecastDays:       getEnvInt("WEATHER_FORECAST_DAYS", 14),
+		AdviceRulesPath:           getEnv("ADVICE_RULES_PATH", ""),
+		GeocoderUserAgent:         getEnv("GEOCODER_USER_AGENT", ""),
+		ExchangeRateAPIKey:        getEnv("EXCHANGE_RATE_API_KEY", ""),
+		FoursquareAPIKey:          getEnv("FOURSQUARE_API_KEY", ""),
+		DiscordBotToken:           getEnv("DISCORD_BOT_TOKEN", ""), // [修改] 讀取 Discord 環境變數
+		DiscordGuildID:            getEnv("DISCORD_GUILD_ID", ""),
+		DiscordTextCommands:       getEnvBool("DISCORD_TEXT_COMMANDS", true),
+		TelegramBotToken:          getEnv("TELEGRAM_BOT_TOKEN", ""),
+		NotificationTemplatesPath: getEnv("NOTIFICATION_TEMPLATES_PATH", ""),
+		SMTPHost:                  getEnv("SMTP_HOST", ""),
+		SMTPPort:                  getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:              getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:              getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:                  getEnv("SMTP_FROM", ""),
+		ServerPort:                getEnv("PORT", "8080"),
+		Environment:               getEnv("ENVIRONMENT", "development"),
+		LogLevel:                  getEnv("LOG_LEVEL", "info"),
 	}
 }
 
@@ -40,6 +66,20 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(getEnv(key, "")); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(getEnv(key, "")); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
 func (c *Config) Validate() error {
 	if c.AmadeusAPIKey == "" {
 		return &ConfigError{Field: "AMADEUS_API_KEY", Message: "Amadeus API Key 不能為空"}
@@ -91,3 +131,13 @@ func (c *Config) HasFoursquareAPI() bool {
 func (c *Config) HasDiscordAPI() bool {
 	return c.DiscordBotToken != ""
 }
+
+// 檢查是否啟用 Telegram Bot
+func (c *Config) HasTelegramAPI() bool {
+	return c.TelegramBotToken != ""
+}
+
+// 檢查是否可以寄送 Email 通知
+func (c *Config) HasSMTP() bool {
+	return c.SMTPHost != "" && c.SMTPFrom != ""
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Digest GET 查詢、POST 建立或更新使用者的摘要 Email 訂閱
+func (h *FlightHandler) Digest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	if h.digestService == nil {
+		writeErr(w, http.StatusServiceUnavailable, "摘要服務未啟用")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		userID := r.URL.Query().Get("user_id")
+		if userID == "" {
+			writeErr(w, http.StatusBadRequest, "缺少必要參數: user_id")
+			return
+		}
+		sub, err := h.digestService.Subscription(userID)
+		if err != nil {
+			writeErr(w, http.StatusNotFound, err.Error())
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data":    sub,
+		})
+		return
+	}
+
+	var req struct {
+		UserID    string `json:"user_id"`
+		Email     string `json:"email"`
+		Frequency string `json:"frequency"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "無效的請求數據")
+		return
+	}
+	if req.UserID == "" || req.Email == "" {
+		writeErr(w, http.StatusBadRequest, "缺少必要參數: user_id, email")
+		return
+	}
+
+	sub, err := h.digestService.Subscribe(req.UserID, req.Email, req.Frequency)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    sub,
+	})
+}
+
+func (h *FlightHandler) DeleteDigest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		writeErr(w, http.StatusBadRequest, "缺少必要參數: user_id")
+		return
+	}
+
+	if h.digestService == nil {
+		writeErr(w, http.StatusServiceUnavailable, "摘要服務未啟用")
+		return
+	}
+
+	if err := h.digestService.Unsubscribe(userID); err != nil {
+		writeErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    map[string]string{"user_id": userID},
+	})
+}
+
+// PreviewDigest 以 HTML 預覽摘要內容，不寄送也不更新上次價格
+func (h *FlightHandler) PreviewDigest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		writeErr(w, http.StatusBadRequest, "缺少必要參數: user_id")
+		return
+	}
+
+	if h.digestService == nil {
+		writeErr(w, http.StatusServiceUnavailable, "摘要服務未啟用")
+		return
+	}
+
+	sub, err := h.digestService.Subscription(userID)
+	if err != nil {
+		writeErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	digest, err := h.digestService.Build(*sub)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	_, html, _, err := h.digestService.Render(digest)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(html))
+}
+
+// SendDigest 立即寄送摘要（確認 SMTP 設定或手動補寄）
+func (h *FlightHandler) SendDigest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		writeErr(w, http.StatusBadRequest, "缺少必要參數: user_id")
+		return
+	}
+
+	if h.digestService == nil {
+		writeErr(w, http.StatusServiceUnavailable, "摘要服務未啟用")
+		return
+	}
+
+	digest, err := h.digestService.Send(userID)
+	if err != nil {
+		writeErr(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    digest,
+	})
+}
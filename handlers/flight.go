@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"final/models"
+	"final/notifications"
 	"final/services"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,6 +18,11 @@ type FlightHandler struct {
 	weatherService    *services.WeatherService
 	exchangeService   *services.ExchangeService
 	foursquareService *services.FoursquareService
+	adviceEngine      *services.AdviceEngine
+	geocodingService  *services.GeocodingService
+	notifier          *notifications.Dispatcher
+	webhooks          *notifications.WebhookHub
+	digestService     *services.DigestService
 }
 
 func NewFlightHandler(flightService *services.AmadeusService, weatherService *services.WeatherService, exchangeService *services.ExchangeService, foursquareService *services.FoursquareService) *FlightHandler {
@@ -26,6 +34,38 @@ func NewFlightHandler(flightService *services.AmadeusService, weatherService *se
 	}
 }
 
+// SetAdviceEngine 使用自訂的旅行建議規則
+func (h *FlightHandler) SetAdviceEngine(engine *services.AdviceEngine) {
+	h.adviceEngine = engine
+}
+
+// SetGeocodingService 設定共用的地理編碼服務
+func (h *FlightHandler) SetGeocodingService(geocoder *services.GeocodingService) {
+	h.geocodingService = geocoder
+}
+
+// SetNotifier 設定通知分派器，啟用通知偏好 API 與追蹤完成通知
+func (h *FlightHandler) SetNotifier(notifier *notifications.Dispatcher) {
+	h.notifier = notifier
+}
+
+// SetWebhookHub 設定 webhook 訂閱，啟用 /api/webhooks
+func (h *FlightHandler) SetWebhookHub(hub *notifications.WebhookHub) {
+	h.webhooks = hub
+}
+
+// SetDigestService 設定追蹤航線摘要 Email，啟用 /api/digest
+func (h *FlightHandler) SetDigestService(digest *services.DigestService) {
+	h.digestService = digest
+}
+
+func (h *FlightHandler) advice() *services.AdviceEngine {
+	if h.adviceEngine == nil {
+		return services.DefaultAdviceEngine()
+	}
+	return h.adviceEngine
+}
+
 func (h *FlightHandler) Index(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, "templates/index.html")
 }
@@ -39,6 +79,8 @@ func (h *FlightHandler) SearchFlights(w http.ResponseWriter, r *http.Request) {
 	returnDate := r.URL.Query().Get("return_date")
 	adultsStr := r.URL.Query().Get("adults")
 	currency := r.URL.Query().Get("currency")
+	displayCurrency := r.URL.Query().Get("display_currency")
+	lang := qStr(r, "lang", services.AdviceLangZH)
 
 	if origin == "" || destination == "" || departureDate == "" {
 		writeErr(w, http.StatusBadRequest, "缺少必要參數 (origin, destination, departure_date)")
@@ -53,14 +95,18 @@ func (h *FlightHandler) SearchFlights(w http.ResponseWriter, r *http.Request) {
 	if currency == "" {
 		currency = "TWD"
 	}
+	if displayCurrency == "" {
+		displayCurrency = currency
+	}
 
 	req := models.SearchRequest{
-		Origin:        origin,
-		Destination:   destination,
-		DepartureDate: departureDate,
-		ReturnDate:    returnDate,
-		Adults:        adults,
-		Currency:      currency,
+		Origin:          origin,
+		Destination:     destination,
+		DepartureDate:   departureDate,
+		ReturnDate:      returnDate,
+		Adults:          adults,
+		Currency:        currency,
+		DisplayCurrency: displayCurrency,
 	}
 
 	// 2. 呼叫 Amadeus Service
@@ -72,21 +118,33 @@ func (h *FlightHandler) SearchFlights(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 起降時段的天氣延誤風險
+	if h.weatherService != nil {
+		h.weatherService.AttachDisruptionRisk(flights)
+	}
+
 	// 3. 準備回應結構
-	response := models.FlightSearchResponseWithWeather{
+	response := models.FlightSearchResponseWithWeatherAndExchange{
 		Flights:     flights,
 		PriceAdvice: advice, // [新增] 將比價建議放入回應
-		Meta: struct {
-			Count         int    `json:"count"`
-			Origin        string `json:"origin"`
-			Destination   string `json:"destination"`
-			DepartureDate string `json:"departure_date"`
-		}{
-			Count:         len(flights),
-			Origin:        origin,
-			Destination:   destination,
-			DepartureDate: departureDate,
-		},
+	}
+	response.Meta.Count = len(flights)
+	response.Meta.Origin = origin
+	response.Meta.Destination = destination
+	response.Meta.DepartureDate = departureDate
+	response.Meta.Currency = displayCurrency
+
+	// 附上顯示貨幣對常用貨幣的匯率
+	if h.exchangeService != nil {
+		if rates, err := h.exchangeService.GetExchangeRates(displayCurrency, []string{"TWD", "USD", "EUR", "JPY", "KRW", "HKD"}); err == nil {
+			delete(rates.Rates, displayCurrency)
+			response.Exchange = &models.ExchangeRateInfo{
+				BaseCurrency: rates.BaseCurrency,
+				Rates:        rates.Rates,
+				LastUpdated:  rates.LastUpdated,
+				NextUpdate:   rates.NextUpdate,
+			}
+		}
 	}
 
 	// 4. (選填) 取得天氣資訊
@@ -107,6 +165,29 @@ func (h *FlightHandler) SearchFlights(w http.ResponseWriter, r *http.Request) {
 			weatherInfo.DestinationWeather = wData
 		}
 
+		// 出發日超出預報範圍時，附上歷年同月的氣候平均
+		if h.weatherService.IsBeyondForecast(departureDate) {
+			if normal, ok := services.GetClimateNormalForDate(originCity, departureDate); ok {
+				weatherInfo.OriginClimate = normal
+			}
+			if normal, ok := services.GetClimateNormalForDate(destCity, departureDate); ok {
+				weatherInfo.DestinationClimate = normal
+			}
+		}
+
+		// 目的地整段行程的天氣展望（單程只看出發當天）
+		if outlook, err := h.weatherService.GetTripOutlook(destCity, departureDate, returnDate); err == nil {
+			weatherInfo.DestinationOutlook = outlook
+		} else {
+			log.Printf("⚠️ 無法取得 %s 行程天氣展望: %v", destCity, err)
+		}
+
+		if weatherInfo.OriginWeather != nil && weatherInfo.DestinationWeather != nil {
+			facts := services.TripAdviceFacts(weatherInfo.OriginWeather, weatherInfo.DestinationWeather, weatherInfo.DestinationOutlook)
+			weatherInfo.AdviceItems = h.advice().Evaluate(services.AdviceScopeTrip, facts, lang)
+			weatherInfo.TravelAdvice = services.FormatAdvice(weatherInfo.AdviceItems, lang)
+		}
+
 		response.Weather = weatherInfo
 	}
 
@@ -180,41 +261,15 @@ func (h *FlightHandler) createWeatherSummary(weather *models.WeatherResponse, ci
 	return summary
 }
 
+// generateTravelAdvice 依建議規則產生旅行建議文字（規則見 services/data/advice_rules.json）
 func (h *FlightHandler) generateTravelAdvice(origin, destination *models.WeatherSummary) string {
 	if origin == nil || destination == nil {
 		return "天氣資訊不足，請確認航班資訊"
 	}
 
-	advice := "旅行建議："
-
-	if origin.ChanceOfRain > 50 {
-		advice += "出發地降雨機率高，建議提早出發並攜帶雨具。"
-	} else if origin.AvgTemp < 10 {
-		advice += "出發地氣溫較低，請注意保暖。"
-	} else if origin.AvgTemp > 30 {
-		advice += "出發地氣溫較高，建議穿著輕便。"
-	}
-
-	if destination.ChanceOfRain > 50 {
-		advice += " 目的地降雨機率高，建議準備室內活動方案。"
-	} else if destination.AvgTemp > 30 {
-		advice += " 目的地氣溫較高，請注意防曬和補充水分。"
-	} else if destination.AvgTemp < 5 {
-		advice += " 目的地氣溫很低，請準備厚重保暖衣物。"
-	} else if destination.AvgTemp >= 15 && destination.AvgTemp <= 25 {
-		advice += " 目的地天氣宜人，適合旅遊。"
-	} else {
-		advice += " 目的地天氣狀況良好。"
-	}
-
-	tempDiff := destination.AvgTemp - origin.AvgTemp
-	if tempDiff > 10 {
-		advice += " 目的地比出發地溫暖許多，建議準備夏季衣物。"
-	} else if tempDiff < -10 {
-		advice += " 目的地比出發地寒冷許多，請準備足夠的保暖衣物。"
-	}
-
-	return advice
+	facts := services.TripAdviceFacts(origin, destination, nil)
+	items := h.advice().Evaluate(services.AdviceScopeTrip, facts, services.AdviceLangZH)
+	return services.FormatAdvice(items, services.AdviceLangZH)
 }
 
 func (h *FlightHandler) TrackFlightPrices(w http.ResponseWriter, r *http.Request) {
@@ -254,6 +309,11 @@ func (h *FlightHandler) TrackFlightPrices(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// 依使用者的通知偏好送出追蹤結果，不影響回應
+	if userID := query.Get("notify_user"); userID != "" && h.notifier != nil {
+		go h.notifyTrackingComplete(userID, *analysis)
+	}
+
 	response := map[string]interface{}{
 		"success": true,
 		"data":    analysis,
@@ -323,16 +383,47 @@ func (h *FlightHandler) GetTrackingHistory(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	parts := strings.Split(strings.ToUpper(route), "-")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		writeErr(w, http.StatusBadRequest, "無效的航線格式，請使用 TPE-NRT")
+		return
+	}
+
+	departureDate := query.Get("departure_date")
+	currency := qStr(r, "currency", models.BaseCurrency)
+
+	records, err := h.amadeusService.GetSearchHistory(parts[0], parts[1], departureDate, currency)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
 		"data": map[string]interface{}{
-			"route":   route,
-			"tracks":  []string{},
-			"message": "歷史數據功能開發中",
+			"route":          route,
+			"departure_date": departureDate,
+			"currency":       currency,
+			"tracks":         records,
+			"count":          len(records),
 		},
 	})
 }
 
+// alertChannels 透過 HTTP 建立警報時可指定的通知管道：只開放頻道與聊天室，
+// 私訊與持續追蹤需透過 Bot 建立，由 Bot 確認使用者身分
+var alertChannels = map[string]bool{"discord": true, "telegram": true}
+
+// priceAlertRequest 網頁建立價格警報的欄位；owner 與 repeat 只由 Bot 設定，不接受 HTTP 傳入
+type priceAlertRequest struct {
+	Route         string       `json:"route"`
+	DepartureDate string       `json:"departure_date"`
+	TargetPrice   models.Money `json:"target_price"`
+	Currency      string       `json:"currency"`
+	Channel       string       `json:"channel"`
+	Target        string       `json:"target"`
+}
+
 func (h *FlightHandler) CreatePriceAlert(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -342,30 +433,43 @@ func (h *FlightHandler) CreatePriceAlert(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var alertReq struct {
-		Route       string  `json:"route"`
-		TargetPrice float64 `json:"target_price"`
-	}
-
+	var alertReq priceAlertRequest
 	if err := json.NewDecoder(r.Body).Decode(&alertReq); err != nil {
 		writeErr(w, http.StatusBadRequest, "無效的請求數據")
 		return
 	}
 
-	if alertReq.Route == "" || alertReq.TargetPrice <= 0 {
+	if alertReq.Route == "" || alertReq.TargetPrice.Sign() <= 0 {
 		writeErr(w, http.StatusBadRequest, "缺少必要參數: route, target_price")
 		return
 	}
+	if alertReq.Channel != "" && !alertChannels[alertReq.Channel] {
+		writeErr(w, http.StatusBadRequest, "不支援的通知管道: "+alertReq.Channel+" (僅支援 discord, telegram)")
+		return
+	}
+
+	if h.amadeusService == nil {
+		writeErr(w, http.StatusServiceUnavailable, "航班服務未啟用")
+		return
+	}
+
+	alert, err := h.amadeusService.CreatePriceAlert(models.PriceAlert{
+		Route:         alertReq.Route,
+		DepartureDate: alertReq.DepartureDate,
+		TargetPrice:   alertReq.TargetPrice,
+		Currency:      alertReq.Currency,
+		Channel:       alertReq.Channel,
+		Target:        alertReq.Target,
+	})
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"data": map[string]interface{}{
-			"alert_id":     "alert_" + strconv.FormatInt(time.Now().Unix(), 10),
-			"route":        alertReq.Route,
-			"target_price": alertReq.TargetPrice,
-			"created_at":   time.Now().Format(time.RFC3339),
-			"message":      "價格警報設置成功，當價格低於目標時會通知您",
-		},
+		"data":    alert,
+		"message": "價格警報設置成功，當價格低於目標時會通知您",
 	})
 }
 
@@ -411,7 +515,7 @@ func (h *FlightHandler) ConvertCurrency(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if req.Amount <= 0 || req.FromCurrency == "" || req.ToCurrency == "" {
+	if req.Amount.Sign() <= 0 || req.FromCurrency == "" || req.ToCurrency == "" {
 		writeErr(w, http.StatusBadRequest, "缺少必要參數: amount, from_currency, to_currency")
 		return
 	}
@@ -421,25 +525,28 @@ func (h *FlightHandler) ConvertCurrency(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	convertedAmount, err := h.exchangeService.ConvertCurrency(req.Amount, req.FromCurrency, req.ToCurrency)
+	// JSON 的金額沒有幣別，依來源貨幣精度取整
+	amount := req.Amount.WithCurrency(req.FromCurrency)
+
+	rates, err := h.exchangeService.GetExchangeRates(amount.Currency, []string{strings.ToUpper(req.ToCurrency)})
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	rates, err := h.exchangeService.GetExchangeRates(req.FromCurrency, []string{req.ToCurrency})
-	if err != nil {
-		writeErr(w, http.StatusInternalServerError, err.Error())
+	exchangeRate, ok := rates.Rates[strings.ToUpper(req.ToCurrency)]
+	if !ok {
+		writeErr(w, http.StatusBadRequest, "不支援的貨幣轉換: "+req.FromCurrency+" -> "+req.ToCurrency)
 		return
 	}
 
-	exchangeRate := rates.Rates[req.ToCurrency]
+	convertedAmount := amount.Convert(exchangeRate, req.ToCurrency)
 
 	response := models.CurrencyConversionResponse{
-		OriginalAmount:  req.Amount,
+		OriginalAmount:  amount,
 		ConvertedAmount: convertedAmount,
-		FromCurrency:    req.FromCurrency,
-		ToCurrency:      req.ToCurrency,
+		FromCurrency:    amount.Currency,
+		ToCurrency:      convertedAmount.Currency,
 		ExchangeRate:    exchangeRate,
 		LastUpdated:     rates.LastUpdated,
 	}
@@ -472,6 +579,348 @@ func (h *FlightHandler) GetSupportedCurrencies(w http.ResponseWriter, r *http.Re
 	})
 }
 
+func (h *FlightHandler) GetRateHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		writeErr(w, http.StatusBadRequest, "缺少必要參數: from, to")
+		return
+	}
+
+	if h.exchangeService == nil {
+		writeErr(w, http.StatusServiceUnavailable, "匯率服務未啟用")
+		return
+	}
+
+	days := qInt(r, "days", 30)
+	if days > services.MaxRateSeriesDays {
+		days = services.MaxRateSeriesDays
+	}
+
+	series, err := h.exchangeService.GetRateSeries(from, to, days)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    series,
+	})
+}
+
+// GetWeatherOutlook 取得城市在行程期間的每日天氣展望
+// 預報範圍內使用天氣預報，超出範圍的日期以歷年同月氣候平均替代
+func (h *FlightHandler) GetWeatherOutlook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	city := r.URL.Query().Get("city")
+	if airport := r.URL.Query().Get("airport"); city == "" && airport != "" {
+		city = models.GetCityByAirportCode(strings.ToUpper(airport))
+	}
+	startDate := r.URL.Query().Get("start_date")
+	endDate := r.URL.Query().Get("end_date")
+	if city == "" || startDate == "" {
+		writeErr(w, http.StatusBadRequest, "缺少必要參數: city 或 airport, start_date")
+		return
+	}
+
+	if h.weatherService == nil {
+		writeErr(w, http.StatusServiceUnavailable, "天氣服務未啟用")
+		return
+	}
+
+	outlook, err := h.weatherService.GetTripOutlook(city, startDate, endDate)
+	if err != nil && outlook == nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    outlook,
+	})
+}
+
+// GetClimateNormals 取得城市的歷年氣候平均（指定 month 時只回傳該月份）
+func (h *FlightHandler) GetClimateNormals(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	city := r.URL.Query().Get("city")
+	if airport := r.URL.Query().Get("airport"); city == "" && airport != "" {
+		city = models.GetCityByAirportCode(strings.ToUpper(airport))
+	}
+	if city == "" {
+		writeErr(w, http.StatusBadRequest, "缺少必要參數: city 或 airport")
+		return
+	}
+
+	month := qInt(r, "month", 0)
+	if date := r.URL.Query().Get("date"); month == 0 && date != "" {
+		t, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			writeErr(w, http.StatusBadRequest, "無效的日期格式，請使用 YYYY-MM-DD")
+			return
+		}
+		month = int(t.Month())
+	}
+
+	if month != 0 {
+		if month < 1 || month > 12 {
+			writeErr(w, http.StatusBadRequest, "month 必須介於 1 到 12")
+			return
+		}
+		normal, ok := services.GetClimateNormal(city, month)
+		if !ok {
+			writeErr(w, http.StatusNotFound, "沒有 "+city+" 的氣候資料")
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data":    normal,
+		})
+		return
+	}
+
+	normals, ok := services.GetClimateYear(city)
+	if !ok {
+		writeErr(w, http.StatusNotFound, "沒有 "+city+" 的氣候資料")
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    normals,
+	})
+}
+
+// GetPackingList 依目的地天氣與停留天數產生行李清單（format=json|markdown|text）
+func (h *FlightHandler) GetPackingList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	req := models.PackingRequest{
+		Origin:      r.URL.Query().Get("origin"),
+		Destination: r.URL.Query().Get("destination"),
+		StartDate:   r.URL.Query().Get("start_date"),
+		EndDate:     r.URL.Query().Get("end_date"),
+	}
+	if req.Destination == "" || req.StartDate == "" {
+		writeErr(w, http.StatusBadRequest, "缺少必要參數: destination, start_date")
+		return
+	}
+
+	format := qStr(r, "format", services.PackingFormatJSON)
+	if format != services.PackingFormatJSON && format != services.PackingFormatMarkdown && format != services.PackingFormatText {
+		writeErr(w, http.StatusBadRequest, "format 必須是 json、markdown 或 text")
+		return
+	}
+
+	list, err := services.NewPackingService(h.weatherService, h.exchangeService).GeneratePackingList(req)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	switch format {
+	case services.PackingFormatMarkdown:
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Write([]byte(services.FormatPackingList(list, format)))
+	case services.PackingFormatText:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(services.FormatPackingList(list, format)))
+	default:
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    list,
+		})
+	}
+}
+
+// Geocode 地理編碼：帶 q 時由地名查座標，帶 lat、lng 時由座標查地名
+func (h *FlightHandler) Geocode(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	query := r.URL.Query()
+	q := query.Get("q")
+	latStr, lngStr := query.Get("lat"), query.Get("lng")
+	if q == "" && (latStr == "" || lngStr == "") {
+		writeErr(w, http.StatusBadRequest, "缺少必要參數: q 或 lat, lng")
+		return
+	}
+
+	if h.geocodingService == nil {
+		writeErr(w, http.StatusServiceUnavailable, "地理編碼服務未啟用")
+		return
+	}
+
+	var place *models.GeoPlace
+	var err error
+	if q != "" {
+		place, err = h.geocodingService.Geocode(q)
+	} else {
+		lat, latErr := strconv.ParseFloat(latStr, 64)
+		lng, lngErr := strconv.ParseFloat(lngStr, 64)
+		if latErr != nil || lngErr != nil {
+			writeErr(w, http.StatusBadRequest, "無效的經緯度參數")
+			return
+		}
+		place, err = h.geocodingService.ReverseGeocode(lat, lng)
+	}
+	// 只有查無結果才回 404，連線逾時或上游錯誤回 502
+	switch {
+	case errors.Is(err, services.ErrInvalidPlaceQuery):
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, services.ErrPlaceNotFound):
+		writeErr(w, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		writeErr(w, http.StatusBadGateway, "地理編碼服務暫時無法使用: "+err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    place,
+	})
+}
+
+func (h *FlightHandler) ListRateAlerts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	if h.exchangeService == nil {
+		writeErr(w, http.StatusServiceUnavailable, "匯率服務未啟用")
+		return
+	}
+
+	alerts, err := h.exchangeService.ListRateAlerts()
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    alerts,
+	})
+}
+
+func (h *FlightHandler) CreateRateAlert(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	var alertReq models.RateAlert
+	if err := json.NewDecoder(r.Body).Decode(&alertReq); err != nil {
+		writeErr(w, http.StatusBadRequest, "無效的請求數據")
+		return
+	}
+
+	if alertReq.From == "" || alertReq.To == "" || alertReq.Threshold <= 0 {
+		writeErr(w, http.StatusBadRequest, "缺少必要參數: from, to, threshold")
+		return
+	}
+	if alertReq.Channel == "" || alertReq.Target == "" {
+		writeErr(w, http.StatusBadRequest, "缺少必要參數: channel, target")
+		return
+	}
+	if !alertChannels[alertReq.Channel] {
+		writeErr(w, http.StatusBadRequest, "不支援的通知管道: "+alertReq.Channel+" (僅支援 discord, telegram)")
+		return
+	}
+
+	if h.exchangeService == nil {
+		writeErr(w, http.StatusServiceUnavailable, "匯率服務未啟用")
+		return
+	}
+
+	alert, err := h.exchangeService.CreateRateAlert(alertReq)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    alert,
+	})
+}
+
+func (h *FlightHandler) DeleteRateAlert(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeErr(w, http.StatusBadRequest, "缺少必要參數: id")
+		return
+	}
+
+	if h.exchangeService == nil {
+		writeErr(w, http.StatusServiceUnavailable, "匯率服務未啟用")
+		return
+	}
+
+	if err := h.exchangeService.DeleteRateAlert(id); err != nil {
+		writeErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    map[string]string{"id": id},
+	})
+}
+
 func (h *FlightHandler) SearchAttractions(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -493,16 +942,50 @@ func (h *FlightHandler) SearchAttractions(w http.ResponseWriter, r *http.Request
 	searchQuery := query.Get("query")
 	category := query.Get("category")
 
-	lat, err := strconv.ParseFloat(latStr, 64)
-	if err != nil {
-		writeErr(w, http.StatusBadRequest, "無效的緯度參數")
-		return
+	// 地點可以是經緯度，或由伺服器解析的地名（place）、機場代碼（airport）、城市（city）
+	placeQuery := query.Get("place")
+	if placeQuery == "" {
+		placeQuery = query.Get("airport")
+	}
+	if placeQuery == "" {
+		placeQuery = query.Get("city")
 	}
 
-	lng, err := strconv.ParseFloat(lngStr, 64)
-	if err != nil {
-		writeErr(w, http.StatusBadRequest, "無效的經度參數")
-		return
+	var lat, lng float64
+	var resolved *models.GeoPlace
+	if latStr == "" && lngStr == "" && placeQuery != "" {
+		if h.geocodingService == nil {
+			writeErr(w, http.StatusServiceUnavailable, "地理編碼服務未啟用，請改用 lat、lng")
+			return
+		}
+		place, err := h.geocodingService.ResolvePlace(placeQuery)
+		// 只有查無結果才回 404，連線逾時或上游錯誤回 502
+		switch {
+		case errors.Is(err, services.ErrInvalidPlaceQuery):
+			writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		case errors.Is(err, services.ErrPlaceNotFound):
+			writeErr(w, http.StatusNotFound, "找不到地點: "+placeQuery)
+			return
+		case err != nil:
+			writeErr(w, http.StatusBadGateway, "地理編碼服務暫時無法使用: "+err.Error())
+			return
+		}
+		resolved = place
+		lat, lng = place.Latitude, place.Longitude
+	} else {
+		var err error
+		lat, err = strconv.ParseFloat(latStr, 64)
+		if err != nil {
+			writeErr(w, http.StatusBadRequest, "無效的緯度參數")
+			return
+		}
+
+		lng, err = strconv.ParseFloat(lngStr, 64)
+		if err != nil {
+			writeErr(w, http.StatusBadRequest, "無效的經度參數")
+			return
+		}
 	}
 
 	req := services.SearchRequest{
@@ -510,6 +993,9 @@ func (h *FlightHandler) SearchAttractions(w http.ResponseWriter, r *http.Request
 		Longitude: lng,
 		Query:     searchQuery,
 		Category:  category,
+		Sort:      query.Get("sort"),
+		Cursor:    query.Get("cursor"),
+		OpenNow:   query.Get("open_now") == "true" || query.Get("open_now") == "1",
 	}
 
 	if radiusStr != "" {
@@ -521,27 +1007,152 @@ func (h *FlightHandler) SearchAttractions(w http.ResponseWriter, r *http.Request
 		req.Radius = 5000
 	}
 
-	attractions, err := h.foursquareService.SearchNearby(req)
+	for param, target := range map[string]*int{
+		"limit":     &req.Limit,
+		"min_price": &req.MinPrice,
+		"max_price": &req.MaxPrice,
+	} {
+		if v := query.Get(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				writeErr(w, http.StatusBadRequest, "無效的 "+param+" 參數")
+				return
+			}
+			*target = n
+		}
+	}
+	if err := req.Validate(); err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.foursquareService.Search(req)
 	if err != nil {
-		// 這裡特別注意，之前的拼接字串也被換掉了
-		writeErr(w, http.StatusInternalServerError, "搜尋景點時發生錯誤: "+err.Error())
+		writeErr(w, http.StatusBadGateway, "搜尋景點時發生錯誤: "+err.Error())
 		return
 	}
+	attractions := page.Attractions
+
+	meta := map[string]interface{}{
+		"latitude":  lat,
+		"longitude": lng,
+		"radius":    req.Radius,
+		"count":     len(attractions),
+		"sort":      req.Sort,
+		"limit":     req.Limit,
+	}
+	if page.NextCursor != "" {
+		meta["next_cursor"] = page.NextCursor
+	}
+	if resolved != nil {
+		meta["location"] = resolved.Name
+		meta["resolved_place"] = resolved
+	}
 
 	response := map[string]interface{}{
 		"success": true,
 		"data":    attractions,
-		"meta": map[string]interface{}{
-			"latitude":  lat,
-			"longitude": lng,
-			"radius":    req.Radius,
-			"count":     len(attractions),
-		},
+		"meta":    meta,
 	}
 
 	json.NewEncoder(w).Encode(response)
 }
 
+// PlanItinerary 依目的地、天數與興趣規劃每日景點行程（format=json|markdown）
+func (h *FlightHandler) PlanItinerary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	req := models.ItineraryRequest{
+		Destination: r.URL.Query().Get("destination"),
+		Days:        qInt(r, "days", 0),
+		StartDate:   r.URL.Query().Get("start_date"),
+		PerDay:      qInt(r, "per_day", 0),
+	}
+	if interests := r.URL.Query().Get("interests"); interests != "" {
+		req.Interests = strings.Split(interests, ",")
+	}
+	if req.Destination == "" || req.Days == 0 {
+		writeErr(w, http.StatusBadRequest, "缺少必要參數: destination, days")
+		return
+	}
+
+	format := qStr(r, "format", "json")
+	if format != "json" && format != "markdown" {
+		writeErr(w, http.StatusBadRequest, "format 必須是 json 或 markdown")
+		return
+	}
+
+	if h.foursquareService == nil || h.geocodingService == nil {
+		writeErr(w, http.StatusServiceUnavailable, "景點服務未啟用")
+		return
+	}
+
+	itinerary, err := services.NewItineraryService(h.foursquareService, h.geocodingService).PlanItinerary(req)
+	// 參數錯誤回 400，查無目的地或景點回 404，景點或地理編碼服務失敗回 502
+	switch {
+	case errors.Is(err, services.ErrInvalidItinerary), errors.Is(err, services.ErrInvalidPlaceQuery):
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, services.ErrPlaceNotFound), errors.Is(err, services.ErrNoItineraryStops):
+		writeErr(w, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		writeErr(w, http.StatusBadGateway, "行程規劃失敗: "+err.Error())
+		return
+	}
+
+	if format == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Write([]byte(services.FormatItinerary(itinerary, services.AdviceLangZH)))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    itinerary,
+	})
+}
+
+// GetAttractionDetails 取得單一景點的營業時間、評分、價位、照片、評論與熱門度
+func (h *FlightHandler) GetAttractionDetails(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	if h.foursquareService == nil {
+		writeErr(w, http.StatusServiceUnavailable, "景點服務未啟用")
+		return
+	}
+
+	id := r.PathValue("id")
+	if id == "" {
+		writeErr(w, http.StatusBadRequest, "缺少景點 ID")
+		return
+	}
+
+	details, err := h.foursquareService.GetPlaceDetails(id)
+	if errors.Is(err, services.ErrAttractionNotFound) {
+		writeErr(w, http.StatusNotFound, "找不到景點: "+id)
+		return
+	}
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "取得景點詳細資料失敗: "+err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    details,
+	})
+}
+
 func (h *FlightHandler) GetAttractionCategories(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -551,20 +1162,15 @@ func (h *FlightHandler) GetAttractionCategories(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	var categories []string
-	if h.foursquareService != nil {
-		categories = h.foursquareService.GetPopularCategories()
-	} else {
-		categories = []string{
-			"museum", "park", "restaurant", "coffee", "bar",
-			"hotel", "shopping_mall", "tourist_attraction",
-			"art_gallery", "theater", "cinema", "stadium",
-		}
+	lang := r.URL.Query().Get("lang")
+	if lang == "" {
+		lang = services.AdviceLangZH
 	}
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success":    true,
-		"categories": categories,
+		"lang":       lang,
+		"categories": services.DefaultCategoryTaxonomy().Tree(lang),
 	})
 }
 
@@ -610,6 +1216,10 @@ func (h *FlightHandler) APIDocs(w http.ResponseWriter, r *http.Request) {
 			"天氣資訊整合",
 			"價格警報設定",
 			"貨幣轉換服務",
+			"匯率走勢與警報",
+			"多管道通知（Discord、Telegram、Email）",
+			"簽章 webhook 事件訂閱",
+			"追蹤航線摘要 Email",
 			"景點查詢服務",
 		},
 		"endpoints": []map[string]string{
@@ -617,13 +1227,19 @@ func (h *FlightHandler) APIDocs(w http.ResponseWriter, r *http.Request) {
 				"method":      "GET",
 				"path":        "/api/flights/search",
 				"description": "搜尋即時航班（包含天氣資訊）",
-				"parameters":  "origin, destination, departure_date, [return_date, adults, currency]",
+				"parameters":  "origin, destination, departure_date, [return_date, adults, currency, display_currency]",
+			},
+			{
+				"method":      "GET",
+				"path":        "/api/flights/history",
+				"description": "查詢航線的歷史價格紀錄（可換算成任意顯示貨幣）",
+				"parameters":  "route, [departure_date, currency]",
 			},
 			{
 				"method":      "GET",
 				"path":        "/api/flights/track-prices",
-				"description": "追蹤機票價格趨勢",
-				"parameters":  "origin, destination, [weeks]",
+				"description": "追蹤機票價格趨勢（可依通知偏好通知結果）",
+				"parameters":  "origin, destination, [weeks, notify_user]",
 			},
 			{
 				"method":      "GET",
@@ -640,8 +1256,8 @@ func (h *FlightHandler) APIDocs(w http.ResponseWriter, r *http.Request) {
 			{
 				"method":      "POST",
 				"path":        "/api/alerts/create",
-				"description": "創建價格警報",
-				"parameters":  "route, target_price",
+				"description": "創建價格警報（最低價低於目標時通知）",
+				"parameters":  "route, target_price, [departure_date（預設 30 天後）], [currency], [channel（discord, telegram）, target]",
 			},
 			{
 				"method":      "POST",
@@ -655,17 +1271,143 @@ func (h *FlightHandler) APIDocs(w http.ResponseWriter, r *http.Request) {
 				"description": "獲取支援的貨幣列表",
 				"parameters":  "無",
 			},
+			{
+				"method":      "GET",
+				"path":        "/api/weather/outlook",
+				"description": "取得行程期間每日天氣展望（超出預報範圍改用氣候平均）",
+				"parameters":  "city 或 airport, start_date, [end_date]",
+			},
+			{
+				"method":      "GET",
+				"path":        "/api/weather/climate",
+				"description": "取得城市歷年每月氣候平均（高低溫、雨量、降雨天數）",
+				"parameters":  "city 或 airport, [month 或 date]",
+			},
+			{
+				"method":      "GET",
+				"path":        "/api/packing-list",
+				"description": "依目的地天氣、停留天數、插座與貨幣產生行李清單",
+				"parameters":  "destination, start_date, [end_date], [origin], [format=json|markdown|text]",
+			},
+			{
+				"method":      "GET",
+				"path":        "/api/geocode",
+				"description": "地理編碼：地名查座標或座標查地名（已快取並節流）",
+				"parameters":  "q 或 lat, lng",
+			},
+			{
+				"method":      "GET",
+				"path":        "/api/currency/history",
+				"description": "取得匯率歷史走勢（含最低、最高、平均）",
+				"parameters":  "from, to, [days]",
+			},
+			{
+				"method":      "GET",
+				"path":        "/api/currency/alerts",
+				"description": "列出匯率警報",
+				"parameters":  "無",
+			},
+			{
+				"method":      "POST",
+				"path":        "/api/currency/alerts/create",
+				"description": "建立匯率警報（跌破或突破門檻時通知）",
+				"parameters":  "from, to, threshold, [direction], channel（discord, telegram）, target",
+			},
+			{
+				"method":      "POST",
+				"path":        "/api/currency/alerts/delete",
+				"description": "刪除匯率警報",
+				"parameters":  "id",
+			},
+			{
+				"method":      "GET",
+				"path":        "/api/notifications/preferences",
+				"description": "查詢使用者的通知管道偏好與可用管道",
+				"parameters":  "user_id",
+			},
+			{
+				"method":      "POST",
+				"path":        "/api/notifications/preferences",
+				"description": "設定使用者的通知管道（log、email、discord、discord_dm、telegram、telegram_dm；webhook 請改用 /api/webhooks），可限定通知種類",
+				"parameters":  "user_id, channels[{channel, target, [kinds]}]",
+			},
+			{
+				"method":      "GET",
+				"path":        "/api/webhooks",
+				"description": "列出 webhook 訂閱（不含 secret）與可訂閱的事件",
+				"parameters":  "無",
+			},
+			{
+				"method":      "POST",
+				"path":        "/api/webhooks",
+				"description": "訂閱事件（price.historical_low、alert.triggered、tracking.completed），以 HMAC-SHA256 簽章的 JSON POST 到 url（不可為內部網路位址），secret 只在建立時回傳",
+				"parameters":  "url, events, [secret, description]",
+			},
+			{
+				"method":      "POST",
+				"path":        "/api/webhooks/delete",
+				"description": "刪除 webhook 訂閱",
+				"parameters":  "id",
+			},
+			{
+				"method":      "GET",
+				"path":        "/api/webhooks/deliveries",
+				"description": "查詢 webhook 傳送紀錄（新的在前，含重試次數與狀態碼）",
+				"parameters":  "[subscription_id, limit]",
+			},
+			{
+				"method":      "POST",
+				"path":        "/api/digest",
+				"description": "訂閱追蹤航線的每日/每週摘要 Email（目前最低價、與上次摘要的變化、歷史最低/平均、出發日天氣）",
+				"parameters":  "user_id, email, [frequency=daily|weekly]",
+			},
+			{
+				"method":      "GET",
+				"path":        "/api/digest",
+				"description": "查詢摘要 Email 訂閱",
+				"parameters":  "user_id",
+			},
+			{
+				"method":      "POST",
+				"path":        "/api/digest/delete",
+				"description": "取消摘要 Email",
+				"parameters":  "user_id",
+			},
+			{
+				"method":      "GET",
+				"path":        "/api/digest/preview",
+				"description": "以 HTML 預覽摘要內容（不寄送）",
+				"parameters":  "user_id",
+			},
+			{
+				"method":      "POST",
+				"path":        "/api/digest/send",
+				"description": "立即寄送摘要 Email",
+				"parameters":  "user_id",
+			},
+			{
+				"method":      "GET",
+				"path":        "/api/itinerary",
+				"description": "依目的地、天數與興趣規劃每日景點行程（依地理分群、最近鄰路線、營業時間）",
+				"parameters":  "destination, days(1-7), [interests(類別 slug，逗號分隔), start_date, per_day(1-8), format(json/markdown)]",
+			},
+			{
+				"method":      "GET",
+				"path":        "/api/attractions/{id}",
+				"description": "景點詳細資料（營業時間、評分、價位、照片、評論、熱門度）",
+				"parameters":  "id (Foursquare fsq_place_id)",
+			},
 			{
 				"method":      "GET",
 				"path":        "/api/attractions/search",
 				"description": "搜尋附近景點",
-				"parameters":  "lat, lng, [radius, query, category]",
+				"parameters":  "lat, lng 或 place / airport / city, [radius, query, category(slug/名稱/ID，可逗號分隔), sort(relevance/rating/popularity/distance), limit(1-50), cursor, open_now, min_price, max_price(1-4)]",
 			},
 			{
 				"method":      "GET",
 				"path":        "/api/attractions/categories",
-				"description": "獲取景點類別列表",
-				"parameters":  "無",
+				"description": "階層式景點類別（slug、中英文名稱、Foursquare ID）",
+				"parameters":  "[lang (zh-TW/en)]",
 			},
 			{
 				"method":      "GET",
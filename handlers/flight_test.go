@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"final/models" // 請確認這裡的路徑跟你的 go.mod 專案名稱一致
+	"final/notifications"
+	"final/services"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -93,6 +96,13 @@ func TestAPI_InputValidation(t *testing.T) {
 		},
 
 		// 4. 價格警報 API 測試
+		{
+			name:       "建立匯率警報-不支援的管道",
+			method:     "POST",
+			path:       "/api/currency/alerts/create",
+			body:       `{"from":"USD","to":"TWD","threshold":30,"channel":"webhook","target":"http://169.254.169.254/"}`,
+			wantStatus: http.StatusBadRequest,
+		},
 		{
 			name:       "建立警報-缺少目標價格",
 			method:     "POST",
@@ -108,6 +118,66 @@ func TestAPI_InputValidation(t *testing.T) {
 			path:       "/api/airports/search", // 沒帶 ?q=
 			wantStatus: http.StatusBadRequest,
 		},
+
+		// 6. 通知偏好 API
+		{
+			name:       "通知偏好-錯誤的方法(PUT)",
+			method:     "PUT",
+			path:       "/api/notifications/preferences",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "通知偏好-通知服務未啟用",
+			method:     "GET",
+			path:       "/api/notifications/preferences?user_id=42",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+
+		// 7. Webhook 訂閱 API
+		{
+			name:       "Webhook-錯誤的方法(PUT)",
+			method:     "PUT",
+			path:       "/api/webhooks",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "刪除 Webhook-缺少 id",
+			method:     "POST",
+			path:       "/api/webhooks/delete",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Webhook 傳送紀錄-服務未啟用",
+			method:     "GET",
+			path:       "/api/webhooks/deliveries",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+
+		// 8. 摘要 Email API
+		{
+			name:       "摘要-錯誤的方法(PUT)",
+			method:     "PUT",
+			path:       "/api/digest",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "取消摘要-缺少 user_id",
+			method:     "POST",
+			path:       "/api/digest/delete",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "預覽摘要-服務未啟用",
+			method:     "GET",
+			path:       "/api/digest/preview?user_id=42",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "寄送摘要-錯誤的方法(GET)",
+			method:     "GET",
+			path:       "/api/digest/send?user_id=42",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
 	}
 
 	for _, tt := range tests {
@@ -133,10 +203,28 @@ func TestAPI_InputValidation(t *testing.T) {
 				h.TrackFlightPrices(rr, req)
 			case strings.Contains(tt.path, "currency/convert"):
 				h.ConvertCurrency(rr, req)
+			case strings.Contains(tt.path, "currency/alerts/create"):
+				h.CreateRateAlert(rr, req)
 			case strings.Contains(tt.path, "alerts/create"):
 				h.CreatePriceAlert(rr, req)
 			case strings.Contains(tt.path, "airports/search"):
 				h.SearchAirports(rr, req)
+			case strings.Contains(tt.path, "notifications/preferences"):
+				h.NotificationPreferences(rr, req)
+			case strings.Contains(tt.path, "webhooks/delete"):
+				h.DeleteWebhook(rr, req)
+			case strings.Contains(tt.path, "webhooks/deliveries"):
+				h.WebhookDeliveries(rr, req)
+			case strings.Contains(tt.path, "webhooks"):
+				h.Webhooks(rr, req)
+			case strings.Contains(tt.path, "digest/delete"):
+				h.DeleteDigest(rr, req)
+			case strings.Contains(tt.path, "digest/preview"):
+				h.PreviewDigest(rr, req)
+			case strings.Contains(tt.path, "digest/send"):
+				h.SendDigest(rr, req)
+			case strings.Contains(tt.path, "digest"):
+				h.Digest(rr, req)
 			}
 
 			// 驗證狀態碼
@@ -148,6 +236,90 @@ func TestAPI_InputValidation(t *testing.T) {
 	}
 }
 
+// 通知偏好不能設定 webhook（沒有身分驗證，外部系統需改用有簽章的訂閱）
+func TestNotificationPreferences_RejectsWebhook(t *testing.T) {
+	h := NewFlightHandler(nil, nil, nil, nil)
+	notifier := notifications.NewDispatcher(notifications.NewPreferenceStore(), nil)
+	notifier.Register(notifications.LogChannel{})
+	h.SetNotifier(notifier)
+
+	rr := httptest.NewRecorder()
+	h.NotificationPreferences(rr, httptest.NewRequest("POST", "/api/notifications/preferences",
+		strings.NewReader(`{"user_id":"42","channels":[{"channel":"webhook","target":"http://169.254.169.254/latest"}]}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("狀態碼 = %d, 預期 400: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	h.NotificationPreferences(rr, httptest.NewRequest("GET", "/api/notifications/preferences?user_id=42", nil))
+	if strings.Contains(rr.Body.String(), `"webhook"`) {
+		t.Errorf("可選的管道不應包含 webhook: %s", rr.Body.String())
+	}
+}
+
+// 地理編碼：查無結果回 404，上游逾時或 5xx 回 502
+func TestGeocode_StatusCodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		upstream   http.HandlerFunc
+		wantStatus int
+	}{
+		{"查無結果", "/api/geocode?q=nowhere", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, `[]`) }, http.StatusNotFound},
+		{"座標查無結果", "/api/geocode?lat=0&lng=0", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, `{"error":"Unable to geocode"}`) }, http.StatusNotFound},
+		{"上游 5xx", "/api/geocode?q=Tokyo", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) }, http.StatusBadGateway},
+		{"上游回應格式錯誤", "/api/geocode?q=Tokyo", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, `<html>`) }, http.StatusBadGateway},
+		{"座標超出範圍", "/api/geocode?lat=91&lng=0", nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(tt.upstream)
+			defer upstream.Close()
+			geocoder := services.NewGeocodingService("GoSkyAlert-test")
+			geocoder.BaseURL = upstream.URL
+
+			h := NewFlightHandler(nil, nil, nil, nil)
+			h.SetGeocodingService(geocoder)
+			rr := httptest.NewRecorder()
+			h.Geocode(rr, httptest.NewRequest("GET", tt.path, nil))
+			if rr.Code != tt.wantStatus {
+				t.Errorf("狀態碼 = %d, 預期 %d: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+// 行程規劃：參數錯誤回 400，查無目的地回 404，上游失敗回 502
+func TestPlanItinerary_StatusCodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		upstream   http.HandlerFunc
+		wantStatus int
+	}{
+		{"天數超出範圍", "/api/itinerary?destination=KIX&days=30", nil, http.StatusBadRequest},
+		{"未知興趣", "/api/itinerary?destination=KIX&days=2&interests=unicorn", nil, http.StatusBadRequest},
+		{"找不到目的地", "/api/itinerary?destination=nowhere&days=2", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, `[]`) }, http.StatusNotFound},
+		{"地理編碼逾時或 5xx", "/api/itinerary?destination=KIX&days=2", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) }, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(tt.upstream)
+			defer upstream.Close()
+			geocoder := services.NewGeocodingService("GoSkyAlert-test")
+			geocoder.BaseURL = upstream.URL
+
+			h := NewFlightHandler(nil, nil, nil, services.NewFoursquareService("test-key"))
+			h.SetGeocodingService(geocoder)
+			rr := httptest.NewRecorder()
+			h.PlanItinerary(rr, httptest.NewRequest("GET", tt.path, nil))
+			if rr.Code != tt.wantStatus {
+				t.Errorf("狀態碼 = %d, 預期 %d: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
 // --- 3. 效能測試 (Benchmarks) ---
 func BenchmarkTravelAdvice(b *testing.B) {
 	h := &FlightHandler{}
@@ -158,3 +330,78 @@ func BenchmarkTravelAdvice(b *testing.B) {
 		h.generateTravelAdvice(origin, dest)
 	}
 }
+
+// 網頁建立價格警報：只接受頻道/聊天室管道，owner 與 repeat 不可由 HTTP 指定
+func TestCreatePriceAlert_NarrowRequest(t *testing.T) {
+	t.Chdir(t.TempDir())
+	amadeus := services.NewAmadeusService(nil)
+	notifier := notifications.NewDispatcher(notifications.NewPreferenceStore(), nil)
+	notifier.Register(notifications.LogChannel{})
+	notifier.Register(notifications.NewDiscordChannel(nil, false))
+	notifier.Register(notifications.NewDiscordChannel(nil, true))
+	amadeus.SetNotifier(notifier)
+	h := NewFlightHandler(amadeus, nil, nil, nil)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"Discord 頻道", `{"route":"TPE-NRT","target_price":8000,"channel":"discord","target":"123","owner":"42","repeat":true}`, http.StatusOK},
+		{"不指定管道", `{"route":"TPE-KIX","target_price":6000}`, http.StatusOK},
+		{"Discord 私訊", `{"route":"TPE-NRT","target_price":8000,"channel":"discord_dm","target":"42"}`, http.StatusBadRequest},
+		{"webhook", `{"route":"TPE-NRT","target_price":8000,"channel":"webhook","target":"http://169.254.169.254/"}`, http.StatusBadRequest},
+		{"日誌管道", `{"route":"TPE-NRT","target_price":8000,"channel":"log","target":"x"}`, http.StatusBadRequest},
+		{"未啟用的 telegram", `{"route":"TPE-NRT","target_price":8000,"channel":"telegram","target":"123"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			h.CreatePriceAlert(rr, httptest.NewRequest("POST", "/api/alerts/create", strings.NewReader(tt.body)))
+			if rr.Code != tt.wantStatus {
+				t.Errorf("狀態碼 = %d, 預期 %d: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+
+	alerts, err := amadeus.ListPriceAlerts()
+	if err != nil || len(alerts) != 2 {
+		t.Fatalf("應保存 2 筆警報: %v %+v", err, alerts)
+	}
+	for _, a := range alerts {
+		if a.Owner != "" || a.Repeat {
+			t.Errorf("owner 與 repeat 不應由 HTTP 指定: %+v", a)
+		}
+	}
+}
+
+// 景點搜尋：地點格式錯誤回 400，查無地點回 404，地理編碼上游失敗回 502
+func TestSearchAttractions_PlaceStatusCodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		upstream   http.HandlerFunc
+		wantStatus int
+	}{
+		{"地名只有空白", "/api/attractions?place=%20%20", nil, http.StatusBadRequest},
+		{"找不到地點", "/api/attractions?place=nowhere", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, `[]`) }, http.StatusNotFound},
+		{"地理編碼逾時或 5xx", "/api/attractions?place=Tokyo", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) }, http.StatusBadGateway},
+		{"上游回應格式錯誤", "/api/attractions?city=Tokyo", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, `<html>`) }, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(tt.upstream)
+			defer upstream.Close()
+			geocoder := services.NewGeocodingService("GoSkyAlert-test")
+			geocoder.BaseURL = upstream.URL
+
+			h := NewFlightHandler(nil, nil, nil, services.NewFoursquareService("test-key"))
+			h.SetGeocodingService(geocoder)
+			rr := httptest.NewRecorder()
+			h.SearchAttractions(rr, httptest.NewRequest("GET", tt.path, nil))
+			if rr.Code != tt.wantStatus {
+				t.Errorf("狀態碼 = %d, 預期 %d: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"final/models"
+	"final/notifications"
+	"log"
+	"net/http"
+)
+
+// NotificationPreferences GET 查詢、POST 設定使用者的通知管道偏好
+func (h *FlightHandler) NotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	if h.notifier == nil || h.notifier.Preferences() == nil {
+		writeErr(w, http.StatusServiceUnavailable, "通知服務未啟用")
+		return
+	}
+	prefs := h.notifier.Preferences()
+
+	if r.Method == http.MethodGet {
+		userID := r.URL.Query().Get("user_id")
+		if userID == "" {
+			writeErr(w, http.StatusBadRequest, "缺少必要參數: user_id")
+			return
+		}
+		endpoints, err := prefs.Get(userID)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"user_id":   userID,
+				"channels":  endpoints,
+				"available": userChannels(h.notifier.Channels()),
+				"kinds":     notifications.Kinds,
+			},
+		})
+		return
+	}
+
+	var req struct {
+		UserID   string                   `json:"user_id"`
+		Channels []notifications.Endpoint `json:"channels"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "無效的請求數據")
+		return
+	}
+	if req.UserID == "" {
+		writeErr(w, http.StatusBadRequest, "缺少必要參數: user_id")
+		return
+	}
+	for _, e := range req.Channels {
+		if !notifications.UserSettable(e.Channel) {
+			writeErr(w, http.StatusBadRequest, e.Channel+" 不能設為個人通知管道，請改用 /api/webhooks 訂閱")
+			return
+		}
+		if !h.notifier.HasChannel(e.Channel) {
+			writeErr(w, http.StatusBadRequest, "未啟用的通知管道: "+e.Channel)
+			return
+		}
+	}
+
+	if err := prefs.Set(req.UserID, req.Channels); err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Channels == nil {
+		req.Channels = []notifications.Endpoint{}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"user_id":  req.UserID,
+			"channels": req.Channels,
+		},
+	})
+}
+
+// userChannels 可設為個人偏好的管道
+func userChannels(names []string) []string {
+	settable := make([]string, 0, len(names))
+	for _, name := range names {
+		if notifications.UserSettable(name) {
+			settable = append(settable, name)
+		}
+	}
+	return settable
+}
+
+// notifyTrackingComplete 依使用者偏好送出價格追蹤結果
+func (h *FlightHandler) notifyTrackingComplete(userID string, analysis models.PriceAnalysis) {
+	err := h.notifier.Send(notifications.Notification{
+		Kind:     notifications.KindTrackingComplete,
+		UserID:   userID,
+		Tracking: &notifications.TrackingEvent{Analysis: analysis},
+	})
+	if err != nil {
+		log.Printf("⚠️ 價格追蹤 %s 通知失敗: %v", analysis.Route, err)
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"final/notifications"
+	"net/http"
+)
+
+// Webhooks GET 列出訂閱、POST 建立訂閱
+func (h *FlightHandler) Webhooks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	if h.webhooks == nil {
+		writeErr(w, http.StatusServiceUnavailable, "Webhook 服務未啟用")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		subs, err := h.webhooks.Subscriptions()
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data":    subs,
+			"events":  notifications.EventTypes,
+		})
+		return
+	}
+
+	var req notifications.WebhookSubscription
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "無效的請求數據")
+		return
+	}
+	if req.URL == "" || len(req.Events) == 0 {
+		writeErr(w, http.StatusBadRequest, "缺少必要參數: url, events")
+		return
+	}
+
+	sub, err := h.webhooks.Subscribe(req)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    sub,
+		"message": "請妥善保存 secret，之後不會再顯示；以 " + notifications.WebhookSignatureHeader + " 標頭驗證事件來源",
+	})
+}
+
+func (h *FlightHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeErr(w, http.StatusBadRequest, "缺少必要參數: id")
+		return
+	}
+
+	if h.webhooks == nil {
+		writeErr(w, http.StatusServiceUnavailable, "Webhook 服務未啟用")
+		return
+	}
+
+	if err := h.webhooks.Unsubscribe(id); err != nil {
+		writeErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    map[string]string{"id": id},
+	})
+}
+
+func (h *FlightHandler) WebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "方法不允許")
+		return
+	}
+
+	if h.webhooks == nil {
+		writeErr(w, http.StatusServiceUnavailable, "Webhook 服務未啟用")
+		return
+	}
+
+	limit := qInt(r, "limit", 50)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	deliveries, err := h.webhooks.Deliveries(r.URL.Query().Get("subscription_id"), limit)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    deliveries,
+	})
+}
@@ -3,9 +3,11 @@ package main
 import (
 	"final/config"
 	"final/handlers"
+	"final/notifications"
 	"final/services"
 	"log"
 	"net/http"
+	"time"
 )
 
 func main() {
@@ -19,29 +21,80 @@ func main() {
 	log.Printf("✅ 配置載入成功")
 	log.Printf("🌍 環境: %s", cfg.Environment)
 
+	// 通知分派器：警報與追蹤結果依指定管道或使用者偏好送出（NOTIFICATION_TEMPLATES_PATH 可自訂訊息）
+	notificationTemplates, err := notifications.LoadTemplates(cfg.NotificationTemplatesPath)
+	if err != nil {
+		log.Printf("⚠️ 自訂通知範本載入失敗，改用內建範本: %v", err)
+		notificationTemplates = notifications.DefaultTemplates()
+	}
+	notifier := notifications.NewDispatcher(notifications.NewPreferenceStore(), notificationTemplates)
+	notifier.Register(notifications.LogChannel{})
+	// 摘要 Email 與 Email 通知共用同一個 SMTP 設定；未設定時保持 nil 介面
+	var mailer services.DigestMailer
+	if cfg.HasSMTP() {
+		smtpChannel := notifications.NewSMTPChannel(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+		notifier.Register(smtpChannel)
+		mailer = smtpChannel
+		log.Printf("📧 Email 通知已啟用")
+	}
+
+	// 外部系統訂閱的 webhook 事件（歷史新低、警報觸發、追蹤完成）
+	webhookHub := notifications.NewWebhookHub()
+
 	// 初始化 Amadeus 服務
 	amadeusService := services.NewAmadeusService(cfg)
+	amadeusService.SetNotifier(notifier)
+	amadeusService.SetWebhookHub(webhookHub)
 
 	// 初始化其他服務 (天氣、匯率、Foursquare)
+	// 旅行建議規則（可用 ADVICE_RULES_PATH 調整，不需改程式）
+	adviceEngine, err := services.LoadAdviceEngine(cfg.AdviceRulesPath)
+	if err != nil {
+		log.Printf("⚠️ 自訂建議規則載入失敗，改用內建規則: %v", err)
+		adviceEngine = services.DefaultAdviceEngine()
+	}
+
 	var weatherService *services.WeatherService
 	if cfg.HasWeatherAPI() {
 		weatherService = services.NewWeatherService(cfg.WeatherAPIKey)
+		weatherService.MaxForecastDays = cfg.WeatherForecastDays
+		weatherService.Advice = adviceEngine
 		log.Printf("🌤️ 天氣服務已初始化")
 	}
 
 	var exchangeService *services.ExchangeService
 	if cfg.HasExchangeRateAPI() {
 		exchangeService = services.NewExchangeService(cfg.ExchangeRateAPIKey)
+		exchangeService.SetNotifier(notifier)
 		log.Printf("💱 匯率服務已初始化")
+
+		// 定期記錄匯率並檢查匯率警報
+		exchangeService.StartRateSnapshots("TWD", 6*time.Hour)
+		exchangeService.StartRateAlertChecker(30 * time.Minute)
 	}
 
+	// 歷史比價統一以 TWD 儲存，需要匯率服務換算其他貨幣
+	amadeusService.SetExchangeService(exchangeService)
+
+	// 每次檢查都會查詢一次 Amadeus，間隔不宜太短
+	amadeusService.StartPriceAlertChecker(3 * time.Hour)
+
 	var foursquareService *services.FoursquareService
 	if cfg.HasFoursquareAPI() {
 		foursquareService = services.NewFoursquareService(cfg.FoursquareAPIKey)
 		log.Printf("🏛️  景點服務已初始化")
 	}
 
-	// [關鍵修改] 初始化 Discord Bot，傳入所有 5 個服務參數
+	// 追蹤航線摘要 Email：沒有 SMTP 時仍可預覽，但不啟動排程
+	digestService := services.NewDigestService(amadeusService, weatherService, mailer)
+	if mailer != nil {
+		digestService.StartDigestScheduler(time.Hour)
+	}
+
+	// 地理編碼（Nominatim）由所有功能共用，統一節流與快取
+	geocodingService := services.NewGeocodingService(cfg.GeocoderUserAgent)
+
+	// [關鍵修改] 初始化 Discord Bot，傳入所有服務參數
 	if cfg.HasDiscordAPI() {
 		discordService, err := services.NewDiscordService(
 			cfg.DiscordBotToken,
@@ -49,16 +102,22 @@ func main() {
 			weatherService,
 			exchangeService,
 			foursquareService,
+			geocodingService,
 		)
 
 		if err != nil {
 			log.Printf("❌ Discord 服務初始化失敗: %v", err)
 		} else {
+			discordService.GuildID = cfg.DiscordGuildID
+			discordService.TextCommands = cfg.DiscordTextCommands
+
 			// 啟動 Discord 連線
 			if err := discordService.Start(); err != nil {
 				log.Printf("❌ Discord 連線失敗: %v", err)
 			} else {
 				log.Printf("🤖 Discord 機器人已啟動並監聽指令")
+				notifier.Register(notifications.NewDiscordChannel(discordService.Session, false))
+				notifier.Register(notifications.NewDiscordChannel(discordService.Session, true))
 				// 程式結束時關閉連線
 				defer discordService.Stop()
 			}
@@ -67,8 +126,32 @@ func main() {
 		log.Printf("⚠️ 未設定 DISCORD_BOT_TOKEN，Bot 功能已禁用")
 	}
 
+	// Telegram Bot 與 Discord 共用同一套指令，以長輪詢接收訊息，不需要公開的 webhook 網址
+	if cfg.HasTelegramAPI() {
+		telegramBot := services.NewTelegramBot(
+			cfg.TelegramBotToken,
+			amadeusService,
+			weatherService,
+			exchangeService,
+			foursquareService,
+			geocodingService,
+		)
+		telegramBot.Start()
+		// 私人聊天室的 chat ID 就是使用者 ID，私訊與頻道通知都直接傳到該 ID
+		notifier.Register(notifications.NewTelegramChannel("telegram", telegramBot.Client))
+		notifier.Register(notifications.NewTelegramChannel("telegram_dm", telegramBot.Client))
+		defer telegramBot.Stop()
+	} else {
+		log.Printf("⚠️ 未設定 TELEGRAM_BOT_TOKEN，Telegram Bot 已禁用")
+	}
+
 	// 初始化 Handler
 	flightHandler := handlers.NewFlightHandler(amadeusService, weatherService, exchangeService, foursquareService)
+	flightHandler.SetAdviceEngine(adviceEngine)
+	flightHandler.SetGeocodingService(geocodingService)
+	flightHandler.SetNotifier(notifier)
+	flightHandler.SetWebhookHub(webhookHub)
+	flightHandler.SetDigestService(digestService)
 
 	// 設置路由
 	setupRoutes(flightHandler)
@@ -91,12 +174,31 @@ func setupRoutes(flightHandler *handlers.FlightHandler) {
 	http.HandleFunc("/api/flights/search", flightHandler.SearchFlights)
 	http.HandleFunc("/api/flights/track-prices", flightHandler.TrackFlightPrices)
 	http.HandleFunc("/api/flights/price-trend", flightHandler.GetPriceTrend)
+	http.HandleFunc("/api/flights/history", flightHandler.GetTrackingHistory)
 	http.HandleFunc("/api/airports/search", flightHandler.SearchAirports)
 	http.HandleFunc("/api/alerts/create", flightHandler.CreatePriceAlert)
+	http.HandleFunc("/api/weather/outlook", flightHandler.GetWeatherOutlook)
+	http.HandleFunc("/api/weather/climate", flightHandler.GetClimateNormals)
+	http.HandleFunc("/api/packing-list", flightHandler.GetPackingList)
+	http.HandleFunc("/api/geocode", flightHandler.Geocode)
 	http.HandleFunc("/api/currency/convert", flightHandler.ConvertCurrency)
 	http.HandleFunc("/api/currency/supported", flightHandler.GetSupportedCurrencies)
+	http.HandleFunc("/api/currency/history", flightHandler.GetRateHistory)
+	http.HandleFunc("/api/currency/alerts", flightHandler.ListRateAlerts)
+	http.HandleFunc("/api/currency/alerts/create", flightHandler.CreateRateAlert)
+	http.HandleFunc("/api/currency/alerts/delete", flightHandler.DeleteRateAlert)
 	http.HandleFunc("/api/attractions/search", flightHandler.SearchAttractions)
 	http.HandleFunc("/api/attractions/categories", flightHandler.GetAttractionCategories)
+	http.HandleFunc("/api/attractions/{id}", flightHandler.GetAttractionDetails)
+	http.HandleFunc("/api/notifications/preferences", flightHandler.NotificationPreferences)
+	http.HandleFunc("/api/webhooks", flightHandler.Webhooks)
+	http.HandleFunc("/api/webhooks/delete", flightHandler.DeleteWebhook)
+	http.HandleFunc("/api/webhooks/deliveries", flightHandler.WebhookDeliveries)
+	http.HandleFunc("/api/digest", flightHandler.Digest)
+	http.HandleFunc("/api/digest/delete", flightHandler.DeleteDigest)
+	http.HandleFunc("/api/digest/preview", flightHandler.PreviewDigest)
+	http.HandleFunc("/api/digest/send", flightHandler.SendDigest)
+	http.HandleFunc("/api/itinerary", flightHandler.PlanItinerary)
 	http.HandleFunc("/api/docs", flightHandler.APIDocs)
 	http.HandleFunc("/health", flightHandler.HealthCheck)
 	http.HandleFunc("/timediff", handlers.TimeDiffHandler)
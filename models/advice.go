@@ -0,0 +1,16 @@
+package models
+
+// 建議分類
+const (
+	AdviceCategoryClothing  = "clothing"  // 衣物
+	AdviceCategoryHealth    = "health"    // 健康（防曬、補水、空氣品質）
+	AdviceCategoryLogistics = "logistics" // 行程與交通安排
+)
+
+// 單則旅行建議
+type AdviceItem struct {
+	ID       string `json:"id"`
+	Category string `json:"category"`
+	Priority int    `json:"priority"`
+	Message  string `json:"message"`
+}
@@ -0,0 +1,63 @@
+package models
+
+// 國家旅遊資訊（貨幣、插座、電壓）
+type CountryInfo struct {
+	Code      string   `json:"code"` // ISO 3166-1 alpha-2
+	Name      string   `json:"name"`
+	Currency  string   `json:"currency"`
+	PlugTypes []string `json:"plug_types"` // 插座類型，例如 A、B、G
+	Voltage   int      `json:"voltage"`
+}
+
+var Countries = map[string]CountryInfo{
+	"TW": {Code: "TW", Name: "台灣", Currency: "TWD", PlugTypes: []string{"A", "B"}, Voltage: 110},
+	"JP": {Code: "JP", Name: "日本", Currency: "JPY", PlugTypes: []string{"A", "B"}, Voltage: 100},
+	"KR": {Code: "KR", Name: "韓國", Currency: "KRW", PlugTypes: []string{"C", "F"}, Voltage: 220},
+	"CN": {Code: "CN", Name: "中國", Currency: "CNY", PlugTypes: []string{"A", "C", "I"}, Voltage: 220},
+	"HK": {Code: "HK", Name: "香港", Currency: "HKD", PlugTypes: []string{"G"}, Voltage: 220},
+	"MO": {Code: "MO", Name: "澳門", Currency: "MOP", PlugTypes: []string{"D", "G", "M"}, Voltage: 220},
+	"SG": {Code: "SG", Name: "新加坡", Currency: "SGD", PlugTypes: []string{"G"}, Voltage: 230},
+	"TH": {Code: "TH", Name: "泰國", Currency: "THB", PlugTypes: []string{"A", "B", "C", "O"}, Voltage: 220},
+	"MY": {Code: "MY", Name: "馬來西亞", Currency: "MYR", PlugTypes: []string{"G"}, Voltage: 240},
+	"ID": {Code: "ID", Name: "印尼", Currency: "IDR", PlugTypes: []string{"C", "F"}, Voltage: 230},
+	"PH": {Code: "PH", Name: "菲律賓", Currency: "PHP", PlugTypes: []string{"A", "B", "C"}, Voltage: 220},
+	"US": {Code: "US", Name: "美國", Currency: "USD", PlugTypes: []string{"A", "B"}, Voltage: 120},
+	"CA": {Code: "CA", Name: "加拿大", Currency: "CAD", PlugTypes: []string{"A", "B"}, Voltage: 120},
+	"GB": {Code: "GB", Name: "英國", Currency: "GBP", PlugTypes: []string{"G"}, Voltage: 230},
+	"FR": {Code: "FR", Name: "法國", Currency: "EUR", PlugTypes: []string{"C", "E"}, Voltage: 230},
+	"DE": {Code: "DE", Name: "德國", Currency: "EUR", PlugTypes: []string{"C", "F"}, Voltage: 230},
+	"NL": {Code: "NL", Name: "荷蘭", Currency: "EUR", PlugTypes: []string{"C", "F"}, Voltage: 230},
+	"IT": {Code: "IT", Name: "義大利", Currency: "EUR", PlugTypes: []string{"C", "F", "L"}, Voltage: 230},
+	"ES": {Code: "ES", Name: "西班牙", Currency: "EUR", PlugTypes: []string{"C", "F"}, Voltage: 230},
+	"AU": {Code: "AU", Name: "澳洲", Currency: "AUD", PlugTypes: []string{"I"}, Voltage: 230},
+	"NZ": {Code: "NZ", Name: "紐西蘭", Currency: "NZD", PlugTypes: []string{"I"}, Voltage: 230},
+}
+
+// 城市所屬國家（城市名稱與 AirportCityMap 一致）
+var CityCountryMap = map[string]string{
+	"Taipei": "TW", "Kaohsiung": "TW", "Taichung": "TW", "Tainan": "TW", "Kinmen": "TW", "Matsu": "TW",
+	"Tokyo": "JP", "Osaka": "JP", "Fukuoka": "JP", "Sapporo": "JP", "Okinawa": "JP",
+	"Seoul": "KR", "Busan": "KR", "Jeju": "KR",
+	"Beijing": "CN", "Shanghai": "CN", "Guangzhou": "CN", "Shenzhen": "CN",
+	"Hong Kong": "HK", "Macau": "MO",
+	"Singapore": "SG", "Bangkok": "TH", "Kuala Lumpur": "MY", "Jakarta": "ID", "Denpasar": "ID", "Manila": "PH",
+	"Los Angeles": "US", "San Francisco": "US", "New York": "US", "Chicago": "US",
+	"Toronto": "CA", "Vancouver": "CA",
+	"London": "GB", "Paris": "FR", "Frankfurt": "DE", "Amsterdam": "NL", "Rome": "IT", "Madrid": "ES",
+	"Sydney": "AU", "Melbourne": "AU", "Brisbane": "AU", "Auckland": "NZ",
+}
+
+// GetCountryByCity 查詢城市所屬國家
+func GetCountryByCity(city string) (CountryInfo, bool) {
+	code, ok := CityCountryMap[city]
+	if !ok {
+		return CountryInfo{}, false
+	}
+	info, ok := Countries[code]
+	return info, ok
+}
+
+// GetCountryByAirportCode 查詢機場所在國家
+func GetCountryByAirportCode(airportCode string) (CountryInfo, bool) {
+	return GetCountryByCity(GetCityByAirportCode(airportCode))
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// 摘要寄送頻率
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestSubscription 使用者的追蹤航線摘要 Email 設定
+type DigestSubscription struct {
+	UserID     string     `json:"user_id"` // 與 PriceAlert.Owner 相同（Discord/Telegram 使用者 ID）
+	Email      string     `json:"email"`
+	Frequency  string     `json:"frequency"` // daily, weekly
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSentAt *time.Time `json:"last_sent_at,omitempty"`
+	// LastPrices 上次摘要中各警報的最低價（以警報 ID 為鍵），用於計算價格變化
+	LastPrices map[string]Money `json:"last_prices,omitempty"`
+}
+
+// Period 寄送間隔
+func (s *DigestSubscription) Period() time.Duration {
+	if s.Frequency == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// IsDue 距離上次寄送已超過間隔（tolerance 用來吸收排程檢查的誤差）
+func (s *DigestSubscription) IsDue(now time.Time, tolerance time.Duration) bool {
+	return s.LastSentAt == nil || now.Sub(*s.LastSentAt) >= s.Period()-tolerance
+}
+
+// DigestRoute 摘要中一條追蹤航線的現況
+type DigestRoute struct {
+	AlertID       string `json:"alert_id"`
+	Route         string `json:"route"`
+	Destination   string `json:"destination"`
+	City          string `json:"city"`
+	DepartureDate string `json:"departure_date"`
+	TargetPrice   Money  `json:"target_price"`
+	CurrentPrice  Money  `json:"current_price,omitzero"`
+	// PreviousPrice 上次摘要的最低價，第一次寄送時為空
+	PreviousPrice Money           `json:"previous_price,omitzero"`
+	Change        Money           `json:"change,omitzero"`
+	ChangePercent float64         `json:"change_percent"`
+	Advice        *PriceAdvice    `json:"advice,omitempty"`
+	Weather       *WeatherOutlook `json:"weather,omitempty"`
+	BelowTarget   bool            `json:"below_target"`
+	Error         string          `json:"error,omitempty"`
+}
+
+// Digest 一封摘要的內容
+type Digest struct {
+	UserID      string        `json:"user_id"`
+	Email       string        `json:"email"`
+	Frequency   string        `json:"frequency"`
+	GeneratedAt time.Time     `json:"generated_at"`
+	Routes      []DigestRoute `json:"routes"`
+}
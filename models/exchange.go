@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// 匯率歷史紀錄（儲存在 rate_history.json）
+type RateRecord struct {
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Rate       float64   `json:"rate"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// 匯率時間序列的單一數據點
+type RatePoint struct {
+	Time time.Time `json:"time"`
+	Rate float64   `json:"rate"`
+}
+
+// 匯率時間序列（含統計）
+type RateSeries struct {
+	From   string      `json:"from"`
+	To     string      `json:"to"`
+	Days   int         `json:"days"`
+	Points []RatePoint `json:"points"`
+	Count  int         `json:"count"`
+	Min    float64     `json:"min"`
+	Max    float64     `json:"max"`
+	Avg    float64     `json:"avg"`
+	Latest float64     `json:"latest"`
+}
+
+// 匯率警報方向
+const (
+	RateAlertBelow = "below"
+	RateAlertAbove = "above"
+)
+
+// 匯率警報設定（儲存在 rate_alerts.json）
+type RateAlert struct {
+	ID            string     `json:"id"`
+	From          string     `json:"from"`
+	To            string     `json:"to"`
+	Threshold     float64    `json:"threshold"`
+	Direction     string     `json:"direction"` // below, above
+	Channel       string     `json:"channel"`   // discord, telegram
+	Target        string     `json:"target"`    // Discord 頻道 ID 或 Telegram chat ID
+	IsActive      bool       `json:"is_active"`
+	CreatedAt     time.Time  `json:"created_at"`
+	TriggeredAt   *time.Time `json:"triggered_at,omitempty"`
+	TriggeredRate float64    `json:"triggered_rate,omitempty"`
+}
+
+// 檢查匯率是否達到警報條件
+func (a *RateAlert) IsTriggeredBy(rate float64) bool {
+	if a.Direction == RateAlertAbove {
+		return rate >= a.Threshold
+	}
+	return rate <= a.Threshold
+}
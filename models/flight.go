@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // 搜尋請求
 type SearchRequest struct {
@@ -10,26 +13,65 @@ type SearchRequest struct {
 	ReturnDate    string `json:"return_date,omitempty"`
 	Adults        int    `json:"adults"`
 	Currency      string `json:"currency"`
+	// 回傳價格時使用的顯示貨幣，空值代表與 Currency 相同
+	DisplayCurrency string `json:"display_currency,omitempty"`
 }
 
+// 歷史價格統一儲存的基準貨幣
+const BaseCurrency = "TWD"
+
 // 儲存在 history.json 的單筆紀錄
 type SearchHistoryRecord struct {
 	Origin        string    `json:"origin"`
 	Destination   string    `json:"destination"`
 	DepartureDate string    `json:"departure_date"` // 出發日期
-	Price         float64   `json:"price"`          // 當時查到的最低價
-	RecordDate    time.Time `json:"record_date"`    // 搜尋當下的時間
+	Price         Money     `json:"price"`          // 當時查到的最低價（以 Currency 計價）
+	Currency      string    `json:"currency,omitempty"`
+	RecordDate    time.Time `json:"record_date"` // 搜尋當下的時間
+
+	// 換算前的原始報價與使用的匯率（舊紀錄沒有這些欄位，一律視為 TWD）
+	OriginalPrice    Money   `json:"original_price,omitzero"`
+	OriginalCurrency string  `json:"original_currency,omitempty"`
+	ExchangeRate     float64 `json:"exchange_rate,omitempty"`
+}
+
+// NormalizeCurrency 為從 JSON 讀出的金額補上幣別
+func (r *SearchHistoryRecord) NormalizeCurrency() {
+	r.Price = r.Price.WithCurrency(r.PriceCurrency())
+	if r.OriginalCurrency != "" {
+		r.OriginalPrice = r.OriginalPrice.WithCurrency(r.OriginalCurrency)
+	}
+}
+
+// 取得紀錄的計價貨幣（舊紀錄沒有 currency 欄位）
+func (r SearchHistoryRecord) PriceCurrency() string {
+	if r.Currency == "" {
+		return BaseCurrency
+	}
+	return r.Currency
 }
 
 // 提供給前端的價格建議
 type PriceAdvice struct {
-	CurrentLowest float64 `json:"current_lowest"` // 本次最低價
-	HistoryAvg    float64 `json:"history_avg"`    // 歷史平均價
-	HistoryLow    float64 `json:"history_low"`    // 歷史最低價
-	HistoryHigh   float64 `json:"history_high"`   // 歷史最高價
+	CurrentLowest Money   `json:"current_lowest"` // 本次最低價
+	HistoryAvg    Money   `json:"history_avg"`    // 歷史平均價
+	HistoryLow    Money   `json:"history_low"`    // 歷史最低價
+	HistoryHigh   Money   `json:"history_high"`   // 歷史最高價
 	Trend         string  `json:"trend"`          // 趨勢: "up", "down", "stable"
 	Advice        string  `json:"advice"`         // 文字建議 (e.g., "快買", "再等等")
 	DiffPercent   float64 `json:"diff_percent"`   // 與平均價的差幅百分比
+	Currency      string  `json:"currency,omitempty"`
+
+	// PreviousLow 本次之前的歷史最低價（第一次追蹤時為空），用於判斷是否創新低
+	PreviousLow Money `json:"previous_low,omitzero"`
+}
+
+// IsNewLow 本次最低價是否低於先前所有紀錄（打平不算）
+func (a *PriceAdvice) IsNewLow() (bool, error) {
+	if a == nil || a.PreviousLow.IsZero() {
+		return false, nil
+	}
+	return a.CurrentLowest.Less(a.PreviousLow)
 }
 
 // 新增：價格追蹤請求
@@ -43,10 +85,10 @@ type PriceTrackingRequest struct {
 type PricePoint struct {
 	Week     int       `json:"week"`
 	Date     time.Time `json:"date"`
-	Price    float64   `json:"price"`
+	Price    Money     `json:"price"`
 	Currency string    `json:"currency"`
-	MinPrice float64   `json:"min_price,omitempty"`
-	MaxPrice float64   `json:"max_price,omitempty"`
+	MinPrice Money     `json:"min_price,omitzero"`
+	MaxPrice Money     `json:"max_price,omitzero"`
 }
 
 // 新增：價格分析結果
@@ -54,9 +96,9 @@ type PriceAnalysis struct {
 	Route          string       `json:"route"`
 	TrackWeeks     int          `json:"track_weeks"`
 	DataPoints     []PricePoint `json:"data_points"`
-	MinPrice       float64      `json:"min_price"`
-	MaxPrice       float64      `json:"max_price"`
-	AvgPrice       float64      `json:"avg_price"`
+	MinPrice       Money        `json:"min_price"`
+	MaxPrice       Money        `json:"max_price"`
+	AvgPrice       Money        `json:"avg_price"`
 	BestDate       time.Time    `json:"best_date"`
 	Recommendation string       `json:"recommendation"`
 	CreatedAt      time.Time    `json:"created_at"`
@@ -68,7 +110,7 @@ type PriceTrend struct {
 	Route    string         `json:"route"`
 	Weeks    int            `json:"weeks"`
 	Labels   []string       `json:"labels"`    // 日期標籤
-	Prices   []float64      `json:"prices"`    // 價格數據
+	Prices   []Money        `json:"prices"`    // 價格數據
 	WeekNums []int          `json:"week_nums"` // 週數（重命名避免衝突）
 	Summary  *PriceAnalysis `json:"summary"`   // 分析摘要
 }
@@ -85,14 +127,71 @@ type TrackingTask struct {
 	CompletedAt *time.Time           `json:"completed_at,omitempty"`
 }
 
-// 新增：價格警報設定
+// 價格警報設定（儲存在 price_alerts.json）
 type PriceAlert struct {
-	ID          string     `json:"id"`
-	Route       string     `json:"route"`
-	TargetPrice float64    `json:"target_price"`
-	IsActive    bool       `json:"is_active"`
-	CreatedAt   time.Time  `json:"created_at"`
-	TriggeredAt *time.Time `json:"triggered_at,omitempty"`
+	ID             string     `json:"id"`
+	Route          string     `json:"route"` // 例如 TPE-NRT
+	DepartureDate  string     `json:"departure_date"`
+	TargetPrice    Money      `json:"target_price"`
+	Currency       string     `json:"currency,omitempty"`
+	Channel        string     `json:"channel,omitempty"` // discord, discord_dm, telegram；空值代表只記錄觸發時間
+	Target         string     `json:"target,omitempty"`  // Discord 頻道/使用者 ID 或 Telegram chat ID
+	Owner          string     `json:"owner,omitempty"`   // 建立者（Discord 使用者 ID），/watches 與 /unwatch 依此篩選
+	IsActive       bool       `json:"is_active"`
+	CreatedAt      time.Time  `json:"created_at"`
+	TriggeredAt    *time.Time `json:"triggered_at,omitempty"`
+	TriggeredPrice Money      `json:"triggered_price,omitzero"`
+
+	// Repeat 持續追蹤（/watch）：觸發後不停用，價格再創新低或冷卻時間過後才再次通知
+	Repeat            bool       `json:"repeat,omitempty"`
+	LastNotifiedAt    *time.Time `json:"last_notified_at,omitempty"`
+	LastNotifiedPrice Money      `json:"last_notified_price,omitzero"`
+}
+
+// PriceCurrency 取得警報的計價貨幣（舊紀錄沒有 currency 欄位）
+func (a *PriceAlert) PriceCurrency() string {
+	if a.Currency == "" {
+		return BaseCurrency
+	}
+	return a.Currency
+}
+
+// NormalizeCurrency 為從 JSON 讀出的金額補上幣別
+func (a *PriceAlert) NormalizeCurrency() {
+	a.TargetPrice = a.TargetPrice.WithCurrency(a.PriceCurrency())
+	if !a.TriggeredPrice.IsZero() {
+		a.TriggeredPrice = a.TriggeredPrice.WithCurrency(a.PriceCurrency())
+	}
+	if !a.LastNotifiedPrice.IsZero() {
+		a.LastNotifiedPrice = a.LastNotifiedPrice.WithCurrency(a.PriceCurrency())
+	}
+}
+
+// RouteCodes 拆出航線的出發與抵達機場
+func (a *PriceAlert) RouteCodes() (string, string, bool) {
+	origin, dest, ok := strings.Cut(a.Route, "-")
+	return origin, dest, ok && origin != "" && dest != ""
+}
+
+// IsTriggeredBy 最低價低於或等於目標價時觸發；價格與目標幣別不同時回傳 ErrCurrencyMismatch
+func (a *PriceAlert) IsTriggeredBy(price Money) (bool, error) {
+	if price.Sign() <= 0 {
+		return false, nil
+	}
+	c, err := price.Cmp(a.TargetPrice)
+	return err == nil && c <= 0, err
+}
+
+// ShouldNotify 持續追蹤的警報：第一次觸發、價格比上次通知更低，或距上次通知超過 cooldown 才再通知
+func (a *PriceAlert) ShouldNotify(price Money, now time.Time, cooldown time.Duration) (bool, error) {
+	if a.LastNotifiedAt == nil {
+		return true, nil
+	}
+	lower, err := price.Less(a.LastNotifiedPrice)
+	if err != nil {
+		return false, err
+	}
+	return lower || now.Sub(*a.LastNotifiedAt) >= cooldown, nil
 }
 
 // 新增：歷史價格記錄
@@ -101,7 +200,7 @@ type HistoricalPrice struct {
 	Route      string    `json:"route"`
 	SearchDate time.Time `json:"search_date"`
 	TravelDate time.Time `json:"travel_date"`
-	Price      float64   `json:"price"`
+	Price      Money     `json:"price"`
 	Currency   string    `json:"currency"`
 	Airline    string    `json:"airline,omitempty"`
 	CreatedAt  time.Time `json:"created_at"`
@@ -156,13 +255,16 @@ type TravelerPricing struct {
 
 // 統一的航班響應格式
 type Flight struct {
-	ID           string  `json:"id"`
-	Price        float64 `json:"price"`
-	Currency     string  `json:"currency"`
-	Airline      string  `json:"airline"`
-	FlightNumber string  `json:"flight_number"`
-	From         Airport `json:"from"`
-	To           Airport `json:"to"`
+	ID       string `json:"id"`
+	Price    Money  `json:"price"`
+	Currency string `json:"currency"`
+	// 價格換算成顯示貨幣時，保留 Amadeus 的原始報價
+	OriginalPrice    Money   `json:"original_price,omitzero"`
+	OriginalCurrency string  `json:"original_currency,omitempty"`
+	Airline          string  `json:"airline"`
+	FlightNumber     string  `json:"flight_number"`
+	From             Airport `json:"from"`
+	To               Airport `json:"to"`
 	// [修改] 改用 string 以保持原始當地時間 (解決 08:06 問題)
 	Departure string `json:"departure"`
 	Arrival   string `json:"arrival"`
@@ -170,6 +272,8 @@ type Flight struct {
 	Stops     int    `json:"stops"`
 	Aircraft  string `json:"aircraft"`
 	DeepLink  string `json:"deep_link,omitempty"`
+	// 起降時段的天氣延誤風險（超出預報範圍時不提供）
+	Risk *DisruptionRisk `json:"risk,omitempty"`
 }
 
 type Airport struct {
@@ -218,10 +322,10 @@ type TrackingProgressResponse struct {
 
 // 新增：價格比較結果
 type PriceComparison struct {
-	CurrentPrice   float64   `json:"current_price"`
-	HistoricalLow  float64   `json:"historical_low"`
-	AveragePrice   float64   `json:"average_price"`
-	Savings        float64   `json:"savings"`
+	CurrentPrice   Money     `json:"current_price"`
+	HistoricalLow  Money     `json:"historical_low"`
+	AveragePrice   Money     `json:"average_price"`
+	Savings        Money     `json:"savings"`
 	SavingsPercent float64   `json:"savings_percent"`
 	IsGoodDeal     bool      `json:"is_good_deal"`
 	Recommendation string    `json:"recommendation"`
@@ -240,7 +344,7 @@ type SeasonalPattern struct {
 type RouteInfo struct {
 	Origin           string            `json:"origin"`
 	Destination      string            `json:"destination"`
-	BasePrice        float64           `json:"base_price"`
+	BasePrice        Money             `json:"base_price"`
 	Distance         int               `json:"distance"`   // 公里
 	Popularity       int               `json:"popularity"` // 1-10
 	SeasonalPatterns []SeasonalPattern `json:"seasonal_patterns,omitempty"`
@@ -270,20 +374,25 @@ type WeatherResponse struct {
 			Icon string `json:"icon"`
 			Code int    `json:"code"`
 		} `json:"condition"`
-		Humidity    int     `json:"humidity"`
-		WindKph     float64 `json:"wind_kph"`
-		WindDir     string  `json:"wind_dir"`
-		FeelsLikeC  float64 `json:"feelslike_c"`
-		FeelsLikeF  float64 `json:"feelslike_f"`
-		UV          float64 `json:"uv"`
-		VisKM       float64 `json:"vis_km"`
-		PrecipMM    float64 `json:"precip_mm"`
-		Cloud       int     `json:"cloud"`
-		LastUpdated string  `json:"last_updated"`
+		Humidity    int         `json:"humidity"`
+		WindKph     float64     `json:"wind_kph"`
+		GustKph     float64     `json:"gust_kph"`
+		WindDir     string      `json:"wind_dir"`
+		FeelsLikeC  float64     `json:"feelslike_c"`
+		FeelsLikeF  float64     `json:"feelslike_f"`
+		UV          float64     `json:"uv"`
+		VisKM       float64     `json:"vis_km"`
+		PrecipMM    float64     `json:"precip_mm"`
+		Cloud       int         `json:"cloud"`
+		LastUpdated string      `json:"last_updated"`
+		AirQuality  *AirQuality `json:"air_quality,omitempty"`
 	} `json:"current"`
 	Forecast struct {
 		Forecastday []ForecastDay `json:"forecastday"`
 	} `json:"forecast"`
+	Alerts struct {
+		Alert []WeatherAlert `json:"alert"`
+	} `json:"alerts"`
 }
 
 // 預報天數
@@ -310,7 +419,8 @@ type ForecastDay struct {
 			Icon string `json:"icon"`
 			Code int    `json:"code"`
 		} `json:"condition"`
-		UV float64 `json:"uv"`
+		UV         float64     `json:"uv"`
+		AirQuality *AirQuality `json:"air_quality,omitempty"`
 	} `json:"day"`
 	Hour []HourlyForecast `json:"hour"`
 }
@@ -369,6 +479,13 @@ type WeatherInfo struct {
 	OriginWeather      *WeatherSummary `json:"origin_weather,omitempty"`
 	DestinationWeather *WeatherSummary `json:"destination_weather,omitempty"`
 	TravelAdvice       string          `json:"travel_advice,omitempty"`
+	// 依規則產生、依優先度排序的個別建議
+	AdviceItems []AdviceItem `json:"advice_items,omitempty"`
+	// 目的地整段停留期間的每日天氣展望
+	DestinationOutlook *WeatherOutlook `json:"destination_outlook,omitempty"`
+	// 出發日超出預報範圍時附上的歷年同月氣候平均
+	OriginClimate      *ClimateNormal `json:"origin_climate,omitempty"`
+	DestinationClimate *ClimateNormal `json:"destination_climate,omitempty"`
 }
 
 // 天氣摘要（用於前端顯示）
@@ -381,7 +498,15 @@ type WeatherSummary struct {
 	Humidity     int     `json:"humidity"`
 	WindSpeed    float64 `json:"wind_speed"` // km/h
 	ChanceOfRain int     `json:"chance_of_rain"`
-	Description  string  `json:"description"`
+	UV           float64 `json:"uv,omitempty"` // 紫外線指數
+	UVLevel      string  `json:"uv_level,omitempty"`
+	// 空氣品質（PM2.5、PM10、美國 EPA 指標），只有預報或當天才有資料
+	AirQuality  *AirQuality `json:"air_quality,omitempty"`
+	AQILevel    string      `json:"aqi_level,omitempty"`
+	Description string      `json:"description"`
+	// 資料來源：forecast（預報）、climate（氣候平均）、current（目前天氣）
+	Source         string `json:"source,omitempty"`
+	BeyondForecast bool   `json:"beyond_forecast,omitempty"` // 日期超出預報範圍
 }
 
 // 新增：機場代碼到城市名稱的映射
@@ -501,10 +626,11 @@ type ExchangeRateInfo struct {
 
 // 航班搜尋響應（包含天氣和匯率）
 type FlightSearchResponseWithWeatherAndExchange struct {
-	Flights  []Flight          `json:"flights"`
-	Weather  *WeatherInfo      `json:"weather,omitempty"`
-	Exchange *ExchangeRateInfo `json:"exchange,omitempty"`
-	Meta     struct {
+	Flights     []Flight          `json:"flights"`
+	Weather     *WeatherInfo      `json:"weather,omitempty"`
+	Exchange    *ExchangeRateInfo `json:"exchange,omitempty"`
+	PriceAdvice *PriceAdvice      `json:"price_advice,omitempty"`
+	Meta        struct {
 		Count         int    `json:"count"`
 		Origin        string `json:"origin"`
 		Destination   string `json:"destination"`
@@ -515,15 +641,15 @@ type FlightSearchResponseWithWeatherAndExchange struct {
 
 // 貨幣轉換請求
 type CurrencyConversionRequest struct {
-	Amount       float64 `json:"amount"`
-	FromCurrency string  `json:"from_currency"`
-	ToCurrency   string  `json:"to_currency"`
+	Amount       Money  `json:"amount"` // JSON 為數字，解析後以 FromCurrency 補上幣別
+	FromCurrency string `json:"from_currency"`
+	ToCurrency   string `json:"to_currency"`
 }
 
 // 貨幣轉換響應
 type CurrencyConversionResponse struct {
-	OriginalAmount  float64   `json:"original_amount"`
-	ConvertedAmount float64   `json:"converted_amount"`
+	OriginalAmount  Money     `json:"original_amount"`
+	ConvertedAmount Money     `json:"converted_amount"`
 	FromCurrency    string    `json:"from_currency"`
 	ToCurrency      string    `json:"to_currency"`
 	ExchangeRate    float64   `json:"exchange_rate"`
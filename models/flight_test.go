@@ -0,0 +1,27 @@
+package models
+
+import "testing"
+
+func TestPriceAdvice_IsNewLow(t *testing.T) {
+	tests := []struct {
+		name    string
+		advice  *PriceAdvice
+		want    bool
+		wantErr bool
+	}{
+		{"沒有建議", nil, false, false},
+		{"第一次追蹤", &PriceAdvice{CurrentLowest: NewMoney(8000, "TWD")}, false, false},
+		{"低於先前最低價", &PriceAdvice{CurrentLowest: NewMoney(7900, "TWD"), PreviousLow: NewMoney(8000, "TWD")}, true, false},
+		{"與先前最低價打平", &PriceAdvice{CurrentLowest: NewMoney(8000, "TWD"), PreviousLow: NewMoney(8000, "TWD")}, false, false},
+		{"高於先前最低價", &PriceAdvice{CurrentLowest: NewMoney(8100, "TWD"), PreviousLow: NewMoney(8000, "TWD")}, false, false},
+		{"幣別不同", &PriceAdvice{CurrentLowest: NewMoney(250, "USD"), PreviousLow: NewMoney(8000, "TWD")}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.advice.IsNewLow()
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("IsNewLow() = %v, %v; 預期 %v, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// 地理編碼結果
+type GeoPlace struct {
+	Latitude    float64   `json:"lat"`
+	Longitude   float64   `json:"lng"`
+	Name        string    `json:"name"`         // 簡短名稱，例如「東京都」
+	DisplayName string    `json:"display_name"` // 完整地址
+	City        string    `json:"city,omitempty"`
+	Country     string    `json:"country,omitempty"`
+	CountryCode string    `json:"country_code,omitempty"` // ISO 3166-1 alpha-2（大寫）
+	Type        string    `json:"type,omitempty"`
+	Airport     string    `json:"airport,omitempty"` // 由機場代碼解析時的 IATA 代碼
+	CachedAt    time.Time `json:"cached_at,omitzero"`
+}
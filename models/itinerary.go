@@ -0,0 +1,49 @@
+package models
+
+// 行程規劃請求
+type ItineraryRequest struct {
+	Destination string   `json:"destination"`          // 地名、城市或機場代碼
+	Days        int      `json:"days"`                 // 1 ~ 7
+	Interests   []string `json:"interests,omitempty"`  // 景點類別 slug，例如 museum、park
+	StartDate   string   `json:"start_date,omitempty"` // YYYY-MM-DD，用來判斷每天的營業時間
+	PerDay      int      `json:"per_day,omitempty"`    // 每天最多幾個景點，預設 4
+}
+
+// 行程中的一個景點
+type ItineraryStop struct {
+	Order     int     `json:"order"`
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Category  string  `json:"category,omitempty"`
+	Address   string  `json:"address,omitempty"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Rating    float64 `json:"rating,omitempty"`
+	Arrive    string  `json:"arrive"` // HH:MM
+	Depart    string  `json:"depart"` // HH:MM
+	Hours     string  `json:"hours,omitempty"`
+	// 從上一站（第一站為住宿/市中心）步行的距離與時間
+	WalkMeters  int `json:"walk_meters"`
+	WalkMinutes int `json:"walk_minutes"`
+	WaitMinutes int `json:"wait_minutes,omitempty"` // 抵達時尚未開門需等待的時間
+}
+
+// 一天的行程
+type ItineraryDay struct {
+	Day              int             `json:"day"`
+	Date             string          `json:"date,omitempty"`
+	Stops            []ItineraryStop `json:"stops"`
+	TotalWalkMeters  int             `json:"total_walk_meters"`
+	TotalWalkMinutes int             `json:"total_walk_minutes"`
+}
+
+// 整趟旅遊行程（與航班的 Itinerary 不同）
+type TripItinerary struct {
+	Destination string         `json:"destination"`
+	Place       *GeoPlace      `json:"place"`
+	Interests   []string       `json:"interests"`
+	StartDate   string         `json:"start_date,omitempty"`
+	Days        []ItineraryDay `json:"days"`
+	// 因營業時間或時間不夠而沒排進行程的景點名稱
+	Unscheduled []string `json:"unscheduled,omitempty"`
+}
@@ -0,0 +1,319 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money 以「最小單位 + ISO 貨幣代碼」表示金額，避免 float64 累積誤差
+// 例如 USD 12.34 => Amount 1234；JPY 5000 => Amount 5000
+type Money struct {
+	Amount   int64  // 以該貨幣最小單位計
+	Currency string // ISO 4217 代碼
+}
+
+// 各貨幣在本系統使用的小數位數，未列出的貨幣預設 2 位
+// TWD 雖然 ISO 定義為 2 位，但機票與日常報價都以整數元計，這裡統一取整數
+var currencyDecimals = map[string]int{
+	"TWD": 0,
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"IDR": 0,
+	"CLP": 0,
+	"ISK": 0,
+	"HUF": 0,
+}
+
+// 尚未確定幣別（例如剛從 JSON 解出的數字）時使用的暫存精度
+const unknownCurrencyDecimals = 4
+
+// CurrencyDecimals 回傳貨幣使用的小數位數
+func CurrencyDecimals(currency string) int {
+	if currency == "" {
+		return unknownCurrencyDecimals
+	}
+	if d, ok := currencyDecimals[strings.ToUpper(currency)]; ok {
+		return d
+	}
+	return 2
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+	return p
+}
+
+// NewMoney 由浮點數建立金額，依貨幣精度四捨五入
+func NewMoney(amount float64, currency string) Money {
+	currency = strings.ToUpper(currency)
+	scale := float64(pow10(CurrencyDecimals(currency)))
+	return Money{Amount: int64(math.Round(amount * scale)), Currency: currency}
+}
+
+// ParseMoney 精確解析十進位字串（例如 Amadeus 的 "12345.67"），超出精度的位數四捨五入
+func ParseMoney(s, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	decimals := CurrencyDecimals(currency)
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, fmt.Errorf("金額不可為空")
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" {
+		intPart = "0"
+	}
+	for _, part := range []string{intPart, fracPart} {
+		for _, c := range part {
+			if c < '0' || c > '9' {
+				return Money{}, fmt.Errorf("無效的金額: %q", s)
+			}
+		}
+	}
+
+	// 超出精度的部分只看下一位決定是否進位
+	roundUp := false
+	if len(fracPart) > decimals {
+		roundUp = fracPart[decimals] >= '5'
+		fracPart = fracPart[:decimals]
+	}
+	fracPart += strings.Repeat("0", decimals-len(fracPart))
+
+	amount, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("無效的金額: %q", s)
+	}
+	if roundUp {
+		amount++
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Float64 轉為浮點數（僅用於顯示或統計，不應再寫回 Money）
+func (m Money) Float64() float64 {
+	return float64(m.Amount) / float64(pow10(CurrencyDecimals(m.Currency)))
+}
+
+// Number 以貨幣精度輸出十進位字串，例如 "1234.50"、"7433"
+func (m Money) Number() string {
+	decimals := CurrencyDecimals(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if decimals == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+	scale := pow10(decimals)
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, decimals, amount%scale)
+}
+
+// String 例如 "7433 TWD"、"12.50 USD"
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Number()
+	}
+	return m.Number() + " " + m.Currency
+}
+
+// IsZero 供 json 的 omitzero 使用
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Sign 回傳 -1、0、1
+func (m Money) Sign() int {
+	switch {
+	case m.Amount < 0:
+		return -1
+	case m.Amount > 0:
+		return 1
+	}
+	return 0
+}
+
+// WithCurrency 標示幣別並調整為該貨幣精度（僅重新標示，不做匯率換算）
+// 主要用於 JSON 解出的數字在得知幣別後補上貨幣
+func (m Money) WithCurrency(currency string) Money {
+	currency = strings.ToUpper(currency)
+	from := CurrencyDecimals(m.Currency)
+	to := CurrencyDecimals(currency)
+	amount := m.Amount
+	if to > from {
+		amount *= pow10(to - from)
+	} else if to < from {
+		amount = roundDiv(amount, pow10(from-to))
+	}
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Convert 依匯率換算成另一種貨幣，並依目標貨幣精度四捨五入
+func (m Money) Convert(rate float64, currency string) Money {
+	return NewMoney(m.Float64()*rate, currency)
+}
+
+// ErrCurrencyMismatch 比較或運算的金額幣別不同（例如匯率換算失敗而保留原幣別的報價）
+var ErrCurrencyMismatch = errors.New("貨幣不一致")
+
+// Add 相加，幣別不同時回傳 ErrCurrencyMismatch
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.match(o); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.resultCurrency(o)}, nil
+}
+
+// Sub 相減，幣別不同時回傳 ErrCurrencyMismatch
+func (m Money) Sub(o Money) (Money, error) {
+	if err := m.match(o); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: m.resultCurrency(o)}, nil
+}
+
+// Cmp 比較大小：m < o 回傳 -1，相等 0，m > o 回傳 1；幣別不同時回傳 ErrCurrencyMismatch
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.match(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Less 是否小於 o
+func (m Money) Less(o Money) (bool, error) {
+	c, err := m.Cmp(o)
+	return c < 0, err
+}
+
+// match 零元可與任何幣別運算，其餘必須同幣別
+func (m Money) match(o Money) error {
+	if m.Currency != o.Currency && m.Amount != 0 && o.Amount != 0 {
+		return fmt.Errorf("%w: %s / %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return nil
+}
+
+func (m Money) resultCurrency(o Money) string {
+	if m.Currency == "" || (m.Amount == 0 && o.Amount != 0) {
+		return o.Currency
+	}
+	return m.Currency
+}
+
+// SameCurrency 確認所有非零金額的幣別一致，排序或統計前先檢查
+func SameCurrency(values []Money) error {
+	var first Money
+	for _, v := range values {
+		if v.IsZero() {
+			continue
+		}
+		if first.Currency == "" {
+			first = v
+			continue
+		}
+		if err := first.match(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MinMaxIndex 最低與最高金額的索引（相同時取第一個）；空切片回傳 -1
+func MinMaxIndex(values []Money) (minIdx, maxIdx int, err error) {
+	if len(values) == 0 {
+		return -1, -1, nil
+	}
+	if err := SameCurrency(values); err != nil {
+		return -1, -1, err
+	}
+	for i, v := range values {
+		if v.Amount < values[minIdx].Amount {
+			minIdx = i
+		}
+		if v.Amount > values[maxIdx].Amount {
+			maxIdx = i
+		}
+	}
+	return minIdx, maxIdx, nil
+}
+
+// AverageMoney 計算平均金額（依貨幣精度四捨五入），空切片回傳零值
+func AverageMoney(values []Money) (Money, error) {
+	if len(values) == 0 {
+		return Money{}, nil
+	}
+	sum := Money{Currency: values[0].Currency}
+	for _, v := range values {
+		var err error
+		if sum, err = sum.Add(v); err != nil {
+			return Money{}, err
+		}
+	}
+	return Money{Amount: roundDiv(sum.Amount, int64(len(values))), Currency: sum.Currency}, nil
+}
+
+// roundDiv 整數除法並四捨五入（遠離零）
+func roundDiv(a, b int64) int64 {
+	if (a < 0) != (b < 0) {
+		return -((-a + b/2) / b)
+	}
+	return (a + b/2) / b
+}
+
+// MarshalJSON 輸出為純數字，保持前端既有的 "price": 7433 格式
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Number()), nil
+}
+
+// UnmarshalJSON 接受數字或字串；JSON 本身沒有幣別，解出後需以 WithCurrency 補上
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+	s := strings.Trim(string(data), `"`)
+	if strings.ContainsAny(s, "eE") {
+		// 科學記號只能先以浮點數解析
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("無效的金額: %s", s)
+		}
+		*m = NewMoney(f, m.Currency)
+		return nil
+	}
+	parsed, err := ParseMoney(s, m.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
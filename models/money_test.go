@@ -0,0 +1,109 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		currency string
+		want     int64
+	}{
+		{"美元保留兩位", "1234.56", "USD", 123456},
+		{"美元補零", "12.5", "USD", 1250},
+		{"台幣取整數元", "7433.00", "TWD", 7433},
+		{"台幣四捨五入", "7433.50", "TWD", 7434},
+		{"日圓沒有小數", "15000", "JPY", 15000},
+		{"日圓捨去", "15000.49", "JPY", 15000},
+		{"負數", "-10.005", "USD", -1001},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseMoney(tt.input, tt.currency)
+			if err != nil {
+				t.Fatalf("[%s] 解析失敗: %v", tt.name, err)
+			}
+			if m.Amount != tt.want || m.Currency != tt.currency {
+				t.Errorf("[%s] 預期 %d %s, 結果 %d %s", tt.name, tt.want, tt.currency, m.Amount, m.Currency)
+			}
+		})
+	}
+
+	if _, err := ParseMoney("12a.3", "USD"); err == nil {
+		t.Error("無效金額應回傳錯誤")
+	}
+}
+
+func TestMoneyConvertAndAverage(t *testing.T) {
+	jpy := NewMoney(10000, "JPY")
+	twd := jpy.Convert(0.2137, "TWD")
+	if twd.Amount != 2137 || twd.Currency != "TWD" {
+		t.Errorf("換算結果錯誤: %s", twd)
+	}
+
+	avg, _ := AverageMoney([]Money{NewMoney(100, "TWD"), NewMoney(101, "TWD")})
+	if avg.Amount != 101 {
+		t.Errorf("平均值應四捨五入為 101, 結果 %s", avg)
+	}
+
+	usd, _ := AverageMoney([]Money{NewMoney(0.1, "USD"), NewMoney(0.2, "USD")})
+	if usd.Number() != "0.15" {
+		t.Errorf("美元平均值應為 0.15, 結果 %s", usd.Number())
+	}
+}
+
+func TestMoney_MixedCurrencies(t *testing.T) {
+	twd, usd := NewMoney(8000, "TWD"), NewMoney(250, "USD")
+
+	if _, err := twd.Cmp(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp 不同幣別應回傳 ErrCurrencyMismatch, 結果 %v", err)
+	}
+	if _, err := twd.Less(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Less 不同幣別應回傳 ErrCurrencyMismatch, 結果 %v", err)
+	}
+	if _, err := twd.Sub(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub 不同幣別應回傳 ErrCurrencyMismatch, 結果 %v", err)
+	}
+	if _, err := AverageMoney([]Money{twd, usd}); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("AverageMoney 不同幣別應回傳 ErrCurrencyMismatch, 結果 %v", err)
+	}
+	if _, _, err := MinMaxIndex([]Money{twd, NewMoney(7000, "TWD"), usd}); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("MinMaxIndex 不同幣別應回傳 ErrCurrencyMismatch, 結果 %v", err)
+	}
+
+	// 零元可與任何幣別運算
+	if diff, err := twd.Sub(Money{}); err != nil || diff != twd {
+		t.Errorf("減去零值 = %s, %v", diff, err)
+	}
+	minIdx, maxIdx, err := MinMaxIndex([]Money{twd, NewMoney(7000, "TWD"), NewMoney(9000, "TWD")})
+	if err != nil || minIdx != 1 || maxIdx != 2 {
+		t.Errorf("MinMaxIndex = %d, %d, %v", minIdx, maxIdx, err)
+	}
+}
+
+func TestMoneyJSONCompatibility(t *testing.T) {
+	// 前端與 history.json 都使用純數字格式
+	data, err := json.Marshal(struct {
+		Price Money `json:"price"`
+	}{NewMoney(12.5, "USD")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"price":12.50}` {
+		t.Errorf("JSON 格式錯誤: %s", data)
+	}
+
+	var record SearchHistoryRecord
+	if err := json.Unmarshal([]byte(`{"origin":"TPE","price":7433.4}`), &record); err != nil {
+		t.Fatal(err)
+	}
+	record.NormalizeCurrency()
+	if record.Price.Amount != 7433 || record.Price.Currency != "TWD" {
+		t.Errorf("舊紀錄應視為 TWD 整數元, 結果 %s", record.Price)
+	}
+}
@@ -0,0 +1,55 @@
+package models
+
+// 行李清單請求
+type PackingRequest struct {
+	Origin      string `json:"origin,omitempty"` // 出發機場，用來判斷是否需要轉接頭與換匯
+	Destination string `json:"destination"`      // 目的地機場代碼或城市名稱
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date,omitempty"`
+}
+
+// 行李清單項目
+type PackingItem struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity,omitempty"`
+	Note     string `json:"note,omitempty"`
+}
+
+// 行李清單分類
+type PackingCategory struct {
+	Key   string        `json:"key"` // documents, clothing, rain, sun, electronics, money, health
+	Title string        `json:"title"`
+	Items []PackingItem `json:"items"`
+}
+
+// 目的地用電資訊
+type PowerInfo struct {
+	PlugTypes      []string `json:"plug_types"`
+	Voltage        int      `json:"voltage"`
+	NeedsAdapter   bool     `json:"needs_adapter"`
+	NeedsConverter bool     `json:"needs_converter"`
+}
+
+// 目的地貨幣資訊
+type CurrencyInfo struct {
+	Code         string  `json:"code"`
+	HomeCurrency string  `json:"home_currency,omitempty"`
+	Rate         float64 `json:"rate,omitempty"` // 1 單位當地貨幣 = Rate 單位出發地貨幣
+}
+
+// 行李清單
+type PackingList struct {
+	Destination string            `json:"destination"`
+	City        string            `json:"city"`
+	Country     string            `json:"country,omitempty"`
+	StartDate   string            `json:"start_date"`
+	EndDate     string            `json:"end_date"`
+	Days        int               `json:"days"`
+	MinTemp     float64           `json:"min_temp"`
+	MaxTemp     float64           `json:"max_temp"`
+	RainyDays   int               `json:"rainy_days"`
+	Weather     *WeatherOutlook   `json:"weather,omitempty"`
+	Power       *PowerInfo        `json:"power,omitempty"`
+	Currency    *CurrencyInfo     `json:"currency,omitempty"`
+	Categories  []PackingCategory `json:"categories"`
+}
@@ -0,0 +1,146 @@
+package models
+
+// 天氣資料來源
+const (
+	WeatherSourceForecast    = "forecast"    // 天氣預報
+	WeatherSourceClimate     = "climate"     // 歷年同月氣候平均
+	WeatherSourceCurrent     = "current"     // 目前天氣（沒有預報也沒有氣候資料時的備案）
+	WeatherSourceUnavailable = "unavailable" // 沒有任何資料
+)
+
+// 空氣品質（WeatherAPI 帶 aqi=yes 時回傳，單位 µg/m³）
+type AirQuality struct {
+	CO           float64 `json:"co"`
+	NO2          float64 `json:"no2"`
+	O3           float64 `json:"o3"`
+	SO2          float64 `json:"so2"`
+	PM25         float64 `json:"pm2_5"`
+	PM10         float64 `json:"pm10"`
+	USEPAIndex   int     `json:"us-epa-index"`   // 1 良好 ~ 6 危害
+	GBDefraIndex int     `json:"gb-defra-index"` // 1 ~ 10
+}
+
+// AQILevel 美國 EPA 空氣品質指標等級說明
+func AQILevel(index int) string {
+	switch index {
+	case 1:
+		return "良好"
+	case 2:
+		return "普通"
+	case 3:
+		return "對敏感族群不健康"
+	case 4:
+		return "不健康"
+	case 5:
+		return "非常不健康"
+	case 6:
+		return "危害"
+	}
+	return "未知"
+}
+
+// UVLevel 紫外線指數等級說明
+func UVLevel(uv float64) string {
+	switch {
+	case uv >= 11:
+		return "危險"
+	case uv >= 8:
+		return "過量"
+	case uv >= 6:
+		return "高"
+	case uv >= 3:
+		return "中"
+	}
+	return "低"
+}
+
+// 每月氣候平均值
+type ClimateNormal struct {
+	City      string  `json:"city"`
+	Month     int     `json:"month"`
+	AvgHighC  float64 `json:"avg_high_c"`
+	AvgLowC   float64 `json:"avg_low_c"`
+	AvgTempC  float64 `json:"avg_temp_c"`
+	PrecipMM  float64 `json:"precip_mm"`
+	RainyDays int     `json:"rainy_days"`
+}
+
+// 行程中單日的天氣展望
+type DailyOutlook struct {
+	Date           string  `json:"date"`
+	Source         string  `json:"source"` // forecast, climate, unavailable
+	BeyondForecast bool    `json:"beyond_forecast"`
+	MaxTemp        float64 `json:"max_temp"`
+	MinTemp        float64 `json:"min_temp"`
+	AvgTemp        float64 `json:"avg_temp"`
+	Condition      string  `json:"condition"`
+	Icon           string  `json:"icon,omitempty"`
+	ChanceOfRain   int     `json:"chance_of_rain"`
+	PrecipMM       float64 `json:"precip_mm"`
+}
+
+// 整段行程的天氣展望（出發日到回程日）
+type WeatherOutlook struct {
+	City            string         `json:"city"`
+	StartDate       string         `json:"start_date"`
+	EndDate         string         `json:"end_date"`
+	ForecastHorizon string         `json:"forecast_horizon,omitempty"` // 預報涵蓋的最後一天
+	Days            []DailyOutlook `json:"days"`
+	MinTemp         float64        `json:"min_temp"`
+	MaxTemp         float64        `json:"max_temp"`
+	RainyDays       int            `json:"rainy_days"` // 降雨機率超過 50% 的天數
+	ForecastDays    int            `json:"forecast_days"`
+	ClimateDays     int            `json:"climate_days"`
+}
+
+// WeatherAPI 回傳的氣象警特報（forecast.json 帶 alerts=yes）
+type WeatherAlert struct {
+	Headline  string `json:"headline"`
+	MsgType   string `json:"msgtype"`
+	Severity  string `json:"severity"`
+	Urgency   string `json:"urgency"`
+	Areas     string `json:"areas"`
+	Category  string `json:"category"`
+	Event     string `json:"event"`
+	Effective string `json:"effective"`
+	Expires   string `json:"expires"`
+	Desc      string `json:"desc"`
+}
+
+// 航班受天氣影響的風險等級
+const (
+	RiskLevelLow      = "low"
+	RiskLevelModerate = "moderate"
+	RiskLevelHigh     = "high"
+	RiskLevelSevere   = "severe"
+)
+
+// 單一機場在起降時段的天氣風險
+type AirportRisk struct {
+	Airport string   `json:"airport"`
+	Time    string   `json:"time"` // 當地起降時間
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// 航班延誤/取消的天氣風險評分（0-100，取出發與抵達兩端較高者）
+type DisruptionRisk struct {
+	Score     int          `json:"score"`
+	Level     string       `json:"level"`
+	Reasons   []string     `json:"reasons,omitempty"`
+	Departure *AirportRisk `json:"departure,omitempty"`
+	Arrival   *AirportRisk `json:"arrival,omitempty"`
+}
+
+// RiskLevelFor 依分數換算風險等級
+func RiskLevelFor(score int) string {
+	switch {
+	case score >= 70:
+		return RiskLevelSevere
+	case score >= 45:
+		return RiskLevelHigh
+	case score >= 20:
+		return RiskLevelModerate
+	}
+	return RiskLevelLow
+}
@@ -0,0 +1,77 @@
+package notifications
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord 單則訊息上限 2000 字
+const discordMessageLimit = 2000
+
+// LogChannel 只寫入伺服器日誌，適合開發或沒有其他管道時使用；target 會一併記錄
+type LogChannel struct{}
+
+func (LogChannel) Name() string { return "log" }
+
+func (LogChannel) Send(target string, msg Message) error {
+	log.Printf("📣 [通知→%s] %s\n%s", target, msg.Subject, msg.Text)
+	return nil
+}
+
+// DiscordChannel 傳到 Discord 頻道（target 為頻道 ID），dm 為 true 時改為私訊（target 為使用者 ID）
+type DiscordChannel struct {
+	session *discordgo.Session
+	dm      bool
+}
+
+func NewDiscordChannel(session *discordgo.Session, dm bool) *DiscordChannel {
+	return &DiscordChannel{session: session, dm: dm}
+}
+
+func (d *DiscordChannel) Name() string {
+	if d.dm {
+		return "discord_dm"
+	}
+	return "discord"
+}
+
+func (d *DiscordChannel) Send(target string, msg Message) error {
+	channelID := target
+	if d.dm {
+		channel, err := d.session.UserChannelCreate(target)
+		if err != nil {
+			return fmt.Errorf("無法建立私訊頻道: %v", err)
+		}
+		channelID = channel.ID
+	}
+	_, err := d.session.ChannelMessageSend(channelID, truncateRunes(msg.Text, discordMessageLimit))
+	return err
+}
+
+// TelegramChannel 傳到 Telegram 聊天室（target 為 chat ID）；私人聊天室的 chat ID 就是使用者 ID，
+// 因此 telegram 與 telegram_dm 共用同一個實作，只是名稱不同
+type TelegramChannel struct {
+	name   string
+	client *TelegramService
+}
+
+func NewTelegramChannel(name string, client *TelegramService) *TelegramChannel {
+	return &TelegramChannel{name: name, client: client}
+}
+
+func (t *TelegramChannel) Name() string { return t.name }
+
+func (t *TelegramChannel) Send(target string, msg Message) error {
+	return t.client.SendText(target, truncateRunes(msg.Text, TelegramMessageLimit))
+}
+
+// truncateRunes 依字元數截斷，避免切斷多位元組字元
+func truncateRunes(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,149 @@
+package notifications
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSMTPChannel(t *testing.T) {
+	ch := NewSMTPChannel("smtp.example.com", 587, "", "", "GoSkyAlert <alert@example.com>")
+	var addr string
+	var to []string
+	var body string
+	ch.sendMail = func(a string, _ smtp.Auth, _ string, rcpt []string, msg []byte) error {
+		addr, to, body = a, rcpt, string(msg)
+		return nil
+	}
+
+	if err := ch.Send("me@example.com", Message{Subject: "✈️ 價格警報", Text: "第一行\n第二行"}); err != nil {
+		t.Fatalf("Send 失敗: %v", err)
+	}
+	if addr != "smtp.example.com:587" || len(to) != 1 || to[0] != "me@example.com" {
+		t.Errorf("收件資訊錯誤: %s %v", addr, to)
+	}
+	for _, want := range []string{"Subject: =?UTF-8?b?", "Content-Type: text/plain; charset=UTF-8", "\r\n\r\n第一行\r\n第二行"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("郵件缺少 %q:\n%s", want, body)
+		}
+	}
+
+	if err := ch.Send("me@example.com\r\nBcc: x@example.com", Message{}); err == nil {
+		t.Error("含換行的收件地址應拒絕，避免標頭注入")
+	}
+	ch.sendMail = func(string, smtp.Auth, string, []string, []byte) error { return errors.New("連線逾時") }
+	if err := ch.Send("me@example.com", Message{}); err == nil {
+		t.Error("寄送失敗應回傳錯誤")
+	}
+}
+
+// smtpSink 本機最小 SMTP 伺服器，收下一封信後把原始內容送到 channel
+func smtpSink(t *testing.T) (host string, port int, received <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("無法啟動 SMTP sink: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	out := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+		reply("220 localhost ESMTP sink")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 end with <CRLF>.<CRLF>")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					data.WriteString(strings.TrimPrefix(l, "."))
+				}
+				out <- data.String()
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				return
+			default: // MAIL, RCPT, RSET, NOOP
+				reply("250 OK")
+			}
+		}
+	}()
+
+	h, p, _ := net.SplitHostPort(ln.Addr().String())
+	port, _ = strconv.Atoi(p)
+	return h, port, out
+}
+
+func TestSMTPChannel_SendHTML(t *testing.T) {
+	host, port, received := smtpSink(t)
+	ch := NewSMTPChannel(host, port, "", "", "alert@example.com")
+
+	html := `<p style="color:#0e7c3a">目前最低 7500 TWD</p>`
+	if err := ch.SendHTML("me@example.com", "✈️ 每日摘要", html, "目前最低 7500 TWD"); err != nil {
+		t.Fatalf("SendHTML 失敗: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(<-received))
+	if err != nil {
+		t.Fatalf("無法解析郵件: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "✈️ 每日摘要" || msg.Header.Get("To") != "me@example.com" {
+		t.Errorf("標頭錯誤: %v", msg.Header)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("應為 multipart/alternative: %s", msg.Header.Get("Content-Type"))
+	}
+
+	// multipart.Reader 會自動解開 quoted-printable
+	parts := map[string]string{}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("讀取 part 失敗: %v", err)
+		}
+		body, _ := io.ReadAll(p)
+		ct, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts[ct] = string(body)
+	}
+	if parts["text/plain"] != "目前最低 7500 TWD" || parts["text/html"] != html {
+		t.Errorf("內容錯誤: %+v", parts)
+	}
+
+	if err := ch.SendHTML("me@example.com\r\nBcc: x@example.com", "s", "h", "t"); err == nil {
+		t.Error("含換行的收件地址應拒絕")
+	}
+}
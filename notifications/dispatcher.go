@@ -0,0 +1,306 @@
+package notifications
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	deadLetterDB = "notification_dead_letters.jsonl"
+
+	defaultMaxAttempts = 3
+	defaultRetryDelay  = 2 * time.Second
+	// 等待重試的通知上限，佇列滿時直接寫入 dead letter
+	retryQueueSize = 256
+	// 同時進行的重試上限
+	retryWorkers = 4
+)
+
+// ErrNoRoute 通知沒有指定管道，使用者也沒有設定偏好
+var ErrNoRoute = errors.New("沒有可用的通知管道")
+
+// ErrChannelUnavailable 指定的管道未啟用或不開放給使用者指定
+var ErrChannelUnavailable = errors.New("不支援的通知管道")
+
+// Channel 通知管道轉接器；target 的意義由管道決定（頻道 ID、chat ID、Email ...）
+type Channel interface {
+	Name() string
+	Send(target string, msg Message) error
+}
+
+// DeadLetter 重試後仍失敗的通知，逐行附加到 notification_dead_letters.jsonl 供人工處理
+type DeadLetter struct {
+	FailedAt     time.Time    `json:"failed_at"`
+	Channel      string       `json:"channel"`
+	Target       string       `json:"target"`
+	Attempts     int          `json:"attempts"`
+	Error        string       `json:"error"`
+	Message      Message      `json:"message"`
+	Notification Notification `json:"notification"`
+}
+
+// retryJob 等待背景重試的單一送達位置
+type retryJob struct {
+	n       Notification
+	route   Endpoint
+	msg     Message
+	attempt int // 下一次是第幾次嘗試
+	delay   time.Duration
+	due     time.Time
+}
+
+// Dispatcher 依通知指定的管道或使用者偏好，套用範本後送出；失敗時交給背景重試，用盡後寫入 dead letter
+type Dispatcher struct {
+	MaxAttempts int
+	// RetryDelay 第一次重試前的等待時間，之後每次加倍
+	RetryDelay time.Duration
+
+	prefs     *PreferenceStore
+	templates *Templates
+
+	mu       sync.RWMutex
+	channels map[string]Channel
+
+	deadLetterPath  string
+	deadLetterMutex sync.Mutex
+
+	retryPending chan struct{}  // 等待中的重試，容量即佇列上限
+	retrySlots   chan struct{}  // 正在傳送的重試
+	retryJobs    sync.WaitGroup // 尚未完成（送達或寫入 dead letter）的重試
+}
+
+func NewDispatcher(prefs *PreferenceStore, templates *Templates) *Dispatcher {
+	if templates == nil {
+		templates = DefaultTemplates()
+	}
+	return &Dispatcher{
+		MaxAttempts:    defaultMaxAttempts,
+		RetryDelay:     defaultRetryDelay,
+		prefs:          prefs,
+		templates:      templates,
+		channels:       make(map[string]Channel),
+		deadLetterPath: deadLetterDB,
+		retryPending:   make(chan struct{}, retryQueueSize),
+		retrySlots:     make(chan struct{}, retryWorkers),
+	}
+}
+
+// Register 註冊管道，名稱重複時取代舊的
+func (d *Dispatcher) Register(ch Channel) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.channels[ch.Name()] = ch
+}
+
+// HasChannel 是否已註冊該名稱的管道
+func (d *Dispatcher) HasChannel(name string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	_, ok := d.channels[name]
+	return ok
+}
+
+// Channels 已註冊的管道名稱
+func (d *Dispatcher) Channels() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	names := make([]string, 0, len(d.channels))
+	for name := range d.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckChannel 使用者指定的管道是否可用：必須已註冊且開放給使用者設定，
+// 建立警報時先檢查，避免存下送不出去（或不該送出）的管道
+func (d *Dispatcher) CheckChannel(name string) error {
+	if !UserSettable(name) || !d.HasChannel(name) {
+		return fmt.Errorf("%w: %s", ErrChannelUnavailable, name)
+	}
+	return nil
+}
+
+// Preferences 使用者偏好的儲存，未設定時為 nil
+func (d *Dispatcher) Preferences() *PreferenceStore {
+	return d.prefs
+}
+
+// routes 通知指定了管道就只送到該處，否則送到使用者偏好中接收此種類的所有位置
+// 指定的管道同樣要通過 CheckChannel，舊資料裡的 webhook 等管道不會送出
+func (d *Dispatcher) routes(n Notification) ([]Endpoint, error) {
+	if n.Channel != "" {
+		if err := d.CheckChannel(n.Channel); err != nil {
+			return nil, err
+		}
+		return []Endpoint{{Channel: n.Channel, Target: n.Target}}, nil
+	}
+	if n.UserID == "" || d.prefs == nil {
+		return nil, ErrNoRoute
+	}
+	endpoints, err := d.prefs.Get(n.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("讀取通知偏好失敗: %v", err)
+	}
+	var routes []Endpoint
+	for _, e := range endpoints {
+		// 舊版偏好檔可能含有已不開放的管道，一律略過
+		if e.Accepts(n.Kind) && UserSettable(e.Channel) {
+			routes = append(routes, e)
+		}
+	}
+	if len(routes) == 0 {
+		return nil, ErrNoRoute
+	}
+	return routes, nil
+}
+
+// Send 送出通知；每個位置只在呼叫端嘗試一次，失敗的交給背景重試，不會阻塞呼叫端
+// 任一位置送達或已排入重試即視為成功，全部無法送出時回傳錯誤
+func (d *Dispatcher) Send(n Notification) error {
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	routes, err := d.routes(n)
+	if err != nil {
+		return err
+	}
+	msg, err := d.templates.Render(n)
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	for _, route := range routes {
+		if err := d.deliver(n, route, msg); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", route.Channel, err))
+		}
+	}
+	if len(failures) == len(routes) {
+		return fmt.Errorf("通知傳送失敗: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// deliver 送到單一位置，第一次失敗時排入重試佇列；管道未啟用或無法重試時寫入 dead letter 並回傳錯誤
+func (d *Dispatcher) deliver(n Notification, route Endpoint, msg Message) error {
+	d.mu.RLock()
+	ch, ok := d.channels[route.Channel]
+	d.mu.RUnlock()
+	if !ok {
+		err := fmt.Errorf("未啟用的通知管道: %s", route.Channel)
+		d.deadLetter(n, route, msg, 0, err)
+		return err
+	}
+
+	attempts := max(d.MaxAttempts, 1)
+	err := ch.Send(route.Target, msg)
+	if err == nil {
+		return nil
+	}
+	log.Printf("⚠️ %s 通知第 1/%d 次傳送失敗: %v", route.Channel, attempts, err)
+	if attempts == 1 {
+		d.deadLetter(n, route, msg, 1, err)
+		return err
+	}
+
+	d.retryJobs.Add(1)
+	if !d.enqueue(retryJob{n: n, route: route, msg: msg, attempt: 2, delay: d.RetryDelay, due: time.Now().Add(d.RetryDelay)}) {
+		d.retryJobs.Done()
+		d.deadLetter(n, route, msg, 1, err)
+		return fmt.Errorf("重試佇列已滿: %v", err)
+	}
+	return nil
+}
+
+// enqueue 為重試設定各自的計時器，到期後在有限的並行數內送出；
+// 每筆依自己的時間執行，退避較久的重試不會擋住後面較早到期的。佇列滿時回傳 false
+func (d *Dispatcher) enqueue(job retryJob) bool {
+	select {
+	case d.retryPending <- struct{}{}:
+	default:
+		return false
+	}
+	time.AfterFunc(time.Until(job.due), func() {
+		d.retrySlots <- struct{}{}
+		<-d.retryPending
+		d.retry(job)
+		<-d.retrySlots
+	})
+	return true
+}
+
+func (d *Dispatcher) retry(job retryJob) {
+	defer d.retryJobs.Done()
+
+	attempts := max(d.MaxAttempts, 1)
+	d.mu.RLock()
+	ch, ok := d.channels[job.route.Channel]
+	d.mu.RUnlock()
+	if !ok {
+		d.deadLetter(job.n, job.route, job.msg, job.attempt-1, fmt.Errorf("未啟用的通知管道: %s", job.route.Channel))
+		return
+	}
+
+	err := ch.Send(job.route.Target, job.msg)
+	if err == nil {
+		log.Printf("✅ %s 通知第 %d 次重試成功", job.route.Channel, job.attempt)
+		return
+	}
+	log.Printf("⚠️ %s 通知第 %d/%d 次傳送失敗: %v", job.route.Channel, job.attempt, attempts, err)
+	if job.attempt >= attempts {
+		d.deadLetter(job.n, job.route, job.msg, job.attempt, err)
+		return
+	}
+
+	job.attempt++
+	job.delay *= 2
+	job.due = time.Now().Add(job.delay)
+	d.retryJobs.Add(1)
+	if !d.enqueue(job) {
+		d.retryJobs.Done()
+		d.deadLetter(job.n, job.route, job.msg, job.attempt-1, err)
+	}
+}
+
+// WaitRetries 等待目前排入的重試全部完成（送達或寫入 dead letter），供關閉服務前呼叫
+func (d *Dispatcher) WaitRetries() {
+	d.retryJobs.Wait()
+}
+
+func (d *Dispatcher) deadLetter(n Notification, route Endpoint, msg Message, attempts int, cause error) {
+	record := DeadLetter{
+		FailedAt:     time.Now(),
+		Channel:      route.Channel,
+		Target:       route.Target,
+		Attempts:     attempts,
+		Error:        cause.Error(),
+		Message:      msg,
+		Notification: n,
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("❌ 無法記錄失敗的通知: %v", err)
+		return
+	}
+
+	d.deadLetterMutex.Lock()
+	defer d.deadLetterMutex.Unlock()
+
+	file, err := os.OpenFile(d.deadLetterPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("❌ 無法開啟 %s: %v", d.deadLetterPath, err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		log.Printf("❌ 寫入 %s 失敗: %v", d.deadLetterPath, err)
+	}
+}
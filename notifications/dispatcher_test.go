@@ -0,0 +1,382 @@
+package notifications
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"final/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeChannel 記錄收到的訊息，前 failures 次傳送回傳錯誤
+type fakeChannel struct {
+	name     string
+	failures int
+	calls    int
+	sent     []string
+}
+
+func (f *fakeChannel) Name() string { return f.name }
+
+func (f *fakeChannel) Send(target string, msg Message) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return errors.New("暫時無法連線")
+	}
+	f.sent = append(f.sent, target+"|"+msg.Text)
+	return nil
+}
+
+func testDispatcher(t *testing.T) *Dispatcher {
+	t.Helper()
+	dir := t.TempDir()
+	d := NewDispatcher(&PreferenceStore{path: filepath.Join(dir, "prefs.json")}, nil)
+	d.RetryDelay = 0
+	d.deadLetterPath = filepath.Join(dir, "dead.jsonl")
+	return d
+}
+
+func testPriceAlert() Notification {
+	return Notification{
+		Kind: KindPriceAlert,
+		PriceAlert: &PriceAlertEvent{
+			Alert: models.PriceAlert{ID: "pa1", Route: "TPE-NRT", DepartureDate: "2026-12-01",
+				TargetPrice: models.NewMoney(8000, "TWD"), Repeat: true},
+			Price: models.NewMoney(7500, "TWD"),
+		},
+	}
+}
+
+func readDeadLetters(t *testing.T, path string) []DeadLetter {
+	t.Helper()
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var records []DeadLetter
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var r DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("dead letter 格式錯誤: %v", err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestTemplates_Render(t *testing.T) {
+	best := time.Date(2026, 11, 3, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		n           Notification
+		wantSubject string
+		wantText    []string
+	}{
+		{
+			name:        "價格警報",
+			n:           testPriceAlert(),
+			wantSubject: "✈️ 價格警報：TPE-NRT (2026-12-01) 已降到 7500 TWD",
+			wantText:    []string{"目標價格：8000 TWD", "/unwatch", "pa1"},
+		},
+		{
+			name: "追蹤完成",
+			n: Notification{Kind: KindTrackingComplete, Tracking: &TrackingEvent{Analysis: models.PriceAnalysis{
+				Route: "TPE-NRT", TrackWeeks: 8, BestDate: best, Recommendation: "建議提早訂票",
+				MinPrice: models.NewMoney(6000, "TWD"), AvgPrice: models.NewMoney(7000, "TWD"), MaxPrice: models.NewMoney(9000, "TWD"),
+			}}},
+			wantSubject: "📈 價格追蹤完成：TPE-NRT",
+			wantText:    []string{"8 週", "2026-11-03 出發", "💡 建議提早訂票"},
+		},
+		{
+			name: "匯率警報",
+			n: Notification{Kind: KindRateAlert, RateAlert: &RateAlertEvent{
+				Alert: models.RateAlert{From: "USD", To: "TWD", Threshold: 32, Direction: "above"}, Rate: 32.5,
+			}},
+			wantSubject: "💱 匯率警報：USD→TWD",
+			wantText:    []string{"已突破 32.0000", "1 USD = 32.5000 TWD"},
+		},
+	}
+
+	templates := DefaultTemplates()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := templates.Render(tt.n)
+			if err != nil {
+				t.Fatalf("Render 失敗: %v", err)
+			}
+			if msg.Subject != tt.wantSubject {
+				t.Errorf("主旨 = %q, 預期 %q", msg.Subject, tt.wantSubject)
+			}
+			for _, want := range tt.wantText {
+				if !strings.Contains(msg.Text, want) {
+					t.Errorf("內文缺少 %q:\n%s", want, msg.Text)
+				}
+			}
+		})
+	}
+}
+
+func TestNewTemplates_Overrides(t *testing.T) {
+	templates, err := NewTemplates(map[Kind]Template{KindRateAlert: {Body: "{{.RateAlert.Alert.From}} 到價"}})
+	if err != nil {
+		t.Fatalf("NewTemplates 失敗: %v", err)
+	}
+	n := Notification{Kind: KindRateAlert, RateAlert: &RateAlertEvent{Alert: models.RateAlert{From: "JPY", To: "TWD"}}}
+	msg, err := templates.Render(n)
+	if err != nil || msg.Text != "JPY 到價" || msg.Subject != "💱 匯率警報：JPY→TWD" {
+		t.Errorf("只覆寫內文時主旨應維持內建範本: %+v, %v", msg, err)
+	}
+
+	if _, err := NewTemplates(map[Kind]Template{"unknown": {Body: "x"}}); err == nil {
+		t.Error("未知的通知種類應回傳錯誤")
+	}
+	if _, err := NewTemplates(map[Kind]Template{KindPriceAlert: {Body: "{{.PriceAlert"}}); err == nil {
+		t.Error("語法錯誤的範本應回傳錯誤")
+	}
+	if _, err := DefaultTemplates().Render(Notification{Kind: KindPriceAlert}); err == nil {
+		t.Error("缺少事件內容時應回傳錯誤而不是送出空白訊息")
+	}
+}
+
+func TestDispatcher_RetriesAndDeadLetter(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		maxAttempts  int
+		wantErr      bool
+		wantCalls    int
+		wantLetters  int
+		wantAttempts int
+	}{
+		{"第一次就成功", 0, 3, false, 1, 0, 0},
+		{"背景重試後成功", 2, 3, false, 3, 0, 0},
+		{"重試用盡寫入 dead letter", 5, 3, false, 3, 1, 3},
+		{"不重試時直接回傳錯誤", 5, 1, true, 1, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := testDispatcher(t)
+			d.MaxAttempts = tt.maxAttempts
+			ch := &fakeChannel{name: "discord", failures: tt.failures}
+			d.Register(ch)
+
+			n := testPriceAlert()
+			n.Channel, n.Target = "discord", "123"
+			err := d.Send(n)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send 錯誤 = %v, 預期錯誤 %v", err, tt.wantErr)
+			}
+			d.WaitRetries()
+			if ch.calls != tt.wantCalls {
+				t.Errorf("傳送 %d 次，預期 %d 次", ch.calls, tt.wantCalls)
+			}
+
+			letters := readDeadLetters(t, d.deadLetterPath)
+			if len(letters) != tt.wantLetters {
+				t.Fatalf("dead letter %d 筆，預期 %d 筆", len(letters), tt.wantLetters)
+			}
+			if tt.wantLetters > 0 {
+				l := letters[0]
+				if l.Channel != "discord" || l.Target != "123" || l.Attempts != tt.wantAttempts || l.Notification.PriceAlert == nil || l.Message.Text == "" {
+					t.Errorf("dead letter 內容不完整: %+v", l)
+				}
+			}
+		})
+	}
+}
+
+// 重試在背景進行，Send 只等第一次嘗試
+func TestDispatcher_SendDoesNotWaitForRetries(t *testing.T) {
+	d := testDispatcher(t)
+	d.RetryDelay = time.Hour
+	ch := &fakeChannel{name: "discord", failures: 5}
+	d.Register(ch)
+
+	n := testPriceAlert()
+	n.Channel, n.Target = "discord", "123"
+	start := time.Now()
+	if err := d.Send(n); err != nil {
+		t.Fatalf("排入重試應視為成功: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Send 花了 %s，不應等待重試", elapsed)
+	}
+	if ch.calls != 1 {
+		t.Errorf("Send 只應嘗試一次，實際 %d 次", ch.calls)
+	}
+}
+
+// signalChannel 每次傳送都把 target 送到 sent，供測試等待背景重試
+type signalChannel struct {
+	name string
+	sent chan string
+}
+
+func (c *signalChannel) Name() string { return c.name }
+
+func (c *signalChannel) Send(target string, msg Message) error {
+	c.sent <- target
+	return nil
+}
+
+// 退避較久的重試不應擋住較早到期的重試
+func TestDispatcher_RetriesRunByDueTime(t *testing.T) {
+	d := testDispatcher(t)
+	slow := &signalChannel{name: "slow", sent: make(chan string, 1)}
+	fast := &signalChannel{name: "fast", sent: make(chan string, 1)}
+	d.Register(slow)
+	d.Register(fast)
+
+	n := testPriceAlert()
+	jobs := []retryJob{
+		{n: n, route: Endpoint{Channel: "slow", Target: "1"}, attempt: 3, delay: time.Hour, due: time.Now().Add(time.Hour)},
+		{n: n, route: Endpoint{Channel: "fast", Target: "2"}, attempt: 2, due: time.Now()},
+	}
+	for _, job := range jobs {
+		d.retryJobs.Add(1)
+		if !d.enqueue(job) {
+			t.Fatal("佇列未滿時應能排入重試")
+		}
+	}
+
+	select {
+	case target := <-fast.sent:
+		if target != "2" {
+			t.Errorf("送到錯誤的位置: %s", target)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("已到期的重試被退避較久的重試擋住")
+	}
+	select {
+	case <-slow.sent:
+		t.Error("尚未到期的重試不應送出")
+	default:
+	}
+}
+
+// 指定的管道必須已註冊且開放給使用者，否則不送出也不記入 dead letter
+func TestDispatcher_ExplicitChannel(t *testing.T) {
+	d := testDispatcher(t)
+	discord := &fakeChannel{name: "discord"}
+	webhook := &fakeChannel{name: "webhook"}
+	d.Register(discord)
+	d.Register(webhook)
+
+	tests := []struct {
+		name    string
+		channel string
+		wantErr error
+	}{
+		{"已註冊的管道", "discord", nil},
+		{"未註冊的管道", "telegram", ErrChannelUnavailable},
+		{"不開放的 webhook 管道", "webhook", ErrChannelUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := testPriceAlert()
+			n.Channel, n.Target = tt.channel, "http://169.254.169.254/"
+			if err := d.CheckChannel(tt.channel); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckChannel 錯誤 = %v, 預期 %v", err, tt.wantErr)
+			}
+			if err := d.Send(n); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Send 錯誤 = %v, 預期 %v", err, tt.wantErr)
+			}
+		})
+	}
+	if webhook.calls != 0 {
+		t.Errorf("webhook 管道不應被呼叫，實際 %d 次", webhook.calls)
+	}
+	if letters := readDeadLetters(t, d.deadLetterPath); len(letters) != 0 {
+		t.Errorf("被拒絕的管道不應記入 dead letter: %+v", letters)
+	}
+}
+
+func TestDispatcher_Preferences(t *testing.T) {
+	d := testDispatcher(t)
+	discord := &fakeChannel{name: "discord_dm"}
+	email := &fakeChannel{name: "email"}
+	d.Register(discord)
+	d.Register(email)
+
+	err := d.Preferences().Set("u1", []Endpoint{
+		{Channel: "discord_dm", Target: "42"},
+		{Channel: "email", Target: "me@example.com", Kinds: []Kind{KindTrackingComplete}},
+	})
+	if err != nil {
+		t.Fatalf("Set 失敗: %v", err)
+	}
+
+	n := testPriceAlert()
+	n.UserID = "u1"
+	if err := d.Send(n); err != nil {
+		t.Fatalf("Send 失敗: %v", err)
+	}
+	if len(discord.sent) != 1 || len(email.sent) != 0 {
+		t.Errorf("價格警報只應送到未限定種類的管道: discord=%v email=%v", discord.sent, email.sent)
+	}
+
+	n.UserID = "nobody"
+	if err := d.Send(n); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("沒有偏好的使用者應回傳 ErrNoRoute，實際 %v", err)
+	}
+
+	// 其中一個管道未啟用時，其餘管道送達仍算成功，失敗的記入 dead letter
+	d.Preferences().Set("u2", []Endpoint{{Channel: "telegram_dm", Target: "42"}, {Channel: "email", Target: "u2@example.com"}})
+	n.UserID = "u2"
+	if err := d.Send(n); err != nil {
+		t.Errorf("部分管道送達應視為成功: %v", err)
+	}
+	if letters := readDeadLetters(t, d.deadLetterPath); len(letters) != 1 || letters[0].Channel != "telegram_dm" || letters[0].Attempts != 0 {
+		t.Errorf("未啟用的管道應直接記入 dead letter: %+v", letters)
+	}
+
+	// 舊版偏好檔中的 webhook 位置不再送出
+	d.Register(&fakeChannel{name: "webhook"})
+	os.WriteFile(d.prefs.path, []byte(`{"u3":[{"channel":"webhook","target":"http://127.0.0.1/hook"}]}`), 0644)
+	n.UserID = "u3"
+	if err := d.Send(n); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("不開放的管道應略過，實際 %v", err)
+	}
+}
+
+func TestPreferenceStore_Set(t *testing.T) {
+	store := &PreferenceStore{path: filepath.Join(t.TempDir(), "prefs.json")}
+	tests := []struct {
+		name      string
+		userID    string
+		endpoints []Endpoint
+		wantErr   bool
+	}{
+		{"正常設定", "u1", []Endpoint{{Channel: "email", Target: "a@b.c", Kinds: []Kind{KindRateAlert}}}, false},
+		{"缺少使用者", "", []Endpoint{{Channel: "email", Target: "a@b.c"}}, true},
+		{"缺少 target", "u1", []Endpoint{{Channel: "email"}}, true},
+		{"未知的種類", "u1", []Endpoint{{Channel: "email", Target: "a@b.c", Kinds: []Kind{"weekly"}}}, true},
+		{"不開放的 webhook 管道", "u1", []Endpoint{{Channel: "webhook", Target: "http://127.0.0.1/hook"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := store.Set(tt.userID, tt.endpoints); (err != nil) != tt.wantErr {
+				t.Errorf("Set 錯誤 = %v, 預期錯誤 %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	got, _ := store.Get("u1")
+	if len(got) != 1 || got[0].Target != "a@b.c" {
+		t.Errorf("失敗的設定不應覆寫既有偏好: %+v", got)
+	}
+	store.Set("u1", nil)
+	if got, _ := store.Get("u1"); len(got) != 0 {
+		t.Errorf("清空後應沒有偏好: %+v", got)
+	}
+}
@@ -0,0 +1,56 @@
+package notifications
+
+import (
+	"final/models"
+	"time"
+)
+
+// Kind 通知種類，用於挑選訊息範本與使用者的管道偏好
+type Kind string
+
+const (
+	KindPriceAlert       Kind = "price_alert"       // 機票價格警報觸發
+	KindTrackingComplete Kind = "tracking_complete" // 多週價格追蹤完成
+	KindRateAlert        Kind = "rate_alert"        // 匯率警報觸發
+)
+
+// Kinds 所有通知種類
+var Kinds = []Kind{KindPriceAlert, KindTrackingComplete, KindRateAlert}
+
+// Notification 一則待送出的通知；依 Kind 填入對應的事件內容
+type Notification struct {
+	Kind Kind `json:"kind"`
+	// Channel 與 Target 指定送達位置（例如警報建立時選的 Discord 頻道）；
+	// Channel 為空時改用 UserID 的管道偏好
+	Channel   string    `json:"channel,omitempty"`
+	Target    string    `json:"target,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+
+	PriceAlert *PriceAlertEvent `json:"price_alert,omitempty"`
+	Tracking   *TrackingEvent   `json:"tracking,omitempty"`
+	RateAlert  *RateAlertEvent  `json:"rate_alert,omitempty"`
+}
+
+// PriceAlertEvent 價格警報觸發時的最低價
+type PriceAlertEvent struct {
+	Alert models.PriceAlert `json:"alert"`
+	Price models.Money      `json:"price"`
+}
+
+// TrackingEvent 價格追蹤的分析結果
+type TrackingEvent struct {
+	Analysis models.PriceAnalysis `json:"analysis"`
+}
+
+// RateAlertEvent 匯率警報觸發時的匯率
+type RateAlertEvent struct {
+	Alert models.RateAlert `json:"alert"`
+	Rate  float64          `json:"rate"`
+}
+
+// Message 依範本產生、實際送到各管道的內容
+type Message struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+}
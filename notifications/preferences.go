@@ -0,0 +1,129 @@
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+const notificationPrefsDB = "notification_prefs.json"
+
+// Endpoint 一個送達位置，例如 {channel: "email", target: "me@example.com"}
+type Endpoint struct {
+	Channel string `json:"channel"`
+	Target  string `json:"target"`
+	// Kinds 只接收這些種類的通知，空值表示全部
+	Kinds []Kind `json:"kinds,omitempty"`
+}
+
+// Accepts 此位置是否接收該種類的通知
+func (e Endpoint) Accepts(kind Kind) bool {
+	if len(e.Kinds) == 0 {
+		return true
+	}
+	for _, k := range e.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// 不開放設為個人偏好的管道：偏好 API 沒有驗證使用者身分，webhook 又能指向任意網址，
+// 外部系統請改用有簽章的 /api/webhooks 訂閱
+var restrictedChannels = map[string]bool{"webhook": true}
+
+// UserSettable 使用者能否把該管道設為自己的通知位置
+func UserSettable(channel string) bool {
+	return !restrictedChannels[channel]
+}
+
+// PreferenceStore 每位使用者的通知管道偏好（儲存在 notification_prefs.json）
+type PreferenceStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewPreferenceStore() *PreferenceStore {
+	return &PreferenceStore{path: notificationPrefsDB}
+}
+
+// load 讀取所有偏好（呼叫端需持有 mu）
+func (s *PreferenceStore) load() (map[string][]Endpoint, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string][]Endpoint{}, nil
+		}
+		return nil, err
+	}
+	prefs := map[string][]Endpoint{}
+	if err := json.Unmarshal(data, &prefs); err != nil {
+		return map[string][]Endpoint{}, nil
+	}
+	return prefs, nil
+}
+
+// Get 取得使用者的通知位置，沒有設定時回傳空陣列
+func (s *PreferenceStore) Get(userID string) ([]Endpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefs, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if endpoints, ok := prefs[userID]; ok {
+		return endpoints, nil
+	}
+	return []Endpoint{}, nil
+}
+
+// Set 取代使用者的通知位置；endpoints 為空時刪除該使用者的設定
+func (s *PreferenceStore) Set(userID string, endpoints []Endpoint) error {
+	if userID == "" {
+		return fmt.Errorf("缺少使用者 ID")
+	}
+	for _, e := range endpoints {
+		if e.Channel == "" || e.Target == "" {
+			return fmt.Errorf("每個通知位置都需要 channel 與 target")
+		}
+		if !UserSettable(e.Channel) {
+			return fmt.Errorf("%s 不能設為個人通知管道，請改用 /api/webhooks 訂閱", e.Channel)
+		}
+		for _, k := range e.Kinds {
+			if !isKind(k) {
+				return fmt.Errorf("不支援的通知種類: %s", k)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefs, err := s.load()
+	if err != nil {
+		return err
+	}
+	if len(endpoints) == 0 {
+		delete(prefs, userID)
+	} else {
+		prefs[userID] = endpoints
+	}
+
+	data, err := json.MarshalIndent(prefs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+func isKind(k Kind) bool {
+	for _, kind := range Kinds {
+		if kind == k {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// SMTPChannel 以 Email 寄送（target 為收件地址）
+type SMTPChannel struct {
+	Addr string // host:port
+	From string
+	auth smtp.Auth
+	// sendMail 預設為 smtp.SendMail，測試時可替換
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPChannel username 為空時不做驗證（例如內部轉寄伺服器）
+func NewSMTPChannel(host string, port int, username, password, from string) *SMTPChannel {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPChannel{
+		Addr:     net.JoinHostPort(host, fmt.Sprint(port)),
+		From:     from,
+		auth:     auth,
+		sendMail: smtp.SendMail,
+	}
+}
+
+func (s *SMTPChannel) Name() string { return "email" }
+
+func (s *SMTPChannel) Send(target string, msg Message) error {
+	if !validEmail(target) {
+		return fmt.Errorf("無效的 Email: %s", target)
+	}
+	if err := s.sendMail(s.Addr, s.auth, s.From, []string{target}, s.compose(target, msg)); err != nil {
+		return fmt.Errorf("寄送 Email 失敗: %v", err)
+	}
+	return nil
+}
+
+// SendHTML 寄送 HTML 郵件，附上純文字版本給不顯示 HTML 的郵件軟體（multipart/alternative）
+func (s *SMTPChannel) SendHTML(to, subject, htmlBody, textBody string) error {
+	if !validEmail(to) {
+		return fmt.Errorf("無效的 Email: %s", to)
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", textBody},
+		{"text/html; charset=UTF-8", htmlBody},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return err
+		}
+		qp := quotedprintable.NewWriter(w)
+		qp.Write([]byte(part.content))
+		qp.Close()
+	}
+	parts.Close()
+
+	msg := s.headers(to, subject, "multipart/alternative; boundary="+parts.Boundary(), "")
+	msg = append(msg, body.Bytes()...)
+	if err := s.sendMail(s.Addr, s.auth, s.From, []string{to}, msg); err != nil {
+		return fmt.Errorf("寄送 Email 失敗: %v", err)
+	}
+	return nil
+}
+
+func validEmail(addr string) bool {
+	return strings.Contains(addr, "@") && !strings.ContainsAny(addr, "\r\n")
+}
+
+// headers 產生郵件標頭（含結尾空行），主旨以 RFC 2047 編碼
+func (s *SMTPChannel) headers(to, subject, contentType, transferEncoding string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + s.From + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: " + contentType + "\r\n")
+	if transferEncoding != "" {
+		b.WriteString("Content-Transfer-Encoding: " + transferEncoding + "\r\n")
+	}
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+// compose 產生純文字 UTF-8 郵件
+func (s *SMTPChannel) compose(to string, msg Message) []byte {
+	mail := s.headers(to, msg.Subject, "text/plain; charset=UTF-8", "8bit")
+	mail = append(mail, strings.ReplaceAll(msg.Text, "\n", "\r\n")...)
+	return append(mail, "\r\n"...)
+}
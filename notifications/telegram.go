@@ -1,14 +1,31 @@
-package services
+// Package notifications 對外通知管道的 API 客戶端，不依賴 services 以避免循環引用
+package notifications
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"net/url"
+	"strconv"
+	"time"
 )
 
+const (
+	defaultTelegramBaseURL = "https://api.telegram.org"
+	// Telegram 單則訊息上限 4096 字
+	TelegramMessageLimit = 4096
+	// callback_data 上限 64 bytes
+	TelegramCallbackDataLimit = 64
+	// 長輪詢時 HTTP 逾時需比 getUpdates 的 timeout 長
+	telegramRequestTimeout = 10 * time.Second
+)
+
+// TelegramService Telegram Bot API 客戶端
 type TelegramService struct {
+	// BaseURL 預設為官方 API，測試或自架 Bot API Server 時可替換
+	BaseURL  string
 	botToken string
+	client   *http.Client
 }
 
 func NewTelegramService(botToken string) *TelegramService {
@@ -16,79 +33,181 @@ func NewTelegramService(botToken string) *TelegramService {
 		return nil // 如果沒有 token，返回 nil
 	}
 	return &TelegramService{
+		BaseURL:  defaultTelegramBaseURL,
 		botToken: botToken,
+		client:   &http.Client{},
 	}
 }
 
-// 發送航班通知 - 超級簡單版本
-func (t *TelegramService) SendFlightNotification(chatID string, flights []map[string]interface{}) error {
+// --- Bot API 型別（只保留用到的欄位） ---
+
+type TelegramUpdate struct {
+	UpdateID      int64                  `json:"update_id"`
+	Message       *TelegramMessage       `json:"message,omitempty"`
+	CallbackQuery *TelegramCallbackQuery `json:"callback_query,omitempty"`
+}
+
+type TelegramMessage struct {
+	MessageID int64         `json:"message_id"`
+	From      *TelegramUser `json:"from,omitempty"`
+	Chat      TelegramChat  `json:"chat"`
+	Text      string        `json:"text,omitempty"`
+}
+
+type TelegramUser struct {
+	ID       int64  `json:"id"`
+	IsBot    bool   `json:"is_bot"`
+	Username string `json:"username,omitempty"`
+}
+
+type TelegramChat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"` // private、group、supergroup、channel
+}
+
+type TelegramCallbackQuery struct {
+	ID      string           `json:"id"`
+	From    TelegramUser     `json:"from"`
+	Message *TelegramMessage `json:"message,omitempty"`
+	Data    string           `json:"data,omitempty"`
+}
+
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type TelegramBotCommand struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+// TelegramOutgoing sendMessage 與 editMessageText 共用的參數；MessageID 非 0 時為編輯
+type TelegramOutgoing struct {
+	ChatID      string                `json:"chat_id"`
+	MessageID   int64                 `json:"message_id,omitempty"`
+	Text        string                `json:"text"`
+	ParseMode   string                `json:"parse_mode,omitempty"`
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+type telegramResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description"`
+	ErrorCode   int             `json:"error_code"`
+}
+
+// call 以 JSON 呼叫 Bot API 方法，result 為 nil 時忽略回傳內容
+func (t *TelegramService) call(method string, params, result interface{}, timeout time.Duration) error {
 	if t == nil {
-		return nil // 如果服務未初始化，靜默返回
+		return fmt.Errorf("Telegram service not initialized")
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("編碼 Telegram 請求失敗: %v", err)
+	}
+
+	client := *t.client
+	client.Timeout = timeout
+	resp, err := client.Post(fmt.Sprintf("%s/bot%s/%s", t.BaseURL, t.botToken, method), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("呼叫 Telegram %s 失敗: %v", method, err)
 	}
+	defer resp.Body.Close()
+
+	var apiResp telegramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return fmt.Errorf("解析 Telegram %s 回應失敗: %v", method, err)
+	}
+	if !apiResp.OK {
+		return fmt.Errorf("Telegram %s 失敗 (%d): %s", method, apiResp.ErrorCode, apiResp.Description)
+	}
+	if result != nil {
+		if err := json.Unmarshal(apiResp.Result, result); err != nil {
+			return fmt.Errorf("解析 Telegram %s 結果失敗: %v", method, err)
+		}
+	}
+	return nil
+}
 
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", t.botToken)
-
-	// 建立簡單的訊息
-	message := "✈️ 找到航班啦！\n\n"
-
-	if len(flights) > 0 {
-		flight := flights[0] // 只顯示最便宜的一個
-		message += fmt.Sprintf(
-			"🛫 %s %s\n"+
-				"📍 %s → %s\n"+
-				"💰 %s %.0f %s\n"+
-				"⏰ %s",
-			flight["airline"],
-			flight["flight_number"],
-			flight["from"].(map[string]interface{})["code"],
-			flight["to"].(map[string]interface{})["code"],
-			"價格:",
-			flight["price"],
-			flight["currency"],
-			"立即查看詳情！",
-		)
-	} else {
-		message = "❌ 沒有找到符合條件的航班"
+// GetUpdates 長輪詢取得 offset 之後的更新，timeout 秒內沒有新訊息時回傳空陣列
+func (t *TelegramService) GetUpdates(offset int64, timeout int) ([]TelegramUpdate, error) {
+	params := map[string]interface{}{
+		"offset":          offset,
+		"timeout":         timeout,
+		"allowed_updates": []string{"message", "callback_query"},
 	}
+	var updates []TelegramUpdate
+	err := t.call("getUpdates", params, &updates, time.Duration(timeout)*time.Second+telegramRequestTimeout)
+	return updates, err
+}
+
+// GetMe 取得機器人本身的帳號資訊（username 用來辨識群組中 "/price@MyBot" 形式的指令）
+func (t *TelegramService) GetMe() (*TelegramUser, error) {
+	var me TelegramUser
+	if err := t.call("getMe", map[string]string{}, &me, telegramRequestTimeout); err != nil {
+		return nil, err
+	}
+	return &me, nil
+}
+
+// SendMessage 傳送訊息，可附上內嵌按鈕
+func (t *TelegramService) SendMessage(msg TelegramOutgoing) (*TelegramMessage, error) {
+	var sent TelegramMessage
+	if err := t.call("sendMessage", msg, &sent, telegramRequestTimeout); err != nil {
+		return nil, err
+	}
+	return &sent, nil
+}
 
-	// 發送請求
-	data := url.Values{}
-	data.Set("chat_id", chatID)
-	data.Set("text", message)
+// EditMessageText 編輯既有訊息的文字與按鈕（翻頁、排序）
+func (t *TelegramService) EditMessageText(msg TelegramOutgoing) error {
+	return t.call("editMessageText", msg, nil, telegramRequestTimeout)
+}
 
-	_, err := http.PostForm(apiURL, data)
+// SendText 傳送純文字訊息（供價格、匯率警報等背景通知使用）
+func (t *TelegramService) SendText(chatID, text string) error {
+	_, err := t.SendMessage(TelegramOutgoing{ChatID: chatID, Text: text})
 	return err
 }
 
-// 獲取 Chat ID 的簡單方法
+// AnswerCallbackQuery 回應按鈕點擊，讓按鈕停止顯示載入中
+func (t *TelegramService) AnswerCallbackQuery(callbackID, text string) error {
+	params := map[string]string{"callback_query_id": callbackID}
+	if text != "" {
+		params["text"] = text
+	}
+	return t.call("answerCallbackQuery", params, nil, telegramRequestTimeout)
+}
+
+// SendChatAction 顯示「輸入中」等狀態
+func (t *TelegramService) SendChatAction(chatID, action string) error {
+	return t.call("sendChatAction", map[string]string{"chat_id": chatID, "action": action}, nil, telegramRequestTimeout)
+}
+
+// SetMyCommands 設定輸入 / 時顯示的指令清單
+func (t *TelegramService) SetMyCommands(commands []TelegramBotCommand) error {
+	return t.call("setMyCommands", map[string]interface{}{"commands": commands}, nil, telegramRequestTimeout)
+}
+
+// 獲取 Chat ID 的簡單方法：取最近一則訊息的 chat ID
 func (t *TelegramService) GetChatID() (string, error) {
 	if t == nil {
 		return "", fmt.Errorf("Telegram service not initialized")
 	}
 
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/getUpdates", t.botToken)
-
-	resp, err := http.Get(apiURL)
+	updates, err := t.GetUpdates(0, 0)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
-	}
-
-	// 簡單解析第一個訊息的 chat ID
-	if updates, ok := result["result"].([]interface{}); ok && len(updates) > 0 {
-		if firstUpdate, ok := updates[0].(map[string]interface{}); ok {
-			if message, ok := firstUpdate["message"].(map[string]interface{}); ok {
-				if chat, ok := message["chat"].(map[string]interface{}); ok {
-					if chatID, ok := chat["id"].(float64); ok {
-						return fmt.Sprintf("%.0f", chatID), nil
-					}
-				}
-			}
+	for i := len(updates) - 1; i >= 0; i-- {
+		if msg := updates[i].Message; msg != nil {
+			return strconv.FormatInt(msg.Chat.ID, 10), nil
 		}
 	}
 
@@ -0,0 +1,93 @@
+package notifications
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeTelegram 模擬 Bot API：記錄每次呼叫的方法與參數，依方法回傳預設結果
+func fakeTelegram(t *testing.T, results map[string]string) (*TelegramService, *[]map[string]interface{}) {
+	t.Helper()
+	var calls []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, ok := strings.CutPrefix(r.URL.Path, "/botTEST:TOKEN/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		var params map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&params)
+		params["_method"] = method
+		calls = append(calls, params)
+
+		result, ok := results[method]
+		if !ok {
+			w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":` + result + `}`))
+	}))
+	t.Cleanup(server.Close)
+
+	svc := NewTelegramService("TEST:TOKEN")
+	svc.BaseURL = server.URL
+	return svc, &calls
+}
+
+func TestTelegramService_GetUpdates(t *testing.T) {
+	svc, calls := fakeTelegram(t, map[string]string{
+		"getUpdates": `[{"update_id":7,"message":{"message_id":1,"from":{"id":42},"chat":{"id":-100,"type":"group"},"text":"/help"}},
+			{"update_id":8,"callback_query":{"id":"cb","from":{"id":42},"message":{"message_id":2,"chat":{"id":-100}},"data":"price:page:k:1:price"}}]`,
+	})
+
+	updates, err := svc.GetUpdates(7, 0)
+	if err != nil {
+		t.Fatalf("GetUpdates 失敗: %v", err)
+	}
+	if len(updates) != 2 || updates[0].Message.Text != "/help" || updates[1].CallbackQuery.Data != "price:page:k:1:price" {
+		t.Fatalf("解析更新錯誤: %+v", updates)
+	}
+	if got := (*calls)[0]["offset"]; got != float64(7) {
+		t.Errorf("應帶上 offset: %v", got)
+	}
+
+	chatID, err := svc.GetChatID()
+	if err != nil || chatID != "-100" {
+		t.Errorf("GetChatID = %q, %v", chatID, err)
+	}
+}
+
+func TestTelegramService_SendMessage(t *testing.T) {
+	svc, calls := fakeTelegram(t, map[string]string{"sendMessage": `{"message_id":9,"chat":{"id":5}}`})
+
+	sent, err := svc.SendMessage(TelegramOutgoing{
+		ChatID:      "5",
+		Text:        "<b>hi</b>",
+		ParseMode:   "HTML",
+		ReplyMarkup: &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{{Text: "下一頁", CallbackData: "spot:page:k:1"}}}},
+	})
+	if err != nil || sent.MessageID != 9 {
+		t.Fatalf("SendMessage = %+v, %v", sent, err)
+	}
+	call := (*calls)[0]
+	keyboard := call["reply_markup"].(map[string]interface{})["inline_keyboard"].([]interface{})
+	if call["chat_id"] != "5" || call["parse_mode"] != "HTML" || len(keyboard) != 1 {
+		t.Errorf("請求內容錯誤: %+v", call)
+	}
+	if _, exists := call["message_id"]; exists {
+		t.Error("新訊息不應帶 message_id")
+	}
+
+	// 未設定的方法由假伺服器回傳 ok=false，錯誤訊息應包含 API 的說明
+	if err := svc.EditMessageText(TelegramOutgoing{ChatID: "5", MessageID: 9, Text: "x"}); err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Errorf("API 錯誤應回傳說明: %v", err)
+	}
+
+	var nilSvc *TelegramService
+	if err := nilSvc.SendText("5", "hi"); err == nil {
+		t.Error("未初始化的服務應回傳錯誤")
+	}
+}
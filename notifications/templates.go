@@ -0,0 +1,125 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// Template 一種通知的主旨與內文範本（text/template，資料為 Notification）
+type Template struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// 內建範本；訊息會送到 Discord、Telegram 與 Email，因此只使用純文字
+var defaultTemplates = map[Kind]Template{
+	KindPriceAlert: {
+		Subject: "✈️ 價格警報：{{.PriceAlert.Alert.Route}} ({{.PriceAlert.Alert.DepartureDate}}) 已降到 {{.PriceAlert.Price}}",
+		Body: "✈️ 價格警報：{{.PriceAlert.Alert.Route}} ({{.PriceAlert.Alert.DepartureDate}}) 已降到 {{.PriceAlert.Price}}\n" +
+			"目標價格：{{.PriceAlert.Alert.TargetPrice}}" +
+			"{{if .PriceAlert.Alert.Repeat}}\n持續追蹤中，不再需要時請使用 /unwatch 取消（追蹤編號 {{.PriceAlert.Alert.ID}}）{{end}}",
+	},
+	KindTrackingComplete: {
+		Subject: "📈 價格追蹤完成：{{.Tracking.Analysis.Route}}",
+		Body: "📈 價格追蹤完成：{{.Tracking.Analysis.Route}}（{{.Tracking.Analysis.TrackWeeks}} 週）\n" +
+			"最低 {{.Tracking.Analysis.MinPrice}}（{{.Tracking.Analysis.BestDate.Format \"2006-01-02\"}} 出發）｜平均 {{.Tracking.Analysis.AvgPrice}}｜最高 {{.Tracking.Analysis.MaxPrice}}" +
+			"{{with .Tracking.Analysis.Recommendation}}\n💡 {{.}}{{end}}",
+	},
+	KindRateAlert: {
+		Subject: "💱 匯率警報：{{.RateAlert.Alert.From}}→{{.RateAlert.Alert.To}}",
+		Body: "💱 匯率警報：{{.RateAlert.Alert.From}}→{{.RateAlert.Alert.To}} 已{{if eq .RateAlert.Alert.Direction \"above\"}}突破{{else}}跌破{{end}} {{printf \"%.4f\" .RateAlert.Alert.Threshold}}\n" +
+			"目前匯率：1 {{.RateAlert.Alert.From}} = {{printf \"%.4f\" .RateAlert.Rate}} {{.RateAlert.Alert.To}}",
+	},
+}
+
+type parsedTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// Templates 各通知種類的已解析範本
+type Templates struct {
+	byKind map[Kind]parsedTemplate
+}
+
+// DefaultTemplates 使用內建範本
+func DefaultTemplates() *Templates {
+	t, err := NewTemplates(nil)
+	if err != nil {
+		panic(fmt.Sprintf("notifications: 內建範本解析失敗: %v", err))
+	}
+	return t
+}
+
+// LoadTemplates 從 JSON 檔（{"price_alert": {"subject": ..., "body": ...}}）覆寫內建範本，path 為空時使用內建範本
+func LoadTemplates(path string) (*Templates, error) {
+	if path == "" {
+		return DefaultTemplates(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("讀取通知範本失敗: %v", err)
+	}
+	var overrides map[Kind]Template
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("解析通知範本失敗: %v", err)
+	}
+	return NewTemplates(overrides)
+}
+
+// NewTemplates 以內建範本為基礎套用 overrides，只需提供要修改的種類
+func NewTemplates(overrides map[Kind]Template) (*Templates, error) {
+	t := &Templates{byKind: make(map[Kind]parsedTemplate)}
+	for _, kind := range Kinds {
+		tmpl := defaultTemplates[kind]
+		if o, ok := overrides[kind]; ok {
+			if o.Subject != "" {
+				tmpl.Subject = o.Subject
+			}
+			if o.Body != "" {
+				tmpl.Body = o.Body
+			}
+		}
+
+		subject, err := template.New(string(kind) + ".subject").Option("missingkey=error").Parse(tmpl.Subject)
+		if err != nil {
+			return nil, fmt.Errorf("範本 %s 主旨錯誤: %v", kind, err)
+		}
+		body, err := template.New(string(kind) + ".body").Option("missingkey=error").Parse(tmpl.Body)
+		if err != nil {
+			return nil, fmt.Errorf("範本 %s 內文錯誤: %v", kind, err)
+		}
+		t.byKind[kind] = parsedTemplate{subject: subject, body: body}
+	}
+	for kind := range overrides {
+		if _, ok := t.byKind[kind]; !ok {
+			return nil, fmt.Errorf("不支援的通知種類: %s", kind)
+		}
+	}
+	return t, nil
+}
+
+// Render 產生通知的主旨與內文
+func (t *Templates) Render(n Notification) (Message, error) {
+	tmpl, ok := t.byKind[n.Kind]
+	if !ok {
+		return Message{}, fmt.Errorf("不支援的通知種類: %s", n.Kind)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.subject.Execute(&subject, n); err != nil {
+		return Message{}, fmt.Errorf("產生 %s 主旨失敗: %v", n.Kind, err)
+	}
+	if err := tmpl.body.Execute(&body, n); err != nil {
+		return Message{}, fmt.Errorf("產生 %s 內文失敗: %v", n.Kind, err)
+	}
+	// 主旨用於 Email 標頭，不可換行
+	return Message{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    strings.TrimSpace(body.String()),
+	}, nil
+}
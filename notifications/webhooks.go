@@ -0,0 +1,516 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"final/models"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	webhookSubscriptionsDB = "webhook_subscriptions.json"
+	webhookDeliveriesDB    = "webhook_deliveries.json"
+
+	// 只保留最近的傳送紀錄
+	maxWebhookDeliveries = 1000
+
+	defaultWebhookAttempts   = 5
+	defaultWebhookRetryDelay = time.Second
+
+	// 接收端驗證用的標頭
+	WebhookSignatureHeader = "X-GoSkyAlert-Signature"
+	WebhookTimestampHeader = "X-GoSkyAlert-Timestamp"
+	WebhookEventHeader     = "X-GoSkyAlert-Event"
+	WebhookDeliveryHeader  = "X-GoSkyAlert-Delivery"
+)
+
+// EventType 可訂閱的事件種類
+type EventType string
+
+const (
+	EventHistoricalLow     EventType = "price.historical_low" // 搜尋到的最低價低於先前所有紀錄
+	EventAlertTriggered    EventType = "alert.triggered"      // 價格警報觸發
+	EventTrackingCompleted EventType = "tracking.completed"   // 多週價格追蹤完成
+)
+
+// EventTypes 所有事件種類
+var EventTypes = []EventType{EventHistoricalLow, EventAlertTriggered, EventTrackingCompleted}
+
+// ErrForbiddenWebhookTarget webhook 網址指向迴路、鏈路本地或私有網段
+var ErrForbiddenWebhookTarget = errors.New("webhook 網址不可指向內部網路位址")
+
+// Event 送到 webhook 的 JSON 內容；Data 依 Type 為 HistoricalLowEvent、AlertTriggeredEvent 或 TrackingEvent
+type Event struct {
+	ID        string      `json:"id"`
+	Type      EventType   `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// HistoricalLowEvent 航線與出發日的比價結果
+type HistoricalLowEvent struct {
+	Origin        string             `json:"origin"`
+	Destination   string             `json:"destination"`
+	DepartureDate string             `json:"departure_date"`
+	Advice        models.PriceAdvice `json:"advice"`
+}
+
+// AlertTriggeredEvent alert.triggered 的內容；只包含警報本身，不含通知目標與建立者
+type AlertTriggeredEvent struct {
+	AlertID       string       `json:"alert_id"`
+	Route         string       `json:"route"`
+	DepartureDate string       `json:"departure_date"`
+	TargetPrice   models.Money `json:"target_price"`
+	Price         models.Money `json:"price"`
+	Repeat        bool         `json:"repeat,omitempty"`
+}
+
+// NewAlertTriggeredEvent 從觸發的警報建立對外公開的事件內容
+func NewAlertTriggeredEvent(alert models.PriceAlert, price models.Money) AlertTriggeredEvent {
+	return AlertTriggeredEvent{
+		AlertID:       alert.ID,
+		Route:         alert.Route,
+		DepartureDate: alert.DepartureDate,
+		TargetPrice:   alert.TargetPrice,
+		Price:         price,
+		Repeat:        alert.Repeat,
+	}
+}
+
+// WebhookSubscription 一個訂閱：事件發生時以 Secret 簽章後 POST 到 URL
+type WebhookSubscription struct {
+	ID          string      `json:"id"`
+	URL         string      `json:"url"`
+	Events      []EventType `json:"events"`
+	Secret      string      `json:"secret,omitempty"`
+	Description string      `json:"description,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// Wants 是否訂閱了此事件
+func (s WebhookSubscription) Wants(t EventType) bool {
+	for _, e := range s.Events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery 一次事件傳送的結果（包含所有重試）
+type WebhookDelivery struct {
+	ID             string     `json:"id"`
+	SubscriptionID string     `json:"subscription_id"`
+	EventID        string     `json:"event_id"`
+	EventType      EventType  `json:"event_type"`
+	URL            string     `json:"url"`
+	Attempts       int        `json:"attempts"`
+	StatusCode     int        `json:"status_code,omitempty"`
+	Success        bool       `json:"success"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+// WebhookHub 管理 webhook 訂閱，發布事件時在背景簽章、傳送並記錄結果
+type WebhookHub struct {
+	MaxAttempts int
+	// RetryDelay 第一次重試前的等待時間，之後每次加倍
+	RetryDelay time.Duration
+
+	subscriptionsPath string
+	deliveriesPath    string
+	subscriptionMutex sync.Mutex // 保護 webhook_subscriptions.json
+	deliveryMutex     sync.Mutex // 保護 webhook_deliveries.json
+
+	client   *http.Client
+	inflight sync.WaitGroup
+
+	// lookupIP 解析訂閱網址的主機；allowPrivate 允許內部網路位址（只在測試使用）
+	lookupIP     func(ctx context.Context, host string) ([]net.IP, error)
+	allowPrivate bool
+}
+
+func NewWebhookHub() *WebhookHub {
+	h := &WebhookHub{
+		MaxAttempts:       defaultWebhookAttempts,
+		RetryDelay:        defaultWebhookRetryDelay,
+		subscriptionsPath: webhookSubscriptionsDB,
+		deliveriesPath:    webhookDeliveriesDB,
+		lookupIP: func(ctx context.Context, host string) ([]net.IP, error) {
+			return net.DefaultResolver.LookupIP(ctx, "ip", host)
+		},
+	}
+	// 連線時再檢查一次實際連到的位址，避免 DNS 在訂閱後改指向內部網路
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			return h.checkIP(net.ParseIP(host))
+		},
+	}
+	h.client = &http.Client{
+		Timeout: 10 * time.Second,
+		// 不經過環境變數的 proxy，確保檢查的是實際連線的位址
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+		// 重新導向可能指向內部網路，一律不跟隨
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return h
+}
+
+// isInternalIP 迴路、鏈路本地、私有網段與未指定位址
+func isInternalIP(ip net.IP) bool {
+	return ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast()
+}
+
+func (h *WebhookHub) checkIP(ip net.IP) error {
+	if !h.allowPrivate && isInternalIP(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenWebhookTarget, ip)
+	}
+	return nil
+}
+
+// checkURL 解析網址的主機，任何一個解析結果是內部網路位址就拒絕
+func (h *WebhookHub) checkURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("無效的 url: %s", rawURL)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ips, err := h.lookupIP(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("無法解析 webhook 主機 %s: %v", u.Hostname(), err)
+	}
+	for _, ip := range ips {
+		if err := h.checkIP(ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SignPayload 產生簽章：HMAC-SHA256(secret, "<timestamp>.<body>") 的十六進位字串，前面加上 "sha256="
+// 接收端以相同方式計算並比對 X-GoSkyAlert-Signature，並檢查時間戳避免重送攻擊
+func SignPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+func isEventType(t EventType) bool {
+	for _, e := range EventTypes {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------------------------------------------------------
+// 訂閱管理
+// ---------------------------------------------------------
+
+// loadSubscriptions 讀取所有訂閱（呼叫端需持有 subscriptionMutex）
+func (h *WebhookHub) loadSubscriptions() ([]WebhookSubscription, error) {
+	data, err := os.ReadFile(h.subscriptionsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []WebhookSubscription{}, nil
+		}
+		return nil, err
+	}
+	var subs []WebhookSubscription
+	if err := json.Unmarshal(data, &subs); err != nil {
+		return []WebhookSubscription{}, nil
+	}
+	return subs, nil
+}
+
+func (h *WebhookHub) saveSubscriptions(subs []WebhookSubscription) error {
+	data, err := json.MarshalIndent(subs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(h.subscriptionsPath, data, 0600)
+}
+
+// Subscribe 建立訂閱；沒有提供 secret 時自動產生，只在建立時回傳一次
+func (h *WebhookHub) Subscribe(sub WebhookSubscription) (*WebhookSubscription, error) {
+	sub.URL = strings.TrimSpace(sub.URL)
+	if !strings.HasPrefix(sub.URL, "http://") && !strings.HasPrefix(sub.URL, "https://") {
+		return nil, fmt.Errorf("url 必須以 http:// 或 https:// 開頭")
+	}
+	if len(sub.Events) == 0 {
+		return nil, fmt.Errorf("至少需要訂閱一種事件")
+	}
+	for _, e := range sub.Events {
+		if !isEventType(e) {
+			return nil, fmt.Errorf("不支援的事件種類: %s", e)
+		}
+	}
+	if err := h.checkURL(sub.URL); err != nil {
+		return nil, err
+	}
+	if sub.Secret == "" {
+		sub.Secret = randomHex(24)
+	}
+	sub.ID = fmt.Sprintf("wh_%d", time.Now().UnixNano())
+	sub.CreatedAt = time.Now()
+
+	h.subscriptionMutex.Lock()
+	defer h.subscriptionMutex.Unlock()
+
+	subs, err := h.loadSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	subs = append(subs, sub)
+	if err := h.saveSubscriptions(subs); err != nil {
+		return nil, err
+	}
+	log.Printf("🪝 已建立 webhook 訂閱 %s: %s %v", sub.ID, sub.URL, sub.Events)
+	return &sub, nil
+}
+
+// Subscriptions 列出所有訂閱，不包含 secret
+func (h *WebhookHub) Subscriptions() ([]WebhookSubscription, error) {
+	h.subscriptionMutex.Lock()
+	defer h.subscriptionMutex.Unlock()
+
+	subs, err := h.loadSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	return subs, nil
+}
+
+// Unsubscribe 刪除訂閱
+func (h *WebhookHub) Unsubscribe(id string) error {
+	h.subscriptionMutex.Lock()
+	defer h.subscriptionMutex.Unlock()
+
+	subs, err := h.loadSubscriptions()
+	if err != nil {
+		return err
+	}
+	for i, s := range subs {
+		if s.ID == id {
+			return h.saveSubscriptions(append(subs[:i], subs[i+1:]...))
+		}
+	}
+	return fmt.Errorf("找不到 webhook 訂閱: %s", id)
+}
+
+// ---------------------------------------------------------
+// 發布與傳送
+// ---------------------------------------------------------
+
+// Publish 將事件送到所有訂閱此種類的網址，在背景傳送不會阻塞呼叫端；回傳符合的訂閱數
+func (h *WebhookHub) Publish(eventType EventType, data interface{}) int {
+	h.subscriptionMutex.Lock()
+	subs, err := h.loadSubscriptions()
+	h.subscriptionMutex.Unlock()
+	if err != nil {
+		log.Printf("⚠️ 讀取 webhook 訂閱失敗: %v", err)
+		return 0
+	}
+
+	event := Event{
+		ID:        "evt_" + randomHex(8),
+		Type:      eventType,
+		CreatedAt: time.Now(),
+		Data:      data,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("⚠️ 無法序列化 webhook 事件 %s: %v", eventType, err)
+		return 0
+	}
+
+	matched := 0
+	for _, sub := range subs {
+		if !sub.Wants(eventType) {
+			continue
+		}
+		matched++
+		h.inflight.Add(1)
+		go func(sub WebhookSubscription) {
+			defer h.inflight.Done()
+			h.deliver(sub, event, body)
+		}(sub)
+	}
+	return matched
+}
+
+// Wait 等待所有背景傳送完成（關閉程式或測試時使用）
+func (h *WebhookHub) Wait() {
+	h.inflight.Wait()
+}
+
+// deliver 傳送並在失敗時以指數退避重試，最後記錄結果
+func (h *WebhookHub) deliver(sub WebhookSubscription, event Event, body []byte) {
+	delivery := WebhookDelivery{
+		ID:             "dlv_" + randomHex(8),
+		SubscriptionID: sub.ID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		URL:            sub.URL,
+		CreatedAt:      time.Now(),
+	}
+
+	attempts := max(h.MaxAttempts, 1)
+	delay := h.RetryDelay
+	for attempt := 1; attempt <= attempts; attempt++ {
+		delivery.Attempts = attempt
+		status, err := h.post(sub, event, delivery.ID, body)
+		delivery.StatusCode = status
+		if err == nil {
+			delivery.Success = true
+			delivery.Error = ""
+			break
+		}
+		delivery.Error = err.Error()
+		// 4xx（除了 429）代表接收端拒絕此內容，重試也不會成功
+		if status >= 400 && status < 500 && status != http.StatusTooManyRequests {
+			break
+		}
+		if attempt < attempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+
+	now := time.Now()
+	delivery.CompletedAt = &now
+	if !delivery.Success {
+		log.Printf("⚠️ webhook %s 傳送 %s 失敗（%d 次）: %s", sub.ID, event.Type, delivery.Attempts, delivery.Error)
+	}
+	if err := h.recordDelivery(delivery); err != nil {
+		log.Printf("⚠️ 記錄 webhook 傳送結果失敗: %v", err)
+	}
+}
+
+func (h *WebhookHub) post(sub WebhookSubscription, event Event, deliveryID string, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GoSkyAlert-Webhook/1.0")
+	req.Header.Set(WebhookEventHeader, string(event.Type))
+	req.Header.Set(WebhookDeliveryHeader, deliveryID)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignPayload(sub.Secret, timestamp, body))
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// ---------------------------------------------------------
+// 傳送紀錄
+// ---------------------------------------------------------
+
+// loadDeliveries 讀取傳送紀錄（呼叫端需持有 deliveryMutex）
+func (h *WebhookHub) loadDeliveries() ([]WebhookDelivery, error) {
+	data, err := os.ReadFile(h.deliveriesPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []WebhookDelivery{}, nil
+		}
+		return nil, err
+	}
+	var deliveries []WebhookDelivery
+	if err := json.Unmarshal(data, &deliveries); err != nil {
+		return []WebhookDelivery{}, nil
+	}
+	return deliveries, nil
+}
+
+func (h *WebhookHub) recordDelivery(d WebhookDelivery) error {
+	h.deliveryMutex.Lock()
+	defer h.deliveryMutex.Unlock()
+
+	deliveries, err := h.loadDeliveries()
+	if err != nil {
+		return err
+	}
+	deliveries = append(deliveries, d)
+	if len(deliveries) > maxWebhookDeliveries {
+		deliveries = deliveries[len(deliveries)-maxWebhookDeliveries:]
+	}
+	data, err := json.MarshalIndent(deliveries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(h.deliveriesPath, data, 0644)
+}
+
+// Deliveries 最近的傳送紀錄（新的在前），subscriptionID 為空時回傳全部訂閱的紀錄
+func (h *WebhookHub) Deliveries(subscriptionID string, limit int) ([]WebhookDelivery, error) {
+	h.deliveryMutex.Lock()
+	defer h.deliveryMutex.Unlock()
+
+	deliveries, err := h.loadDeliveries()
+	if err != nil {
+		return nil, err
+	}
+	result := []WebhookDelivery{}
+	for i := len(deliveries) - 1; i >= 0; i-- {
+		if subscriptionID != "" && deliveries[i].SubscriptionID != subscriptionID {
+			continue
+		}
+		result = append(result, deliveries[i])
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result, nil
+}
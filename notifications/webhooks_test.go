@@ -0,0 +1,240 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"final/models"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func testWebhookHub(t *testing.T) *WebhookHub {
+	t.Helper()
+	dir := t.TempDir()
+	h := NewWebhookHub()
+	h.RetryDelay = 0
+	// 測試不查 DNS；httptest 伺服器在 127.0.0.1，預設允許內部位址
+	h.allowPrivate = true
+	h.lookupIP = func(ctx context.Context, host string) ([]net.IP, error) {
+		if ip := net.ParseIP(host); ip != nil {
+			return []net.IP{ip}, nil
+		}
+		switch host {
+		case "localhost":
+			return []net.IP{net.ParseIP("127.0.0.1")}, nil
+		case "intranet.example.com":
+			return []net.IP{net.ParseIP("203.0.113.10"), net.ParseIP("192.168.1.10")}, nil
+		}
+		return []net.IP{net.ParseIP("93.184.216.34")}, nil
+	}
+	h.subscriptionsPath = filepath.Join(dir, "subs.json")
+	h.deliveriesPath = filepath.Join(dir, "deliveries.json")
+	return h
+}
+
+func TestWebhookHub_Subscribe(t *testing.T) {
+	h := testWebhookHub(t)
+	tests := []struct {
+		name    string
+		sub     WebhookSubscription
+		wantErr bool
+	}{
+		{"正常訂閱", WebhookSubscription{URL: "https://example.com/hook", Events: []EventType{EventAlertTriggered}}, false},
+		{"不支援的網址", WebhookSubscription{URL: "ftp://example.com", Events: []EventType{EventAlertTriggered}}, true},
+		{"沒有事件", WebhookSubscription{URL: "https://example.com/hook"}, true},
+		{"未知的事件", WebhookSubscription{URL: "https://example.com/hook", Events: []EventType{"price.changed"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := h.Subscribe(tt.sub)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Subscribe 錯誤 = %v, 預期錯誤 %v", err, tt.wantErr)
+			}
+			if err == nil && (sub.ID == "" || len(sub.Secret) < 32) {
+				t.Errorf("應產生 ID 與 secret: %+v", sub)
+			}
+		})
+	}
+
+	subs, _ := h.Subscriptions()
+	if len(subs) != 1 || subs[0].Secret != "" {
+		t.Errorf("列表應只有一筆且不含 secret: %+v", subs)
+	}
+	if err := h.Unsubscribe(subs[0].ID); err != nil {
+		t.Errorf("Unsubscribe 失敗: %v", err)
+	}
+	if err := h.Unsubscribe(subs[0].ID); err == nil {
+		t.Error("刪除不存在的訂閱應回傳錯誤")
+	}
+}
+
+func TestWebhookHub_PublishSigned(t *testing.T) {
+	var mu sync.Mutex
+	var received []Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		want := SignPayload("s3cret", r.Header.Get(WebhookTimestampHeader), body)
+		if r.Header.Get(WebhookSignatureHeader) != want {
+			t.Errorf("簽章不符: %s != %s", r.Header.Get(WebhookSignatureHeader), want)
+		}
+		var e Event
+		json.Unmarshal(body, &e)
+		if r.Header.Get(WebhookEventHeader) != string(e.Type) || r.Header.Get(WebhookDeliveryHeader) == "" {
+			t.Errorf("事件標頭錯誤: %v", r.Header)
+		}
+		mu.Lock()
+		received = append(received, e)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	h := testWebhookHub(t)
+	h.Subscribe(WebhookSubscription{URL: server.URL, Events: []EventType{EventAlertTriggered}, Secret: "s3cret"})
+	h.Subscribe(WebhookSubscription{URL: server.URL, Events: []EventType{EventTrackingCompleted}, Secret: "other"})
+
+	alert := models.PriceAlert{ID: "pa1", Route: "TPE-NRT", Channel: "discord_dm", Target: "12345", Owner: "67890"}
+	data := NewAlertTriggeredEvent(alert, models.NewMoney(7500, "TWD"))
+	if n := h.Publish(EventAlertTriggered, data); n != 1 {
+		t.Fatalf("應只送到訂閱 alert.triggered 的 1 個網址，實際 %d", n)
+	}
+	h.Wait()
+
+	if len(received) != 1 || received[0].Type != EventAlertTriggered || received[0].ID == "" {
+		t.Fatalf("收到的事件錯誤: %+v", received)
+	}
+	payload, _ := json.Marshal(received[0].Data)
+	var got AlertTriggeredEvent
+	json.Unmarshal(payload, &got)
+	if got.AlertID != "pa1" || got.Route != "TPE-NRT" || got.Price.Float64() != 7500 {
+		t.Errorf("事件內容錯誤: %s", payload)
+	}
+	// 通知目標與建立者不應送到外部網址
+	for _, leaked := range []string{"12345", "67890", "discord_dm"} {
+		if strings.Contains(string(payload), leaked) {
+			t.Errorf("事件內容不應包含 %q: %s", leaked, payload)
+		}
+	}
+}
+
+func TestWebhookHub_RejectsInternalTargets(t *testing.T) {
+	h := testWebhookHub(t)
+	h.allowPrivate = false
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{"公開網址", "https://example.com/hook", false},
+		{"迴路位址", "http://127.0.0.1:8080/hook", true},
+		{"解析到迴路位址的主機", "http://localhost/hook", true},
+		{"雲端中繼資料位址", "http://169.254.169.254/latest/meta-data", true},
+		{"私有網段", "http://10.0.0.5/hook", true},
+		{"IPv6 迴路位址", "http://[::1]/hook", true},
+		{"任一解析結果為私有網段", "https://intranet.example.com/hook", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := h.Subscribe(WebhookSubscription{URL: tt.url, Events: []EventType{EventAlertTriggered}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Subscribe(%s) 錯誤 = %v, 預期錯誤 %v", tt.url, err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrForbiddenWebhookTarget) {
+				t.Errorf("應回傳 ErrForbiddenWebhookTarget: %v", err)
+			}
+		})
+	}
+}
+
+// 訂閱後主機改指向內部網路時，連線階段仍會拒絕
+func TestWebhookHub_RejectsInternalTargetsAtDial(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer server.Close()
+
+	h := testWebhookHub(t)
+	h.MaxAttempts = 1
+	sub, err := h.Subscribe(WebhookSubscription{URL: server.URL, Events: EventTypes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.allowPrivate = false
+	h.Publish(EventHistoricalLow, HistoricalLowEvent{Origin: "TPE", Destination: "NRT"})
+	h.Wait()
+
+	deliveries, _ := h.Deliveries(sub.ID, 0)
+	if len(deliveries) != 1 || deliveries[0].Success || calls != 0 {
+		t.Fatalf("不應連到內部位址: %+v（呼叫 %d 次）", deliveries, calls)
+	}
+	if !strings.Contains(deliveries[0].Error, ErrForbiddenWebhookTarget.Error()) {
+		t.Errorf("錯誤訊息應說明原因: %s", deliveries[0].Error)
+	}
+}
+
+func TestWebhookHub_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int // 依序回應的狀態碼，用完後回應最後一個
+		wantAttempts int
+		wantSuccess  bool
+	}{
+		{"第一次就成功", []int{200}, 1, true},
+		{"伺服器錯誤後重試成功", []int{500, 503, 204}, 3, true},
+		{"重試用盡", []int{500}, 5, false},
+		{"4xx 不重試", []int{410}, 1, false},
+		{"429 會重試", []int{429, 200}, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statuses[min(calls, len(tt.statuses)-1)])
+				calls++
+			}))
+			defer server.Close()
+
+			h := testWebhookHub(t)
+			sub, _ := h.Subscribe(WebhookSubscription{URL: server.URL, Events: EventTypes})
+			h.Publish(EventHistoricalLow, HistoricalLowEvent{Origin: "TPE", Destination: "NRT"})
+			h.Wait()
+
+			deliveries, err := h.Deliveries(sub.ID, 0)
+			if err != nil || len(deliveries) != 1 {
+				t.Fatalf("應有 1 筆傳送紀錄: %+v, %v", deliveries, err)
+			}
+			d := deliveries[0]
+			if d.Attempts != tt.wantAttempts || d.Success != tt.wantSuccess || calls != tt.wantAttempts {
+				t.Errorf("傳送紀錄 = %+v（呼叫 %d 次），預期 %d 次、成功 %v", d, calls, tt.wantAttempts, tt.wantSuccess)
+			}
+			if d.CompletedAt == nil || d.EventType != EventHistoricalLow || (!d.Success && d.Error == "") {
+				t.Errorf("傳送紀錄不完整: %+v", d)
+			}
+		})
+	}
+}
+
+func TestWebhookHub_Deliveries(t *testing.T) {
+	h := testWebhookHub(t)
+	for _, id := range []string{"a", "b", "a"} {
+		h.recordDelivery(WebhookDelivery{ID: id + "-dlv", SubscriptionID: id})
+	}
+	h.recordDelivery(WebhookDelivery{ID: "last", SubscriptionID: "a"})
+
+	got, _ := h.Deliveries("a", 2)
+	if len(got) != 2 || got[0].ID != "last" {
+		t.Errorf("應依新到舊回傳並套用 limit 與篩選: %+v", got)
+	}
+	if all, _ := h.Deliveries("", 0); len(all) != 4 {
+		t.Errorf("不篩選時應回傳全部: %d 筆", len(all))
+	}
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"final/models"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 預設的旅行建議規則，可用 ADVICE_RULES_PATH 指定自訂規則檔覆蓋
+//
+//go:embed data/advice_rules.json
+var defaultAdviceRulesJSON []byte
+
+// 建議語系
+const (
+	AdviceLangZH = "zh-TW"
+	AdviceLangEN = "en"
+)
+
+// 規則適用範圍
+const (
+	AdviceScopeTrip    = "trip"    // 航班搜尋的旅行建議
+	AdviceScopeWeather = "weather" // 單一城市的天氣描述
+)
+
+// AdviceCondition 規則條件：欄位 op 數值，例如 dest_temp > 30
+type AdviceCondition struct {
+	Field string  `json:"field"`
+	Op    string  `json:"op"`
+	Value float64 `json:"value"`
+}
+
+// AdviceRule 一條宣告式建議規則
+// 同一 group 的規則只會採用優先度最高的一條（相當於 if / else if）
+type AdviceRule struct {
+	ID       string            `json:"id"`
+	Scope    string            `json:"scope"`
+	Category string            `json:"category"`
+	Priority int               `json:"priority"`
+	Group    string            `json:"group,omitempty"`
+	When     []AdviceCondition `json:"when"`
+	Message  map[string]string `json:"message"` // 語系 => 訊息，可用 {欄位} 帶入數值
+}
+
+// AdviceFacts 規則評估時可用的欄位數值；缺少的欄位會讓條件不成立
+type AdviceFacts map[string]float64
+
+// AdviceEngine 依規則產生建議
+type AdviceEngine struct {
+	rules []AdviceRule
+}
+
+var (
+	defaultAdviceOnce   sync.Once
+	defaultAdviceEngine *AdviceEngine
+)
+
+// DefaultAdviceEngine 使用內嵌預設規則的建議引擎
+func DefaultAdviceEngine() *AdviceEngine {
+	defaultAdviceOnce.Do(func() {
+		engine, err := ParseAdviceRules(defaultAdviceRulesJSON)
+		if err != nil {
+			log.Printf("❌ 預設建議規則解析失敗: %v", err)
+			engine = &AdviceEngine{}
+		}
+		defaultAdviceEngine = engine
+	})
+	return defaultAdviceEngine
+}
+
+// LoadAdviceEngine 從 JSON 規則檔建立建議引擎，path 為空時使用預設規則
+func LoadAdviceEngine(path string) (*AdviceEngine, error) {
+	if path == "" {
+		return DefaultAdviceEngine(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("讀取建議規則失敗: %v", err)
+	}
+	return ParseAdviceRules(data)
+}
+
+// ParseAdviceRules 解析並驗證 JSON 規則
+func ParseAdviceRules(data []byte) (*AdviceEngine, error) {
+	var rules []AdviceRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("解析建議規則失敗: %v", err)
+	}
+	return NewAdviceEngine(rules)
+}
+
+// NewAdviceEngine 以規則建立建議引擎
+func NewAdviceEngine(rules []AdviceRule) (*AdviceEngine, error) {
+	ids := make(map[string]bool)
+	for i, rule := range rules {
+		if rule.ID == "" {
+			return nil, fmt.Errorf("第 %d 條規則缺少 id", i+1)
+		}
+		if ids[rule.ID] {
+			return nil, fmt.Errorf("規則 id 重複: %s", rule.ID)
+		}
+		ids[rule.ID] = true
+
+		if rule.Scope == "" {
+			rules[i].Scope = AdviceScopeTrip
+		}
+		if rule.Message[AdviceLangZH] == "" {
+			return nil, fmt.Errorf("規則 %s 缺少 %s 訊息", rule.ID, AdviceLangZH)
+		}
+		for _, cond := range rule.When {
+			if !validAdviceOp(cond.Op) {
+				return nil, fmt.Errorf("規則 %s 使用了不支援的運算子: %s", rule.ID, cond.Op)
+			}
+		}
+	}
+	return &AdviceEngine{rules: rules}, nil
+}
+
+func validAdviceOp(op string) bool {
+	switch op {
+	case "<", "<=", ">", ">=", "==", "!=":
+		return true
+	}
+	return false
+}
+
+func (c AdviceCondition) match(facts AdviceFacts) bool {
+	v, ok := facts[c.Field]
+	if !ok {
+		return false
+	}
+	switch c.Op {
+	case "<":
+		return v < c.Value
+	case "<=":
+		return v <= c.Value
+	case ">":
+		return v > c.Value
+	case ">=":
+		return v >= c.Value
+	case "==":
+		return v == c.Value
+	case "!=":
+		return v != c.Value
+	}
+	return false
+}
+
+func (r AdviceRule) match(facts AdviceFacts) bool {
+	for _, cond := range r.When {
+		if !cond.match(facts) {
+			return false
+		}
+	}
+	return true
+}
+
+// message 取得指定語系的訊息並帶入欄位數值，沒有該語系時退回中文
+func (r AdviceRule) message(lang string, facts AdviceFacts) string {
+	msg, ok := r.Message[lang]
+	if !ok || msg == "" {
+		msg = r.Message[AdviceLangZH]
+	}
+	if !strings.Contains(msg, "{") {
+		return msg
+	}
+	pairs := make([]string, 0, len(facts)*2)
+	for field, v := range facts {
+		pairs = append(pairs, "{"+field+"}", strconv.FormatFloat(v, 'f', 0, 64))
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+// Evaluate 評估指定範圍的規則，依優先度由高到低回傳建議
+func (e *AdviceEngine) Evaluate(scope string, facts AdviceFacts, lang string) []models.AdviceItem {
+	if e == nil {
+		return nil
+	}
+
+	var matched []AdviceRule
+	for _, rule := range e.rules {
+		if rule.Scope == scope && rule.match(facts) {
+			matched = append(matched, rule)
+		}
+	}
+	// 優先度相同時保留規則檔中的順序
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].Priority > matched[j].Priority
+	})
+
+	usedGroups := make(map[string]bool)
+	items := make([]models.AdviceItem, 0, len(matched))
+	for _, rule := range matched {
+		if rule.Group != "" {
+			if usedGroups[rule.Group] {
+				continue
+			}
+			usedGroups[rule.Group] = true
+		}
+		items = append(items, models.AdviceItem{
+			ID:       rule.ID,
+			Category: rule.Category,
+			Priority: rule.Priority,
+			Message:  rule.message(lang, facts),
+		})
+	}
+	return items
+}
+
+// TripAdviceFacts 由出發地、目的地天氣與行程展望整理出旅行建議的欄位
+func TripAdviceFacts(origin, destination *models.WeatherSummary, outlook *models.WeatherOutlook) AdviceFacts {
+	facts := AdviceFacts{}
+	climate := false
+	if origin != nil {
+		facts["origin_temp"] = origin.AvgTemp
+		facts["origin_rain"] = float64(origin.ChanceOfRain)
+		if aq := origin.AirQuality; aq != nil && aq.USEPAIndex > 0 {
+			facts["origin_aqi"] = float64(aq.USEPAIndex)
+		}
+		climate = climate || origin.Source == models.WeatherSourceClimate
+	}
+	if destination != nil {
+		facts["dest_temp"] = destination.AvgTemp
+		facts["dest_rain"] = float64(destination.ChanceOfRain)
+		facts["dest_wind"] = destination.WindSpeed
+		if destination.UV > 0 {
+			facts["dest_uv"] = destination.UV
+		}
+		if aq := destination.AirQuality; aq != nil && aq.USEPAIndex > 0 {
+			facts["dest_aqi"] = float64(aq.USEPAIndex)
+			facts["dest_pm25"] = aq.PM25
+			facts["dest_pm10"] = aq.PM10
+		}
+		facts["dest_climate"] = boolFact(destination.Source == models.WeatherSourceClimate)
+		climate = climate || destination.Source == models.WeatherSourceClimate
+	}
+	if origin != nil && destination != nil {
+		facts["temp_delta"] = destination.AvgTemp - origin.AvgTemp
+	}
+	if outlook != nil && len(outlook.Days) > 0 {
+		facts["trip_days"] = float64(len(outlook.Days))
+		facts["dest_rainy_days"] = float64(outlook.RainyDays)
+	}
+	facts["climate_estimate"] = boolFact(climate)
+	return facts
+}
+
+func boolFact(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// adviceHeading 旅行建議的開頭文字
+var adviceHeading = map[string]string{
+	AdviceLangZH: "旅行建議：",
+	AdviceLangEN: "Travel advice: ",
+}
+
+// FormatAdvice 將多則建議組合成一段文字
+func FormatAdvice(items []models.AdviceItem, lang string) string {
+	heading, ok := adviceHeading[lang]
+	if !ok {
+		heading = adviceHeading[AdviceLangZH]
+	}
+	messages := make([]string, 0, len(items))
+	for _, item := range items {
+		messages = append(messages, item.Message)
+	}
+	sep := ""
+	if lang == AdviceLangEN {
+		sep = " "
+	}
+	return heading + strings.Join(messages, sep)
+}
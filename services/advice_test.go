@@ -0,0 +1,81 @@
+package services
+
+import (
+	"final/models"
+	"strings"
+	"testing"
+)
+
+func TestAdviceEngine_DefaultRules(t *testing.T) {
+	engine := DefaultAdviceEngine()
+
+	tests := []struct {
+		name     string
+		origin   *models.WeatherSummary
+		dest     *models.WeatherSummary
+		outlook  *models.WeatherOutlook
+		lang     string
+		wantID   string
+		notID    string
+		contains string
+	}{
+		{"降雨優先於高溫", &models.WeatherSummary{AvgTemp: 25}, &models.WeatherSummary{AvgTemp: 33, ChanceOfRain: 80}, nil, AdviceLangZH, "dest_rain", "dest_hot", "室內活動"},
+		{"氣候平均提示", &models.WeatherSummary{AvgTemp: 25}, &models.WeatherSummary{AvgTemp: 20, Source: models.WeatherSourceClimate}, nil, AdviceLangZH, "climate_estimate", "", "歷年同期"},
+		{"高紫外線", &models.WeatherSummary{AvgTemp: 25}, &models.WeatherSummary{AvgTemp: 28, UV: 10}, nil, AdviceLangZH, "dest_high_uv", "", "UV 10"},
+		{"長途行程", &models.WeatherSummary{AvgTemp: 25}, &models.WeatherSummary{AvgTemp: 20}, &models.WeatherOutlook{Days: make([]models.DailyOutlook, 12), RainyDays: 4}, AdviceLangZH, "long_trip_laundry", "", "12 天"},
+		{"空氣品質不健康", &models.WeatherSummary{AvgTemp: 25}, &models.WeatherSummary{AvgTemp: 20, AirQuality: &models.AirQuality{USEPAIndex: 4, PM25: 62.4}}, nil, AdviceLangZH, "dest_air_unhealthy", "dest_air_sensitive", "PM2.5 約 62"},
+		{"敏感族群", &models.WeatherSummary{AvgTemp: 25}, &models.WeatherSummary{AvgTemp: 20, AirQuality: &models.AirQuality{USEPAIndex: 3}}, nil, AdviceLangZH, "dest_air_sensitive", "", "吸入器"},
+		{"英文輸出", &models.WeatherSummary{AvgTemp: 20}, &models.WeatherSummary{AvgTemp: 0}, nil, AdviceLangEN, "dest_cold", "dest_fair", "very cold"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := engine.Evaluate(AdviceScopeTrip, TripAdviceFacts(tt.origin, tt.dest, tt.outlook), tt.lang)
+			ids := make(map[string]bool)
+			for i, item := range items {
+				ids[item.ID] = true
+				if i > 0 && items[i-1].Priority < item.Priority {
+					t.Errorf("[%s] 建議未依優先度排序: %v", tt.name, items)
+				}
+			}
+			if !ids[tt.wantID] {
+				t.Errorf("[%s] 預期包含規則 %s, 結果 %v", tt.name, tt.wantID, items)
+			}
+			if tt.notID != "" && ids[tt.notID] {
+				t.Errorf("[%s] 同組規則 %s 不應同時出現", tt.name, tt.notID)
+			}
+			if text := FormatAdvice(items, tt.lang); !strings.Contains(text, tt.contains) {
+				t.Errorf("[%s] 預期包含 '%s', 結果: %s", tt.name, tt.contains, text)
+			}
+		})
+	}
+}
+
+func TestParseAdviceRules_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+	}{
+		{"缺少 id", `[{"message":{"zh-TW":"a"}}]`},
+		{"id 重複", `[{"id":"a","message":{"zh-TW":"a"}},{"id":"a","message":{"zh-TW":"b"}}]`},
+		{"缺少中文訊息", `[{"id":"a","message":{"en":"a"}}]`},
+		{"不支援的運算子", `[{"id":"a","when":[{"field":"dest_temp","op":"=~","value":1}],"message":{"zh-TW":"a"}}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseAdviceRules([]byte(tt.json)); err == nil {
+				t.Errorf("[%s] 應回傳錯誤", tt.name)
+			}
+		})
+	}
+
+	engine, err := ParseAdviceRules([]byte(`[{"id":"hot","category":"health","priority":1,"when":[{"field":"dest_temp","op":">","value":28}],"message":{"zh-TW":"很熱 {dest_temp} 度"}}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := engine.Evaluate(AdviceScopeTrip, AdviceFacts{"dest_temp": 31}, AdviceLangEN)
+	if len(items) != 1 || items[0].Message != "很熱 31 度" {
+		t.Errorf("沒有英文訊息時應退回中文並帶入數值, 結果 %v", items)
+	}
+}
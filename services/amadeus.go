@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"final/config"
 	"final/models"
+	"final/notifications"
 	"fmt"
 	"io"
 	"log"
@@ -23,6 +24,7 @@ import (
 const (
 	historyFilePath = "amadeus_api_history.jsonl" // 原始響應紀錄 (JSONL)
 	priceHistoryDB  = "history.json"              // 結構化價格紀錄 (JSON Array)
+	priceAlertsDB   = "price_alerts.json"         // 價格警報設定 (JSON Array)
 )
 
 type AmadeusService struct {
@@ -32,17 +34,54 @@ type AmadeusService struct {
 	tokenExpiry   time.Time
 	trackingData  map[string]*models.PriceAnalysis
 	trackingMutex sync.RWMutex
-	historyMutex  sync.Mutex // 用於保護 history.json 的寫入
+	historyMutex  sync.Mutex       // 用於保護 history.json 的寫入
+	exchange      *ExchangeService // 用於將歷史價格統一換算成基準貨幣
+
+	priceAlertsPath string
+	alertMutex      sync.Mutex // 保護 price_alerts.json
+	checkMutex      sync.Mutex // 避免價格警報檢查重疊執行而重複通知
+	notifier        *notifications.Dispatcher
+	webhooks        *notifications.WebhookHub
+	alertLimiter    notifyLimiter
 }
 
 func NewAmadeusService(cfg *config.Config) *AmadeusService {
 	return &AmadeusService{
-		config:       cfg,
-		client:       &http.Client{Timeout: 30 * time.Second},
-		trackingData: make(map[string]*models.PriceAnalysis),
+		config:          cfg,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		trackingData:    make(map[string]*models.PriceAnalysis),
+		priceAlertsPath: priceAlertsDB,
 	}
 }
 
+// SetWebhookHub 設定 webhook 訂閱，發布歷史新低、警報觸發與追蹤完成事件
+func (s *AmadeusService) SetWebhookHub(hub *notifications.WebhookHub) {
+	s.webhooks = hub
+}
+
+// publish 發布 webhook 事件，未設定時略過
+func (s *AmadeusService) publish(eventType notifications.EventType, data interface{}) {
+	if s.webhooks != nil {
+		s.webhooks.Publish(eventType, data)
+	}
+}
+
+// SetExchangeService 設定匯率服務，啟用非 TWD 搜尋的歷史比價與顯示貨幣換算
+func (s *AmadeusService) SetExchangeService(exchange *ExchangeService) {
+	s.exchange = exchange
+}
+
+// convertRate 取得 from -> to 的匯率
+func (s *AmadeusService) convertRate(from, to string) (float64, error) {
+	if from == to {
+		return 1.0, nil
+	}
+	if s.exchange == nil {
+		return 0, fmt.Errorf("匯率服務未啟用，無法換算 %s -> %s", from, to)
+	}
+	return s.exchange.GetRate(from, to)
+}
+
 // ---------------------------------------------------------
 // [新增] 本地歷史比價功能方法
 // ---------------------------------------------------------
@@ -66,6 +105,10 @@ func (s *AmadeusService) loadSearchHistory() ([]models.SearchHistoryRecord, erro
 		// 如果檔案是空的或格式錯誤，回傳空切片
 		return []models.SearchHistoryRecord{}, nil
 	}
+	// JSON 中的價格只有數字，依紀錄的幣別還原成 Money
+	for i := range history {
+		history[i].NormalizeCurrency()
+	}
 	return history, nil
 }
 
@@ -95,60 +138,71 @@ func (s *AmadeusService) saveSearchHistory(record models.SearchHistoryRecord) er
 }
 
 // analyzePriceHistory 比較當前價格與歷史紀錄，生成建議
-func (s *AmadeusService) analyzePriceHistory(origin, dest, date string, currentPrice float64) *models.PriceAdvice {
+func (s *AmadeusService) analyzePriceHistory(origin, dest, date string, currentPrice models.Money) *models.PriceAdvice {
 	history, err := s.loadSearchHistory()
 	if err != nil {
 		log.Printf("⚠️ 讀取歷史紀錄失敗: %v", err)
 		return nil
 	}
 
-	var relevantPrices []float64
+	var relevantPrices []models.Money
 	for _, h := range history {
-		// 篩選相同行程 (起點、終點、出發日期)
-		if h.Origin == origin && h.Destination == dest && h.DepartureDate == date {
+		// 篩選相同行程 (起點、終點、出發日期)，價格皆已換算為基準貨幣
+		if h.Origin == origin && h.Destination == dest && h.DepartureDate == date && h.PriceCurrency() == models.BaseCurrency {
 			relevantPrices = append(relevantPrices, h.Price)
 		}
 	}
 
+	advice, err := comparePriceHistory(relevantPrices, currentPrice, models.BaseCurrency)
+	if err != nil {
+		log.Printf("⚠️ 無法比較歷史價格: %v", err)
+		return nil
+	}
+	return advice
+}
+
+// comparePriceHistory 以先前的價格（同一貨幣）評估目前價格，history 為空時視為第一次追蹤
+func comparePriceHistory(relevantPrices []models.Money, currentPrice models.Money, currency string) (*models.PriceAdvice, error) {
 	// 如果沒有歷史紀錄，無法給出建議
 	if len(relevantPrices) == 0 {
 		return &models.PriceAdvice{
 			CurrentLowest: currentPrice,
 			Advice:        "這是我們第一次追蹤此日期的價格，建議您持續關注。",
 			Trend:         "new",
-		}
+			Currency:      currency,
+		}, nil
 	}
 
-	// 計算統計數據
-	minPrice := currentPrice
-	maxPrice := currentPrice
-	sumPrice := currentPrice
-	count := 1.0 // 包含這一次
-
-	for _, p := range relevantPrices {
-		if p < minPrice {
-			minPrice = p
-		}
-		if p > maxPrice {
-			maxPrice = p
-		}
-		sumPrice += p
-		count++
+	// 計算統計數據（包含這一次）；幣別不一致時無法比較
+	all := append(relevantPrices[:len(relevantPrices):len(relevantPrices)], currentPrice)
+	minIdx, maxIdx, err := models.MinMaxIndex(all)
+	if err != nil {
+		return nil, err
 	}
+	prevIdx, _, _ := models.MinMaxIndex(relevantPrices)
+	previousLow, minPrice, maxPrice := relevantPrices[prevIdx], all[minIdx], all[maxIdx]
 
-	avgPrice := sumPrice / count
-	diffPercent := ((currentPrice - avgPrice) / avgPrice) * 100
+	avgPrice, err := models.AverageMoney(all)
+	if err != nil {
+		return nil, err
+	}
+	diffPercent := 0.0
+	if !avgPrice.IsZero() {
+		diffPercent = (currentPrice.Float64() - avgPrice.Float64()) / avgPrice.Float64() * 100
+	}
 
 	advice := &models.PriceAdvice{
 		CurrentLowest: currentPrice,
 		HistoryAvg:    avgPrice,
 		HistoryLow:    minPrice,
 		HistoryHigh:   maxPrice,
+		PreviousLow:   previousLow,
 		DiffPercent:   diffPercent,
+		Currency:      currency,
 	}
 
-	// 生成建議邏輯
-	if currentPrice <= minPrice {
+	// 生成建議邏輯（幣別已確認一致，直接比較金額）
+	if currentPrice.Amount <= minPrice.Amount {
 		advice.Trend = "down"
 		advice.Advice = "🔥 歷史新低價！強烈建議立即購買，現在最划算！"
 	} else if diffPercent <= -10 {
@@ -162,7 +216,7 @@ func (s *AmadeusService) analyzePriceHistory(origin, dest, date string, currentP
 		advice.Advice = "⚖️ 價格持平。目前價格在平均範圍內，可依需求購買。"
 	}
 
-	return advice
+	return advice, nil
 }
 
 // 新增：將 API 響應儲存到本地歷史記錄檔案
@@ -246,12 +300,12 @@ func (s *AmadeusService) TrackFlightPrices(req models.PriceTrackingRequest) (*mo
 			Week:     week,
 			Date:     travelDate,
 			Price:    price,
-			Currency: "TWD",
+			Currency: price.Currency,
 		}
 
 		analysis.DataPoints = append(analysis.DataPoints, dataPoint)
 
-		log.Printf("💰 第 %d 週 - 出發: %s, 價格: $%.0f",
+		log.Printf("💰 第 %d 週 - 出發: %s, 價格: %s",
 			week, travelDate.Format("2006-01-02"), price)
 
 		// 如果是真實長期追蹤，這裡會暫停
@@ -261,17 +315,18 @@ func (s *AmadeusService) TrackFlightPrices(req models.PriceTrackingRequest) (*mo
 	// 計算統計數據
 	s.calculatePriceStatistics(analysis)
 
-	log.Printf("✅ 真實價格追蹤完成: %s, 最低價: $%.0f", route, analysis.MinPrice)
+	log.Printf("✅ 真實價格追蹤完成: %s, 最低價: %s", route, analysis.MinPrice)
+	s.publish(notifications.EventTrackingCompleted, notifications.TrackingEvent{Analysis: *analysis})
 	return analysis, nil
 }
 
 // 新增：實時價格查詢
 // 修改：過濾重複航空公司，只取每個航空公司的最低價格
 // 完整的 getRealTimePrice 方法
-func (s *AmadeusService) getRealTimePrice(origin, destination, departureDate string) (float64, error) {
+func (s *AmadeusService) getRealTimePrice(origin, destination, departureDate string) (models.Money, error) {
 	token, err := s.getAccessToken()
 	if err != nil {
-		return 0, err
+		return models.Money{}, err
 	}
 
 	apiURL := fmt.Sprintf("%s/shopping/flight-offers", s.config.AmadeusBaseURL)
@@ -288,7 +343,7 @@ func (s *AmadeusService) getRealTimePrice(origin, destination, departureDate str
 
 	httpReq, err := http.NewRequest("GET", fullURL, nil)
 	if err != nil {
-		return 0, fmt.Errorf("創建請求失敗: %v", err)
+		return models.Money{}, fmt.Errorf("創建請求失敗: %v", err)
 	}
 
 	httpReq.Header.Add("Authorization", "Bearer "+token)
@@ -298,18 +353,18 @@ func (s *AmadeusService) getRealTimePrice(origin, destination, departureDate str
 	// 發送請求
 	resp, err := s.client.Do(httpReq)
 	if err != nil {
-		return 0, fmt.Errorf("API請求失敗: %v", err)
+		return models.Money{}, fmt.Errorf("API請求失敗: %v", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return 0, fmt.Errorf("讀取響應失敗: %v", err)
+		return models.Money{}, fmt.Errorf("讀取響應失敗: %v", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("❌ API錯誤: 狀態碼 %d, 響應: %s", resp.StatusCode, string(body))
-		return 0, fmt.Errorf("API錯誤: 狀態碼 %d", resp.StatusCode)
+		return models.Money{}, fmt.Errorf("API錯誤: 狀態碼 %d", resp.StatusCode)
 	}
 
 	// !!! 新增功能：將 API 響應儲存到歷史記錄檔案
@@ -319,15 +374,15 @@ func (s *AmadeusService) getRealTimePrice(origin, destination, departureDate str
 	// 解析響應
 	var apiResponse models.AmadeusFlightOffersResponse
 	if err := json.Unmarshal(body, &apiResponse); err != nil {
-		return 0, fmt.Errorf("解析JSON失敗: %v", err)
+		return models.Money{}, fmt.Errorf("解析JSON失敗: %v", err)
 	}
 
 	if len(apiResponse.Data) == 0 {
-		return 0, fmt.Errorf("未找到航班")
+		return models.Money{}, fmt.Errorf("未找到航班")
 	}
 
 	// 使用 map 來儲存每個航空公司的唯一最低價格
-	airlinePrices := make(map[string]float64)
+	airlinePrices := make(map[string]models.Money)
 	var uniqueAirlines []string
 
 	log.Printf("   📊 原始找到 %d 個航班", len(apiResponse.Data))
@@ -337,7 +392,7 @@ func (s *AmadeusService) getRealTimePrice(origin, destination, departureDate str
 			continue
 		}
 
-		price, err := strconv.ParseFloat(offer.Price.Total, 64)
+		price, err := models.ParseMoney(offer.Price.Total, offer.Price.Currency)
 		if err != nil {
 			continue
 		}
@@ -346,7 +401,12 @@ func (s *AmadeusService) getRealTimePrice(origin, destination, departureDate str
 		airline := s.getAirlineName(carrierCode)
 
 		// 如果這個航空公司還沒有記錄，或者找到更低的價格，就更新
-		if existingPrice, exists := airlinePrices[airline]; !exists || price < existingPrice {
+		existingPrice, exists := airlinePrices[airline]
+		lower, err := price.Less(existingPrice)
+		if err != nil {
+			return models.Money{}, fmt.Errorf("航班報價幣別不一致: %v", err)
+		}
+		if !exists || lower {
 			airlinePrices[airline] = price
 		}
 
@@ -355,71 +415,65 @@ func (s *AmadeusService) getRealTimePrice(origin, destination, departureDate str
 			uniqueAirlines = append(uniqueAirlines, airline)
 		}
 
-		log.Printf("   ✈️ 航班 %d: %s (%s) - $%.0f", i+1, airline, carrierCode, price)
+		log.Printf("   ✈️ 航班 %d: %s (%s) - %s", i+1, airline, carrierCode, price)
 	}
 
-	// 轉換為價格列表來計算平均
-	var prices []float64
-	var airlines []string
-
-	log.Printf("   🔄 過濾後得到 %d 個獨特航空公司:", len(airlinePrices))
-	for airline, price := range airlinePrices {
-		prices = append(prices, price)
-		airlines = append(airlines, airline)
-		log.Printf("      ✅ %s: $%.0f", airline, price)
-	}
-
-	if len(prices) == 0 {
-		return 0, fmt.Errorf("無法解析航班價格")
+	if len(airlinePrices) == 0 {
+		return models.Money{}, fmt.Errorf("無法解析航班價格")
 	}
 
 	// 使用所有過濾後的獨特航空公司計算平均價格
-	totalPrice := 0.0
 	validAirlines := len(airlinePrices)
 
+	log.Printf("   🔄 過濾後得到 %d 個獨特航空公司:", validAirlines)
 	log.Printf("   📈 使用 %d 個獨特航空公司計算平均價格:", validAirlines)
 
 	// 按價格排序以便顯示
 	sortedAirlines := make([]struct {
 		airline string
-		price   float64
+		price   models.Money
 	}, 0, len(airlinePrices))
 
+	prices := make([]models.Money, 0, len(airlinePrices))
 	for airline, price := range airlinePrices {
 		sortedAirlines = append(sortedAirlines, struct {
 			airline string
-			price   float64
+			price   models.Money
 		}{airline, price})
-		totalPrice += price
+		prices = append(prices, price)
+	}
+
+	if err := models.SameCurrency(prices); err != nil {
+		return models.Money{}, fmt.Errorf("航班報價幣別不一致: %v", err)
 	}
 
-	// 按價格排序
+	// 按價格排序（幣別已確認一致，以下直接比較與運算）
 	sort.Slice(sortedAirlines, func(i, j int) bool {
-		return sortedAirlines[i].price < sortedAirlines[j].price
+		return sortedAirlines[i].price.Amount < sortedAirlines[j].price.Amount
 	})
 
 	// 顯示排序後的價格
 	for i, item := range sortedAirlines {
-		log.Printf("      %d. %s: $%.0f", i+1, item.airline, item.price)
+		log.Printf("      %d. %s: %s", i+1, item.airline, item.price)
 	}
 
-	averagePrice := totalPrice / float64(validAirlines)
+	averagePrice, _ := models.AverageMoney(prices)
 
 	// 計算價格範圍和標準差
 	minPrice := sortedAirlines[0].price
 	maxPrice := sortedAirlines[len(sortedAirlines)-1].price
-	priceRange := maxPrice - minPrice
+	priceRange, _ := maxPrice.Sub(minPrice)
 
-	// 計算標準差
+	// 計算標準差（僅供紀錄，使用浮點數即可）
 	variance := 0.0
 	for _, item := range sortedAirlines {
-		variance += math.Pow(item.price-averagePrice, 2)
+		variance += math.Pow(item.price.Float64()-averagePrice.Float64(), 2)
 	}
 	stdDev := math.Sqrt(variance / float64(validAirlines))
 
-	log.Printf("   🎯 平均價格: $%.0f (基於 %d 個獨特航空公司)", averagePrice, validAirlines)
-	log.Printf("   📊 價格範圍: $%.0f - $%.0f (範圍: $%.0f)", minPrice, maxPrice, priceRange)
-	log.Printf("   📐 標準差: $%.0f", stdDev)
+	log.Printf("   🎯 平均價格: %s (基於 %d 個獨特航空公司)", averagePrice, validAirlines)
+	log.Printf("   📊 價格範圍: %s - %s (範圍: %s)", minPrice, maxPrice, priceRange)
+	log.Printf("   📐 標準差: %.0f", stdDev)
 
 	return averagePrice, nil
 }
@@ -435,7 +489,7 @@ func contains(slice []string, item string) bool {
 }
 
 // 新增：智能價格估算（當 API 失敗時使用）
-func (s *AmadeusService) estimatePrice(origin, destination string, date time.Time, week, totalWeeks int) float64 {
+func (s *AmadeusService) estimatePrice(origin, destination string, date time.Time, week, totalWeeks int) models.Money {
 	basePrice := s.getBasePrice(origin, destination)
 	seasonalFactor := s.getSeasonalFactor(date)
 	advanceDiscount := s.getAdvanceDiscount(week, totalWeeks)
@@ -446,14 +500,14 @@ func (s *AmadeusService) estimatePrice(origin, destination string, date time.Tim
 	log.Printf("   📊 智能估算價格: $%.0f (基礎: $%.0f, 季節: %.2f, 折扣: %.2f)",
 		estimatedPrice, basePrice, seasonalFactor, advanceDiscount)
 
-	return math.Round(estimatedPrice)
+	return models.NewMoney(estimatedPrice, "TWD")
 }
 
 // 新增：獲取真實航班價格
-func (s *AmadeusService) getRealFlightPrice(origin, destination string, date time.Time) (float64, error) {
+func (s *AmadeusService) getRealFlightPrice(origin, destination string, date time.Time) (models.Money, error) {
 	token, err := s.getAccessToken()
 	if err != nil {
-		return 0, err
+		return models.Money{}, err
 	}
 
 	apiURL := fmt.Sprintf("%s/shopping/flight-offers", s.config.AmadeusBaseURL)
@@ -470,44 +524,56 @@ func (s *AmadeusService) getRealFlightPrice(origin, destination string, date tim
 
 	httpReq, err := http.NewRequest("GET", fullURL, nil)
 	if err != nil {
-		return 0, err
+		return models.Money{}, err
 	}
 
 	httpReq.Header.Add("Authorization", "Bearer "+token)
 
 	resp, err := s.client.Do(httpReq)
 	if err != nil {
-		return 0, err
+		return models.Money{}, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return 0, err
+		return models.Money{}, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("API錯誤: 狀態碼 %d", resp.StatusCode)
+		return models.Money{}, fmt.Errorf("API錯誤: 狀態碼 %d", resp.StatusCode)
 	}
 
 	// 解析響應獲取最低價格
 	var apiResponse models.AmadeusFlightOffersResponse
 	if err := json.Unmarshal(body, &apiResponse); err != nil {
-		return 0, err
+		return models.Money{}, err
 	}
 
 	if len(apiResponse.Data) == 0 {
-		return 0, fmt.Errorf("未找到航班")
+		return models.Money{}, fmt.Errorf("未找到航班")
 	}
 
 	// 返回最低價格
-	minPrice := 0.0
-	for i, offer := range apiResponse.Data {
-		price, _ := strconv.ParseFloat(offer.Price.Total, 64)
-		if i == 0 || price < minPrice {
+	var minPrice models.Money
+	found := false
+	for _, offer := range apiResponse.Data {
+		price, err := models.ParseMoney(offer.Price.Total, offer.Price.Currency)
+		if err != nil {
+			continue
+		}
+		lower, err := price.Less(minPrice)
+		if err != nil {
+			return models.Money{}, fmt.Errorf("航班報價幣別不一致: %v", err)
+		}
+		if !found || lower {
 			minPrice = price
+			found = true
 		}
 	}
+	if !found {
+		return models.Money{}, fmt.Errorf("無法解析航班價格")
+	}
 
 	return minPrice, nil
 }
@@ -629,56 +695,84 @@ func (s *AmadeusService) calculatePriceStatistics(analysis *models.PriceAnalysis
 		return
 	}
 
-	// 初始化為第一個數據點的值
-	minPrice := analysis.DataPoints[0].Price
-	maxPrice := analysis.DataPoints[0].Price
-	sum := 0.0
-	bestDate := analysis.DataPoints[0].Date // 初始化最佳日期
-
 	log.Printf("📊 開始計算價格統計，共 %d 個數據點", len(analysis.DataPoints))
 
+	// 真實報價與估算價格的幣別可能不同，混用時先統一換算成基準貨幣
+	if err := s.normalizePricePoints(analysis.DataPoints); err != nil {
+		log.Printf("⚠️ 價格幣別不一致且無法換算，略過統計: %v", err)
+		return
+	}
+
+	prices := make([]models.Money, 0, len(analysis.DataPoints))
 	for _, point := range analysis.DataPoints {
-		log.Printf("   📅 第 %d 週: 日期=%s, 價格=$%.0f",
+		log.Printf("   📅 第 %d 週: 日期=%s, 價格=%s",
 			point.Week, point.Date.Format("2006-01-02"), point.Price)
-
-		if point.Price < minPrice {
-			minPrice = point.Price
-			bestDate = point.Date // 更新最佳日期
-			log.Printf("   🎯 發現新的最低價格: $%.0f, 日期: %s", minPrice, bestDate.Format("2006-01-02"))
-		}
-		if point.Price > maxPrice {
-			maxPrice = point.Price
-		}
-		sum += point.Price
+		prices = append(prices, point.Price)
 	}
 
-	analysis.MinPrice = minPrice
-	analysis.MaxPrice = maxPrice
-	analysis.AvgPrice = sum / float64(len(analysis.DataPoints))
+	minIdx, maxIdx, _ := models.MinMaxIndex(prices)
+	bestDate := analysis.DataPoints[minIdx].Date
+	log.Printf("   🎯 最低價格: %s, 日期: %s", prices[minIdx], bestDate.Format("2006-01-02"))
+
+	analysis.MinPrice = prices[minIdx]
+	analysis.MaxPrice = prices[maxIdx]
+	analysis.AvgPrice, _ = models.AverageMoney(prices)
 	analysis.BestDate = bestDate // 設置最佳日期
 	analysis.Recommendation = s.generateRecommendation(analysis)
 
 	log.Printf("✅ 統計計算完成:")
-	log.Printf("   📈 最低價格: $%.0f", analysis.MinPrice)
-	log.Printf("   📈 最高價格: $%.0f", analysis.MaxPrice)
-	log.Printf("   📊 平均價格: $%.0f", analysis.AvgPrice)
+	log.Printf("   📈 最低價格: %s", analysis.MinPrice)
+	log.Printf("   📈 最高價格: %s", analysis.MaxPrice)
+	log.Printf("   📊 平均價格: %s", analysis.AvgPrice)
 	log.Printf("   🎯 最佳出發日期: %s", analysis.BestDate.Format("2006-01-02"))
 	log.Printf("   💡 推薦建議: %s", analysis.Recommendation)
 }
 
+// normalizePricePoints 數據點幣別不一致時全部換算成基準貨幣
+func (s *AmadeusService) normalizePricePoints(points []models.PricePoint) error {
+	prices := make([]models.Money, len(points))
+	for i, p := range points {
+		prices[i] = p.Price
+	}
+	if models.SameCurrency(prices) == nil {
+		return nil
+	}
+
+	converted := make([]models.Money, len(points))
+	for i, p := range points {
+		rate, err := s.convertRate(p.Price.Currency, models.BaseCurrency)
+		if err != nil {
+			return err
+		}
+		converted[i] = p.Price.Convert(rate, models.BaseCurrency)
+	}
+	for i := range points {
+		points[i].Price = converted[i]
+		points[i].Currency = models.BaseCurrency
+	}
+	return nil
+}
+
 // 新增：生成推薦建議
 func (s *AmadeusService) generateRecommendation(analysis *models.PriceAnalysis) string {
-	savings := analysis.AvgPrice - analysis.MinPrice
-	savingsRatio := (savings / analysis.AvgPrice) * 100
+	if analysis.AvgPrice.IsZero() {
+		return "價格波動不大，可根據個人行程安排選擇出發時間"
+	}
+
+	savings, err := analysis.AvgPrice.Sub(analysis.MinPrice)
+	if err != nil {
+		return "價格波動不大，可根據個人行程安排選擇出發時間"
+	}
+	savingsRatio := (savings.Float64() / analysis.AvgPrice.Float64()) * 100
 
 	bestDateStr := analysis.BestDate.Format("2006年1月2日")
 
 	if savingsRatio > 20 {
-		return fmt.Sprintf("強烈建議在 %s 出發！價格 $%.0f 為最低價，相比平均價格節省 $%.0f (%.0f%%)",
-			bestDateStr, analysis.MinPrice, savings, savingsRatio)
+		return fmt.Sprintf("強烈建議在 %s 出發！價格 $%s 為最低價，相比平均價格節省 $%s (%.0f%%)",
+			bestDateStr, analysis.MinPrice.Number(), savings.Number(), savingsRatio)
 	} else if savingsRatio > 10 {
-		return fmt.Sprintf("建議在 %s 出發，價格 $%.0f 較為優惠，可節省 $%.0f",
-			bestDateStr, analysis.MinPrice, savings)
+		return fmt.Sprintf("建議在 %s 出發，價格 $%s 較為優惠，可節省 $%s",
+			bestDateStr, analysis.MinPrice.Number(), savings.Number())
 	} else {
 		return "價格波動不大，可根據個人行程安排選擇出發時間"
 	}
@@ -777,6 +871,12 @@ func (s *AmadeusService) getAccessToken() (string, error) {
 
 // 搜尋航班報價
 func (s *AmadeusService) SearchFlights(req models.SearchRequest) ([]models.Flight, *models.PriceAdvice, error) {
+	return s.searchFlights(req, true)
+}
+
+// searchFlights 查詢報價；record 為 false 時只讀取，不寫入 API 紀錄與價格歷史，也不發布歷史新低事件
+// （不會有比價建議，需要時由呼叫端以 analyzePriceHistory 自行比較）
+func (s *AmadeusService) searchFlights(req models.SearchRequest, record bool) ([]models.Flight, *models.PriceAdvice, error) {
 	token, err := s.getAccessToken()
 	if err != nil {
 		return nil, nil, err
@@ -829,7 +929,9 @@ func (s *AmadeusService) SearchFlights(req models.SearchRequest) ([]models.Fligh
 	}
 
 	// 保存原始 API 響應 (既有功能)
-	s.saveApiHistory(req.Origin, req.Destination, req.DepartureDate, body)
+	if record {
+		s.saveApiHistory(req.Origin, req.Destination, req.DepartureDate, body)
+	}
 
 	// 解析響應
 	var apiResponse models.AmadeusFlightOffersResponse
@@ -846,40 +948,192 @@ func (s *AmadeusService) SearchFlights(req models.SearchRequest) ([]models.Fligh
 	// ---------------------------------------------------------
 	var advice *models.PriceAdvice
 
-	if len(flights) > 0 {
-		// 1. 找出本次搜尋的最低價格
-		lowestPrice := flights[0].Price
-		for _, f := range flights {
-			if f.Price < lowestPrice {
-				lowestPrice = f.Price
-			}
+	if record && len(flights) > 0 {
+		// 1. 找出本次搜尋的最低價格（報價幣別不一致時無法比價）
+		lowestPrice, err := lowestFlightPrice(flights)
+		quoteCurrency := flights[0].Currency
+		if quoteCurrency == "" {
+			quoteCurrency = req.Currency
 		}
 
-		// 2. 生成比價建議 (在儲存本次紀錄前先比較，這樣才能跟"過去"比)
-		// 只有當貨幣為 TWD 時才進行精確比價，避免匯率問題，或者假設前端都傳 TWD
-		if req.Currency == "TWD" || req.Currency == "" {
-			advice = s.analyzePriceHistory(req.Origin, req.Destination, req.DepartureDate, lowestPrice)
+		// 2. 將最低價換算成基準貨幣，不同貨幣的搜尋才能放在一起比價
+		var rate float64
+		if err == nil {
+			rate, err = s.convertRate(quoteCurrency, models.BaseCurrency)
+		}
+		if err != nil {
+			log.Printf("⚠️ 無法換算 %s 價格，略過歷史比價: %v", quoteCurrency, err)
+		} else {
+			basePrice := lowestPrice.Convert(rate, models.BaseCurrency)
+
+			// 在儲存本次紀錄前先比較，這樣才能跟"過去"比
+			advice = s.analyzePriceHistory(req.Origin, req.Destination, req.DepartureDate, basePrice)
 
 			// 3. 儲存本次紀錄到 history.json
 			newRecord := models.SearchHistoryRecord{
-				Origin:        req.Origin,
-				Destination:   req.Destination,
-				DepartureDate: req.DepartureDate,
-				Price:         lowestPrice,
-				RecordDate:    time.Now(),
+				Origin:           req.Origin,
+				Destination:      req.Destination,
+				DepartureDate:    req.DepartureDate,
+				Price:            basePrice,
+				Currency:         models.BaseCurrency,
+				RecordDate:       time.Now(),
+				OriginalPrice:    lowestPrice,
+				OriginalCurrency: quoteCurrency,
+				ExchangeRate:     rate,
+			}
+
+			if isNewLow, _ := advice.IsNewLow(); isNewLow {
+				s.publish(notifications.EventHistoricalLow, notifications.HistoricalLowEvent{
+					Origin:        req.Origin,
+					Destination:   req.Destination,
+					DepartureDate: req.DepartureDate,
+					Advice:        *advice,
+				})
 			}
 
 			if err := s.saveSearchHistory(newRecord); err != nil {
 				log.Printf("⚠️ 無法儲存搜尋歷史: %v", err)
 			} else {
-				log.Printf("💾 已儲存價格紀錄: %s->%s ($%.0f)", req.Origin, req.Destination, lowestPrice)
+				log.Printf("💾 已儲存價格紀錄: %s->%s (%s)", req.Origin, req.Destination, basePrice)
 			}
 		}
 	}
 
+	// 4. 換算成顯示貨幣
+	displayCurrency := req.DisplayCurrency
+	if displayCurrency == "" {
+		displayCurrency = req.Currency
+	}
+	if displayCurrency != "" {
+		flights = s.convertFlights(flights, displayCurrency)
+		advice = s.convertAdvice(advice, displayCurrency)
+	}
+
 	return flights, advice, nil
 }
 
+// lowestFlightPrice 航班中的最低價；報價幣別不一致時回傳 models.ErrCurrencyMismatch
+func lowestFlightPrice(flights []models.Flight) (models.Money, error) {
+	prices := make([]models.Money, len(flights))
+	for i, f := range flights {
+		prices[i] = f.Price
+	}
+	minIdx, _, err := models.MinMaxIndex(prices)
+	if err != nil {
+		return models.Money{}, err
+	}
+	if minIdx < 0 {
+		return models.Money{}, fmt.Errorf("找不到航班")
+	}
+	return prices[minIdx], nil
+}
+
+// sortFlightsByPrice 依價格由低到高排序；幣別不一致時不排序並回傳錯誤
+func sortFlightsByPrice(flights []models.Flight) error {
+	prices := make([]models.Money, len(flights))
+	for i, f := range flights {
+		prices[i] = f.Price
+	}
+	if err := models.SameCurrency(prices); err != nil {
+		return err
+	}
+	sort.SliceStable(flights, func(i, j int) bool { return flights[i].Price.Amount < flights[j].Price.Amount })
+	return nil
+}
+
+// convertFlights 將航班價格換算成顯示貨幣，並保留原始報價
+// 任何一種報價幣別無法換算時整批保留原幣別，避免同一批結果混用不同貨幣
+func (s *AmadeusService) convertFlights(flights []models.Flight, currency string) []models.Flight {
+	rates := make(map[string]float64)
+	for _, f := range flights {
+		if _, ok := rates[f.Currency]; ok || f.Currency == currency {
+			continue
+		}
+		rate, err := s.convertRate(f.Currency, currency)
+		if err != nil {
+			log.Printf("⚠️ 無法換算航班價格 %s -> %s，保留原始報價: %v", f.Currency, currency, err)
+			return flights
+		}
+		rates[f.Currency] = rate
+	}
+
+	for i := range flights {
+		f := &flights[i]
+		if f.Currency == currency {
+			continue
+		}
+		f.OriginalPrice = f.Price
+		f.OriginalCurrency = f.Currency
+		f.Price = f.Price.Convert(rates[f.Currency], currency)
+		f.Currency = currency
+	}
+	return flights
+}
+
+// convertAdvice 將比價建議中的金額換算成顯示貨幣
+func (s *AmadeusService) convertAdvice(advice *models.PriceAdvice, currency string) *models.PriceAdvice {
+	if advice == nil || advice.Currency == currency {
+		return advice
+	}
+	rate, err := s.convertRate(advice.Currency, currency)
+	if err != nil {
+		log.Printf("⚠️ 無法換算比價建議 %s -> %s: %v", advice.Currency, currency, err)
+		return advice
+	}
+	converted := *advice
+	converted.CurrentLowest = advice.CurrentLowest.Convert(rate, currency)
+	converted.HistoryAvg = advice.HistoryAvg.Convert(rate, currency)
+	converted.HistoryLow = advice.HistoryLow.Convert(rate, currency)
+	converted.HistoryHigh = advice.HistoryHigh.Convert(rate, currency)
+	converted.PreviousLow = advice.PreviousLow.Convert(rate, currency)
+	converted.Currency = currency
+	return &converted
+}
+
+// GetSearchHistory 取得指定航線的歷史價格紀錄，並換算成顯示貨幣
+// departureDate 為空時回傳該航線所有出發日期的紀錄
+func (s *AmadeusService) GetSearchHistory(origin, destination, departureDate, displayCurrency string) ([]models.SearchHistoryRecord, error) {
+	history, err := s.loadSearchHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	if displayCurrency == "" {
+		displayCurrency = models.BaseCurrency
+	}
+
+	rates := make(map[string]float64)
+	records := []models.SearchHistoryRecord{}
+	for _, h := range history {
+		if h.Origin != origin || h.Destination != destination {
+			continue
+		}
+		if departureDate != "" && h.DepartureDate != departureDate {
+			continue
+		}
+
+		from := h.PriceCurrency()
+		rate, ok := rates[from]
+		if !ok {
+			rate, err = s.convertRate(from, displayCurrency)
+			if err != nil {
+				return nil, err
+			}
+			rates[from] = rate
+		}
+
+		h.Price = h.Price.Convert(rate, displayCurrency)
+		h.Currency = displayCurrency
+		records = append(records, h)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].RecordDate.Before(records[j].RecordDate)
+	})
+
+	return records, nil
+}
+
 // 轉換Amadeus響應為統一格式
 func (s *AmadeusService) transformResponse(response models.AmadeusFlightOffersResponse) []models.Flight {
 	var flights []models.Flight
@@ -897,8 +1151,12 @@ func (s *AmadeusService) transformResponse(response models.AmadeusFlightOffersRe
 		firstSegment := itinerary.Segments[0]
 		lastSegment := itinerary.Segments[len(itinerary.Segments)-1]
 
-		// 解析價格
-		price, _ := strconv.ParseFloat(offer.Price.Total, 64)
+		// 解析價格（Amadeus 回傳十進位字串，直接精確解析）
+		price, err := models.ParseMoney(offer.Price.Total, offer.Price.Currency)
+		if err != nil {
+			log.Printf("⚠️ 無法解析報價 %s 的價格 %q: %v", offer.ID, offer.Price.Total, err)
+			continue
+		}
 
 		// [修改] 不再解析時間，直接使用 API 回傳的原始字串
 		// 這樣可以保留 "當地時間" 語意，避免時區轉換錯誤導致的 08:06 問題
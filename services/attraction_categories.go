@@ -0,0 +1,177 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// 景點類別分類表：穩定的 slug、中英文名稱與 Foursquare 類別 ID
+// 新版 Places API 的類別 ID 是 24 位十六進位的 BSON ID，舊版 v3 的數字 ID 只保留在 legacy_id 供對照
+//
+//go:embed data/attraction_categories.json
+var attractionCategoriesJSON []byte
+
+// AttractionCategory 一個景點類別，Parent 為上層類別的 slug（頂層為空）
+type AttractionCategory struct {
+	Slug     string            `json:"slug"`
+	ID       string            `json:"id"`
+	LegacyID string            `json:"legacy_id,omitempty"` // 舊版 v3 的數字 ID
+	Parent   string            `json:"parent,omitempty"`
+	Name     map[string]string `json:"name"` // 語系 => 名稱
+}
+
+// isCategoryID 是否為新版 Places API 的類別 ID（24 位十六進位）
+func isCategoryID(s string) bool {
+	if len(s) != 24 {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// CategoryNode 階層式瀏覽用的類別節點（名稱已依語系選好）
+type CategoryNode struct {
+	Slug     string         `json:"slug"`
+	ID       string         `json:"id"`
+	Name     string         `json:"name"`
+	Children []CategoryNode `json:"children,omitempty"`
+}
+
+// CategoryTaxonomy 景點類別分類表
+type CategoryTaxonomy struct {
+	categories []AttractionCategory
+	index      map[string]*AttractionCategory // slug、ID、舊版 ID 與各語系名稱（小寫）=> 類別
+}
+
+var (
+	defaultTaxonomyOnce sync.Once
+	defaultTaxonomy     *CategoryTaxonomy
+)
+
+// DefaultCategoryTaxonomy 使用內嵌分類表
+func DefaultCategoryTaxonomy() *CategoryTaxonomy {
+	defaultTaxonomyOnce.Do(func() {
+		taxonomy, err := ParseCategoryTaxonomy(attractionCategoriesJSON)
+		if err != nil {
+			log.Printf("❌ 景點類別分類表解析失敗: %v", err)
+			taxonomy = &CategoryTaxonomy{index: map[string]*AttractionCategory{}}
+		}
+		defaultTaxonomy = taxonomy
+	})
+	return defaultTaxonomy
+}
+
+// ParseCategoryTaxonomy 解析並驗證類別分類表
+func ParseCategoryTaxonomy(data []byte) (*CategoryTaxonomy, error) {
+	var categories []AttractionCategory
+	if err := json.Unmarshal(data, &categories); err != nil {
+		return nil, fmt.Errorf("解析景點類別失敗: %v", err)
+	}
+
+	t := &CategoryTaxonomy{
+		categories: categories,
+		index:      make(map[string]*AttractionCategory),
+	}
+	slugs := make(map[string]bool)
+	for i := range categories {
+		c := &categories[i]
+		if c.Slug == "" || c.ID == "" {
+			return nil, fmt.Errorf("第 %d 個類別缺少 slug 或 id", i+1)
+		}
+		if !isCategoryID(c.ID) {
+			return nil, fmt.Errorf("類別 %s 的 id 不是 Foursquare 類別 ID: %s", c.Slug, c.ID)
+		}
+		if slugs[c.Slug] {
+			return nil, fmt.Errorf("類別 slug 重複: %s", c.Slug)
+		}
+		slugs[c.Slug] = true
+	}
+	for i := range categories {
+		c := &categories[i]
+		if c.Parent != "" && !slugs[c.Parent] {
+			return nil, fmt.Errorf("類別 %s 的上層類別不存在: %s", c.Slug, c.Parent)
+		}
+		t.index[c.Slug] = c
+		t.index[c.ID] = c
+		if c.LegacyID != "" {
+			t.index[c.LegacyID] = c
+		}
+		for _, name := range c.Name {
+			if key := strings.ToLower(name); key != "" {
+				if _, taken := t.index[key]; !taken {
+					t.index[key] = c
+				}
+			}
+		}
+	}
+	return t, nil
+}
+
+// Lookup 以 slug、Foursquare ID（含舊版數字 ID）或中英文名稱查詢類別
+func (t *CategoryTaxonomy) Lookup(key string) (AttractionCategory, bool) {
+	c, ok := t.index[strings.ToLower(strings.TrimSpace(key))]
+	if !ok {
+		return AttractionCategory{}, false
+	}
+	return *c, true
+}
+
+// ResolveIDs 將逗號分隔的 slug / 名稱 / ID 轉成 Foursquare 的 categories 參數
+// 分類表中的舊版數字 ID 會轉成新 ID；不在分類表中的 BSON ID 直接送出，新版 API 不接受的數字 ID 則回傳錯誤
+func (t *CategoryTaxonomy) ResolveIDs(input string) (string, error) {
+	var ids []string
+	for _, part := range strings.Split(input, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if c, ok := t.Lookup(part); ok {
+			ids = append(ids, c.ID)
+			continue
+		}
+		if id := strings.ToLower(part); isCategoryID(id) {
+			ids = append(ids, id)
+			continue
+		}
+		return "", fmt.Errorf("不支援的景點類別: %s", part)
+	}
+	return strings.Join(ids, ","), nil
+}
+
+// Tree 依語系回傳階層式類別，子類別依分類表順序排列
+func (t *CategoryTaxonomy) Tree(lang string) []CategoryNode {
+	children := make(map[string][]CategoryNode)
+	for _, c := range t.categories {
+		if c.Parent != "" {
+			children[c.Parent] = append(children[c.Parent], c.node(lang, nil))
+		}
+	}
+
+	nodes := make([]CategoryNode, 0)
+	for _, c := range t.categories {
+		if c.Parent == "" {
+			nodes = append(nodes, c.node(lang, children[c.Slug]))
+		}
+	}
+	return nodes
+}
+
+func (c AttractionCategory) node(lang string, children []CategoryNode) CategoryNode {
+	return CategoryNode{Slug: c.Slug, ID: c.ID, Name: c.LocalizedName(lang), Children: children}
+}
+
+// LocalizedName 取得指定語系的名稱，沒有該語系時退回中文
+func (c AttractionCategory) LocalizedName(lang string) string {
+	if name := c.Name[lang]; name != "" {
+		return name
+	}
+	return c.Name[AdviceLangZH]
+}
@@ -0,0 +1,98 @@
+package services
+
+import "testing"
+
+func TestCategoryTaxonomy_ResolveIDs(t *testing.T) {
+	taxonomy := DefaultCategoryTaxonomy()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"slug", "museum", "4bf58dd8d48988d181941735", false},
+		{"中文名稱", "博物館", "4bf58dd8d48988d181941735", false},
+		{"英文名稱不分大小寫", "scenic lookout", "4bf58dd8d48988d165941735", false},
+		{"多個類別", "park, museum", "4bf58dd8d48988d163941735,4bf58dd8d48988d181941735", false},
+		{"舊版數字 ID 轉成新 ID", "16000", "4d4b7105d754a06377d81259", false},
+		{"原生 BSON ID 直接通過", "4D4B7105D754A06377D81259", "4d4b7105d754a06377d81259", false},
+		{"分類表外的 BSON ID", "4bf58dd8d48988d1e5931735", "4bf58dd8d48988d1e5931735", false},
+		{"分類表外的數字 ID", "19046", "", true},
+		{"長度錯誤的 ID", "4bf58dd8d48988d1e593173", "", true},
+		{"未知 slug", "museums", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := taxonomy.ResolveIDs(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveIDs(%q) = %q, 預期 %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCategoryTaxonomy_Tree(t *testing.T) {
+	tree := DefaultCategoryTaxonomy().Tree(AdviceLangEN)
+	if len(tree) == 0 {
+		t.Fatal("分類表不應為空")
+	}
+
+	var landmarks *CategoryNode
+	for i := range tree {
+		if tree[i].Slug == "landmarks" {
+			landmarks = &tree[i]
+		}
+		for _, child := range tree[i].Children {
+			if len(child.Children) != 0 {
+				t.Errorf("子類別 %s 不應再有子類別", child.Slug)
+			}
+		}
+	}
+	if landmarks == nil || landmarks.Name != "Landmarks and Outdoors" || landmarks.ID != "4d4b7105d754a06377d81259" {
+		t.Fatalf("頂層類別錯誤: %+v", landmarks)
+	}
+	found := false
+	for _, child := range landmarks.Children {
+		found = found || child.Slug == "park"
+	}
+	if !found {
+		t.Error("landmarks 底下應包含 park")
+	}
+}
+
+func TestParseCategoryTaxonomy_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"缺少 id":   `[{"slug":"a"}]`,
+		"舊版數字 id": `[{"slug":"a","id":"16000"}]`,
+		"slug 重複": `[{"slug":"a","id":"4d4b7105d754a06377d81259"},{"slug":"a","id":"4d4b7105d754a06378d81259"}]`,
+		"上層類別不存在": `[{"slug":"a","id":"4d4b7105d754a06377d81259","parent":"b"}]`,
+	}
+	for name, data := range tests {
+		if _, err := ParseCategoryTaxonomy([]byte(data)); err == nil {
+			t.Errorf("%s: 應回傳錯誤", name)
+		}
+	}
+}
+
+// 內嵌分類表的每個類別都必須是新版 Places API 的 BSON ID，且不重複
+func TestDefaultCategoryTaxonomy_CurrentIDs(t *testing.T) {
+	taxonomy := DefaultCategoryTaxonomy()
+	if len(taxonomy.categories) == 0 {
+		t.Fatal("分類表不應為空")
+	}
+	seen := make(map[string]string)
+	for _, c := range taxonomy.categories {
+		ids, err := taxonomy.ResolveIDs(c.Slug)
+		if err != nil || !isCategoryID(ids) {
+			t.Errorf("%s 應解析成 24 位十六進位的類別 ID，實際 %q (%v)", c.Slug, ids, err)
+		}
+		if other, dup := seen[ids]; dup {
+			t.Errorf("%s 與 %s 的類別 ID 重複: %s", c.Slug, other, ids)
+		}
+		seen[ids] = c.Slug
+	}
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"final/models"
+	"strings"
+)
+
+// TravelBot 聊天機器人的指令實作，與平台無關；Discord、Telegram 等轉接層透過
+// NewCommandRouter 註冊的指令呼叫這裡的方法
+type TravelBot struct {
+	Amadeus    *AmadeusService
+	Weather    *WeatherService
+	Exchange   *ExchangeService
+	Foursquare *FoursquareService
+	Geocoder   *GeocodingService
+
+	// 最近的搜尋結果，供翻頁、排序等按鈕使用
+	results *resultCache
+	// settings 伺服器與使用者的偏好（貨幣、語系、預設出發機場），nil 時不提供 /settings
+	settings *BotSettingsStore
+}
+
+func NewTravelBot(amadeus *AmadeusService, weather *WeatherService, exchange *ExchangeService, foursquare *FoursquareService, geocoder *GeocodingService) *TravelBot {
+	return &TravelBot{
+		Amadeus:    amadeus,
+		Weather:    weather,
+		Exchange:   exchange,
+		Foursquare: foursquare,
+		Geocoder:   geocoder,
+		results:    newResultCache(),
+	}
+}
+
+// rateReply amountStr 為空時換算 1 單位
+func (b *TravelBot) rateReply(from, to, amountStr, lang string) string {
+	if b.Exchange == nil {
+		return botText(lang, "rate.disabled")
+	}
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	amount := models.NewMoney(1, from)
+	if amountStr != "" {
+		if val, err := models.ParseMoney(amountStr, from); err == nil {
+			amount = val
+		}
+	}
+
+	res, err := b.Exchange.GetExchangeRates(from, []string{to})
+	if err != nil {
+		return botText(lang, "rate.failed")
+	}
+	rate := res.Rates[to]
+	converted := amount.Convert(rate, to)
+
+	return botText(lang, "rate.result", from, rate, to, amount, converted)
+}
+
+// rateAlertReply 建立匯率警報，觸發時透過 platform 的通知管道發到 channelID
+func (b *TravelBot) rateAlertReply(from, to, direction string, threshold float64, platform, channelID, lang string) string {
+	if b.Exchange == nil {
+		return botText(lang, "rate.disabled")
+	}
+	alert, err := b.Exchange.CreateRateAlert(models.RateAlert{
+		From:      from,
+		To:        to,
+		Direction: strings.ToLower(direction),
+		Threshold: threshold,
+		Channel:   platform,
+		Target:    channelID,
+	})
+	if err != nil {
+		return botText(lang, "rate.alert_failed", err)
+	}
+
+	key := "rate.alert_below"
+	if alert.Direction == models.RateAlertAbove {
+		key = "rate.alert_above"
+	}
+	return botText(lang, key, alert.From, alert.To, alert.Threshold)
+}
+
+func (b *TravelBot) weatherReply(city, lang string) string {
+	if b.Weather == nil {
+		return botText(lang, "weather.disabled")
+	}
+	wData, err := b.Weather.GetCurrentWeather(city)
+	if err != nil {
+		return botText(lang, "weather.not_found")
+	}
+
+	msg := botText(lang, "weather.current",
+		wData.Location.Name, wData.Location.Country,
+		wData.Current.TempC, wData.Current.FeelsLikeC,
+		wData.Current.Condition.Text,
+		wData.Current.Humidity,
+		wData.Current.WindKph,
+		wData.Current.UV, uvLevelLabel(wData.Current.UV, lang))
+	if aq := wData.Current.AirQuality; aq != nil && aq.USEPAIndex > 0 {
+		msg += botText(lang, "weather.air", aqiLevelLabel(aq.USEPAIndex, lang), aq.PM25, aq.PM10)
+		if aq.USEPAIndex >= 3 {
+			msg += botText(lang, "weather.air_warning")
+		}
+	}
+	if !b.Weather.IsWeatherSuitableForTravel(wData) {
+		msg += botText(lang, "weather.flight_warning")
+	}
+	return msg
+}
+
+func (b *TravelBot) packReply(req models.PackingRequest, lang string) string {
+	list, err := NewPackingService(b.Weather, b.Exchange).GeneratePackingList(req)
+	if err != nil {
+		return botText(lang, "pack.failed", err)
+	}
+	return FormatPackingList(list, PackingFormatMarkdown)
+}
+
+func (b *TravelBot) planReply(req models.ItineraryRequest, lang string) string {
+	if b.Foursquare == nil || b.Geocoder == nil {
+		return botText(lang, "plan.disabled")
+	}
+	itinerary, err := NewItineraryService(b.Foursquare, b.Geocoder).PlanItinerary(req)
+	if err != nil {
+		return botText(lang, "plan.failed", err)
+	}
+	return FormatItinerary(itinerary, lang)
+}
+
+// /spot 預設只列出 5 個地標景點，避免訊息過長
+const (
+	spotDefaultLimit = 5
+	spotMaxLimit     = 10
+)
+
+// newSpotRequest /spot 的預設搜尋條件：方圓 3 公里內的地標
+func newSpotRequest() SearchRequest {
+	return SearchRequest{
+		Radius:   3000,
+		Category: "landmarks",
+		Limit:    spotDefaultLimit,
+	}
+}
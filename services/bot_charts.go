@@ -0,0 +1,194 @@
+package services
+
+import (
+	"final/commands"
+	"final/models"
+	"fmt"
+	"log"
+)
+
+// ---------------------------------------------------------
+// /trend、/history 價格圖表
+// ---------------------------------------------------------
+
+const (
+	trendDefaultWeeks = 8
+	trendMaxWeeks     = 26
+
+	chartFileName = "chart.png"
+)
+
+// chartStats 圖表卡片的最低、平均、最高價與對應的日期標籤
+type chartStats struct {
+	Min, Avg, Max      models.Money
+	MinLabel, MaxLabel string
+}
+
+// summarizePrices 計算最低、平均、最高價；prices 不可為空，幣別不一致時回傳錯誤
+func summarizePrices(labels []string, prices []models.Money) (chartStats, error) {
+	minIdx, maxIdx, err := models.MinMaxIndex(prices)
+	if err != nil {
+		return chartStats{}, err
+	}
+	avg, err := models.AverageMoney(prices)
+	if err != nil {
+		return chartStats{}, err
+	}
+	stats := chartStats{Min: prices[minIdx], Avg: avg, Max: prices[maxIdx]}
+	if len(labels) == len(prices) {
+		stats.MinLabel, stats.MaxLabel = labels[minIdx], labels[maxIdx]
+	}
+	return stats, nil
+}
+
+func chartFields(stats chartStats, lang string) []commands.Field {
+	return []commands.Field{
+		{Name: botText(lang, "chart.min"), Value: fmt.Sprintf("**%s** · %s", stats.Min, stats.MinLabel)},
+		{Name: botText(lang, "chart.avg"), Value: fmt.Sprintf("**%s**", stats.Avg)},
+		{Name: botText(lang, "chart.max"), Value: fmt.Sprintf("**%s** · %s", stats.Max, stats.MaxLabel)},
+	}
+}
+
+// chartReply 繪製價格折線圖並附加在卡片中；繪製失敗時仍回覆統計卡片
+func chartReply(card commands.Card, labels []string, prices []models.Money, avg models.Money) commands.Reply {
+	values := make([]float64, len(prices))
+	for i, p := range prices {
+		values[i] = p.Float64()
+	}
+	data, err := renderPriceChart(labels, values, avg.Float64())
+	if err != nil {
+		log.Printf("⚠️ 繪製價格圖表失敗: %v", err)
+		return commands.Reply{Cards: []commands.Card{card}}
+	}
+	card.Image = "attachment://" + chartFileName
+	return commands.Reply{
+		Cards: []commands.Card{card},
+		Files: []commands.File{{Name: chartFileName, ContentType: "image/png", Data: data}},
+	}
+}
+
+// convertPrices 將價格換算成顯示貨幣；任何一筆無法換算時保留原始價格
+func (b *TravelBot) convertPrices(prices []models.Money, currency string) []models.Money {
+	rates := make(map[string]float64)
+	converted := make([]models.Money, len(prices))
+	for i, p := range prices {
+		rate, ok := rates[p.Currency]
+		if !ok {
+			var err error
+			if rate, err = b.Amadeus.convertRate(p.Currency, currency); err != nil {
+				log.Printf("⚠️ 無法換算圖表價格: %v", err)
+				return prices
+			}
+			rates[p.Currency] = rate
+		}
+		converted[i] = p.Convert(rate, currency)
+	}
+	return converted
+}
+
+// trendReply /trend：未來數週出發的價格走勢（GeneratePriceTrend）
+func (b *TravelBot) trendReply(origin, dest string, weeks int, prefs BotSettings) commands.Reply {
+	if b.Amadeus == nil {
+		return commands.Text(botText(prefs.Lang, "price.disabled"))
+	}
+	if weeks <= 0 {
+		weeks = trendDefaultWeeks
+	}
+
+	trend, err := b.Amadeus.GeneratePriceTrend(origin, dest, weeks)
+	if err != nil {
+		return commands.Text(botText(prefs.Lang, "price.failed", err))
+	}
+	if len(trend.Prices) == 0 {
+		return commands.Text(botText(prefs.Lang, "price.empty"))
+	}
+
+	prices := b.convertPrices(trend.Prices, prefs.Currency)
+	stats, err := summarizePrices(trend.Labels, prices)
+	if err != nil {
+		return commands.Text(botText(prefs.Lang, "price.failed", err))
+	}
+	// 以換算後的價格重新產生建議，避免與卡片上的金額幣別不一致
+	analysis := &models.PriceAnalysis{MinPrice: stats.Min, AvgPrice: stats.Avg, MaxPrice: stats.Max}
+	if trend.Summary != nil {
+		analysis.BestDate = trend.Summary.BestDate
+	}
+	recommendation := trendRecommendationEN(analysis)
+	if prefs.Lang != AdviceLangEN {
+		recommendation = b.Amadeus.generateRecommendation(analysis)
+	}
+
+	card := commands.Card{
+		Title:       botText(prefs.Lang, "chart.trend_title", origin, dest, len(prices)),
+		Description: "💡 " + recommendation,
+		Color:       defaultEmbedColor,
+		Fields:      chartFields(stats, prefs.Lang),
+		Footer:      botText(prefs.Lang, "chart.trend_footer"),
+	}
+	return chartReply(card, trend.Labels, prices, stats.Avg)
+}
+
+// trendRecommendationEN generateRecommendation 的英文版本
+func trendRecommendationEN(a *models.PriceAnalysis) string {
+	if a.AvgPrice.IsZero() {
+		return "Prices are fairly steady — pick whichever dates suit your plans."
+	}
+	savings, err := a.AvgPrice.Sub(a.MinPrice)
+	if err != nil {
+		return "Prices are fairly steady — pick whichever dates suit your plans."
+	}
+	ratio := savings.Float64() / a.AvgPrice.Float64() * 100
+	bestDate := a.BestDate.Format("Jan 2, 2006")
+
+	switch {
+	case ratio > 20:
+		return fmt.Sprintf("Strongly recommend departing on %s! At %s it's the lowest fare, %s (%.0f%%) below average.", bestDate, a.MinPrice, savings, ratio)
+	case ratio > 10:
+		return fmt.Sprintf("Consider departing on %s: %s is a good deal, %s below average.", bestDate, a.MinPrice, savings)
+	}
+	return "Prices are fairly steady — pick whichever dates suit your plans."
+}
+
+// historyReply /history：同一航線與出發日期的歷次搜尋價格
+func (b *TravelBot) historyReply(origin, dest, date, prefix string, prefs BotSettings) commands.Reply {
+	if b.Amadeus == nil {
+		return commands.Text(botText(prefs.Lang, "price.disabled"))
+	}
+	records, err := b.Amadeus.GetSearchHistory(origin, dest, date, prefs.Currency)
+	if err != nil {
+		return commands.Text(botText(prefs.Lang, "price.failed", err))
+	}
+	return historyChartReply(origin, dest, date, prefix, records, prefs.Lang)
+}
+
+// historyChartReply 以搜尋紀錄（已換算為同一貨幣、依時間排序）產生圖表卡片；最新一筆與先前的紀錄比較給出建議
+func historyChartReply(origin, dest, date, prefix string, records []models.SearchHistoryRecord, lang string) commands.Reply {
+	if len(records) == 0 {
+		return commands.Text(botText(lang, "chart.history_empty", origin, dest, date, prefix))
+	}
+
+	labels := make([]string, len(records))
+	prices := make([]models.Money, len(records))
+	for i, r := range records {
+		labels[i] = r.RecordDate.Format("01/02")
+		prices[i] = r.Price
+	}
+	stats, err := summarizePrices(labels, prices)
+	if err != nil {
+		return commands.Text(botText(lang, "price.failed", err))
+	}
+	last := len(prices) - 1
+	advice, err := comparePriceHistory(prices[:last], prices[last], prices[last].Currency)
+	if err != nil {
+		return commands.Text(botText(lang, "price.failed", err))
+	}
+
+	card := commands.Card{
+		Title:       botText(lang, "chart.history_title", origin, dest, date),
+		Description: botText(lang, "chart.latest", prices[last], labels[last]) + "\n💡 " + priceAdviceText(advice, lang),
+		Color:       trendColor(advice),
+		Fields:      chartFields(stats, lang),
+		Footer:      botText(lang, "chart.history_footer", len(records), records[0].RecordDate.Format("2006-01-02"), records[last].RecordDate.Format("2006-01-02")),
+	}
+	return chartReply(card, labels, prices, stats.Avg)
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"final/commands"
+	"final/models"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testHistoryRecords(prices ...float64) []models.SearchHistoryRecord {
+	start := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	records := make([]models.SearchHistoryRecord, len(prices))
+	for i, p := range prices {
+		records[i] = models.SearchHistoryRecord{
+			Origin: "TPE", Destination: "NRT", DepartureDate: "2026-03-01",
+			Price: models.NewMoney(p, "TWD"), Currency: "TWD", RecordDate: start.AddDate(0, 0, i),
+		}
+	}
+	return records
+}
+
+func TestHistoryChartReply(t *testing.T) {
+	reply := historyChartReply("TPE", "NRT", "2026-03-01", "/", testHistoryRecords(9000, 8000, 10000, 7000), AdviceLangZH)
+	if len(reply.Cards) != 1 || len(reply.Files) != 1 {
+		t.Fatalf("應回覆一張卡片與一個圖表附件: %+v", reply)
+	}
+
+	card, file := reply.Cards[0], reply.Files[0]
+	if card.Image != "attachment://"+file.Name || file.ContentType != "image/png" || len(file.Data) == 0 {
+		t.Errorf("卡片應引用圖表附件: %q / %+v", card.Image, file.Name)
+	}
+	for _, want := range []string{"最近一次查詢：**7000 TWD**（01/13）", "歷史新低價"} {
+		if !strings.Contains(card.Description, want) {
+			t.Errorf("描述缺少 %q: %q", want, card.Description)
+		}
+	}
+	wantFields := []string{"**7000 TWD** · 01/13", "**8500 TWD**", "**10000 TWD** · 01/12"}
+	for i, want := range wantFields {
+		if card.Fields[i].Value != want {
+			t.Errorf("欄位 %d = %q, 預期 %q", i, card.Fields[i].Value, want)
+		}
+	}
+	if card.Color != trendColors["down"] || !strings.HasPrefix(card.Footer, "共 4 筆搜尋紀錄（2026-01-10 ~ 2026-01-13）") {
+		t.Errorf("顏色或頁尾錯誤: %x / %q", card.Color, card.Footer)
+	}
+
+	// 只有一筆紀錄時無從比較
+	reply = historyChartReply("TPE", "NRT", "2026-03-01", "/", testHistoryRecords(9000), AdviceLangEN)
+	if !strings.Contains(reply.Cards[0].Description, "first time we've tracked") {
+		t.Errorf("單筆紀錄應提示持續關注: %q", reply.Cards[0].Description)
+	}
+
+	// 幣別不一致時回覆錯誤而不是 panic
+	mixed := testHistoryRecords(9000, 8000)
+	mixed[1].Price = models.NewMoney(250, "USD")
+	if reply = historyChartReply("TPE", "NRT", "2026-03-01", "/", mixed, AdviceLangZH); !strings.Contains(reply.Text, "貨幣不一致") {
+		t.Errorf("幣別不一致應回覆錯誤: %+v", reply)
+	}
+
+	reply = historyChartReply("TPE", "NRT", "2026-03-01", "!", nil, AdviceLangZH)
+	if !strings.Contains(reply.Text, "先用 `!price` 查詢") || len(reply.Files) != 0 {
+		t.Errorf("沒有紀錄時應提示先查詢: %+v", reply)
+	}
+}
+
+func TestTrendRecommendationEN(t *testing.T) {
+	best := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		min, avg float64
+		want     string
+	}{
+		{"大幅低於平均", 6000, 8000, "Strongly recommend departing on Mar 8, 2026! At 6000 TWD it's the lowest fare, 2000 TWD (25%) below average."},
+		{"略低於平均", 7000, 8000, "Consider departing on Mar 8, 2026: 7000 TWD is a good deal, 1000 TWD below average."},
+		{"價格平穩", 7800, 8000, "Prices are fairly steady"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := trendRecommendationEN(&models.PriceAnalysis{MinPrice: models.NewMoney(tt.min, "TWD"), AvgPrice: models.NewMoney(tt.avg, "TWD"), BestDate: best})
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("trendRecommendationEN() = %q, 預期 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChartCommands_Disabled(t *testing.T) {
+	router := NewCommandRouter(&TravelBot{results: newResultCache()})
+	for _, text := range []string{"!trend TPE NRT", "!history TPE NRT 2026-03-01"} {
+		reply, _ := router.RunText(&commands.Context{Platform: discordPlatform, Prefix: "!"}, text)
+		if reply.Text != "⚠️ 航班服務未啟用" {
+			t.Errorf("%s => %q", text, reply.Text)
+		}
+	}
+	reply, _ := router.RunText(&commands.Context{Platform: discordPlatform, Prefix: "!"}, "!trend TPE NRT 40")
+	if !strings.Contains(reply.Text, "weeks") {
+		t.Errorf("週數超過上限應提示參數錯誤: %q", reply.Text)
+	}
+}
+
+func TestDiscordMessage_Files(t *testing.T) {
+	msg := discordMessage(commands.Reply{
+		Cards: []commands.Card{{Title: "📈", Image: "attachment://chart.png"}},
+		Files: []commands.File{{Name: "chart.png", ContentType: "image/png", Data: []byte("png")}},
+	})
+	if len(msg.Files) != 1 || msg.Files[0].Name != "chart.png" || msg.Embeds[0].Image == nil || msg.Embeds[0].Image.URL != "attachment://chart.png" {
+		t.Fatalf("附件或 embed 圖片錯誤: %+v", msg)
+	}
+	if data, _ := io.ReadAll(msg.Files[0].Reader); string(data) != "png" {
+		t.Errorf("附件內容 = %q", data)
+	}
+}
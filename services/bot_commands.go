@@ -0,0 +1,402 @@
+package services
+
+import (
+	"final/commands"
+	"final/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewCommandRouter 註冊所有聊天機器人指令；Discord 的斜線指令與說明都由這裡的定義產生
+func NewCommandRouter(bot *TravelBot) *commands.Router {
+	router := commands.NewRouter()
+	router.Translate = bot.translate
+
+	router.Register(&commands.Command{
+		Name:        "help",
+		Aliases:     []string{"start"},
+		Emoji:       "❓",
+		Description: "顯示所有指令說明",
+		Handler: func(ctx *commands.Context) commands.Reply {
+			return commands.Text(router.Help(ctx, botText(bot.settingsFor(ctx).Lang, "help.title")))
+		},
+	})
+
+	router.Register(&commands.Command{
+		Name:        "price",
+		Emoji:       "✈️",
+		Description: "查詢航班與價格分析",
+		// Discord 要求必填選項在前，出發機場放最後；文字指令仍可用「出發 抵達 日期」的順序
+		Args: []commands.Arg{
+			{Name: "destination", Description: "抵達機場代碼，例如 NRT", Required: true, Autocomplete: true},
+			{Name: "date", Description: "出發日期 YYYY-MM-DD", Required: true},
+			{Name: "origin", Description: "出發機場代碼，例如 TPE（預設為設定的出發機場）", Autocomplete: true},
+		},
+		Example:   "TPE NRT 2026-03-01",
+		Slow:      true,
+		ParseText: priceTextArgs,
+		Handler: func(ctx *commands.Context) commands.Reply {
+			prefs := bot.settingsFor(ctx)
+			origin := strings.ToUpper(ctx.Args.String("origin"))
+			if origin == "" {
+				origin = prefs.Home
+			}
+			if origin == "" {
+				msg := botText(prefs.Lang, "price.no_origin")
+				if bot.settings != nil {
+					msg = botText(prefs.Lang, "price.no_origin_set", ctx.Prefix)
+				}
+				return commands.Reply{Text: msg, Ephemeral: true}
+			}
+			return bot.priceReply(origin, strings.ToUpper(ctx.Args.String("destination")), ctx.Args.String("date"), prefs)
+		},
+	})
+	router.Handle("price", bot.priceAction)
+
+	router.Register(&commands.Command{
+		Name:        "trend",
+		Emoji:       "📈",
+		Description: "未來數週的價格走勢圖",
+		Args: []commands.Arg{
+			{Name: "origin", Description: "出發機場代碼，例如 TPE", Required: true, Autocomplete: true},
+			{Name: "destination", Description: "抵達機場代碼，例如 NRT", Required: true, Autocomplete: true},
+			{Name: "weeks", Description: fmt.Sprintf("追蹤幾週（預設 %d）", trendDefaultWeeks), Type: commands.Integer, Min: commands.Bound(2), Max: commands.Bound(trendMaxWeeks)},
+		},
+		Example: "TPE NRT",
+		Slow:    true,
+		Handler: func(ctx *commands.Context) commands.Reply {
+			return bot.trendReply(strings.ToUpper(ctx.Args.String("origin")), strings.ToUpper(ctx.Args.String("destination")),
+				ctx.Args.Int("weeks"), bot.settingsFor(ctx))
+		},
+	})
+
+	router.Register(&commands.Command{
+		Name:        "history",
+		Emoji:       "📉",
+		Description: "查詢過的歷史價格走勢圖",
+		Args: []commands.Arg{
+			{Name: "origin", Description: "出發機場代碼，例如 TPE", Required: true, Autocomplete: true},
+			{Name: "destination", Description: "抵達機場代碼，例如 NRT", Required: true, Autocomplete: true},
+			{Name: "date", Description: "出發日期 YYYY-MM-DD", Required: true},
+		},
+		Example: "TPE NRT 2026-03-01",
+		Handler: func(ctx *commands.Context) commands.Reply {
+			return bot.historyReply(strings.ToUpper(ctx.Args.String("origin")), strings.ToUpper(ctx.Args.String("destination")),
+				ctx.Args.String("date"), ctx.Prefix, bot.settingsFor(ctx))
+		},
+	})
+
+	router.Register(&commands.Command{
+		Name:        "rate",
+		Emoji:       "💱",
+		Description: "查詢即時匯率",
+		Args: []commands.Arg{
+			{Name: "from", Description: "持有貨幣，例如 USD", Required: true, Autocomplete: true},
+			{Name: "to", Description: "目標貨幣，例如 TWD", Required: true, Autocomplete: true},
+			{Name: "amount", Description: "換算金額（預設 1）", Type: commands.Number, Min: commands.Bound(0)},
+		},
+		Example: "JPY TWD 1000",
+		Slow:    true,
+		Handler: func(ctx *commands.Context) commands.Reply {
+			return commands.Text(bot.rateReply(ctx.Args.String("from"), ctx.Args.String("to"), ctx.Args.String("amount"), bot.settingsFor(ctx).Lang))
+		},
+	})
+
+	router.Register(&commands.Command{
+		Name:        "ratealert",
+		Emoji:       "🔔",
+		Description: "設定匯率警報（跌破/突破門檻時通知此頻道）",
+		Args: []commands.Arg{
+			{Name: "from", Description: "持有貨幣，例如 JPY", Required: true, Autocomplete: true},
+			{Name: "to", Description: "目標貨幣，例如 TWD", Required: true, Autocomplete: true},
+			{Name: "direction", Description: "跌破（below）或突破（above）", Required: true, Choices: []string{models.RateAlertBelow, models.RateAlertAbove}},
+			{Name: "threshold", Description: "匯率門檻，例如 0.205", Type: commands.Number, Required: true, Min: commands.Bound(0)},
+		},
+		Example: "JPY TWD below 0.205",
+		Handler: func(ctx *commands.Context) commands.Reply {
+			return commands.Text(bot.rateAlertReply(ctx.Args.String("from"), ctx.Args.String("to"), ctx.Args.String("direction"),
+				ctx.Args.Float("threshold"), ctx.Platform, ctx.ChannelID, bot.settingsFor(ctx).Lang))
+		},
+	})
+
+	router.Register(&commands.Command{
+		Name:        "weather",
+		Emoji:       "🌤️",
+		Description: "查詢城市目前天氣",
+		Args: []commands.Arg{
+			{Name: "city", Description: "城市名稱，例如 Tokyo", Required: true, Rest: true},
+		},
+		Example: "Tokyo",
+		Slow:    true,
+		Handler: func(ctx *commands.Context) commands.Reply {
+			return commands.Text(bot.weatherReply(ctx.Args.String("city"), bot.settingsFor(ctx).Lang))
+		},
+	})
+
+	router.Register(&commands.Command{
+		Name:        "pack",
+		Emoji:       "🧳",
+		Description: "依目的地天氣與天數產生行李清單",
+		Args: []commands.Arg{
+			{Name: "destination", Description: "目的地機場代碼或城市", Required: true, Autocomplete: true},
+			{Name: "start_date", Description: "出發日 YYYY-MM-DD", Required: true},
+			{Name: "end_date", Description: "回程日 YYYY-MM-DD"},
+			{Name: "origin", Description: "出發機場代碼（判斷轉接頭與換匯）", Autocomplete: true},
+		},
+		Example: "NRT 2026-03-01 2026-03-07 TPE",
+		Slow:    true,
+		Handler: func(ctx *commands.Context) commands.Reply {
+			return commands.Text(bot.packReply(models.PackingRequest{
+				Destination: ctx.Args.String("destination"),
+				StartDate:   ctx.Args.String("start_date"),
+				EndDate:     ctx.Args.String("end_date"),
+				Origin:      ctx.Args.String("origin"),
+			}, bot.settingsFor(ctx).Lang))
+		},
+	})
+
+	router.Register(&commands.Command{
+		Name:        "spot",
+		Emoji:       "🏛️",
+		Description: "查詢附近景點",
+		Args: []commands.Arg{
+			{Name: "place", Description: "地名、城市或機場代碼", Required: true},
+			{Name: "category", Description: "景點類別，例如 museum、公園", Autocomplete: true},
+			{Name: "sort", Description: "排序方式", Choices: AttractionSortOptions},
+			{Name: "limit", Description: "顯示幾個景點", Type: commands.Integer, Min: commands.Bound(1), Max: commands.Bound(spotMaxLimit)},
+			{Name: "open_now", Description: "只顯示營業中的地點", Type: commands.Boolean},
+			{Name: "min_price", Description: "最低價位 1-4", Type: commands.Integer, Min: commands.Bound(1), Max: commands.Bound(4)},
+			{Name: "max_price", Description: "最高價位 1-4", Type: commands.Integer, Min: commands.Bound(1), Max: commands.Bound(4)},
+		},
+		Example:   "Tokyo sort=rating open=now price=1-2",
+		Slow:      true,
+		ParseText: spotTextArgs,
+		Handler: func(ctx *commands.Context) commands.Reply {
+			lang := bot.settingsFor(ctx).Lang
+			req, err := spotRequestFromArgs(ctx.Args)
+			if err != nil {
+				return commands.Reply{Text: "⚠️ " + errorText(lang, err), Ephemeral: true}
+			}
+			return bot.spotReply(ctx.Args.String("place"), req, lang)
+		},
+	})
+	router.Handle("spot", bot.spotAction)
+
+	router.Register(&commands.Command{
+		Name:        "watch",
+		Emoji:       "👀",
+		Description: "追蹤航線價格，低於目標價時通知你",
+		Args: []commands.Arg{
+			{Name: "origin", Description: "出發機場代碼，例如 TPE", Required: true, Autocomplete: true},
+			{Name: "destination", Description: "抵達機場代碼，例如 NRT", Required: true, Autocomplete: true},
+			{Name: "date", Description: "出發日期 YYYY-MM-DD", Required: true},
+			{Name: "below", Description: "目標價格（以搜尋貨幣計，預設 TWD）", Type: commands.Number, Required: true, Min: commands.Bound(0)},
+			{Name: "deliver", Description: "通知方式：私訊（dm，預設）或此頻道（channel）", Choices: []string{watchDeliverDM, watchDeliverChannel}},
+		},
+		Example:   "TPE NRT 2026-03-01 below 8000",
+		ParseText: watchTextArgs,
+		Handler: func(ctx *commands.Context) commands.Reply {
+			req, err := watchRequestFromArgs(ctx.Args)
+			if err != nil {
+				return commands.Reply{Text: "⚠️ " + errorText(bot.settingsFor(ctx).Lang, err), Ephemeral: true}
+			}
+			return commands.Reply{Text: bot.watchReply(req, ctx), Ephemeral: true}
+		},
+	})
+
+	router.Register(&commands.Command{
+		Name:        "unwatch",
+		Emoji:       "🗑️",
+		Description: "取消航線價格追蹤",
+		Args: []commands.Arg{
+			{Name: "id", Description: "追蹤編號或 watches 列出的序號", Required: true, Autocomplete: true},
+		},
+		Example: "1",
+		Handler: func(ctx *commands.Context) commands.Reply {
+			return commands.Reply{Text: bot.unwatchReply(ctx, ctx.Args.String("id")), Ephemeral: true}
+		},
+	})
+
+	router.Register(&commands.Command{
+		Name:        "watches",
+		Emoji:       "📋",
+		Description: "列出你追蹤中的航線",
+		Handler: func(ctx *commands.Context) commands.Reply {
+			return commands.Reply{Text: bot.watchesReply(ctx), Ephemeral: true}
+		},
+	})
+
+	if bot.settings != nil {
+		router.Register(&commands.Command{
+			Name:        "settings",
+			Emoji:       "⚙️",
+			Description: "設定搜尋貨幣、預設出發機場與回覆語系",
+			Args: []commands.Arg{
+				{Name: "key", Description: "設定項目", Choices: []string{settingCurrency, settingHome, settingLang}},
+				{Name: "value", Description: "設定值，例如 USD、TPE、en；reset 恢復預設", Autocomplete: true},
+				{Name: "scope", Description: "個人（user，預設）或整個伺服器（server）", Choices: []string{settingScopeUser, settingScopeServer}},
+			},
+			Example: "currency USD",
+			Handler: bot.settingsReply,
+		})
+	}
+
+	router.Register(&commands.Command{
+		Name:        "plan",
+		Emoji:       "🗺️",
+		Description: "規劃多日景點行程",
+		Args: []commands.Arg{
+			{Name: "destination", Description: "目的地、城市或機場代碼", Required: true, Autocomplete: true},
+			{Name: "days", Description: "天數", Type: commands.Integer, Required: true, Min: commands.Bound(1), Max: commands.Bound(maxItineraryDays)},
+			{Name: "start_date", Description: "出發日 YYYY-MM-DD（用來判斷營業時間）"},
+			{Name: "interests", Description: "興趣類別，逗號分隔，例如 museum,park", Autocomplete: true},
+		},
+		Example:   "KIX 2 2026-03-01 museum park",
+		Slow:      true,
+		ParseText: planTextArgs,
+		Handler: func(ctx *commands.Context) commands.Reply {
+			return commands.Text(bot.planReply(planRequestFromArgs(ctx.Args), bot.settingsFor(ctx).Lang))
+		},
+	})
+
+	return router
+}
+
+// priceTextArgs 解析文字指令 price 的參數：YYYY-MM-DD 為日期，其餘為機場代碼
+// 支援 `TPE NRT 2026-03-01`、與斜線指令同順序的 `NRT 2026-03-01 TPE`，以及使用預設出發機場的 `NRT 2026-03-01`
+func priceTextArgs(words []string) (commands.Args, error) {
+	args := make(commands.Args)
+	var before, after []string // 日期前後的機場代碼
+	for _, word := range words {
+		if key, value, ok := strings.Cut(word, "="); ok {
+			switch key = strings.ToLower(key); key {
+			case "origin", "destination", "date":
+				args[key] = value
+				continue
+			}
+			return nil, botError("args.price_option", key)
+		}
+		if _, err := time.Parse("2006-01-02", word); err == nil && args["date"] == "" {
+			args["date"] = word
+			continue
+		}
+		if args["date"] == "" {
+			before = append(before, word)
+		} else {
+			after = append(after, word)
+		}
+	}
+
+	switch {
+	case args["date"] == "" && len(before) == 3:
+		// 日期格式不對時仍依「出發 抵達 日期」的位置對應，交給搜尋回報錯誤
+		args["origin"], args["destination"], args["date"] = before[0], before[1], before[2]
+	case len(before) == 1 && len(after) == 1:
+		args["destination"], args["origin"] = before[0], after[0]
+	case len(before)+len(after) > 2:
+		return nil, botError("args.too_many", strings.Join(append(before, after...)[2:], " "))
+	default:
+		airports := append(before, after...)
+		if len(airports) == 2 {
+			args["origin"], airports = airports[0], airports[1:]
+		}
+		if len(airports) == 1 {
+			args["destination"] = airports[0]
+		}
+	}
+	return args, nil
+}
+
+// spotTextArgs 解析文字指令 spot 的參數：key=value 形式的選項，其餘組成地點名稱
+// 除了斜線指令的選項名稱外，也支援較短的 open=now 與 price=2 或 price=1-3
+func spotTextArgs(words []string) (commands.Args, error) {
+	args := make(commands.Args)
+	var place []string
+	for _, word := range words {
+		key, value, ok := strings.Cut(word, "=")
+		if !ok {
+			place = append(place, word)
+			continue
+		}
+
+		switch key = strings.ToLower(key); key {
+		case "category", "sort", "limit", "open_now", "min_price", "max_price":
+			args[key] = value
+		case "open":
+			args["open_now"] = strconv.FormatBool(value == "now" || value == "1" || value == "true")
+		case "price":
+			minStr, maxStr, isRange := strings.Cut(value, "-")
+			if !isRange {
+				maxStr = minStr
+			}
+			_, err1 := strconv.Atoi(minStr)
+			_, err2 := strconv.Atoi(maxStr)
+			if err1 != nil || err2 != nil {
+				return nil, botError("args.spot_price")
+			}
+			args["min_price"], args["max_price"] = minStr, maxStr
+		default:
+			return nil, botError("args.spot_option", key)
+		}
+	}
+	args["place"] = strings.Join(place, " ")
+	return args, nil
+}
+
+// spotRequestFromArgs 將已驗證的參數套用到 /spot 的預設搜尋條件
+// 排序、筆數與價位範圍已由 Router 檢查，這裡先檢查其餘條件，回覆才能依語系顯示
+func spotRequestFromArgs(args commands.Args) (SearchRequest, error) {
+	req := newSpotRequest()
+	if v := args.String("category"); v != "" {
+		if _, err := DefaultCategoryTaxonomy().ResolveIDs(v); err != nil {
+			return req, botError("args.spot_category", v)
+		}
+		req.Category = v
+	}
+	if v := args.Int("limit"); v > 0 {
+		req.Limit = v
+	}
+	req.Sort = args.String("sort")
+	req.OpenNow = args.Bool("open_now")
+	req.MinPrice = args.Int("min_price")
+	req.MaxPrice = args.Int("max_price")
+	if req.MinPrice > 0 && req.MaxPrice > 0 && req.MinPrice > req.MaxPrice {
+		return req, botError("args.spot_price_order")
+	}
+	return req, req.Validate()
+}
+
+// planTextArgs 解析文字指令 plan 的參數：目的地、天數，之後可接出發日（YYYY-MM-DD）與興趣類別
+func planTextArgs(words []string) (commands.Args, error) {
+	args := make(commands.Args)
+	for i, name := range []string{"destination", "days"} {
+		if i < len(words) {
+			args[name] = words[i]
+		}
+	}
+
+	var interests []string
+	for i := 2; i < len(words); i++ {
+		if _, err := time.Parse("2006-01-02", words[i]); err == nil {
+			args["start_date"] = words[i]
+			continue
+		}
+		interests = append(interests, words[i])
+	}
+	args["interests"] = strings.Join(interests, ",")
+	return args, nil
+}
+
+func planRequestFromArgs(args commands.Args) models.ItineraryRequest {
+	req := models.ItineraryRequest{
+		Destination: args.String("destination"),
+		Days:        args.Int("days"),
+		StartDate:   args.String("start_date"),
+	}
+	if v := args.String("interests"); v != "" {
+		req.Interests = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	return req
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"final/commands"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// parseTextCommand 以文字指令的方式解析並驗證參數，不需要連上任何聊天平台
+func parseTextCommand(name string, words []string) (commands.Args, error) {
+	cmd := NewCommandRouter(&TravelBot{}).Lookup(name)
+	args, err := cmd.ParseWords(words)
+	if err != nil {
+		return nil, err
+	}
+	return args, cmd.Validate(args)
+}
+
+func TestSpotCommand_ParseText(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantLocation string
+		wantSort     string
+		wantLimit    int
+		wantOpen     bool
+		wantPrice    [2]int
+		wantCategory string
+		wantErr      bool
+	}{
+		{name: "只有地點", args: []string{"101大樓"}, wantLocation: "101大樓", wantSort: AttractionSortDistance, wantLimit: spotDefaultLimit, wantCategory: "4d4b7105d754a06377d81259"},
+		{name: "多字地點加選項", args: []string{"Tokyo", "Tower", "category=博物館", "sort=rating", "open=now", "price=1-2", "limit=8"},
+			wantLocation: "Tokyo Tower", wantSort: AttractionSortRating, wantLimit: 8, wantOpen: true, wantPrice: [2]int{1, 2}, wantCategory: "4bf58dd8d48988d181941735"},
+		{name: "單一價位", args: []string{"大阪", "price=3"}, wantLocation: "大阪", wantSort: AttractionSortDistance, wantLimit: spotDefaultLimit, wantPrice: [2]int{3, 3}, wantCategory: "4d4b7105d754a06377d81259"},
+		{name: "未知類別", args: []string{"大阪", "category=unicorn"}, wantErr: true},
+		{name: "未知選項", args: []string{"大阪", "color=red"}, wantErr: true},
+		{name: "筆數過多", args: []string{"大阪", "limit=30"}, wantErr: true},
+		{name: "排序錯誤", args: []string{"大阪", "sort=name"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := parseTextCommand("spot", tt.args)
+			location := args.String("place")
+			var req SearchRequest
+			if err == nil {
+				req, err = spotRequestFromArgs(args)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if location != tt.wantLocation || req.Sort != tt.wantSort || req.Limit != tt.wantLimit ||
+				req.OpenNow != tt.wantOpen || req.MinPrice != tt.wantPrice[0] || req.MaxPrice != tt.wantPrice[1] ||
+				req.Category != tt.wantCategory {
+				t.Errorf("解析結果錯誤: location=%q req=%+v", location, req)
+			}
+		})
+	}
+}
+
+func TestWatchCommand_ParseText(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    watchRequest
+		wantErr bool
+	}{
+		{name: "完整格式", args: []string{"tpe", "nrt", "2026-03-01", "below", "8000"},
+			want: watchRequest{Origin: "TPE", Destination: "NRT", Date: "2026-03-01", Threshold: 8000, Deliver: watchDeliverDM}},
+		{name: "省略 below 並改在頻道通知", args: []string{"TPE", "KIX", "2026-03-01", "7,500", "channel"},
+			want: watchRequest{Origin: "TPE", Destination: "KIX", Date: "2026-03-01", Threshold: 7500, Deliver: watchDeliverChannel}},
+		{name: "缺少門檻", args: []string{"TPE", "NRT", "2026-03-01", "below"}, wantErr: true},
+		{name: "門檻不是數字", args: []string{"TPE", "NRT", "2026-03-01", "below", "cheap"}, wantErr: true},
+		{name: "日期格式錯誤", args: []string{"TPE", "NRT", "03/01", "8000"}, wantErr: true},
+		{name: "機場代碼錯誤", args: []string{"Taipei", "NRT", "2026-03-01", "8000"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := parseTextCommand("watch", tt.args)
+			var req watchRequest
+			if err == nil {
+				req, err = watchRequestFromArgs(args)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && req != tt.want {
+				t.Errorf("解析結果 = %+v, 預期 %+v", req, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanCommand_ParseText(t *testing.T) {
+	args, err := parseTextCommand("plan", []string{"KIX", "2", "museum", "2026-03-01", "park,公園"})
+	if err != nil {
+		t.Fatalf("解析失敗: %v", err)
+	}
+	req := planRequestFromArgs(args)
+	if req.Destination != "KIX" || req.Days != 2 || req.StartDate != "2026-03-01" || !reflect.DeepEqual(req.Interests, []string{"museum", "park", "公園"}) {
+		t.Errorf("解析結果錯誤: %+v", req)
+	}
+
+	if _, err := parseTextCommand("plan", []string{"KIX", "兩天"}); err == nil {
+		t.Error("天數不是數字應回傳錯誤")
+	}
+	if _, err := parseTextCommand("plan", []string{"KIX", "30"}); err == nil {
+		t.Errorf("天數超過 %d 應回傳錯誤", maxItineraryDays)
+	}
+}
+
+func TestCommandRouter_WithoutPlatform(t *testing.T) {
+	router := NewCommandRouter(&TravelBot{results: newResultCache()})
+	run := func(text string) (commands.Reply, bool) {
+		return router.RunText(&commands.Context{Platform: "test", Prefix: "!", UserID: "u1", ChannelID: "c1"}, text)
+	}
+
+	help, ok := run("!start")
+	if !ok || !strings.HasPrefix(help.Text, botText(AdviceLangZH, "help.title")) {
+		t.Fatalf("!start 應是 help 的別名: %q", help.Text)
+	}
+	for _, cmd := range router.Commands() {
+		if !strings.Contains(help.Text, "`"+cmd.Usage("!")+"`") {
+			t.Errorf("說明缺少指令 %s", cmd.Name)
+		}
+	}
+
+	tests := []struct {
+		text          string
+		wantContains  string
+		wantEphemeral bool
+	}{
+		{"!price TPE", "缺少參數 date", true},
+		{"!price NRT 2026-03-01", "缺少參數 origin", true},
+		{"!price TPE NRT 2026-03-01", "航班服務未啟用", false},
+		{"!weather New York", "天氣服務未啟用", false},
+		{"!ratealert JPY TWD sideways 0.2", "direction 只能是 below、above", true},
+		{"!watches", "航班服務未啟用", true},
+		{"!spot 大阪 limit=30", "limit 超出範圍", true},
+	}
+	for _, tt := range tests {
+		reply, ok := run(tt.text)
+		if !ok || !strings.Contains(reply.Text, tt.wantContains) || reply.Ephemeral != tt.wantEphemeral {
+			t.Errorf("%s => %q (ephemeral=%v), 預期包含 %q (ephemeral=%v)", tt.text, reply.Text, reply.Ephemeral, tt.wantContains, tt.wantEphemeral)
+		}
+	}
+
+	if _, ok := run("!unknown"); ok {
+		t.Error("不認識的文字指令應忽略")
+	}
+
+	reply, ok := router.RunAction(&commands.Context{Prefix: "/"}, "price:page:missing:1:price")
+	if !ok || !reply.Ephemeral || !strings.Contains(reply.Text, "`/price`") {
+		t.Errorf("過期的按鈕應提示重新搜尋: %+v", reply)
+	}
+}
+
+// 語系設為英文時，指令回覆、用法提示與說明都不應出現中文
+func TestCommandRouter_English(t *testing.T) {
+	bot := &TravelBot{results: newResultCache(), settings: newTestSettings(t)}
+	if _, err := bot.settings.Set(settingScopeUser, "u1", settingLang, "en"); err != nil {
+		t.Fatal(err)
+	}
+	router := NewCommandRouter(bot)
+	ctx := func() *commands.Context {
+		return &commands.Context{Platform: "test", Prefix: "!", UserID: "u1", ChannelID: "c1"}
+	}
+	han := regexp.MustCompile(`\p{Han}`)
+
+	tests := []struct {
+		text         string
+		wantContains string
+	}{
+		{"!help", "Search flights with price analysis"},
+		{"!price TPE", "Missing argument date"},
+		{"!price TPE NRT 2026-03-01", "Flight search is not available"},
+		{"!price TPE NRT KIX 2026-03-01", "Too many arguments: KIX"},
+		{"!rate USD TWD", "Exchange rates are not available"},
+		{"!ratealert JPY TWD sideways 0.2", "direction must be one of"},
+		{"!weather New York", "Weather is not available"},
+		{"!spot Osaka limit=30", "limit must be between 1 and 10"},
+		{"!spot Osaka category=unicorn", "Unknown attraction category: unicorn"},
+		{"!spot Osaka price=cheap", "Invalid price"},
+		{"!spot Osaka sort=rating", "Attractions are not available"},
+		{"!plan KIX 2", "Attractions are not available"},
+		{"!watch TPE NRT 03/01 8000", "Invalid date"},
+		{"!watches", "Flight search is not available"},
+		{"!unwatch 1", "Flight search is not available"},
+		{"!settings lang fr", "Language must be zh-TW or en"},
+		{"!trend TPE NRT weeks=99", "weeks must be between"},
+	}
+	for _, tt := range tests {
+		reply, ok := router.RunText(ctx(), tt.text)
+		if !ok || !strings.Contains(reply.Text, tt.wantContains) {
+			t.Errorf("%s => %q, 預期包含 %q", tt.text, reply.Text, tt.wantContains)
+		}
+		if han.MatchString(reply.Text) {
+			t.Errorf("%s 的英文回覆含有中文: %q", tt.text, reply.Text)
+		}
+	}
+
+	reply, _ := router.RunAction(ctx(), "spot:page:missing:1")
+	if !strings.Contains(reply.Text, "These results have expired") {
+		t.Errorf("過期的按鈕應以英文提示: %q", reply.Text)
+	}
+
+	// 沒有設定語系的使用者仍以中文回覆
+	other := ctx()
+	other.UserID = "u2"
+	if reply, _ := router.RunText(other, "!price TPE"); !strings.Contains(reply.Text, "缺少參數 date") || !strings.Contains(reply.Text, "用法：") {
+		t.Errorf("預設應以中文回覆: %q", reply.Text)
+	}
+}
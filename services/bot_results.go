@@ -0,0 +1,551 @@
+package services
+
+import (
+	"final/commands"
+	"final/models"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 按鈕與選單的資料格式為「指令:動作:快取鍵:參數...」，Discord 限制 100 字元、Telegram 限制 64 bytes
+const (
+	actionPage    = "page"
+	actionAlert   = "alert"
+	actionWeather = "weather"
+
+	flightSortPrice    = "price"
+	flightSortDuration = "duration"
+
+	flightsPerPage = 5
+	// 搜尋結果保留時間，逾時後按鈕會提示重新搜尋
+	resultCacheTTL = 30 * time.Minute
+)
+
+// 依 PriceAdvice.Trend 決定卡片左側顏色
+var trendColors = map[string]int{
+	"down":   0x2ECC71, // 低於平均或歷史新低：綠
+	"stable": 0xF1C40F, // 持平：黃
+	"up":     0xE74C3C, // 偏高：紅
+}
+
+const defaultEmbedColor = 0x3498DB
+
+func trendColor(advice *models.PriceAdvice) int {
+	if advice != nil {
+		if color, ok := trendColors[advice.Trend]; ok {
+			return color
+		}
+	}
+	return defaultEmbedColor
+}
+
+func componentID(command, action, key string, args ...string) string {
+	return strings.Join(append([]string{command, action, key}, args...), ":")
+}
+
+// parseActionArgs 拆解 Router 傳入的動作參數（已去掉指令前綴），格式不符時 ok 為 false
+func parseActionArgs(args []string) (action, key string, rest []string, ok bool) {
+	if len(args) < 2 {
+		return "", "", nil, false
+	}
+	return args[0], args[1], args[2:], true
+}
+
+// resultCache 以短鍵保存最近的搜尋結果，讓按鈕只需在資料中帶上鍵值
+type resultCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedResult
+}
+
+type cachedResult struct {
+	value   interface{}
+	expires time.Time
+}
+
+func newResultCache() *resultCache {
+	return &resultCache{entries: make(map[string]cachedResult)}
+}
+
+// put 存入結果並回傳鍵值，同時清掉過期的項目
+func (c *resultCache) put(value interface{}) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	key := strconv.FormatInt(now.UnixNano(), 36)
+	c.entries[key] = cachedResult{value: value, expires: now.Add(resultCacheTTL)}
+	return key
+}
+
+func (c *resultCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// ---------------------------------------------------------
+// /price 航班結果
+// ---------------------------------------------------------
+
+// flightResults 一次 /price 搜尋的結果，Flights 依價格由低到高排列
+type flightResults struct {
+	Origin      string
+	Destination string
+	Date        string
+	Flights     []models.Flight
+	Advice      *models.PriceAdvice
+	Lang        string // 搜尋者的回覆語系，翻頁時沿用
+}
+
+// parseISODuration 解析 Amadeus 的 ISO 8601 時長（例如 PT3H5M、P1DT2H），失敗回傳 0
+func parseISODuration(s string) time.Duration {
+	s, ok := strings.CutPrefix(s, "P")
+	if !ok {
+		return 0
+	}
+	var total time.Duration
+	unit := map[byte]time.Duration{'D': 24 * time.Hour, 'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+	num := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			num = num*10 + int(c-'0')
+		case c == 'T':
+		case unit[c] > 0:
+			total += time.Duration(num) * unit[c]
+			num = 0
+		default:
+			return 0
+		}
+	}
+	return total
+}
+
+// formatFlightDuration 例如 3h05m
+func formatFlightDuration(s string) string {
+	d := parseISODuration(s)
+	if d == 0 {
+		return s
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// order 依排序方式回傳 Flights 的索引，保留原始索引供設定警報使用
+func (r *flightResults) order(sortBy string) []int {
+	idx := make([]int, len(r.Flights))
+	for i := range idx {
+		idx[i] = i
+	}
+	if sortBy == flightSortDuration {
+		sort.SliceStable(idx, func(a, b int) bool {
+			return parseISODuration(r.Flights[idx[a]].Duration) < parseISODuration(r.Flights[idx[b]].Duration)
+		})
+	}
+	return idx
+}
+
+func (r *flightResults) pageCount() int {
+	return (len(r.Flights) + flightsPerPage - 1) / flightsPerPage
+}
+
+// pageIndexes 取得某一頁的航班索引，頁碼超出範圍時取最接近的頁
+func (r *flightResults) pageIndexes(page int, sortBy string) (int, []int) {
+	if last := r.pageCount() - 1; page > last {
+		page = last
+	}
+	if page < 0 {
+		page = 0
+	}
+	idx := r.order(sortBy)
+	end := min((page+1)*flightsPerPage, len(idx))
+	return page, idx[page*flightsPerPage : end]
+}
+
+func flightField(n int, f models.Flight, lang string) commands.Field {
+	stops := botText(lang, "price.nonstop")
+	if f.Stops > 0 {
+		stops = botText(lang, "price.stops", f.Stops)
+	}
+	value := fmt.Sprintf("💰 **%s %s** ｜ ⏱️ %s ｜ %s\n%s %s ➝ %s %s",
+		f.Price.Number(), f.Currency, formatFlightDuration(f.Duration), stops,
+		f.From.Code, formatTimeStr(f.Departure), f.To.Code, formatTimeStr(f.Arrival))
+	if f.Risk != nil {
+		value += botText(lang, "price.risk", riskLevelLabel(f.Risk.Level, lang), f.Risk.Score)
+		// 延誤原因只有中文
+		if len(f.Risk.Reasons) > 0 && lang != AdviceLangEN {
+			value += " - " + strings.Join(f.Risk.Reasons, "、")
+		}
+	}
+	return commands.Field{
+		Name:  fmt.Sprintf("%d. %s (%s)", n, f.Airline, f.FlightNumber),
+		Value: value,
+	}
+}
+
+// priceCard 航班結果卡片：每個航班一個欄位，顏色代表價格趨勢
+func priceCard(r *flightResults, page int, sortBy string) commands.Card {
+	page, indexes := r.pageIndexes(page, sortBy)
+
+	card := commands.Card{
+		Title: fmt.Sprintf("✈️ %s ➝ %s (%s)", r.Origin, r.Destination, r.Date),
+		Color: trendColor(r.Advice),
+	}
+	if a := r.Advice; a != nil {
+		card.Description = "💡 " + priceAdviceText(a, r.Lang)
+		if !a.HistoryAvg.IsZero() {
+			card.Description += botText(r.Lang, "price.history", a.HistoryAvg, a.HistoryLow, a.DiffPercent)
+		}
+	}
+	for n, i := range indexes {
+		card.Fields = append(card.Fields, flightField(page*flightsPerPage+n+1, r.Flights[i], r.Lang))
+	}
+
+	sortLabel := botText(r.Lang, "price.sort_price")
+	if sortBy == flightSortDuration {
+		sortLabel = botText(r.Lang, "price.sort_duration")
+	}
+	card.Footer = botText(r.Lang, "price.footer", page+1, r.pageCount(), sortLabel, len(r.Flights))
+	return card
+}
+
+// priceRows 翻頁、切換排序、目的地天氣按鈕與「以此價格設定警報」選單
+func priceRows(key string, r *flightResults, page int, sortBy string) []commands.Row {
+	page, indexes := r.pageIndexes(page, sortBy)
+
+	toggle, toggleLabel, toggleEmoji := flightSortDuration, botText(r.Lang, "price.sort_duration"), "⏱️"
+	if sortBy == flightSortDuration {
+		toggle, toggleLabel, toggleEmoji = flightSortPrice, botText(r.Lang, "price.sort_price"), "💰"
+	}
+
+	buttons := commands.Row{Buttons: []commands.Button{
+		{
+			Label:    botText(r.Lang, "page.prev"),
+			Emoji:    "◀️",
+			Data:     componentID("price", actionPage, key, strconv.Itoa(page-1), sortBy),
+			Disabled: page == 0,
+		},
+		{
+			Label:    botText(r.Lang, "page.next"),
+			Emoji:    "▶️",
+			Data:     componentID("price", actionPage, key, strconv.Itoa(page+1), sortBy),
+			Disabled: page >= r.pageCount()-1,
+		},
+		{
+			Label:   toggleLabel,
+			Emoji:   toggleEmoji,
+			Data:    componentID("price", actionPage, key, "0", toggle),
+			Primary: true,
+		},
+		{
+			Label: botText(r.Lang, "price.weather"),
+			Emoji: "🌤️",
+			Data:  componentID("price", actionWeather, key),
+		},
+	}}
+
+	options := make([]commands.Option, 0, len(indexes))
+	for n, i := range indexes {
+		f := r.Flights[i]
+		options = append(options, commands.Option{
+			Label:       fmt.Sprintf("%d. %s %s", page*flightsPerPage+n+1, f.FlightNumber, f.Price),
+			Description: fmt.Sprintf("%s %s ➝ %s %s", f.From.Code, formatTimeStr(f.Departure), f.To.Code, formatTimeStr(f.Arrival)),
+			Value:       strconv.Itoa(i),
+			Emoji:       "🔔",
+		})
+	}
+	alertMenu := commands.Row{Menu: &commands.Menu{
+		Data:        componentID("price", actionAlert, key),
+		Placeholder: botText(r.Lang, "price.alert_menu"),
+		Options:     options,
+	}}
+
+	return []commands.Row{buttons, alertMenu}
+}
+
+// priceReply 依使用者設定的貨幣搜尋航班，並以設定的語系產生含卡片與按鈕的回覆
+func (b *TravelBot) priceReply(origin, dest, date string, prefs BotSettings) commands.Reply {
+	if b.Amadeus == nil {
+		return commands.Text(botText(prefs.Lang, "price.disabled"))
+	}
+	req := models.SearchRequest{Origin: origin, Destination: dest, DepartureDate: date, Adults: 1, Currency: prefs.Currency}
+
+	flights, advice, err := b.Amadeus.SearchFlights(req)
+	if err != nil {
+		return commands.Text(botText(prefs.Lang, "price.failed", err))
+	}
+	if len(flights) == 0 {
+		return commands.Text(botText(prefs.Lang, "price.empty"))
+	}
+
+	if b.Weather != nil {
+		b.Weather.AttachDisruptionRisk(flights)
+	}
+	if err := sortFlightsByPrice(flights); err != nil {
+		log.Printf("⚠️ 航班價格幣別不一致，維持原始順序: %v", err)
+	}
+
+	results := &flightResults{Origin: origin, Destination: dest, Date: date, Flights: flights, Advice: advice, Lang: prefs.Lang}
+	key := b.results.put(results)
+	return commands.Reply{
+		Cards: []commands.Card{priceCard(results, 0, flightSortPrice)},
+		Rows:  priceRows(key, results, 0, flightSortPrice),
+	}
+}
+
+// ---------------------------------------------------------
+// /spot 景點結果
+// ---------------------------------------------------------
+
+// spotResults 一次 /spot 搜尋已載入的各頁結果，往後翻頁時才以 cursor 載入下一頁
+type spotResults struct {
+	mu         sync.Mutex
+	Place      *models.GeoPlace
+	Request    SearchRequest
+	Pages      [][]Attraction
+	NextCursor string
+	Lang       string // 搜尋者的回覆語系，翻頁時沿用
+}
+
+func formatSpotDistance(meters float64) string {
+	if meters > 1000 {
+		return fmt.Sprintf("%.1fkm", meters/1000)
+	}
+	return fmt.Sprintf("%.0fm", meters)
+}
+
+// spotCard 景點結果卡片：每個景點一個欄位
+func spotCard(r *spotResults, page int) commands.Card {
+	card := commands.Card{
+		Title: botText(r.Lang, "spot.title", r.Place.Name),
+		Color: defaultEmbedColor,
+	}
+	offset := 0
+	for _, p := range r.Pages[:page] {
+		offset += len(p)
+	}
+	for n, spot := range r.Pages[page] {
+		value := botText(r.Lang, "spot.distance", formatSpotDistance(spot.Distance))
+		if spot.Rating > 0 {
+			value += fmt.Sprintf(" ｜ ⭐ %.1f", spot.Rating)
+		}
+		if spot.Price > 0 {
+			value += " ｜ " + strings.Repeat("$", spot.Price)
+		}
+		if spot.Address != "" {
+			value += "\n" + spot.Address
+		}
+		card.Fields = append(card.Fields, commands.Field{
+			Name:  fmt.Sprintf("%d. %s", offset+n+1, spot.Name),
+			Value: value,
+		})
+	}
+	card.Footer = botText(r.Lang, "spot.footer", page+1, r.Request.Sort)
+	return card
+}
+
+func spotRows(key string, r *spotResults, page int) []commands.Row {
+	hasNext := page < len(r.Pages)-1 || r.NextCursor != ""
+	return []commands.Row{{Buttons: []commands.Button{
+		{
+			Label:    botText(r.Lang, "page.prev"),
+			Emoji:    "◀️",
+			Data:     componentID("spot", actionPage, key, strconv.Itoa(page-1)),
+			Disabled: page == 0,
+		},
+		{
+			Label:    botText(r.Lang, "page.next"),
+			Emoji:    "▶️",
+			Data:     componentID("spot", actionPage, key, strconv.Itoa(page+1)),
+			Disabled: !hasNext,
+		},
+		{
+			Label: botText(r.Lang, "spot.weather"),
+			Emoji: "🌤️",
+			Data:  componentID("spot", actionWeather, key),
+		},
+	}}}
+}
+
+// loadSpotPage 取得指定頁，尚未載入時以 cursor 向 Foursquare 取下一頁
+func (b *TravelBot) loadSpotPage(r *spotResults, page int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if page < 0 {
+		page = 0
+	}
+	for page >= len(r.Pages) {
+		if r.NextCursor == "" {
+			return len(r.Pages) - 1, nil
+		}
+		req := r.Request
+		req.Cursor = r.NextCursor
+		result, err := b.Foursquare.Search(req)
+		if err != nil {
+			return 0, err
+		}
+		if len(result.Attractions) == 0 {
+			r.NextCursor = ""
+			continue
+		}
+		r.Pages = append(r.Pages, result.Attractions)
+		r.NextCursor = result.NextCursor
+	}
+	return page, nil
+}
+
+// spotReply 搜尋景點並產生含卡片與翻頁按鈕的回覆
+func (b *TravelBot) spotReply(locationName string, req SearchRequest, lang string) commands.Reply {
+	if b.Foursquare == nil || b.Geocoder == nil {
+		return commands.Text(botText(lang, "spot.disabled"))
+	}
+
+	place, err := b.Geocoder.ResolvePlace(locationName)
+	if err != nil {
+		return commands.Text(botText(lang, "spot.not_found", locationName))
+	}
+
+	req.Latitude = place.Latitude
+	req.Longitude = place.Longitude
+	page, err := b.Foursquare.Search(req)
+	if err != nil {
+		return commands.Text(botText(lang, "spot.failed"))
+	}
+	if len(page.Attractions) == 0 {
+		return commands.Text(botText(lang, "spot.empty", place.Name))
+	}
+
+	req.Cursor = ""
+	results := &spotResults{Place: place, Request: req, Pages: [][]Attraction{page.Attractions}, NextCursor: page.NextCursor, Lang: lang}
+	key := b.results.put(results)
+	return commands.Reply{
+		Cards: []commands.Card{spotCard(results, 0)},
+		Rows:  spotRows(key, results, 0),
+	}
+}
+
+// ---------------------------------------------------------
+// 按鈕與選單動作
+// ---------------------------------------------------------
+
+// expiredReply 結果已過期時無法得知搜尋時的語系，改用按下按鈕的人的設定
+func (b *TravelBot) expiredReply(ctx *commands.Context, command string) commands.Reply {
+	return commands.Reply{Text: botText(b.settingsFor(ctx).Lang, "results.expired", ctx.Prefix, command), Ephemeral: true}
+}
+
+// priceAction 處理 /price 結果上的按鈕：翻頁與排序更新原訊息、天氣另發一則、警報只回覆按下的人
+func (b *TravelBot) priceAction(ctx *commands.Context, args []string) commands.Reply {
+	action, key, rest, ok := parseActionArgs(args)
+	if !ok {
+		return commands.Reply{}
+	}
+	cached, found := b.results.get(key)
+	r, isFlight := cached.(*flightResults)
+	if !found || !isFlight {
+		return b.expiredReply(ctx, "price")
+	}
+
+	switch action {
+	case actionPage:
+		if len(rest) < 2 {
+			return commands.Reply{}
+		}
+		page, _ := strconv.Atoi(rest[0])
+		return commands.Reply{
+			Cards:  []commands.Card{priceCard(r, page, rest[1])},
+			Rows:   priceRows(key, r, page, rest[1]),
+			Update: true,
+		}
+
+	case actionWeather:
+		city := r.Destination
+		if name, ok := models.AirportCityMap[r.Destination]; ok {
+			city = name
+		}
+		return commands.Text(b.weatherReply(city, r.Lang))
+
+	case actionAlert:
+		if len(rest) == 0 {
+			return commands.Reply{}
+		}
+		idx, err := strconv.Atoi(rest[0])
+		if err != nil || idx < 0 || idx >= len(r.Flights) {
+			return commands.Reply{}
+		}
+		return commands.Reply{Text: b.priceAlertReply(ctx, r, r.Flights[idx]), Ephemeral: true}
+	}
+	return commands.Reply{}
+}
+
+// priceAlertReply 以選定航班的價格建立一次性價格警報，觸發時通知目前頻道
+func (b *TravelBot) priceAlertReply(ctx *commands.Context, r *flightResults, f models.Flight) string {
+	if b.Amadeus == nil {
+		return botText(r.Lang, "price.disabled")
+	}
+	alert, err := b.Amadeus.CreatePriceAlert(models.PriceAlert{
+		Route:         r.Origin + "-" + r.Destination,
+		DepartureDate: r.Date,
+		TargetPrice:   f.Price,
+		Currency:      f.Price.Currency,
+		Channel:       ctx.Platform,
+		Target:        ctx.ChannelID,
+		Owner:         ctx.UserID,
+	})
+	if err != nil {
+		return botText(r.Lang, "price.alert_failed", err)
+	}
+	return botText(r.Lang, "price.alert_set", alert.Route, alert.DepartureDate, alert.TargetPrice)
+}
+
+// spotAction 處理 /spot 結果上的翻頁與天氣按鈕
+func (b *TravelBot) spotAction(ctx *commands.Context, args []string) commands.Reply {
+	action, key, rest, ok := parseActionArgs(args)
+	if !ok {
+		return commands.Reply{}
+	}
+	cached, found := b.results.get(key)
+	r, isSpot := cached.(*spotResults)
+	if !found || !isSpot {
+		return b.expiredReply(ctx, "spot")
+	}
+
+	switch action {
+	case actionPage:
+		if len(rest) < 1 {
+			return commands.Reply{}
+		}
+		page, _ := strconv.Atoi(rest[0])
+		page, err := b.loadSpotPage(r, page)
+		if err != nil {
+			return commands.Reply{Text: botText(r.Lang, "spot.load_failed"), Ephemeral: true}
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return commands.Reply{
+			Cards:  []commands.Card{spotCard(r, page)},
+			Rows:   spotRows(key, r, page),
+			Update: true,
+		}
+
+	case actionWeather:
+		// WeatherAPI 接受「緯度,經度」查詢，比地名更精確
+		return commands.Text(b.weatherReply(fmt.Sprintf("%.4f,%.4f", r.Place.Latitude, r.Place.Longitude), r.Lang))
+	}
+	return commands.Reply{}
+}
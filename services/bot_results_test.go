@@ -0,0 +1,123 @@
+package services
+
+import (
+	"final/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseISODuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"PT3H5M", 3*time.Hour + 5*time.Minute},
+		{"PT45M", 45 * time.Minute},
+		{"P1DT2H", 26 * time.Hour},
+		{"3h", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := parseISODuration(tt.in); got != tt.want {
+			t.Errorf("parseISODuration(%q) = %v, 預期 %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// testFlightResults 7 個依價格排序的航班，越便宜飛越久
+func testFlightResults(trend string) *flightResults {
+	r := &flightResults{Origin: "TPE", Destination: "NRT", Date: "2026-03-01", Advice: &models.PriceAdvice{Trend: trend, Advice: "測試"}}
+	for i := 0; i < 7; i++ {
+		r.Flights = append(r.Flights, models.Flight{
+			ID:           strconv.Itoa(i),
+			Airline:      "EVA Air",
+			FlightNumber: fmt.Sprintf("BR%d", 100+i),
+			Price:        models.NewMoney(float64(5000+i*500), "TWD"),
+			Currency:     "TWD",
+			Duration:     fmt.Sprintf("PT%dH", 10-i),
+			From:         models.Airport{Code: "TPE"},
+			To:           models.Airport{Code: "NRT"},
+		})
+	}
+	return r
+}
+
+func TestPriceCard_PagesAndSort(t *testing.T) {
+	r := testFlightResults("down")
+
+	first := priceCard(r, 0, flightSortPrice)
+	if len(first.Fields) != flightsPerPage || first.Color != trendColors["down"] {
+		t.Fatalf("第一頁應有 %d 個欄位且為綠色: fields=%d color=%x", flightsPerPage, len(first.Fields), first.Color)
+	}
+	if last := priceCard(r, 5, flightSortPrice); len(last.Fields) != 2 || last.Fields[0].Name != "6. EVA Air (BR105)" {
+		t.Errorf("頁碼超出範圍應停在最後一頁: %+v", last.Fields[0])
+	}
+
+	byDuration := priceCard(r, 0, flightSortDuration)
+	if byDuration.Fields[0].Name != "1. EVA Air (BR106)" {
+		t.Errorf("依飛行時間排序時最短的航班應在最前面: %s", byDuration.Fields[0].Name)
+	}
+
+	if c := trendColor(&models.PriceAdvice{Trend: "up"}); c != trendColors["up"] {
+		t.Errorf("價格偏高應為紅色: %x", c)
+	}
+	if c := trendColor(nil); c != defaultEmbedColor {
+		t.Errorf("沒有價格建議應使用預設顏色: %x", c)
+	}
+}
+
+func TestPriceRows(t *testing.T) {
+	r := testFlightResults("stable")
+	rows := priceRows("abc123", r, 0, flightSortDuration)
+
+	buttons := rows[0].Buttons
+	seen := make(map[string]bool)
+	for _, b := range buttons {
+		// Discord custom_id 上限 100 字元，Telegram callback_data 上限 64 bytes
+		if len(b.Data) > 64 || seen[b.Data] {
+			t.Errorf("按鈕資料需唯一且不超過 64 bytes: %s", b.Data)
+		}
+		seen[b.Data] = true
+	}
+	if !buttons[0].Disabled {
+		t.Error("第一頁的上一頁按鈕應停用")
+	}
+
+	parts := strings.Split(buttons[1].Data, ":")
+	action, key, args, ok := parseActionArgs(parts[1:])
+	if parts[0] != "price" || !ok || action != actionPage || key != "abc123" || args[0] != "1" || args[1] != flightSortDuration {
+		t.Errorf("下一頁按鈕資料解析錯誤: %s", buttons[1].Data)
+	}
+
+	// 依飛行時間排序時，選單的值仍對應原始（依價格排序）的索引
+	menu := rows[1].Menu
+	if menu == nil || len(menu.Options) != flightsPerPage || menu.Options[0].Value != "6" {
+		t.Fatalf("警報選單應列出本頁航班並帶原始索引: %+v", menu)
+	}
+	if menu.Data != "price:alert:abc123" {
+		t.Errorf("警報選單資料錯誤: %s", menu.Data)
+	}
+
+	if _, _, _, ok := parseActionArgs([]string{"page"}); ok {
+		t.Error("格式不完整的動作資料應解析失敗")
+	}
+}
+
+func TestResultCache(t *testing.T) {
+	c := newResultCache()
+	key := c.put(&flightResults{Origin: "TPE"})
+	if v, ok := c.get(key); !ok || v.(*flightResults).Origin != "TPE" {
+		t.Fatalf("應能取回剛存入的結果: %v %v", v, ok)
+	}
+
+	c.entries[key] = cachedResult{value: c.entries[key].value, expires: time.Now().Add(-time.Second)}
+	if _, ok := c.get(key); ok {
+		t.Error("過期的結果不應取回")
+	}
+	if _, ok := c.get("missing"); ok {
+		t.Error("不存在的鍵不應取回")
+	}
+}
@@ -0,0 +1,668 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"final/commands"
+	"final/models"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+const discordSettingsDB = "discord_settings.json"
+
+// /settings 可設定的項目
+const (
+	settingCurrency = "currency"
+	settingHome     = "home"
+	settingLang     = "lang"
+
+	// settingReset 清除設定，改用上一層（伺服器或預設值）
+	settingReset = "reset"
+)
+
+// 設定的範圍：個人設定優先於伺服器設定
+const (
+	settingScopeUser   = "user"
+	settingScopeServer = "server"
+)
+
+// defaultBotSettings 沒有任何設定時的搜尋貨幣與語系
+var defaultBotSettings = BotSettings{Currency: "TWD", Lang: AdviceLangZH}
+
+// BotSettings 機器人的偏好設定；空欄位代表沿用上一層
+type BotSettings struct {
+	Currency string `json:"currency,omitempty"`
+	Home     string `json:"home,omitempty"` // 預設出發機場
+	Lang     string `json:"lang,omitempty"` // zh-TW、en
+}
+
+// merge 以 s 的非空欄位覆蓋 base
+func (s BotSettings) merge(base BotSettings) BotSettings {
+	if s.Currency != "" {
+		base.Currency = s.Currency
+	}
+	if s.Home != "" {
+		base.Home = s.Home
+	}
+	if s.Lang != "" {
+		base.Lang = s.Lang
+	}
+	return base
+}
+
+func (s BotSettings) get(key string) string {
+	switch key {
+	case settingCurrency:
+		return s.Currency
+	case settingHome:
+		return s.Home
+	}
+	return s.Lang
+}
+
+func (s *BotSettings) set(key, value string) {
+	switch key {
+	case settingCurrency:
+		s.Currency = value
+	case settingHome:
+		s.Home = value
+	case settingLang:
+		s.Lang = value
+	}
+}
+
+func (s BotSettings) isEmpty() bool {
+	return s == BotSettings{}
+}
+
+// botSettingsFile discord_settings.json 的內容
+type botSettingsFile struct {
+	Guilds map[string]BotSettings `json:"guilds"`
+	Users  map[string]BotSettings `json:"users"`
+}
+
+// BotSettingsStore 以 JSON 檔保存每個伺服器與每位使用者的設定
+type BotSettingsStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewBotSettingsStore() *BotSettingsStore {
+	return &BotSettingsStore{path: discordSettingsDB}
+}
+
+// load 讀取所有設定（呼叫端需持有 mu）
+func (s *BotSettingsStore) load() (*botSettingsFile, error) {
+	f := &botSettingsFile{}
+	data, err := os.ReadFile(s.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, f); err != nil {
+			return nil, fmt.Errorf("解析設定檔失敗: %v", err)
+		}
+	}
+	if f.Guilds == nil {
+		f.Guilds = make(map[string]BotSettings)
+	}
+	if f.Users == nil {
+		f.Users = make(map[string]BotSettings)
+	}
+	return f, nil
+}
+
+func (s *BotSettingsStore) save(f *botSettingsFile) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// Get 取得某個範圍自己的設定（不含上一層）
+func (s *BotSettingsStore) Get(scope, id string) BotSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.load()
+	if err != nil {
+		return BotSettings{}
+	}
+	if scope == settingScopeServer {
+		return f.Guilds[id]
+	}
+	return f.Users[id]
+}
+
+// Resolve 合併預設值、伺服器設定與個人設定；guildID 為空（私訊）時只看個人設定
+func (s *BotSettingsStore) Resolve(guildID, userID string) BotSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.load()
+	if err != nil {
+		return defaultBotSettings
+	}
+	settings := defaultBotSettings
+	if guildID != "" {
+		settings = f.Guilds[guildID].merge(settings)
+	}
+	return f.Users[userID].merge(settings)
+}
+
+// Set 更新一個項目；value 為 reset 時清除該項目
+func (s *BotSettingsStore) Set(scope, id, key, value string) (BotSettings, error) {
+	value, err := normalizeSetting(key, value)
+	if err != nil {
+		return BotSettings{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.load()
+	if err != nil {
+		return BotSettings{}, err
+	}
+	entries := f.Users
+	if scope == settingScopeServer {
+		entries = f.Guilds
+	}
+	settings := entries[id]
+	settings.set(key, value)
+	if settings.isEmpty() {
+		delete(entries, id)
+	} else {
+		entries[id] = settings
+	}
+	return settings, s.save(f)
+}
+
+// normalizeSetting 驗證並統一設定值的格式；reset 回傳空字串
+func normalizeSetting(key, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, settingReset) {
+		return "", nil
+	}
+
+	switch key {
+	case settingCurrency:
+		value = strings.ToUpper(value)
+		for _, c := range supportedCurrencies {
+			if c == value {
+				return value, nil
+			}
+		}
+		return "", botError("args.currency", value, strings.Join(supportedCurrencies, "、"))
+	case settingHome:
+		value = strings.ToUpper(value)
+		if len(value) != 3 || strings.Trim(value, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return "", botError("args.home")
+		}
+		return value, nil
+	case settingLang:
+		switch strings.ToLower(value) {
+		case "zh", "zh-tw", "tw", "中文":
+			return AdviceLangZH, nil
+		case "en", "english":
+			return AdviceLangEN, nil
+		}
+		return "", botError("args.lang")
+	}
+	return "", botError("args.setting", key)
+}
+
+// settingsFor 指令呼叫者目前生效的設定；未啟用設定（例如 Telegram）時使用預設值
+func (b *TravelBot) settingsFor(ctx *commands.Context) BotSettings {
+	if b.settings == nil {
+		return defaultBotSettings
+	}
+	return b.settings.Resolve(ctx.GuildID, ctx.UserID)
+}
+
+// settingsReply /settings：沒有 key 時顯示目前設定，否則更新個人或伺服器設定
+func (b *TravelBot) settingsReply(ctx *commands.Context) commands.Reply {
+	key, value, scope := ctx.Args.String("key"), ctx.Args.String("value"), ctx.Args.String("scope")
+	if scope == "" {
+		scope = settingScopeUser
+	}
+
+	if key == "" {
+		return commands.Reply{Text: b.describeSettings(ctx), Ephemeral: true}
+	}
+	lang := b.settingsFor(ctx).Lang
+	if value == "" {
+		return commands.Reply{Text: botText(lang, "settings.missing_value", ctx.Prefix, key), Ephemeral: true}
+	}
+
+	id := ctx.UserID
+	if scope == settingScopeServer {
+		if ctx.GuildID == "" {
+			return commands.Reply{Text: botText(lang, "settings.server_only"), Ephemeral: true}
+		}
+		if !ctx.Admin {
+			return commands.Reply{Text: botText(lang, "settings.no_permission"), Ephemeral: true}
+		}
+		id = ctx.GuildID
+	}
+
+	if _, err := b.settings.Set(scope, id, key, value); err != nil {
+		return commands.Reply{Text: "⚠️ " + errorText(lang, err), Ephemeral: true}
+	}
+	// 以新的設定回覆，切換語系時立即生效
+	current := b.settingsFor(ctx)
+	msg := botText(current.Lang, "settings.updated_"+scope, key, displaySetting(current.Lang, current.get(key)))
+	return commands.Reply{Text: msg, Ephemeral: true}
+}
+
+// describeSettings 列出目前生效的設定與來源
+func (b *TravelBot) describeSettings(ctx *commands.Context) string {
+	current := b.settingsFor(ctx)
+	user := b.settings.Get(settingScopeUser, ctx.UserID)
+	var guild BotSettings
+	if ctx.GuildID != "" {
+		guild = b.settings.Get(settingScopeServer, ctx.GuildID)
+	}
+
+	var msg strings.Builder
+	msg.WriteString(botText(current.Lang, "settings.title"))
+	for _, key := range []string{settingCurrency, settingHome, settingLang} {
+		source := "settings.source_default"
+		switch {
+		case user.get(key) != "":
+			source = "settings.source_user"
+		case guild.get(key) != "":
+			source = "settings.source_server"
+		}
+		msg.WriteString(botText(current.Lang, "settings.line", key, displaySetting(current.Lang, current.get(key)), botText(current.Lang, source)))
+	}
+	msg.WriteString("\n\n" + botText(current.Lang, "settings.usage", ctx.Prefix))
+	return msg.String()
+}
+
+func displaySetting(lang, value string) string {
+	if value == "" {
+		return botText(lang, "settings.unset")
+	}
+	return value
+}
+
+// botTexts 機器人回覆的多語系文字，缺少的語系或鍵值使用中文
+var botTexts = map[string]map[string]string{
+	AdviceLangZH: {
+		"settings.title":          "⚙️ **目前設定**",
+		"settings.line":           "\n• `%s`：**%s**（%s）",
+		"settings.source_default": "預設",
+		"settings.source_user":    "個人設定",
+		"settings.source_server":  "伺服器設定",
+		"settings.unset":          "未設定",
+		"settings.usage":          "使用 `%[1]ssettings currency USD`、`%[1]ssettings home TPE` 或 `%[1]ssettings lang en` 變更個人設定；scope 選 server 可設定整個伺服器的預設值（需要管理伺服器權限），值為 `reset` 時恢復預設。",
+		"settings.missing_value":  "⚠️ 請輸入要設定的值，例如 `%ssettings %s ...`",
+		"settings.server_only":    "⚠️ 伺服器設定只能在伺服器頻道中變更",
+		"settings.no_permission":  "⚠️ 需要「管理伺服器」權限才能變更伺服器設定",
+		"settings.updated_user":   "✅ 已更新你的 `%s`：**%s**",
+		"settings.updated_server": "✅ 已更新伺服器的 `%s`（個人設定優先）：**%s**",
+
+		"price.disabled":      "⚠️ 航班服務未啟用",
+		"price.no_origin":     "⚠️ 缺少參數 origin（出發機場代碼）",
+		"price.no_origin_set": "⚠️ 缺少參數 origin（出發機場代碼），也可以用 `%ssettings home TPE` 設定預設出發機場",
+		"price.failed":        "❌ 搜尋失敗: %v",
+		"price.empty":         "📭 找不到航班。",
+		"price.history":       "\n歷史平均 %s ｜ 歷史最低 %s ｜ 差幅 %+.1f%%",
+		"price.nonstop":       "直飛",
+		"price.stops":         "轉機 %d 次",
+		"price.risk":          "\n⛈️ 天氣延誤風險：%s (%d/100)",
+		"price.sort_price":    "依價格排序",
+		"price.sort_duration": "依飛行時間排序",
+		"price.footer":        "第 %d/%d 頁 · %s · 共 %d 個航班",
+		"price.weather":       "目的地天氣",
+		"price.alert_menu":    "🔔 以此價格設定警報",
+		"price.alert_set":     "🔔 已設定價格警報：**%s** (%s) 最低價低於或等於 **%s** 時會在此頻道通知。",
+		"price.alert_failed":  "❌ 建立價格警報失敗: %v",
+
+		"chart.trend_title":    "📈 %s ➝ %s 價格趨勢（%d 週）",
+		"chart.trend_footer":   "每週出發日的最低價 · 綠點最低、紅點最高、橘色虛線為平均",
+		"chart.history_title":  "📉 %s ➝ %s %s 歷史價格",
+		"chart.history_footer": "共 %d 筆搜尋紀錄（%s ~ %s）· 綠點最低、紅點最高、橘色虛線為平均",
+		"chart.history_empty":  "📭 還沒有 %s ➝ %s %s 的搜尋紀錄，先用 `%sprice` 查詢一次就會開始記錄。",
+		"chart.latest":         "最近一次查詢：**%s**（%s）",
+		"chart.min":            "🟢 最低",
+		"chart.avg":            "📊 平均",
+		"chart.max":            "🔴 最高",
+
+		// 指令說明 help.<指令> 的中文使用 Command.Description
+		"help.title":         "**👋 GoSkyAlert 全能旅遊機器人**",
+		"help.text_commands": "\n\n也可以用 `%[1]s` 開頭的文字指令，例如 `%[1]sprice TPE NRT 2026-03-01`",
+
+		"args.too_many":         "參數太多：%s",
+		"args.price_option":     "不支援的選項 `%s`（可用 origin、destination、date）",
+		"args.spot_option":      "不支援的選項 `%s`（可用 category、sort、limit、open、price）",
+		"args.spot_price":       "price 格式錯誤，例如 `price=2` 或 `price=1-3`",
+		"args.spot_category":    "不支援的景點類別: %s",
+		"args.spot_price_order": "min_price 不可大於 max_price",
+		"args.airport":          "機場代碼必須是 3 碼，例如 TPE",
+		"args.date":             "日期格式錯誤，請使用 YYYY-MM-DD",
+		"args.threshold":        "請輸入大於 0 的目標價格",
+		"args.deliver":          "通知方式只支援 dm 或 channel",
+		"args.currency":         "不支援的貨幣 %s（可用 %s）",
+		"args.home":             "機場代碼必須是 3 碼英文字母，例如 TPE",
+		"args.lang":             "語系只支援 zh-TW 或 en",
+		"args.setting":          "不支援的設定項目 %s",
+
+		"results.expired": "⌛ 搜尋結果已過期，請重新使用 `%s%s`",
+
+		"page.prev": "上一頁",
+		"page.next": "下一頁",
+
+		"rate.disabled":     "⚠️ 匯率服務未啟用",
+		"rate.failed":       "❌ 匯率查詢失敗",
+		"rate.result":       "💱 **匯率換算**\n\n1 %s = %.4f %s\n\n💰 **%s ≈ %s**",
+		"rate.alert_failed": "❌ 建立警報失敗: %v",
+		"rate.alert_below":  "🔔 已設定匯率警報：當 **%s→%s** 跌破 **%.4f** 時會在此頻道通知。",
+		"rate.alert_above":  "🔔 已設定匯率警報：當 **%s→%s** 突破 **%.4f** 時會在此頻道通知。",
+
+		"weather.disabled":       "⚠️ 天氣服務未啟用",
+		"weather.not_found":      "❌ 找不到該城市天氣資訊",
+		"weather.current":        "🌤️ **%s (%s) 目前天氣**\n\n🌡️ 氣溫: **%.1f°C** (體感 %.1f°C)\n☁️ 狀況: %s\n💧 濕度: %d%%\n🌬️ 風速: %.1f km/h\n🕶️ 紫外線: %.0f (%s)",
+		"weather.air":            "\n😷 空氣品質: **%s** (PM2.5 %.0f / PM10 %.0f µg/m³)",
+		"weather.air_warning":    "\n⚠️ 氣喘或呼吸道敏感者請配戴口罩並減少戶外活動。",
+		"weather.flight_warning": "\n\n⚠️ 目前天氣可能影響航班起降，出發前請留意航空公司公告。",
+
+		"pack.failed": "❌ 無法產生行李清單: %v",
+
+		"plan.disabled":     "⚠️ 景點服務未啟用",
+		"plan.failed":       "❌ 無法規劃行程: %v",
+		"plan.title":        "🗺️ **%s %d 日行程**（%s）\n",
+		"plan.list_sep":     "、",
+		"plan.free":         "\n自由活動\n",
+		"plan.walk":         "（步行約 %.1f km）\n",
+		"plan.walk_minutes": " 🚶 %d 分鐘",
+		"plan.unscheduled":  "\n⏰ 因營業時間未排入：%s\n",
+
+		"spot.disabled":    "⚠️ 景點服務未啟用",
+		"spot.not_found":   "❌ 找不到地點「%s」",
+		"spot.failed":      "❌ 景點搜尋失敗",
+		"spot.empty":       "📭 在 **%s** 附近沒找到景點。",
+		"spot.title":       "🏛️ %s 附近的熱門景點",
+		"spot.distance":    "📍 距離: %s",
+		"spot.footer":      "第 %d 頁 · 排序：%s",
+		"spot.weather":     "當地天氣",
+		"spot.load_failed": "❌ 載入下一頁失敗，請稍後再試",
+
+		"watch.past_date":     "⚠️ 出發日期已過",
+		"watch.failed":        "❌ 建立追蹤失敗: %v",
+		"watch.where_dm":      "私訊通知你",
+		"watch.where_channel": "在此頻道通知",
+		"watch.created":       "👀 開始追蹤 **%s** (%s)：最低價低於或等於 **%s** 時會%s。\n同一價格 %d 小時內只通知一次，價格再創新低時會立即通知。\n追蹤編號：`%s`",
+		"watch.kind_repeat":   "持續追蹤",
+		"watch.kind_once":     "一次性",
+		"watch.dest_channel":  "頻道",
+		"watch.dest_dm":       "私訊",
+		"watch.last_notified": " ｜ 上次通知 %s (%s)",
+		"watch.list_failed":   "❌ 讀取追蹤清單失敗",
+		"watch.empty":         "📭 目前沒有追蹤任何航線，使用 `%swatch` 開始追蹤。",
+		"watch.list_title":    "👀 **你的航線追蹤**（%d/%d）\n",
+		"watch.list_footer":   "\n\n使用 `%sunwatch <編號或追蹤編號>` 取消追蹤",
+		"watch.no_index":      "⚠️ 找不到第 %d 筆追蹤，請用 `%swatches` 查看清單",
+		"watch.not_owned":     "⚠️ 找不到這筆追蹤，或不是你建立的",
+		"watch.removed":       "🗑️ 已取消追蹤 `%s`",
+	},
+	AdviceLangEN: {
+		"settings.title":          "⚙️ **Current settings**",
+		"settings.line":           "\n• `%s`: **%s** (%s)",
+		"settings.source_default": "default",
+		"settings.source_user":    "personal",
+		"settings.source_server":  "server",
+		"settings.unset":          "not set",
+		"settings.usage":          "Use `%[1]ssettings currency USD`, `%[1]ssettings home TPE` or `%[1]ssettings lang en` to change your own settings; set scope to server to change the defaults for the whole server (requires Manage Server), or use `reset` to restore the default.",
+		"settings.missing_value":  "⚠️ Please provide a value, e.g. `%ssettings %s ...`",
+		"settings.server_only":    "⚠️ Server settings can only be changed in a server channel",
+		"settings.no_permission":  "⚠️ You need the Manage Server permission to change server settings",
+		"settings.updated_user":   "✅ Your `%s` is now **%s**",
+		"settings.updated_server": "✅ Server `%s` is now **%s** (personal settings take precedence)",
+
+		"price.disabled":      "⚠️ Flight search is not available",
+		"price.no_origin":     "⚠️ Missing origin airport code",
+		"price.no_origin_set": "⚠️ Missing origin airport code; you can also set a home airport with `%ssettings home TPE`",
+		"price.failed":        "❌ Search failed: %v",
+		"price.empty":         "📭 No flights found.",
+		"price.history":       "\nAverage %s | Lowest %s | %+.1f%% vs. average",
+		"price.nonstop":       "Nonstop",
+		"price.stops":         "%d stop(s)",
+		"price.risk":          "\n⛈️ Weather delay risk: %s (%d/100)",
+		"price.sort_price":    "Sorted by price",
+		"price.sort_duration": "Sorted by duration",
+		"price.footer":        "Page %d/%d · %s · %d flights",
+		"price.weather":       "Destination weather",
+		"price.alert_menu":    "🔔 Set a price alert at this fare",
+		"price.alert_set":     "🔔 Price alert set: you'll be notified in this channel when **%s** (%s) drops to **%s** or below.",
+		"price.alert_failed":  "❌ Failed to create price alert: %v",
+
+		"chart.trend_title":    "📈 %s ➝ %s price trend (%d weeks)",
+		"chart.trend_footer":   "Lowest fare for one departure per week · green = lowest, red = highest, dashed orange = average",
+		"chart.history_title":  "📉 %s ➝ %s %s price history",
+		"chart.history_footer": "%d searches (%s ~ %s) · green = lowest, red = highest, dashed orange = average",
+		"chart.history_empty":  "📭 No searches recorded for %s ➝ %s %s yet — run `%sprice` once to start tracking.",
+		"chart.latest":         "Latest search: **%s** (%s)",
+		"chart.min":            "🟢 Lowest",
+		"chart.avg":            "📊 Average",
+		"chart.max":            "🔴 Highest",
+
+		"help.title":         "**👋 GoSkyAlert travel bot**",
+		"help.text_commands": "\n\nText commands starting with `%[1]s` work too, e.g. `%[1]sprice TPE NRT 2026-03-01`",
+		"help.help":          "Show all commands",
+		"help.price":         "Search flights with price analysis",
+		"help.trend":         "Price trend chart for the coming weeks",
+		"help.history":       "Chart of previously searched prices",
+		"help.rate":          "Live exchange rates",
+		"help.ratealert":     "Exchange rate alert (notifies this channel when the rate crosses a threshold)",
+		"help.weather":       "Current weather for a city",
+		"help.pack":          "Packing list based on destination weather and trip length",
+		"help.spot":          "Find nearby attractions",
+		"help.watch":         "Watch a route and get notified when the fare drops below your target",
+		"help.unwatch":       "Stop watching a route",
+		"help.watches":       "List the routes you are watching",
+		"help.settings":      "Set your search currency, home airport and reply language",
+		"help.plan":          "Plan a multi-day sightseeing itinerary",
+
+		"args.too_many":         "Too many arguments: %s",
+		"args.price_option":     "Unknown option `%s` (use origin, destination or date)",
+		"args.spot_option":      "Unknown option `%s` (use category, sort, limit, open or price)",
+		"args.spot_price":       "Invalid price, e.g. `price=2` or `price=1-3`",
+		"args.spot_category":    "Unknown attraction category: %s",
+		"args.spot_price_order": "min_price cannot be greater than max_price",
+		"args.airport":          "Airport codes must be 3 letters, e.g. TPE",
+		"args.date":             "Invalid date, please use YYYY-MM-DD",
+		"args.threshold":        "Please enter a target price greater than 0",
+		"args.deliver":          "Delivery must be dm or channel",
+		"args.currency":         "Unsupported currency %s (use %s)",
+		"args.home":             "Airport codes must be 3 letters, e.g. TPE",
+		"args.lang":             "Language must be zh-TW or en",
+		"args.setting":          "Unknown setting %s",
+
+		"results.expired": "⌛ These results have expired, please run `%s%s` again",
+
+		"page.prev": "Previous",
+		"page.next": "Next",
+
+		"rate.disabled":     "⚠️ Exchange rates are not available",
+		"rate.failed":       "❌ Failed to look up the exchange rate",
+		"rate.result":       "💱 **Currency conversion**\n\n1 %s = %.4f %s\n\n💰 **%s ≈ %s**",
+		"rate.alert_failed": "❌ Failed to create the alert: %v",
+		"rate.alert_below":  "🔔 Rate alert set: this channel will be notified when **%s→%s** falls below **%.4f**.",
+		"rate.alert_above":  "🔔 Rate alert set: this channel will be notified when **%s→%s** rises above **%.4f**.",
+
+		"weather.disabled":       "⚠️ Weather is not available",
+		"weather.not_found":      "❌ No weather found for that city",
+		"weather.current":        "🌤️ **Current weather in %s (%s)**\n\n🌡️ Temperature: **%.1f°C** (feels like %.1f°C)\n☁️ Conditions: %s\n💧 Humidity: %d%%\n🌬️ Wind: %.1f km/h\n🕶️ UV index: %.0f (%s)",
+		"weather.air":            "\n😷 Air quality: **%s** (PM2.5 %.0f / PM10 %.0f µg/m³)",
+		"weather.air_warning":    "\n⚠️ If you have asthma or sensitive airways, wear a mask and limit time outdoors.",
+		"weather.flight_warning": "\n\n⚠️ Current weather may affect flights; check with your airline before you leave.",
+
+		"pack.failed": "❌ Couldn't build a packing list: %v",
+
+		"plan.disabled":     "⚠️ Attractions are not available",
+		"plan.failed":       "❌ Couldn't plan the itinerary: %v",
+		"plan.title":        "🗺️ **%[2]d-day itinerary for %[1]s** (%[3]s)\n",
+		"plan.list_sep":     ", ",
+		"plan.free":         "\nFree time\n",
+		"plan.walk":         " (about %.1f km on foot)\n",
+		"plan.walk_minutes": " 🚶 %d min",
+		"plan.unscheduled":  "\n⏰ Not scheduled because of opening hours: %s\n",
+
+		"spot.disabled":    "⚠️ Attractions are not available",
+		"spot.not_found":   "❌ Couldn't find \"%s\"",
+		"spot.failed":      "❌ Attraction search failed",
+		"spot.empty":       "📭 No attractions found near **%s**.",
+		"spot.title":       "🏛️ Popular attractions near %s",
+		"spot.distance":    "📍 Distance: %s",
+		"spot.footer":      "Page %d · sorted by %s",
+		"spot.weather":     "Local weather",
+		"spot.load_failed": "❌ Couldn't load the next page, please try again later",
+
+		"watch.past_date":     "⚠️ The departure date has already passed",
+		"watch.failed":        "❌ Failed to start watching: %v",
+		"watch.where_dm":      "DM you",
+		"watch.where_channel": "post in this channel",
+		"watch.created":       "👀 Watching **%s** (%s): I'll %[4]s when the lowest fare is **%[3]s** or less.\nThe same fare is reported at most once every %[5]d hours; a new low is reported right away.\nWatch ID: `%[6]s`",
+		"watch.kind_repeat":   "ongoing",
+		"watch.kind_once":     "one-time",
+		"watch.dest_channel":  "channel",
+		"watch.dest_dm":       "DM",
+		"watch.last_notified": " | last notified %s (%s)",
+		"watch.list_failed":   "❌ Failed to load your watches",
+		"watch.empty":         "📭 You're not watching any routes yet. Use `%swatch` to start.",
+		"watch.list_title":    "👀 **Your watched routes** (%d/%d)\n",
+		"watch.list_footer":   "\n\nUse `%sunwatch <number or watch ID>` to stop watching",
+		"watch.no_index":      "⚠️ There is no watch #%d, use `%swatches` to see the list",
+		"watch.not_owned":     "⚠️ That watch doesn't exist or isn't yours",
+		"watch.removed":       "🗑️ Stopped watching `%s`",
+
+		// router.* 的中文由 commands 套件提供
+		"router.unknown":    "❓ Unknown command `%[1]s%[2]s`, type `%[1]shelp` to see all commands",
+		"router.usage":      "Usage: `%s`",
+		"router.example":    "Example: `%s`",
+		"router.aliases":    " (also %s)",
+		"router.too_many":   "Too many arguments: %s",
+		"router.missing":    "Missing argument %[1]s",
+		"router.not_number": "%s must be a number",
+		"router.range":      "%s must be between %s and %s",
+		"router.range_min":  "%s must be at least %s",
+		"router.range_max":  "%s must be at most %s",
+		"router.not_bool":   "%s must be true or false",
+		"router.choices":    "%s must be one of %s",
+	},
+}
+
+// botText 取得語系文字，有參數時以 fmt.Sprintf 帶入
+func botText(lang, key string, a ...interface{}) string {
+	msg, ok := botTexts[lang][key]
+	if !ok {
+		msg = botTexts[AdviceLangZH][key]
+	}
+	if len(a) > 0 {
+		return fmt.Sprintf(msg, a...)
+	}
+	return msg
+}
+
+// botError 可翻譯的錯誤，Router 的用法提示與 errorText 會依語系顯示
+func botError(key string, a ...interface{}) error {
+	return commands.Errorf(key, botTexts[AdviceLangZH][key], a...)
+}
+
+// errorText 依語系顯示 botError 產生的錯誤，其他錯誤原樣顯示
+func errorText(lang string, err error) string {
+	var e *commands.Error
+	if errors.As(err, &e) {
+		if format, ok := botTexts[lang][e.Key]; ok {
+			return fmt.Sprintf(format, e.Args...)
+		}
+	}
+	return err.Error()
+}
+
+// translate 供 Router.Translate 使用：依指令呼叫者的語系翻譯用法提示與指令說明
+func (b *TravelBot) translate(ctx *commands.Context, key string) string {
+	return botTexts[b.settingsFor(ctx).Lang][key]
+}
+
+// priceAdviceText 價格建議；analyzePriceHistory 產生的是中文，英文依趨勢重新描述
+func priceAdviceText(a *models.PriceAdvice, lang string) string {
+	if lang != AdviceLangEN {
+		return a.Advice
+	}
+	switch {
+	case a.Trend == "new":
+		return "This is the first time we've tracked this date — keep an eye on it."
+	case a.Trend == "down" && !a.HistoryLow.IsZero() && a.CurrentLowest.Float64() <= a.HistoryLow.Float64():
+		return "🔥 All-time low! Strongly recommend booking now."
+	case a.Trend == "down":
+		return "💰 Big drop! More than 10% below average — a good time to buy."
+	case a.Trend == "up":
+		return "📈 Pricier than usual. More than 10% above average; wait if you can."
+	case a.Trend == "stable":
+		return "⚖️ Steady. The price is within the usual range."
+	}
+	return a.Advice
+}
+
+// riskLevelLabel 天氣延誤風險等級
+func riskLevelLabel(level, lang string) string {
+	if lang != AdviceLangEN {
+		return RiskLevelLabel(level)
+	}
+	switch level {
+	case models.RiskLevelSevere:
+		return "🔴 Severe"
+	case models.RiskLevelHigh:
+		return "🟠 High"
+	case models.RiskLevelModerate:
+		return "🟡 Moderate"
+	}
+	return "🟢 Low"
+}
+
+// uvLevelLabel 紫外線指數等級
+func uvLevelLabel(uv float64, lang string) string {
+	if lang != AdviceLangEN {
+		return models.UVLevel(uv)
+	}
+	switch {
+	case uv >= 11:
+		return "Extreme"
+	case uv >= 8:
+		return "Very high"
+	case uv >= 6:
+		return "High"
+	case uv >= 3:
+		return "Moderate"
+	}
+	return "Low"
+}
+
+// aqiLevelLabel 美國 EPA 空氣品質指標等級
+func aqiLevelLabel(index int, lang string) string {
+	if lang != AdviceLangEN {
+		return models.AQILevel(index)
+	}
+	switch index {
+	case 1:
+		return "Good"
+	case 2:
+		return "Moderate"
+	case 3:
+		return "Unhealthy for sensitive groups"
+	case 4:
+		return "Unhealthy"
+	case 5:
+		return "Very unhealthy"
+	case 6:
+		return "Hazardous"
+	}
+	return "Unknown"
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"final/commands"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestSettings(t *testing.T) *BotSettingsStore {
+	s := NewBotSettingsStore()
+	s.path = filepath.Join(t.TempDir(), "discord_settings.json")
+	return s
+}
+
+func TestBotSettingsStore_Resolve(t *testing.T) {
+	s := newTestSettings(t)
+	for _, set := range []struct{ scope, id, key, value string }{
+		{settingScopeServer, "g1", settingCurrency, "jpy"},
+		{settingScopeServer, "g1", settingHome, "kix"},
+		{settingScopeUser, "u1", settingCurrency, "USD"},
+		{settingScopeUser, "u1", settingLang, "en"},
+	} {
+		if _, err := s.Set(set.scope, set.id, set.key, set.value); err != nil {
+			t.Fatalf("Set(%+v) 失敗: %v", set, err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		guild, user string
+		want        BotSettings
+	}{
+		{"沒有任何設定", "g2", "u2", BotSettings{Currency: "TWD", Lang: AdviceLangZH}},
+		{"伺服器設定", "g1", "u2", BotSettings{Currency: "JPY", Home: "KIX", Lang: AdviceLangZH}},
+		{"個人設定優先", "g1", "u1", BotSettings{Currency: "USD", Home: "KIX", Lang: AdviceLangEN}},
+		{"私訊不套用伺服器設定", "", "u1", BotSettings{Currency: "USD", Lang: AdviceLangEN}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Resolve(tt.guild, tt.user); got != tt.want {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// reset 清除個人設定後回到伺服器設定；全部清除時移除該使用者
+	s.Set(settingScopeUser, "u1", settingCurrency, "reset")
+	s.Set(settingScopeUser, "u1", settingLang, "reset")
+	if got := s.Resolve("g1", "u1"); got.Currency != "JPY" || got.Lang != AdviceLangZH {
+		t.Errorf("reset 後應沿用伺服器設定: %+v", got)
+	}
+	if got := s.Get(settingScopeUser, "u1"); !got.isEmpty() {
+		t.Errorf("全部 reset 後個人設定應為空: %+v", got)
+	}
+}
+
+func TestNormalizeSetting(t *testing.T) {
+	tests := []struct {
+		key, value string
+		want       string
+		wantErr    bool
+	}{
+		{settingCurrency, "usd", "USD", false},
+		{settingCurrency, "XYZ", "", true},
+		{settingHome, " tpe ", "TPE", false},
+		{settingHome, "Taipei", "", true},
+		{settingHome, "T1E", "", true},
+		{settingLang, "EN", AdviceLangEN, false},
+		{settingLang, "zh", AdviceLangZH, false},
+		{settingLang, "fr", "", true},
+		{settingLang, "Reset", "", false},
+		{"timezone", "UTC", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			got, err := normalizeSetting(tt.key, tt.value)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("normalizeSetting() = %q, %v; want %q, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPriceCommand_ParseText(t *testing.T) {
+	tests := []struct {
+		name               string
+		args               []string
+		origin, dest, date string
+		wantErr            bool
+	}{
+		{"出發 抵達 日期", []string{"TPE", "NRT", "2026-03-01"}, "TPE", "NRT", "2026-03-01", false},
+		{"使用預設出發機場", []string{"NRT", "2026-03-01"}, "", "NRT", "2026-03-01", false},
+		{"與斜線指令同順序", []string{"NRT", "2026-03-01", "TPE"}, "TPE", "NRT", "2026-03-01", false},
+		{"name=value", []string{"origin=KHH", "NRT", "date=2026-03-01"}, "KHH", "NRT", "2026-03-01", false},
+		{"日期格式錯誤仍依位置對應", []string{"TPE", "NRT", "03/01"}, "TPE", "NRT", "03/01", false},
+		{"缺少日期", []string{"NRT"}, "", "", "", true},
+		{"參數太多", []string{"TPE", "NRT", "KIX", "2026-03-01"}, "", "", "", true},
+		{"未知選項", []string{"NRT", "2026-03-01", "adults=2"}, "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := parseTextCommand("price", tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (args.String("origin") != tt.origin || args.String("destination") != tt.dest || args.String("date") != tt.date) {
+				t.Errorf("解析結果錯誤: %+v", args)
+			}
+		})
+	}
+}
+
+func TestSettingsCommand(t *testing.T) {
+	bot := &TravelBot{results: newResultCache(), settings: newTestSettings(t)}
+	router := NewCommandRouter(bot)
+	run := func(text string, admin bool) commands.Reply {
+		reply, _ := router.RunText(&commands.Context{Platform: discordPlatform, Prefix: "!", UserID: "u1", ChannelID: "c1", GuildID: "g1", Admin: admin}, text)
+		return reply
+	}
+
+	tests := []struct {
+		name  string
+		text  string
+		admin bool
+		want  string
+	}{
+		{"顯示目前設定", "!settings", false, "`currency`：**TWD**（預設）"},
+		{"缺少設定值", "!settings home", false, "請輸入要設定的值"},
+		{"不支援的貨幣", "!settings currency XYZ", false, "不支援的貨幣"},
+		{"非管理員不可改伺服器設定", "!settings home TPE server", false, "管理伺服器"},
+		{"管理員設定伺服器出發機場", "!settings home tpe server", true, "已更新伺服器的 `home`"},
+		{"個人貨幣", "!settings currency usd", false, "已更新你的 `currency`：**USD**"},
+		{"切換語系後以英文回覆", "!settings lang en", false, "Your `lang` is now **en**"},
+		{"設定來源", "!settings", false, "`home`: **TPE** (server)"},
+		{"使用預設出發機場並以英文回覆", "!price NRT 2026-03-01", false, "Flight search is not available"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply := run(tt.text, tt.admin)
+			if !strings.Contains(reply.Text, tt.want) {
+				t.Errorf("%s => %q, 預期包含 %q", tt.text, reply.Text, tt.want)
+			}
+		})
+	}
+
+	// 私訊沒有伺服器
+	reply, _ := router.RunText(&commands.Context{Platform: discordPlatform, Prefix: "!", UserID: "u1"}, "!settings home TPE server")
+	if !strings.Contains(reply.Text, "server channel") {
+		t.Errorf("私訊中不可變更伺服器設定: %q", reply.Text)
+	}
+}
+
+func TestPriceCard_English(t *testing.T) {
+	r := testFlightResults("up")
+	r.Lang = AdviceLangEN
+	card := priceCard(r, 0, flightSortPrice)
+	if !strings.HasPrefix(card.Footer, "Page 1/2 · Sorted by price") || !strings.Contains(card.Description, "Pricier than usual") {
+		t.Errorf("英文卡片內容錯誤: %q / %q", card.Footer, card.Description)
+	}
+	if card.Fields[0].Value != "💰 **5000 TWD** ｜ ⏱️ 10h00m ｜ Nonstop\nTPE  ➝ NRT " {
+		t.Errorf("英文航班欄位錯誤: %q", card.Fields[0].Value)
+	}
+	if rows := priceRows("abc123", r, 0, flightSortPrice); rows[0].Buttons[0].Label != "Previous" || rows[1].Menu.Placeholder != "🔔 Set a price alert at this fare" {
+		t.Errorf("英文按鈕錯誤: %+v", rows[0].Buttons[0])
+	}
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"final/commands"
+	"final/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// /watch 的通知方式：私訊建立者或發到建立指令的頻道
+const (
+	watchDeliverDM      = "dm"
+	watchDeliverChannel = "channel"
+)
+
+// watchRequest /watch 的參數，門檻以使用者設定的貨幣計（與 /price 的搜尋貨幣相同）
+type watchRequest struct {
+	Origin      string
+	Destination string
+	Date        string
+	Threshold   float64
+	Deliver     string
+}
+
+// watchTextArgs 解析文字指令 watch 的參數：出發、抵達、日期，之後可接 below、門檻金額與 dm/channel
+// 例如 `TPE NRT 2026-03-01 below 8000` 或 `TPE NRT 2026-03-01 8000 channel`
+func watchTextArgs(words []string) (commands.Args, error) {
+	args := make(commands.Args)
+	for i, name := range []string{"origin", "destination", "date"} {
+		if i < len(words) {
+			args[name] = words[i]
+		}
+	}
+	if len(words) <= 3 {
+		return args, nil
+	}
+
+	for _, word := range words[3:] {
+		switch lower := strings.ToLower(word); lower {
+		case "below", "<":
+		case watchDeliverDM, watchDeliverChannel:
+			args["deliver"] = lower
+		default:
+			args["below"] = strings.ReplaceAll(word, ",", "")
+		}
+	}
+	return args, nil
+}
+
+// watchRequestFromArgs 將已驗證的參數轉成 watchRequest
+func watchRequestFromArgs(args commands.Args) (watchRequest, error) {
+	req := watchRequest{
+		Origin:      strings.ToUpper(args.String("origin")),
+		Destination: strings.ToUpper(args.String("destination")),
+		Date:        args.String("date"),
+		Threshold:   args.Float("below"),
+		Deliver:     args.String("deliver"),
+	}
+	if req.Deliver == "" {
+		req.Deliver = watchDeliverDM
+	}
+	return req, req.validate()
+}
+
+func (r watchRequest) validate() error {
+	if len(r.Origin) != 3 || len(r.Destination) != 3 {
+		return botError("args.airport")
+	}
+	if _, err := time.Parse("2006-01-02", r.Date); err != nil {
+		return botError("args.date")
+	}
+	if r.Threshold <= 0 {
+		return botError("args.threshold")
+	}
+	if r.Deliver != watchDeliverDM && r.Deliver != watchDeliverChannel {
+		return botError("args.deliver")
+	}
+	return nil
+}
+
+// dmChannel 平台的私訊通知管道名稱，例如 discord_dm
+func dmChannel(platform string) string {
+	return platform + "_dm"
+}
+
+// watchReply 建立持續追蹤的價格警報
+func (b *TravelBot) watchReply(req watchRequest, ctx *commands.Context) string {
+	prefs := b.settingsFor(ctx)
+	if b.Amadeus == nil {
+		return botText(prefs.Lang, "price.disabled")
+	}
+	if err := req.validate(); err != nil {
+		return "⚠️ " + errorText(prefs.Lang, err)
+	}
+	if req.Date < time.Now().Format("2006-01-02") {
+		return botText(prefs.Lang, "watch.past_date")
+	}
+
+	currency := prefs.Currency
+	alert := models.PriceAlert{
+		Route:         req.Origin + "-" + req.Destination,
+		DepartureDate: req.Date,
+		TargetPrice:   models.NewMoney(req.Threshold, currency),
+		Currency:      currency,
+		Owner:         ctx.UserID,
+		Repeat:        true,
+		Channel:       dmChannel(ctx.Platform),
+		Target:        ctx.UserID,
+	}
+	where := botText(prefs.Lang, "watch.where_dm")
+	if req.Deliver == watchDeliverChannel {
+		alert.Channel, alert.Target = ctx.Platform, ctx.ChannelID
+		where = botText(prefs.Lang, "watch.where_channel")
+	}
+
+	created, err := b.Amadeus.CreatePriceAlert(alert)
+	if err != nil {
+		return botText(prefs.Lang, "watch.failed", err)
+	}
+	return botText(prefs.Lang, "watch.created",
+		created.Route, created.DepartureDate, created.TargetPrice, where, int(priceWatchCooldown.Hours()), created.ID)
+}
+
+func describeWatch(n int, a models.PriceAlert, lang string) string {
+	kind := botText(lang, "watch.kind_repeat")
+	if !a.Repeat {
+		kind = botText(lang, "watch.kind_once")
+	}
+	where := botText(lang, "watch.dest_channel")
+	if strings.HasSuffix(a.Channel, "_dm") {
+		where = botText(lang, "watch.dest_dm")
+	}
+	line := fmt.Sprintf("%d. **%s** (%s) ≤ %s ｜ %s ｜ %s", n, a.Route, a.DepartureDate, a.TargetPrice, kind, where)
+	if a.LastNotifiedAt != nil {
+		line += botText(lang, "watch.last_notified", a.LastNotifiedPrice, a.LastNotifiedAt.Format("01/02 15:04"))
+	}
+	return line + fmt.Sprintf("\n　`%s`", a.ID)
+}
+
+// watchesReply 列出使用者的追蹤與價格警報
+func (b *TravelBot) watchesReply(ctx *commands.Context) string {
+	lang := b.settingsFor(ctx).Lang
+	if b.Amadeus == nil {
+		return botText(lang, "price.disabled")
+	}
+	alerts, err := b.Amadeus.ListPriceAlertsByOwner(ctx.UserID)
+	if err != nil {
+		return botText(lang, "watch.list_failed")
+	}
+	if len(alerts) == 0 {
+		return botText(lang, "watch.empty", ctx.Prefix)
+	}
+
+	var msg strings.Builder
+	msg.WriteString(botText(lang, "watch.list_title", len(alerts), maxWatchesPerOwner))
+	for i, a := range alerts {
+		msg.WriteString("\n" + describeWatch(i+1, a, lang))
+	}
+	msg.WriteString(botText(lang, "watch.list_footer", ctx.Prefix))
+	return msg.String()
+}
+
+// unwatchReply 取消追蹤，可輸入 /watches 列出的序號或完整追蹤編號
+func (b *TravelBot) unwatchReply(ctx *commands.Context, idOrIndex string) string {
+	lang := b.settingsFor(ctx).Lang
+	if b.Amadeus == nil {
+		return botText(lang, "price.disabled")
+	}
+	id := strings.TrimSpace(idOrIndex)
+	if n, err := strconv.Atoi(id); err == nil {
+		alerts, err := b.Amadeus.ListPriceAlertsByOwner(ctx.UserID)
+		if err != nil {
+			return botText(lang, "watch.list_failed")
+		}
+		if n < 1 || n > len(alerts) {
+			return botText(lang, "watch.no_index", n, ctx.Prefix)
+		}
+		id = alerts[n-1].ID
+	}
+
+	if err := b.Amadeus.DeleteOwnedPriceAlert(ctx.UserID, id); err != nil {
+		return botText(lang, "watch.not_owned")
+	}
+	return botText(lang, "watch.removed", id)
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 價格折線圖（/trend、/history 的附件），純 Go 繪製，不依賴外部圖表服務
+const (
+	chartWidth  = 800
+	chartHeight = 400
+
+	chartLeft   = 80
+	chartRight  = chartWidth - 24
+	chartTop    = 24
+	chartBottom = chartHeight - 44
+
+	chartGridLines = 4
+	// 座標軸文字放大倍數（字型為 5x7 點陣）
+	chartTextScale = 2
+)
+
+var (
+	chartBackground = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	chartGrid       = color.RGBA{0xE5, 0xE7, 0xEB, 0xFF}
+	chartAxis       = color.RGBA{0x9C, 0xA3, 0xAF, 0xFF}
+	chartText       = color.RGBA{0x37, 0x41, 0x51, 0xFF}
+	chartLine       = color.RGBA{0x34, 0x98, 0xDB, 0xFF}
+	chartAverage    = color.RGBA{0xF3, 0x9C, 0x12, 0xFF}
+	chartMin        = color.RGBA{0x2E, 0xCC, 0x71, 0xFF}
+	chartMax        = color.RGBA{0xE7, 0x4C, 0x3C, 0xFF}
+)
+
+// chartGlyphs 座標軸用的 5x7 點陣字型，每列以低 5 位元表示（最高位在左）
+var chartGlyphs = map[rune][7]uint8{
+	'0': {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1': {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3': {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4': {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5': {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6': {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9': {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	'/': {0x01, 0x02, 0x02, 0x04, 0x08, 0x08, 0x10},
+	',': {0x00, 0x00, 0x00, 0x00, 0x06, 0x04, 0x08},
+	'.': {0x00, 0x00, 0x00, 0x00, 0x00, 0x0C, 0x0C},
+	'-': {0x00, 0x00, 0x00, 0x1F, 0x00, 0x00, 0x00},
+	' ': {},
+}
+
+// priceChart 價格折線圖；average 大於 0 時畫出平均價虛線，最低點標綠、最高點標紅
+type priceChart struct {
+	labels  []string
+	values  []float64
+	average float64
+	lo, hi  float64
+}
+
+func newPriceChart(labels []string, values []float64, average float64) *priceChart {
+	c := &priceChart{labels: labels, values: values, average: average}
+	if len(values) == 0 {
+		return c
+	}
+
+	c.lo, c.hi = values[0], values[0]
+	for _, v := range values {
+		c.lo = math.Min(c.lo, v)
+		c.hi = math.Max(c.hi, v)
+	}
+	// 上下各留 10% 空間；價格完全相同時以 5% 撐開
+	pad := (c.hi - c.lo) * 0.1
+	if pad == 0 {
+		pad = math.Max(math.Abs(c.hi)*0.05, 1)
+	}
+	c.lo, c.hi = c.lo-pad, c.hi+pad
+	if c.lo < 0 && values[indexOfMin(values)] >= 0 {
+		c.lo = 0
+	}
+	return c
+}
+
+// point 第 i 筆資料在圖上的位置
+func (c *priceChart) point(i int) image.Point {
+	x := (chartLeft + chartRight) / 2
+	if len(c.values) > 1 {
+		x = chartLeft + i*(chartRight-chartLeft)/(len(c.values)-1)
+	}
+	return image.Pt(x, c.y(c.values[i]))
+}
+
+func (c *priceChart) y(v float64) int {
+	return chartBottom - int(math.Round((v-c.lo)/(c.hi-c.lo)*float64(chartBottom-chartTop)))
+}
+
+func (c *priceChart) render() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{chartBackground}, image.Point{}, draw.Src)
+
+	// 水平格線與價格刻度
+	decimals := 0
+	if c.hi-c.lo < 10 {
+		decimals = 2
+	}
+	for k := 0; k <= chartGridLines; k++ {
+		v := c.lo + float64(k)*(c.hi-c.lo)/chartGridLines
+		y := c.y(v)
+		fillRect(img, chartLeft, y, chartRight, y+1, chartGrid)
+		label := formatChartNumber(v, decimals)
+		drawChartText(img, chartLeft-10-chartTextWidth(label), y-7*chartTextScale/2, label, chartText)
+	}
+	fillRect(img, chartLeft, chartTop, chartLeft+1, chartBottom+1, chartAxis)
+	fillRect(img, chartLeft, chartBottom, chartRight+1, chartBottom+2, chartAxis)
+
+	// 日期標籤太密時間隔顯示
+	step := 1
+	if slots := (chartRight - chartLeft) / (chartTextWidth("00/00") + 16); len(c.labels) > slots && slots > 0 {
+		step = (len(c.labels) + slots - 1) / slots
+	}
+	for i := 0; i < len(c.labels) && i < len(c.values); i += step {
+		x := c.point(i).X
+		fillRect(img, x, chartBottom+2, x+1, chartBottom+7, chartAxis)
+		drawChartText(img, x-chartTextWidth(c.labels[i])/2, chartBottom+14, c.labels[i], chartText)
+	}
+
+	if c.average > 0 {
+		y := c.y(c.average)
+		for x := chartLeft + 2; x < chartRight; x += 12 {
+			fillRect(img, x, y-1, min(x+7, chartRight), y+1, chartAverage)
+		}
+	}
+
+	for i := 1; i < len(c.values); i++ {
+		drawChartLine(img, c.point(i-1), c.point(i), chartLine)
+	}
+	for i := range c.values {
+		fillCircle(img, c.point(i), 3, chartLine)
+	}
+	if len(c.values) > 0 {
+		fillCircle(img, c.point(indexOfMax(c.values)), 6, chartMax)
+		fillCircle(img, c.point(indexOfMin(c.values)), 6, chartMin)
+	}
+	return img
+}
+
+// renderPriceChart 繪製價格折線圖並編碼為 PNG
+func renderPriceChart(labels []string, values []float64, average float64) ([]byte, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("沒有價格資料可繪製")
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, newPriceChart(labels, values, average).render()); err != nil {
+		return nil, fmt.Errorf("圖表編碼失敗: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func indexOfMin(values []float64) int {
+	idx := 0
+	for i, v := range values {
+		if v < values[idx] {
+			idx = i
+		}
+	}
+	return idx
+}
+
+func indexOfMax(values []float64) int {
+	idx := 0
+	for i, v := range values {
+		if v > values[idx] {
+			idx = i
+		}
+	}
+	return idx
+}
+
+// formatChartNumber 千分位格式的刻度，例如 12,500
+func formatChartNumber(v float64, decimals int) string {
+	s := strconv.FormatFloat(math.Abs(v), 'f', decimals, 64)
+	intPart, frac, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	if v < 0 && s != strconv.FormatFloat(0, 'f', decimals, 64) {
+		b.WriteByte('-')
+	}
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	if frac != "" {
+		b.WriteString("." + frac)
+	}
+	return b.String()
+}
+
+func chartTextWidth(s string) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	return (n*6 - 1) * chartTextScale
+}
+
+// drawChartText 以點陣字型繪製文字，(x, y) 為左上角；字型沒有的字元留白
+func drawChartText(img *image.RGBA, x, y int, s string, c color.RGBA) {
+	for _, r := range s {
+		glyph := chartGlyphs[r]
+		for row, bits := range glyph {
+			for col := 0; col < 5; col++ {
+				if bits&(1<<(4-col)) != 0 {
+					px, py := x+col*chartTextScale, y+row*chartTextScale
+					fillRect(img, px, py, px+chartTextScale, py+chartTextScale, c)
+				}
+			}
+		}
+		x += 6 * chartTextScale
+	}
+}
+
+// drawChartLine 以 Bresenham 演算法畫 3px 寬的線段
+func drawChartLine(img *image.RGBA, from, to image.Point, c color.RGBA) {
+	dx, dy := abs(to.X-from.X), -abs(to.Y-from.Y)
+	sx, sy := 1, 1
+	if from.X > to.X {
+		sx = -1
+	}
+	if from.Y > to.Y {
+		sy = -1
+	}
+	err := dx + dy
+	for p := from; ; {
+		fillRect(img, p.X-1, p.Y-1, p.X+2, p.Y+2, c)
+		if p == to {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			p.X += sx
+		}
+		if e2 <= dx {
+			err += dx
+			p.Y += sy
+		}
+	}
+}
+
+func fillCircle(img *image.RGBA, center image.Point, r int, c color.RGBA) {
+	for y := -r; y <= r; y++ {
+		for x := -r; x <= r; x++ {
+			if x*x+y*y <= r*r {
+				img.SetRGBA(center.X+x, center.Y+y, c)
+			}
+		}
+	}
+}
+
+func fillRect(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	draw.Draw(img, image.Rect(x0, y0, x1, y1), &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
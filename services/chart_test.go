@@ -0,0 +1,81 @@
+package services
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestRenderPriceChart(t *testing.T) {
+	labels := []string{"03/01", "03/08", "03/15", "03/22"}
+	values := []float64{8000, 6000, 9500, 7000}
+	data, err := renderPriceChart(labels, values, 7625)
+	if err != nil {
+		t.Fatalf("繪製失敗: %v", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("不是有效的 PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != chartWidth || b.Dy() != chartHeight {
+		t.Fatalf("圖片尺寸 = %v，預期 %dx%d", b, chartWidth, chartHeight)
+	}
+
+	chart := newPriceChart(labels, values, 7625)
+	tests := []struct {
+		name string
+		x, y int
+		want color.RGBA
+	}{
+		{"背景", 2, 2, chartBackground},
+		{"最低點", chart.point(1).X, chart.point(1).Y, chartMin},
+		{"最高點", chart.point(2).X, chart.point(2).Y, chartMax},
+		{"一般資料點", chart.point(0).X, chart.point(0).Y, chartLine},
+		{"平均虛線", chartLeft + 4, chart.y(7625), chartAverage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := color.RGBAModel.Convert(img.At(tt.x, tt.y)).(color.RGBA); got != tt.want {
+				t.Errorf("(%d, %d) 顏色 = %v，預期 %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+
+	if chart.point(2).Y >= chart.point(1).Y {
+		t.Error("價格越高應畫在越上方")
+	}
+	if _, err := renderPriceChart(nil, nil, 0); err == nil {
+		t.Error("沒有資料時應回傳錯誤")
+	}
+}
+
+func TestPriceChart_SinglePoint(t *testing.T) {
+	chart := newPriceChart([]string{"03/01"}, []float64{5000}, 5000)
+	p := chart.point(0)
+	if p.X != (chartLeft+chartRight)/2 || p.Y <= chartTop || p.Y >= chartBottom {
+		t.Errorf("單一資料點應畫在圖表中央: %v", p)
+	}
+	chart.render()
+}
+
+func TestFormatChartNumber(t *testing.T) {
+	tests := []struct {
+		v        float64
+		decimals int
+		want     string
+	}{
+		{7500, 0, "7,500"},
+		{1234567.4, 0, "1,234,567"},
+		{999, 0, "999"},
+		{245.5, 2, "245.50"},
+		{-1200, 0, "-1,200"},
+		{0, 0, "0"},
+	}
+	for _, tt := range tests {
+		if got := formatChartNumber(tt.v, tt.decimals); got != tt.want {
+			t.Errorf("formatChartNumber(%v, %d) = %q, 預期 %q", tt.v, tt.decimals, got, tt.want)
+		}
+	}
+}
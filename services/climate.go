@@ -0,0 +1,98 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"final/models"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 內嵌的城市每月氣候平均值（以 AirportCityMap 的城市名稱為 key）
+//
+//go:embed data/climate_normals.json
+var climateNormalsJSON []byte
+
+type climateRecord struct {
+	HighC     [12]float64 `json:"high_c"`
+	LowC      [12]float64 `json:"low_c"`
+	PrecipMM  [12]float64 `json:"precip_mm"`
+	RainyDays [12]int     `json:"rainy_days"`
+}
+
+var (
+	climateOnce sync.Once
+	climateData map[string]climateRecord
+)
+
+func loadClimateData() map[string]climateRecord {
+	climateOnce.Do(func() {
+		climateData = make(map[string]climateRecord)
+		var raw map[string]climateRecord
+		if err := json.Unmarshal(climateNormalsJSON, &raw); err != nil {
+			log.Printf("❌ 氣候資料解析失敗: %v", err)
+			return
+		}
+		for city, record := range raw {
+			climateData[strings.ToLower(city)] = record
+		}
+	})
+	return climateData
+}
+
+// GetClimateNormal 取得城市在指定月份（1-12）的氣候平均值
+func GetClimateNormal(city string, month int) (*models.ClimateNormal, bool) {
+	if month < 1 || month > 12 {
+		return nil, false
+	}
+	record, ok := loadClimateData()[strings.ToLower(city)]
+	if !ok {
+		return nil, false
+	}
+
+	i := month - 1
+	return &models.ClimateNormal{
+		City:      city,
+		Month:     month,
+		AvgHighC:  record.HighC[i],
+		AvgLowC:   record.LowC[i],
+		AvgTempC:  math.Round((record.HighC[i]+record.LowC[i])/2*10) / 10,
+		PrecipMM:  record.PrecipMM[i],
+		RainyDays: record.RainyDays[i],
+	}, true
+}
+
+// climateChanceOfRain 以每月降雨天數估算單日降雨機率
+func climateChanceOfRain(normal *models.ClimateNormal) int {
+	return int(math.Round(float64(normal.RainyDays) / 30 * 100))
+}
+
+// GetClimateNormalForAirport 以機場代碼（例如 NRT）查詢所在城市的氣候平均值
+func GetClimateNormalForAirport(airportCode string, month int) (*models.ClimateNormal, bool) {
+	return GetClimateNormal(models.GetCityByAirportCode(strings.ToUpper(airportCode)), month)
+}
+
+// GetClimateYear 取得城市全年 12 個月的氣候平均值
+func GetClimateYear(city string) ([]models.ClimateNormal, bool) {
+	if _, ok := loadClimateData()[strings.ToLower(city)]; !ok {
+		return nil, false
+	}
+	normals := make([]models.ClimateNormal, 0, 12)
+	for month := 1; month <= 12; month++ {
+		normal, _ := GetClimateNormal(city, month)
+		normals = append(normals, *normal)
+	}
+	return normals, true
+}
+
+// GetClimateNormalForDate 取得城市在指定日期（YYYY-MM-DD）所在月份的氣候平均值
+func GetClimateNormalForDate(city, date string) (*models.ClimateNormal, bool) {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, false
+	}
+	return GetClimateNormal(city, int(t.Month()))
+}
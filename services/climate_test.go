@@ -0,0 +1,49 @@
+package services
+
+import (
+	"final/models"
+	"testing"
+)
+
+// 每個機場對應的城市都必須有氣候資料，否則超出預報範圍時會沒有天氣資訊
+func TestClimateNormals_CoverAllAirportCities(t *testing.T) {
+	for code, city := range models.AirportCityMap {
+		if _, ok := GetClimateNormal(city, 1); !ok {
+			t.Errorf("[%s] 缺少 %s 的氣候資料", code, city)
+		}
+	}
+}
+
+func TestClimateNormals_Values(t *testing.T) {
+	tests := []struct {
+		name    string
+		airport string
+		month   int
+	}{
+		{"東京一月", "NRT", 1},
+		{"曼谷八月", "BKK", 8},
+		{"雪梨七月", "SYD", 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normal, ok := GetClimateNormalForAirport(tt.airport, tt.month)
+			if !ok {
+				t.Fatalf("[%s] 找不到氣候資料", tt.name)
+			}
+			if normal.AvgHighC < normal.AvgLowC {
+				t.Errorf("[%s] 平均高溫 %.1f 不應低於平均低溫 %.1f", tt.name, normal.AvgHighC, normal.AvgLowC)
+			}
+			if normal.RainyDays < 0 || normal.RainyDays > 31 {
+				t.Errorf("[%s] 降雨天數不合理: %d", tt.name, normal.RainyDays)
+			}
+			if chance := climateChanceOfRain(normal); chance < 0 || chance > 100 {
+				t.Errorf("[%s] 降雨機率不合理: %d", tt.name, chance)
+			}
+		})
+	}
+
+	if _, ok := GetClimateNormal("Tokyo", 13); ok {
+		t.Error("無效月份應回傳 false")
+	}
+}
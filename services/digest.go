@@ -0,0 +1,409 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"final/models"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 追蹤航線摘要 Email 的 HTML 範本
+//
+//go:embed data/digest.html
+var digestHTML string
+
+const (
+	digestSubscriptionsDB = "digest_subscriptions.json"
+
+	// 排程檢查的間隔不一定剛好對齊，提早這麼久也視為到期，避免每天往後延一小時
+	digestDueTolerance = 30 * time.Minute
+)
+
+var digestTemplate = template.Must(template.New("digest").Funcs(template.FuncMap{
+	"changeColor": func(change models.Money) string {
+		switch change.Sign() {
+		case -1:
+			return "#0e7c3a"
+		case 1:
+			return "#cf1124"
+		}
+		return "#52606d"
+	},
+	"changeLabel": digestChangeLabel,
+}).Parse(digestHTML))
+
+// DigestMailer 寄送 HTML 郵件（notifications.SMTPChannel）
+type DigestMailer interface {
+	SendHTML(to, subject, htmlBody, textBody string) error
+}
+
+// DigestService 依訂閱定期寄送追蹤航線（使用者建立的價格警報）的摘要 Email
+type DigestService struct {
+	mailer DigestMailer
+	path   string
+	mu     sync.Mutex // 保護 digest_subscriptions.json
+
+	// 以下預設使用 AmadeusService 與 WeatherService，測試時可替換
+	listRoutes    func(owner string) ([]models.PriceAlert, error)
+	lookupFare    func(alert models.PriceAlert) (models.Money, *models.PriceAdvice, error)
+	lookupWeather func(city, date string) (*models.WeatherOutlook, error)
+}
+
+func NewDigestService(amadeus *AmadeusService, weather *WeatherService, mailer DigestMailer) *DigestService {
+	d := &DigestService{
+		mailer:     mailer,
+		path:       digestSubscriptionsDB,
+		listRoutes: amadeus.ListPriceAlertsByOwner,
+		lookupFare: amadeus.fareWithHistory,
+	}
+	if weather != nil {
+		d.lookupWeather = func(city, date string) (*models.WeatherOutlook, error) {
+			return weather.GetTripOutlook(city, date, "")
+		}
+	}
+	return d
+}
+
+// fareWithHistory 查詢警報航線的最低價，並與價格歷史比較（歷史以基準貨幣計算，再換回警報幣別）
+// 摘要只是唯讀報告：查價不寫入價格歷史，也不發布歷史新低等 webhook 事件
+func (s *AmadeusService) fareWithHistory(alert models.PriceAlert) (models.Money, *models.PriceAdvice, error) {
+	origin, dest, _ := alert.RouteCodes()
+	price, err := s.lowestFare(origin, dest, alert.DepartureDate, alert.PriceCurrency(), false)
+	if err != nil {
+		return models.Money{}, nil, err
+	}
+
+	rate, err := s.convertRate(price.Currency, models.BaseCurrency)
+	if err != nil {
+		log.Printf("⚠️ 無法換算 %s 價格，摘要略過歷史比價: %v", price.Currency, err)
+		return price, nil, nil
+	}
+	advice := s.analyzePriceHistory(origin, dest, alert.DepartureDate, price.Convert(rate, models.BaseCurrency))
+	return price, s.convertAdvice(advice, price.Currency), nil
+}
+
+// ---------------------------------------------------------
+// 訂閱管理
+// ---------------------------------------------------------
+
+// loadSubscriptions 讀取所有訂閱（呼叫端需持有 mu）
+func (d *DigestService) loadSubscriptions() ([]models.DigestSubscription, error) {
+	data, err := os.ReadFile(d.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []models.DigestSubscription{}, nil
+		}
+		return nil, err
+	}
+	var subs []models.DigestSubscription
+	if err := json.Unmarshal(data, &subs); err != nil {
+		return []models.DigestSubscription{}, nil
+	}
+	return subs, nil
+}
+
+func (d *DigestService) saveSubscriptions(subs []models.DigestSubscription) error {
+	data, err := json.MarshalIndent(subs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(d.path, data, 0644)
+}
+
+// Subscribe 建立或更新使用者的摘要設定（每位使用者一筆），保留上次寄送的價格
+func (d *DigestService) Subscribe(userID, email, frequency string) (*models.DigestSubscription, error) {
+	email = strings.TrimSpace(email)
+	if userID == "" {
+		return nil, fmt.Errorf("缺少使用者 ID")
+	}
+	if !strings.Contains(email, "@") || strings.ContainsAny(email, "\r\n ") {
+		return nil, fmt.Errorf("無效的 Email: %s", email)
+	}
+	if frequency == "" {
+		frequency = models.DigestDaily
+	}
+	if frequency != models.DigestDaily && frequency != models.DigestWeekly {
+		return nil, fmt.Errorf("frequency 只能是 daily 或 weekly")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	subs, err := d.loadSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		if subs[i].UserID == userID {
+			subs[i].Email = email
+			subs[i].Frequency = frequency
+			sub := subs[i]
+			return &sub, d.saveSubscriptions(subs)
+		}
+	}
+
+	sub := models.DigestSubscription{UserID: userID, Email: email, Frequency: frequency, CreatedAt: time.Now()}
+	subs = append(subs, sub)
+	if err := d.saveSubscriptions(subs); err != nil {
+		return nil, err
+	}
+	log.Printf("📧 %s 已訂閱%s摘要: %s", userID, frequencyLabel(frequency), email)
+	return &sub, nil
+}
+
+// Subscription 取得使用者的摘要設定
+func (d *DigestService) Subscription(userID string) (*models.DigestSubscription, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	subs, err := d.loadSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range subs {
+		if s.UserID == userID {
+			return &s, nil
+		}
+	}
+	return nil, fmt.Errorf("找不到摘要訂閱: %s", userID)
+}
+
+// Unsubscribe 取消使用者的摘要
+func (d *DigestService) Unsubscribe(userID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	subs, err := d.loadSubscriptions()
+	if err != nil {
+		return err
+	}
+	for i, s := range subs {
+		if s.UserID == userID {
+			return d.saveSubscriptions(append(subs[:i], subs[i+1:]...))
+		}
+	}
+	return fmt.Errorf("找不到摘要訂閱: %s", userID)
+}
+
+// ---------------------------------------------------------
+// 產生與寄送
+// ---------------------------------------------------------
+
+// Build 產生摘要內容：每條追蹤航線的目前最低價、與上次摘要的差異、歷史最低/平均與出發日天氣
+func (d *DigestService) Build(sub models.DigestSubscription) (*models.Digest, error) {
+	alerts, err := d.listRoutes(sub.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := &models.Digest{
+		UserID:      sub.UserID,
+		Email:       sub.Email,
+		Frequency:   sub.Frequency,
+		GeneratedAt: time.Now(),
+		Routes:      []models.DigestRoute{},
+	}
+	for _, alert := range alerts {
+		alert.NormalizeCurrency()
+		_, dest, _ := alert.RouteCodes()
+		route := models.DigestRoute{
+			AlertID:       alert.ID,
+			Route:         alert.Route,
+			Destination:   dest,
+			City:          models.GetCityByAirportCode(dest),
+			DepartureDate: alert.DepartureDate,
+			TargetPrice:   alert.TargetPrice,
+		}
+
+		price, advice, err := d.lookupFare(alert)
+		if err == nil {
+			route.BelowTarget, err = alert.IsTriggeredBy(price)
+		}
+		if err != nil {
+			log.Printf("⚠️ 摘要查詢 %s 價格失敗: %v", alert.Route, err)
+			route.Error = "暫時查不到價格，下次摘要再試"
+		} else {
+			route.CurrentPrice = price
+			route.Advice = advice
+			if prev, ok := sub.LastPrices[alert.ID]; ok && !prev.IsZero() {
+				// JSON 只保存數字，幣別與警報相同
+				route.PreviousPrice = prev.WithCurrency(price.Currency)
+				if change, err := price.Sub(route.PreviousPrice); err == nil {
+					route.Change = change
+					route.ChangePercent = change.Float64() / route.PreviousPrice.Float64() * 100
+				}
+			}
+		}
+
+		if d.lookupWeather != nil {
+			if outlook, err := d.lookupWeather(route.City, alert.DepartureDate); err == nil {
+				route.Weather = outlook
+			}
+		}
+		digest.Routes = append(digest.Routes, route)
+	}
+	return digest, nil
+}
+
+// Render 產生主旨、HTML 與純文字內容
+func (d *DigestService) Render(digest *models.Digest) (subject, htmlBody, textBody string, err error) {
+	subject = fmt.Sprintf("✈️ GoSkyAlert %s摘要：%d 條追蹤航線", frequencyLabel(digest.Frequency), len(digest.Routes))
+
+	var buf bytes.Buffer
+	err = digestTemplate.Execute(&buf, map[string]interface{}{
+		"Subject":     subject,
+		"PeriodLabel": frequencyLabel(digest.Frequency) + "摘要",
+		"Digest":      digest,
+	})
+	if err != nil {
+		return "", "", "", fmt.Errorf("產生摘要失敗: %v", err)
+	}
+	return subject, buf.String(), digestText(digest), nil
+}
+
+// digestText 純文字版本
+func digestText(digest *models.Digest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "GoSkyAlert %s摘要（%s）\n", frequencyLabel(digest.Frequency), digest.GeneratedAt.Format("2006-01-02 15:04"))
+	if len(digest.Routes) == 0 {
+		b.WriteString("\n目前沒有追蹤中的航線，可在 Discord 或 Telegram 使用 /watch 開始追蹤。\n")
+	}
+	for _, r := range digest.Routes {
+		fmt.Fprintf(&b, "\n%s（%s 出發）\n", r.Route, r.DepartureDate)
+		if r.Error != "" {
+			fmt.Fprintf(&b, "  %s\n", r.Error)
+		} else {
+			fmt.Fprintf(&b, "  目前最低 %s", r.CurrentPrice)
+			if !r.PreviousPrice.IsZero() {
+				fmt.Fprintf(&b, " %s", digestChangeLabel(r.Change, r.ChangePercent))
+			}
+			fmt.Fprintf(&b, "｜目標 %s\n", r.TargetPrice)
+			if r.Advice != nil && !r.Advice.HistoryLow.IsZero() {
+				fmt.Fprintf(&b, "  歷史最低 %s｜平均 %s\n", r.Advice.HistoryLow, r.Advice.HistoryAvg)
+			}
+		}
+		if r.Weather != nil && len(r.Weather.Days) > 0 {
+			day := r.Weather.Days[0]
+			fmt.Fprintf(&b, "  %s 天氣：%s %.0f–%.0f°C，降雨機率 %d%%\n", r.City, day.Condition, day.MinTemp, day.MaxTemp, day.ChanceOfRain)
+		}
+	}
+	return b.String()
+}
+
+// digestChangeLabel 例如「▼ 500 TWD (-6.3%)」
+func digestChangeLabel(change models.Money, percent float64) string {
+	switch change.Sign() {
+	case -1:
+		return fmt.Sprintf("▼ %s (%.1f%%)", models.NewMoney(-change.Float64(), change.Currency), percent)
+	case 1:
+		return fmt.Sprintf("▲ %s (+%.1f%%)", change, percent)
+	}
+	return "持平"
+}
+
+func frequencyLabel(frequency string) string {
+	if frequency == models.DigestWeekly {
+		return "每週"
+	}
+	return "每日"
+}
+
+// Send 立即寄送使用者的摘要，並記錄這次的價格供下次比較
+func (d *DigestService) Send(userID string) (*models.Digest, error) {
+	sub, err := d.Subscription(userID)
+	if err != nil {
+		return nil, err
+	}
+	return d.send(*sub, time.Now())
+}
+
+func (d *DigestService) send(sub models.DigestSubscription, now time.Time) (*models.Digest, error) {
+	if d.mailer == nil {
+		return nil, fmt.Errorf("未設定 SMTP，無法寄送摘要")
+	}
+	digest, err := d.Build(sub)
+	if err != nil {
+		return nil, err
+	}
+	subject, htmlBody, textBody, err := d.Render(digest)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.mailer.SendHTML(sub.Email, subject, htmlBody, textBody); err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]models.Money)
+	for _, r := range digest.Routes {
+		if !r.CurrentPrice.IsZero() {
+			prices[r.AlertID] = r.CurrentPrice
+		} else if prev, ok := sub.LastPrices[r.AlertID]; ok {
+			// 這次查不到價格時保留上次的，下次仍可比較
+			prices[r.AlertID] = prev
+		}
+	}
+	d.markSent(sub.UserID, now, prices)
+	return digest, nil
+}
+
+// markSent 記錄寄送時間與價格；重新讀檔以免覆寫寄送期間的設定變更
+func (d *DigestService) markSent(userID string, now time.Time, prices map[string]models.Money) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	subs, err := d.loadSubscriptions()
+	if err != nil {
+		log.Printf("⚠️ 讀取摘要訂閱失敗: %v", err)
+		return
+	}
+	for i := range subs {
+		if subs[i].UserID == userID {
+			subs[i].LastSentAt = &now
+			subs[i].LastPrices = prices
+		}
+	}
+	if err := d.saveSubscriptions(subs); err != nil {
+		log.Printf("⚠️ 儲存摘要訂閱失敗: %v", err)
+	}
+}
+
+// SendDue 寄送所有到期的摘要
+func (d *DigestService) SendDue(now time.Time) {
+	d.mu.Lock()
+	subs, err := d.loadSubscriptions()
+	d.mu.Unlock()
+	if err != nil {
+		log.Printf("⚠️ 讀取摘要訂閱失敗: %v", err)
+		return
+	}
+
+	for _, sub := range subs {
+		if !sub.IsDue(now, digestDueTolerance) {
+			continue
+		}
+		digest, err := d.send(sub, now)
+		if err != nil {
+			log.Printf("⚠️ 寄送 %s 的摘要失敗: %v", sub.UserID, err)
+			continue
+		}
+		log.Printf("📧 已寄送 %s 的%s摘要（%d 條航線）", sub.UserID, frequencyLabel(sub.Frequency), len(digest.Routes))
+	}
+}
+
+// StartDigestScheduler 在背景定期寄送到期的摘要
+func (d *DigestService) StartDigestScheduler(interval time.Duration) {
+	go func() {
+		for {
+			d.SendDue(time.Now())
+			time.Sleep(interval)
+		}
+	}()
+	log.Printf("📧 摘要排程已啟動 (每 %s 檢查)", interval)
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"errors"
+	"final/config"
+	"final/models"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeMailer struct {
+	to, subject, html, text string
+	sent                    int
+	err                     error
+}
+
+func (m *fakeMailer) SendHTML(to, subject, htmlBody, textBody string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.to, m.subject, m.html, m.text = to, subject, htmlBody, textBody
+	m.sent++
+	return nil
+}
+
+// newTestDigest 以固定的警報、票價與天氣建立摘要服務
+func newTestDigest(t *testing.T, mailer DigestMailer, fares map[string]models.Money) *DigestService {
+	return &DigestService{
+		mailer: mailer,
+		path:   filepath.Join(t.TempDir(), "digest_subscriptions.json"),
+		listRoutes: func(owner string) ([]models.PriceAlert, error) {
+			return []models.PriceAlert{
+				{ID: "a1", Route: "TPE-NRT", DepartureDate: "2026-03-01", TargetPrice: models.NewMoney(8000, "TWD"), Currency: "TWD", Owner: owner},
+				{ID: "a2", Route: "TPE-KIX", DepartureDate: "2026-04-01", TargetPrice: models.NewMoney(6000, "TWD"), Currency: "TWD", Owner: owner},
+			}, nil
+		},
+		lookupFare: func(alert models.PriceAlert) (models.Money, *models.PriceAdvice, error) {
+			price, ok := fares[alert.ID]
+			if !ok {
+				return models.Money{}, nil, errors.New("查無航班")
+			}
+			return price, &models.PriceAdvice{
+				HistoryLow:  models.NewMoney(7000, "TWD"),
+				HistoryAvg:  models.NewMoney(9000, "TWD"),
+				HistoryHigh: models.NewMoney(11000, "TWD"),
+				Advice:      "低於平均，建議購買",
+			}, nil
+		},
+		lookupWeather: func(city, date string) (*models.WeatherOutlook, error) {
+			return &models.WeatherOutlook{City: city, Days: []models.DailyOutlook{
+				{Date: date, Source: "climate", Condition: "晴時多雲", MinTemp: 3, MaxTemp: 11, ChanceOfRain: 20},
+			}}, nil
+		},
+	}
+}
+
+func TestDigestSubscription_IsDue(t *testing.T) {
+	now := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time { t := now.Add(-d); return &t }
+
+	tests := []struct {
+		name string
+		sub  models.DigestSubscription
+		want bool
+	}{
+		{"從未寄送", models.DigestSubscription{Frequency: models.DigestDaily}, true},
+		{"每日-剛滿一天", models.DigestSubscription{Frequency: models.DigestDaily, LastSentAt: ago(24 * time.Hour)}, true},
+		{"每日-排程提早 20 分鐘", models.DigestSubscription{Frequency: models.DigestDaily, LastSentAt: ago(24*time.Hour - 20*time.Minute)}, true},
+		{"每日-才過半天", models.DigestSubscription{Frequency: models.DigestDaily, LastSentAt: ago(12 * time.Hour)}, false},
+		{"每週-才過三天", models.DigestSubscription{Frequency: models.DigestWeekly, LastSentAt: ago(72 * time.Hour)}, false},
+		{"每週-滿七天", models.DigestSubscription{Frequency: models.DigestWeekly, LastSentAt: ago(7 * 24 * time.Hour)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sub.IsDue(now, digestDueTolerance); got != tt.want {
+				t.Errorf("IsDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestSubscribe(t *testing.T) {
+	tests := []struct {
+		name, userID, email, frequency string
+		wantErr                        bool
+	}{
+		{"預設每日", "42", "me@example.com", "", false},
+		{"更新為每週", "42", "new@example.com", models.DigestWeekly, false},
+		{"缺少使用者", "", "me@example.com", "", true},
+		{"無效的 Email", "43", "not-an-email", "", true},
+		{"Email 含換行", "43", "me@example.com\r\nBcc: x@example.com", "", true},
+		{"不支援的頻率", "43", "me@example.com", "hourly", true},
+	}
+
+	d := newTestDigest(t, nil, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := d.Subscribe(tt.userID, tt.email, tt.frequency)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	sub, err := d.Subscription("42")
+	if err != nil || sub.Email != "new@example.com" || sub.Frequency != models.DigestWeekly {
+		t.Fatalf("重複訂閱應更新同一筆: %v %+v", err, sub)
+	}
+	if err := d.Unsubscribe("42"); err != nil {
+		t.Fatalf("取消失敗: %v", err)
+	}
+	if _, err := d.Subscription("42"); err == nil {
+		t.Error("取消後應查不到訂閱")
+	}
+	if err := d.Unsubscribe("42"); err == nil {
+		t.Error("取消不存在的訂閱應回傳錯誤")
+	}
+}
+
+func TestDigestSendDue(t *testing.T) {
+	mailer := &fakeMailer{}
+	fares := map[string]models.Money{"a1": models.NewMoney(8000, "TWD")}
+	d := newTestDigest(t, mailer, fares)
+	if _, err := d.Subscribe("42", "me@example.com", models.DigestDaily); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	d.SendDue(now)
+	if mailer.sent != 1 || mailer.to != "me@example.com" {
+		t.Fatalf("第一次應寄出摘要: %+v", mailer)
+	}
+	for _, want := range []string{"TPE-NRT", "8000 TWD", "首次摘要", "歷史最低 7000 TWD", "晴時多雲", "暫時查不到價格"} {
+		if !strings.Contains(mailer.html, want) {
+			t.Errorf("HTML 缺少 %q", want)
+		}
+	}
+	if strings.Contains(mailer.html, "ZgotmplZ") {
+		t.Error("HTML 範本輸出被 html/template 過濾（ZgotmplZ）")
+	}
+
+	// 尚未到期不應重寄
+	d.SendDue(now.Add(time.Hour))
+	if mailer.sent != 1 {
+		t.Fatalf("未到期不應寄送，實際寄出 %d 封", mailer.sent)
+	}
+
+	// 隔天價格下降，應顯示與上次摘要的差異
+	fares["a1"] = models.NewMoney(7500, "TWD")
+	fares["a2"] = models.NewMoney(5800, "TWD")
+	d.SendDue(now.Add(24 * time.Hour))
+	if mailer.sent != 2 {
+		t.Fatalf("到期應再次寄送，實際寄出 %d 封", mailer.sent)
+	}
+	for _, want := range []string{"▼ 500 TWD (-6.2%)", "已低於目標", "#0e7c3a"} {
+		if !strings.Contains(mailer.html, want) {
+			t.Errorf("HTML 缺少 %q", want)
+		}
+	}
+	if !strings.Contains(mailer.text, "目前最低 7500 TWD ▼ 500 TWD (-6.2%)") {
+		t.Errorf("純文字版本缺少價格變化:\n%s", mailer.text)
+	}
+
+	sub, _ := d.Subscription("42")
+	if sub.LastSentAt == nil || sub.LastPrices["a1"].Float64() != 7500 || sub.LastPrices["a2"].Float64() != 5800 {
+		t.Errorf("應記錄這次寄送的價格: %+v", sub)
+	}
+
+	// 寄送失敗不應更新上次價格
+	mailer.err = errors.New("連線逾時")
+	fares["a1"] = models.NewMoney(9000, "TWD")
+	if _, err := d.Send("42"); err == nil {
+		t.Error("寄送失敗應回傳錯誤")
+	}
+	if sub, _ := d.Subscription("42"); sub.LastPrices["a1"].Float64() != 7500 {
+		t.Errorf("寄送失敗後價格不應變動: %v", sub.LastPrices["a1"])
+	}
+}
+
+func TestDigestSend_NoMailer(t *testing.T) {
+	d := newTestDigest(t, nil, nil)
+	d.Subscribe("42", "me@example.com", "")
+	if _, err := d.Send("42"); err == nil {
+		t.Error("未設定 SMTP 時應回傳錯誤")
+	}
+	if _, err := d.Send("missing"); err == nil {
+		t.Error("沒有訂閱時應回傳錯誤")
+	}
+}
+
+func TestDigestChangeLabel(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  models.Money
+		percent float64
+		want    string
+	}{
+		{"下降", models.NewMoney(-500, "TWD"), -6.25, "▼ 500 TWD (-6.2%)"},
+		{"上漲", models.NewMoney(1200, "TWD"), 15, "▲ 1200 TWD (+15.0%)"},
+		{"持平", models.NewMoney(0, "TWD"), 0, "持平"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := digestChangeLabel(tt.change, tt.percent); got != tt.want {
+				t.Errorf("digestChangeLabel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// 摘要查價是唯讀的：不寫入 API 紀錄與價格歷史，一般搜尋才會寫入
+func TestFareWithHistory_ReadOnly(t *testing.T) {
+	t.Chdir(t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":"1","price":{"total":"7200.00","currency":"TWD"},"itineraries":[{"segments":[{"departure":{"iataCode":"TPE"},"arrival":{"iataCode":"NRT"},"carrierCode":"BR","number":"198"}]}]}]}`)
+	}))
+	defer server.Close()
+
+	s := NewAmadeusService(&config.Config{AmadeusBaseURL: server.URL})
+	s.accessToken, s.tokenExpiry = "test-token", time.Now().Add(time.Hour)
+
+	// 超過 recordedFareMaxAge 的舊紀錄：不會直接當作現價，但會用來比價
+	old := models.SearchHistoryRecord{Origin: "TPE", Destination: "NRT", DepartureDate: "2026-03-01",
+		Price: models.NewMoney(9000, "TWD"), Currency: "TWD", RecordDate: time.Now().Add(-48 * time.Hour)}
+	if err := s.saveSearchHistory(old); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(priceHistoryDB)
+
+	alert := models.PriceAlert{ID: "a1", Route: "TPE-NRT", DepartureDate: "2026-03-01", Currency: "TWD"}
+	price, advice, err := s.fareWithHistory(alert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price != models.NewMoney(7200, "TWD") || advice == nil || advice.HistoryHigh != models.NewMoney(9000, "TWD") {
+		t.Errorf("查價結果錯誤: %v %+v", price, advice)
+	}
+	if after, _ := os.ReadFile(priceHistoryDB); string(after) != string(before) {
+		t.Error("摘要查價不應寫入價格歷史")
+	}
+	if _, err := os.Stat(historyFilePath); !os.IsNotExist(err) {
+		t.Errorf("摘要查價不應寫入 API 紀錄: %v", err)
+	}
+
+	if _, _, err := s.SearchFlights(models.SearchRequest{Origin: "TPE", Destination: "NRT", DepartureDate: "2026-03-01", Adults: 1, Currency: "TWD"}); err != nil {
+		t.Fatal(err)
+	}
+	history, _ := s.loadSearchHistory()
+	if len(history) != 2 {
+		t.Errorf("一般搜尋應寫入價格歷史，共 %d 筆", len(history))
+	}
+}
@@ -0,0 +1,411 @@
+package services
+
+import (
+	"final/commands"
+	"final/models"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Discord 自動完成最多顯示 25 個選項
+	maxAutocompleteChoices = 25
+	// 自動完成必須在 3 秒內回應，外部查詢超過這個時間就只用本地資料
+	autocompleteTimeout = 2 * time.Second
+)
+
+// discordOptionTypes 指令參數型別對應的 Discord 選項型別
+var discordOptionTypes = map[commands.ArgType]discordgo.ApplicationCommandOptionType{
+	commands.String:  discordgo.ApplicationCommandOptionString,
+	commands.Integer: discordgo.ApplicationCommandOptionInteger,
+	commands.Number:  discordgo.ApplicationCommandOptionNumber,
+	commands.Boolean: discordgo.ApplicationCommandOptionBoolean,
+}
+
+// discordCommands 依路由註冊的指令產生 Discord 斜線指令與選項（別名只用於文字指令）
+func discordCommands(router *commands.Router) []*discordgo.ApplicationCommand {
+	cmds := make([]*discordgo.ApplicationCommand, 0, len(router.Commands()))
+	for _, cmd := range router.Commands() {
+		appCmd := &discordgo.ApplicationCommand{Name: cmd.Name, Description: cmd.Description}
+		for _, arg := range cmd.Args {
+			opt := &discordgo.ApplicationCommandOption{
+				Type:         discordOptionTypes[arg.Type],
+				Name:         arg.Name,
+				Description:  arg.Description,
+				Required:     arg.Required,
+				Autocomplete: arg.Autocomplete,
+				MinValue:     arg.Min,
+			}
+			if arg.Max != nil {
+				opt.MaxValue = *arg.Max
+			}
+			for _, v := range arg.Choices {
+				opt.Choices = append(opt.Choices, &discordgo.ApplicationCommandOptionChoice{Name: v, Value: v})
+			}
+			appCmd.Options = append(appCmd.Options, opt)
+		}
+		cmds = append(cmds, appCmd)
+	}
+	return cmds
+}
+
+// registerCommands 以 bulk overwrite 註冊指令，移除舊版本中已不存在的指令
+func (s *DiscordService) registerCommands() error {
+	appID := s.Session.State.User.ID
+	commands, err := s.Session.ApplicationCommandBulkOverwrite(appID, s.GuildID, discordCommands(s.router))
+	if err != nil {
+		return err
+	}
+	scope := "全域"
+	if s.GuildID != "" {
+		scope = "伺服器 " + s.GuildID
+	}
+	log.Printf("✅ 已註冊 %d 個斜線指令（%s）", len(commands), scope)
+	return nil
+}
+
+func (s *DiscordService) handleInteraction(sess *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		s.handleCommand(sess, i)
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		s.handleAutocomplete(sess, i)
+	case discordgo.InteractionMessageComponent:
+		s.handleComponent(sess, i)
+	}
+}
+
+// interactionUserID 伺服器內的互動帶 Member，私訊則帶 User
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
+// optionArgs 將斜線指令的選項轉成指令參數；數字一律以不含指數的十進位字串保存
+func optionArgs(options []*discordgo.ApplicationCommandInteractionDataOption) commands.Args {
+	args := make(commands.Args, len(options))
+	for _, opt := range options {
+		switch v := opt.Value.(type) {
+		case string:
+			args[opt.Name] = strings.TrimSpace(v)
+		case float64:
+			args[opt.Name] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			args[opt.Name] = strconv.FormatBool(v)
+		default:
+			args[opt.Name] = fmt.Sprint(v)
+		}
+	}
+	return args
+}
+
+func interactionData(reply commands.Reply) *discordgo.InteractionResponseData {
+	data := &discordgo.InteractionResponseData{
+		Content:    truncateMessage(reply.Text),
+		Embeds:     discordEmbeds(reply.Cards),
+		Components: discordComponents(reply.Rows),
+		Files:      discordFiles(reply.Files),
+	}
+	if reply.Ephemeral {
+		data.Flags = discordgo.MessageFlagsEphemeral
+	}
+	return data
+}
+
+// respond 立即回覆（用於不需要外部查詢的指令）
+func respond(sess *discordgo.Session, i *discordgo.InteractionCreate, reply commands.Reply) {
+	err := sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: interactionData(reply),
+	})
+	if err != nil {
+		log.Printf("❌ 回覆斜線指令失敗: %v", err)
+	}
+}
+
+// respondDeferred 先回覆「思考中」，再於查詢完成後編輯訊息，避免超過 3 秒的回應期限
+func respondDeferred(sess *discordgo.Session, i *discordgo.InteractionCreate, build func() commands.Reply) {
+	err := sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("❌ 延遲回覆斜線指令失敗: %v", err)
+		return
+	}
+	editResponse(sess, i, build())
+}
+
+// editResponse 以回覆內容取代互動的原始回覆（延遲回覆或按鈕所在的訊息）
+func editResponse(sess *discordgo.Session, i *discordgo.InteractionCreate, reply commands.Reply) {
+	msg := discordMessage(reply)
+	edit := &discordgo.WebhookEdit{Content: &msg.Content, Embeds: &msg.Embeds, Components: &msg.Components, Files: msg.Files}
+	if _, err := sess.InteractionResponseEdit(i.Interaction, edit); err != nil {
+		log.Printf("❌ 更新互動回覆失敗: %v", err)
+	}
+}
+
+func (s *DiscordService) commandContext(i *discordgo.InteractionCreate) *commands.Context {
+	ctx := &commands.Context{Platform: discordPlatform, Prefix: "/", UserID: interactionUserID(i), ChannelID: i.ChannelID, GuildID: i.GuildID}
+	// 伺服器內的互動會帶上成員在該頻道的權限
+	if i.Member != nil {
+		ctx.Admin = i.Member.Permissions&discordgo.PermissionManageGuild != 0
+	}
+	return ctx
+}
+
+func (s *DiscordService) handleCommand(sess *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	ctx := s.commandContext(i)
+	ctx.Command = data.Name
+	ctx.Args = optionArgs(data.Options)
+
+	cmd := s.router.Lookup(data.Name)
+	// 參數錯誤時立即回覆（只有自己看得到），需要外部查詢的指令才延遲回覆
+	if cmd == nil || !cmd.Slow || cmd.Validate(ctx.Args) != nil {
+		reply := s.router.Run(ctx)
+		if cmd != nil && cmd.Name == "help" && s.TextCommands {
+			reply.Text += botText(s.settingsFor(ctx).Lang, "help.text_commands", discordTextPrefix)
+		}
+		respond(sess, i, reply)
+		return
+	}
+	respondDeferred(sess, i, func() commands.Reply {
+		return s.router.Run(ctx)
+	})
+}
+
+// handleComponent 處理按鈕與選單：先確認收到互動，再依回覆更新原訊息或另發一則
+func (s *DiscordService) handleComponent(sess *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+	action := data.CustomID
+	if len(data.Values) > 0 {
+		action += ":" + data.Values[0]
+	}
+
+	err := sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		log.Printf("❌ 延遲更新訊息失敗: %v", err)
+		return
+	}
+
+	reply, ok := s.router.RunAction(s.commandContext(i), action)
+	if !ok || reply.Empty() {
+		return
+	}
+	if reply.Update {
+		editResponse(sess, i, reply)
+		return
+	}
+
+	msg := discordMessage(reply)
+	params := &discordgo.WebhookParams{Content: msg.Content, Embeds: msg.Embeds, Components: msg.Components, Files: msg.Files}
+	if reply.Ephemeral {
+		params.Flags = discordgo.MessageFlagsEphemeral
+	}
+	if _, err := sess.FollowupMessageCreate(i.Interaction, true, params); err != nil {
+		log.Printf("❌ 傳送互動訊息失敗: %v", err)
+	}
+}
+
+func (s *DiscordService) handleAutocomplete(sess *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+
+	var focused *discordgo.ApplicationCommandInteractionDataOption
+	for _, opt := range data.Options {
+		if opt.Focused {
+			focused = opt
+			break
+		}
+	}
+	if focused == nil {
+		return
+	}
+
+	query := strings.TrimSpace(focused.StringValue())
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	switch focused.Name {
+	case "origin", "destination":
+		choices = airportChoices(query, s.searchAirportsQuickly(query))
+	case "from", "to":
+		var currencies []string
+		if s.Exchange != nil {
+			currencies = s.Exchange.GetSupportedCurrencies()
+		}
+		choices = currencyChoices(query, currencies)
+	case "category", "interests":
+		choices = categoryChoices(query)
+	case "id":
+		if s.Amadeus != nil {
+			alerts, _ := s.Amadeus.ListPriceAlertsByOwner(interactionUserID(i))
+			choices = watchChoices(query, alerts)
+		}
+	case "value":
+		choices = s.settingValueChoices(query, data.Options)
+	}
+
+	err := sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	})
+	if err != nil {
+		log.Printf("❌ 自動完成回覆失敗: %v", err)
+	}
+}
+
+// searchAirportsQuickly 透過 Amadeus 查詢機場，逾時或失敗時回傳 nil 改用本地資料
+func (s *DiscordService) searchAirportsQuickly(query string) []models.Airport {
+	if s.Amadeus == nil || len([]rune(query)) < 2 {
+		return nil
+	}
+
+	result := make(chan []models.Airport, 1)
+	go func() {
+		airports, err := s.Amadeus.SearchAirports(query)
+		if err != nil {
+			log.Printf("⚠️ 機場自動完成查詢失敗: %v", err)
+		}
+		result <- airports
+	}()
+
+	select {
+	case airports := <-result:
+		return airports
+	case <-time.After(autocompleteTimeout):
+		return nil
+	}
+}
+
+// airportChoices 合併 Amadeus 結果與本地機場表，依代碼去重
+func airportChoices(query string, remote []models.Airport) []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, maxAutocompleteChoices)
+	seen := make(map[string]bool)
+	add := func(code, label string) {
+		if code == "" || seen[code] || len(choices) >= maxAutocompleteChoices {
+			return
+		}
+		seen[code] = true
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: label, Value: code})
+	}
+
+	for _, a := range remote {
+		label := a.Code
+		if a.Name != "" {
+			label = fmt.Sprintf("%s - %s", a.Code, a.Name)
+		}
+		if a.City != "" {
+			label += fmt.Sprintf(" (%s)", a.City)
+		}
+		add(a.Code, label)
+	}
+
+	codes := make([]string, 0, len(models.AirportCityMap))
+	for code := range models.AirportCityMap {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	q := strings.ToLower(query)
+	for _, code := range codes {
+		city := models.AirportCityMap[code]
+		if q == "" || strings.HasPrefix(strings.ToLower(code), q) || strings.Contains(strings.ToLower(city), q) {
+			add(code, fmt.Sprintf("%s - %s", code, city))
+		}
+	}
+	return choices
+}
+
+// currencyChoices 依輸入篩選支援的貨幣
+func currencyChoices(query string, currencies []string) []*discordgo.ApplicationCommandOptionChoice {
+	q := strings.ToUpper(query)
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, maxAutocompleteChoices)
+	for _, c := range currencies {
+		if len(choices) >= maxAutocompleteChoices {
+			break
+		}
+		if strings.HasPrefix(c, q) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: c, Value: c})
+		}
+	}
+	return choices
+}
+
+// categoryChoices 依 slug 或中英文名稱篩選景點類別；逗號分隔時只比對最後一段
+func categoryChoices(query string) []*discordgo.ApplicationCommandOptionChoice {
+	prefix := ""
+	if idx := strings.LastIndex(query, ","); idx >= 0 {
+		prefix, query = query[:idx+1], query[idx+1:]
+	}
+	q := strings.ToLower(strings.TrimSpace(query))
+
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, maxAutocompleteChoices)
+	for _, parent := range DefaultCategoryTaxonomy().Tree(AdviceLangZH) {
+		nodes := append([]CategoryNode{parent}, parent.Children...)
+		for _, node := range nodes {
+			if len(choices) >= maxAutocompleteChoices {
+				return choices
+			}
+			if q == "" || strings.Contains(node.Slug, q) || strings.Contains(strings.ToLower(node.Name), q) {
+				choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+					Name:  fmt.Sprintf("%s (%s)", node.Name, node.Slug),
+					Value: prefix + node.Slug,
+				})
+			}
+		}
+	}
+	return choices
+}
+
+// watchChoices /unwatch 的自動完成：列出使用者的追蹤
+func watchChoices(query string, alerts []models.PriceAlert) []*discordgo.ApplicationCommandOptionChoice {
+	q := strings.ToUpper(strings.TrimSpace(query))
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, maxAutocompleteChoices)
+	for _, a := range alerts {
+		if len(choices) >= maxAutocompleteChoices {
+			break
+		}
+		if q != "" && !strings.Contains(a.Route, q) && !strings.Contains(strings.ToUpper(a.ID), q) {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  fmt.Sprintf("%s %s ≤ %s", a.Route, a.DepartureDate, a.TargetPrice),
+			Value: a.ID,
+		})
+	}
+	return choices
+}
+
+// settingValueChoices /settings 的 value 依已選的 key 提示貨幣、機場或語系
+func (s *DiscordService) settingValueChoices(query string, options []*discordgo.ApplicationCommandInteractionDataOption) []*discordgo.ApplicationCommandOptionChoice {
+	key := ""
+	for _, opt := range options {
+		if opt.Name == "key" {
+			key = opt.StringValue()
+		}
+	}
+
+	switch key {
+	case settingCurrency:
+		return currencyChoices(query, supportedCurrencies)
+	case settingHome:
+		return airportChoices(query, s.searchAirportsQuickly(query))
+	case settingLang:
+		return []*discordgo.ApplicationCommandOptionChoice{
+			{Name: "中文 (zh-TW)", Value: AdviceLangZH},
+			{Name: "English (en)", Value: AdviceLangEN},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"final/commands"
+	"final/models"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDiscordCommands_Schema(t *testing.T) {
+	names := make(map[string]bool)
+	for _, cmd := range discordCommands(NewCommandRouter(&TravelBot{settings: NewBotSettingsStore()})) {
+		if names[cmd.Name] {
+			t.Errorf("指令名稱重複: %s", cmd.Name)
+		}
+		names[cmd.Name] = true
+		if cmd.Name != strings.ToLower(cmd.Name) || len([]rune(cmd.Description)) > 100 {
+			t.Errorf("指令 %s 名稱需小寫且說明不超過 100 字", cmd.Name)
+		}
+
+		// Discord 要求必填選項排在選填選項之前
+		optional := false
+		for _, opt := range cmd.Options {
+			if !opt.Required {
+				optional = true
+			} else if optional {
+				t.Errorf("指令 %s 的必填選項 %s 排在選填選項之後", cmd.Name, opt.Name)
+			}
+			if opt.Description == "" || len([]rune(opt.Description)) > 100 {
+				t.Errorf("指令 %s 的選項 %s 說明需為 1 到 100 字", cmd.Name, opt.Name)
+			}
+			if opt.Autocomplete && len(opt.Choices) > 0 {
+				t.Errorf("指令 %s 的選項 %s 不可同時有自動完成與固定選項", cmd.Name, opt.Name)
+			}
+		}
+	}
+	for _, want := range []string{"help", "price", "rate", "ratealert", "weather", "pack", "spot", "watch", "unwatch", "watches", "settings", "plan", "trend", "history"} {
+		if !names[want] {
+			t.Errorf("缺少斜線指令 %s", want)
+		}
+	}
+}
+
+func TestAutocompleteChoices(t *testing.T) {
+	remote := []models.Airport{{Code: "NRT", Name: "Narita Intl", City: "Tokyo"}}
+	choices := airportChoices("tok", remote)
+	if len(choices) < 2 || choices[0].Value != "NRT" || choices[0].Name != "NRT - Narita Intl (Tokyo)" {
+		t.Fatalf("Amadeus 結果應排在最前面: %+v", choices[0])
+	}
+	seen := make(map[interface{}]bool)
+	for _, c := range choices {
+		if seen[c.Value] {
+			t.Errorf("機場代碼重複: %v", c.Value)
+		}
+		seen[c.Value] = true
+	}
+	if !seen["HND"] {
+		t.Error("本地機場表中的 HND（Tokyo）也應列出")
+	}
+	if got := airportChoices("", nil); len(got) != maxAutocompleteChoices {
+		t.Errorf("空白輸入應回傳 %d 個選項, 實際 %d", maxAutocompleteChoices, len(got))
+	}
+
+	currencies := currencyChoices("j", []string{"TWD", "JPY", "USD"})
+	if len(currencies) != 1 || currencies[0].Value != "JPY" {
+		t.Errorf("貨幣篩選錯誤: %+v", currencies)
+	}
+
+	categories := categoryChoices("museum,公園")
+	if len(categories) == 0 || categories[0].Value != "museum,park" {
+		t.Errorf("多個類別時應保留前面已輸入的部分: %+v", categories)
+	}
+}
+
+func TestOptionArgs(t *testing.T) {
+	args := optionArgs([]*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "place", Type: discordgo.ApplicationCommandOptionString, Value: " 大阪 "},
+		{Name: "limit", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(8)},
+		{Name: "below", Type: discordgo.ApplicationCommandOptionNumber, Value: float64(1000000)},
+		{Name: "open_now", Type: discordgo.ApplicationCommandOptionBoolean, Value: true},
+	})
+	want := commands.Args{"place": "大阪", "limit": "8", "below": "1000000", "open_now": "true"}
+	for k, v := range want {
+		if args[k] != v {
+			t.Errorf("選項 %s = %q, 預期 %q", k, args[k], v)
+		}
+	}
+}
+
+func TestDiscordComponents(t *testing.T) {
+	rows := discordComponents([]commands.Row{
+		{Buttons: []commands.Button{{Label: "下一頁", Emoji: "▶️", Data: "spot:page:k:1"}, {Label: "排序", Data: "price:page:k:0:price", Primary: true}}},
+		{Menu: &commands.Menu{Data: "price:alert:k", Options: []commands.Option{{Label: "1", Value: "0"}}}},
+	})
+	buttons := rows[0].(discordgo.ActionsRow).Components
+	next, toggle := buttons[0].(discordgo.Button), buttons[1].(discordgo.Button)
+	if next.CustomID != "spot:page:k:1" || next.Style != discordgo.SecondaryButton || next.Emoji == nil || toggle.Style != discordgo.PrimaryButton || toggle.Emoji != nil {
+		t.Errorf("按鈕轉換錯誤: %+v %+v", next, toggle)
+	}
+	if menu := rows[1].(discordgo.ActionsRow).Components[0].(discordgo.SelectMenu); menu.CustomID != "price:alert:k" || len(menu.Options) != 1 {
+		t.Errorf("選單轉換錯誤: %+v", menu)
+	}
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"final/models"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 起降時間前後納入評估的小時數
+const riskWindowHours = 2
+
+// WeatherAPI 天氣代碼分類
+var (
+	thunderCodes      = map[int]bool{1087: true, 1273: true, 1276: true, 1279: true, 1282: true}
+	heavySnowCodes    = map[int]bool{1114: true, 1117: true, 1222: true, 1225: true, 1258: true}
+	freezingRainCodes = map[int]bool{1072: true, 1168: true, 1171: true, 1198: true, 1201: true, 1237: true, 1261: true, 1264: true}
+)
+
+// 單一時段的天氣條件（小時預報與目前天氣共用）
+type riskConditions struct {
+	Code         int
+	GustKph      float64
+	WindKph      float64
+	VisKM        float64
+	ChanceOfSnow int
+	WillItSnow   int
+}
+
+// riskFactors 各風險因子的分數與說明，同一因子在時間窗內只取最嚴重的一次
+type riskFactors map[string]riskFactor
+
+type riskFactor struct {
+	Score  int
+	Reason string
+}
+
+func (f riskFactors) add(key string, score int, reason string) {
+	if current, ok := f[key]; !ok || score > current.Score {
+		f[key] = riskFactor{Score: score, Reason: reason}
+	}
+}
+
+func (f riskFactors) total() (int, []string) {
+	score := 0
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	// 依分數高到低排列說明
+	sort.Slice(keys, func(i, j int) bool {
+		if f[keys[i]].Score != f[keys[j]].Score {
+			return f[keys[i]].Score > f[keys[j]].Score
+		}
+		return keys[i] < keys[j]
+	})
+
+	reasons := make([]string, 0, len(keys))
+	for _, k := range keys {
+		score += f[k].Score
+		reasons = append(reasons, f[k].Reason)
+	}
+	if score > 100 {
+		score = 100
+	}
+	return score, reasons
+}
+
+// scoreConditions 評估單一時段的陣風、能見度、雷雨與降雪
+func scoreConditions(c riskConditions, factors riskFactors) {
+	gust := math.Max(c.GustKph, c.WindKph)
+	switch {
+	case gust >= 90:
+		factors.add("wind", 40, fmt.Sprintf("強陣風 %.0f km/h", gust))
+	case gust >= 60:
+		factors.add("wind", 20, fmt.Sprintf("陣風 %.0f km/h", gust))
+	case gust >= 45:
+		factors.add("wind", 10, fmt.Sprintf("風勢偏強 %.0f km/h", gust))
+	}
+
+	// 能見度 0 通常代表缺少資料，不列入計算
+	switch {
+	case c.VisKM > 0 && c.VisKM < 1:
+		factors.add("visibility", 30, fmt.Sprintf("能見度極低 %.1f km", c.VisKM))
+	case c.VisKM > 0 && c.VisKM < 3:
+		factors.add("visibility", 15, fmt.Sprintf("能見度不佳 %.1f km", c.VisKM))
+	}
+
+	if thunderCodes[c.Code] {
+		factors.add("thunder", 35, "雷雨")
+	}
+
+	switch {
+	case heavySnowCodes[c.Code]:
+		factors.add("snow", 40, "大雪或暴風雪")
+	case freezingRainCodes[c.Code]:
+		factors.add("snow", 30, "凍雨或結冰")
+	case c.WillItSnow == 1 || c.ChanceOfSnow >= 50:
+		factors.add("snow", 25, fmt.Sprintf("降雪機率 %d%%", c.ChanceOfSnow))
+	}
+}
+
+// scoreAlerts 起降時間落在氣象警特報有效期間內時加分
+func scoreAlerts(alerts []models.WeatherAlert, at time.Time, factors riskFactors) {
+	for _, alert := range alerts {
+		if !alertActiveAt(alert, at) {
+			continue
+		}
+		score := 20
+		switch strings.ToLower(alert.Severity) {
+		case "extreme":
+			score = 45
+		case "severe":
+			score = 30
+		}
+		name := alert.Event
+		if name == "" {
+			name = alert.Headline
+		}
+		factors.add("alert", score, "氣象警報："+name)
+	}
+}
+
+// alertActiveAt 判斷警報在指定時間是否有效（時間無法解析時視為有效）
+func alertActiveAt(alert models.WeatherAlert, at time.Time) bool {
+	effective, err1 := time.Parse(time.RFC3339, alert.Effective)
+	expires, err2 := time.Parse(time.RFC3339, alert.Expires)
+	if err1 != nil || err2 != nil {
+		return true
+	}
+	// 航班時間是當地時間字串，這裡只比較到日期附近，容忍時區誤差
+	slack := 12 * time.Hour
+	return !at.Before(effective.Add(-slack)) && !at.After(expires.Add(slack))
+}
+
+// assessAirportRisk 評估機場在 localTime（YYYY-MM-DDTHH:MM:SS 當地時間）前後的天氣風險
+func assessAirportRisk(airport, localTime string, weather *models.WeatherResponse) (*models.AirportRisk, bool) {
+	at, err := time.Parse("2006-01-02T15:04:05", localTime)
+	if err != nil {
+		return nil, false
+	}
+
+	factors := riskFactors{}
+	found := false
+	for _, day := range weather.Forecast.Forecastday {
+		for _, hour := range day.Hour {
+			// WeatherAPI 的小時預報時間也是當地時間
+			t, err := time.Parse("2006-01-02 15:04", hour.Time)
+			if err != nil {
+				continue
+			}
+			if math.Abs(t.Sub(at).Hours()) > riskWindowHours {
+				continue
+			}
+			found = true
+			scoreConditions(riskConditions{
+				Code:         hour.Condition.Code,
+				GustKph:      hour.GustKph,
+				WindKph:      hour.WindKph,
+				VisKM:        hour.VisKM,
+				ChanceOfSnow: hour.ChanceOfSnow,
+				WillItSnow:   hour.WillItSnow,
+			}, factors)
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	scoreAlerts(weather.Alerts.Alert, at, factors)
+
+	score, reasons := factors.total()
+	return &models.AirportRisk{
+		Airport: airport,
+		Time:    localTime,
+		Score:   score,
+		Reasons: reasons,
+	}, true
+}
+
+// combineRisk 合併出發與抵達兩端的風險
+func combineRisk(departure, arrival *models.AirportRisk) *models.DisruptionRisk {
+	if departure == nil && arrival == nil {
+		return nil
+	}
+
+	risk := &models.DisruptionRisk{Departure: departure, Arrival: arrival}
+	if departure != nil {
+		risk.Score = departure.Score
+		for _, r := range departure.Reasons {
+			risk.Reasons = append(risk.Reasons, fmt.Sprintf("出發 %s：%s", departure.Airport, r))
+		}
+	}
+	if arrival != nil {
+		if arrival.Score > risk.Score {
+			risk.Score = arrival.Score
+		}
+		for _, r := range arrival.Reasons {
+			risk.Reasons = append(risk.Reasons, fmt.Sprintf("抵達 %s：%s", arrival.Airport, r))
+		}
+	}
+	risk.Level = models.RiskLevelFor(risk.Score)
+	return risk
+}
+
+// AttachDisruptionRisk 為每個航班加上起降時段的天氣風險評分
+// 同一機場只查詢一次預報；超出預報範圍或查詢失敗的航班不附上風險
+func (s *WeatherService) AttachDisruptionRisk(flights []models.Flight) {
+	if len(flights) == 0 {
+		return
+	}
+
+	// 找出每個機場需要涵蓋到的最晚日期
+	latest := make(map[string]string)
+	for _, f := range flights {
+		for code, ts := range map[string]string{f.From.Code: f.Departure, f.To.Code: f.Arrival} {
+			if code == "" || len(ts) < 10 {
+				continue
+			}
+			if date := ts[:10]; date > latest[code] {
+				latest[code] = date
+			}
+		}
+	}
+
+	forecasts := make(map[string]*models.WeatherResponse)
+	for code, date := range latest {
+		if s.IsBeyondForecast(date) {
+			continue
+		}
+		weather, err := s.GetForecast("iata:"+code, s.forecastDaysFor(date))
+		if err != nil {
+			log.Printf("⚠️ 無法取得 %s 機場預報: %v", code, err)
+			continue
+		}
+		forecasts[code] = weather
+	}
+
+	for i := range flights {
+		f := &flights[i]
+		var departure, arrival *models.AirportRisk
+		if weather, ok := forecasts[f.From.Code]; ok {
+			departure, _ = assessAirportRisk(f.From.Code, f.Departure, weather)
+		}
+		if weather, ok := forecasts[f.To.Code]; ok {
+			arrival, _ = assessAirportRisk(f.To.Code, f.Arrival, weather)
+		}
+		f.Risk = combineRisk(departure, arrival)
+	}
+}
+
+// RiskLevelLabel 風險等級的中文顯示
+func RiskLevelLabel(level string) string {
+	switch level {
+	case models.RiskLevelSevere:
+		return "🔴 極高"
+	case models.RiskLevelHigh:
+		return "🟠 高"
+	case models.RiskLevelModerate:
+		return "🟡 中"
+	}
+	return "🟢 低"
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"final/models"
+	"testing"
+)
+
+func hourlyForecast(time string, code int, gust, vis float64) models.HourlyForecast {
+	h := models.HourlyForecast{Time: time, GustKph: gust, VisKM: vis}
+	h.Condition.Code = code
+	return h
+}
+
+func TestAssessAirportRisk(t *testing.T) {
+	weather := &models.WeatherResponse{}
+	weather.Forecast.Forecastday = []models.ForecastDay{{
+		Date: "2026-03-01",
+		Hour: []models.HourlyForecast{
+			hourlyForecast("2026-03-01 06:00", 1000, 20, 10),
+			hourlyForecast("2026-03-01 08:00", 1087, 95, 10),  // 雷雨 + 強陣風
+			hourlyForecast("2026-03-01 09:00", 1030, 30, 0.5), // 濃霧
+			hourlyForecast("2026-03-01 18:00", 1000, 20, 10),
+		},
+	}}
+
+	tests := []struct {
+		name      string
+		localTime string
+		wantLevel string
+	}{
+		{"雷雨強風加濃霧", "2026-03-01T08:30:00", models.RiskLevelSevere},
+		{"晴朗時段", "2026-03-01T18:00:00", models.RiskLevelLow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			risk, ok := assessAirportRisk("NRT", tt.localTime, weather)
+			if !ok {
+				t.Fatalf("[%s] 應該找到小時預報", tt.name)
+			}
+			if level := models.RiskLevelFor(risk.Score); level != tt.wantLevel {
+				t.Errorf("[%s] 預期等級 %s, 結果 %s (分數 %d, 原因 %v)", tt.name, tt.wantLevel, level, risk.Score, risk.Reasons)
+			}
+		})
+	}
+
+	if _, ok := assessAirportRisk("NRT", "2026-03-05T08:00:00", weather); ok {
+		t.Error("預報範圍外的時間不應評分")
+	}
+}
+
+func TestCombineRisk_UsesWorseEnd(t *testing.T) {
+	risk := combineRisk(
+		&models.AirportRisk{Airport: "TPE", Score: 10, Reasons: []string{"風勢偏強 50 km/h"}},
+		&models.AirportRisk{Airport: "NRT", Score: 50, Reasons: []string{"大雪或暴風雪"}},
+	)
+	if risk.Score != 50 || risk.Level != models.RiskLevelHigh {
+		t.Errorf("應取較高的抵達端分數, 結果 %d %s", risk.Score, risk.Level)
+	}
+	if len(risk.Reasons) != 2 {
+		t.Errorf("應保留兩端的原因, 結果 %v", risk.Reasons)
+	}
+	if combineRisk(nil, nil) != nil {
+		t.Error("兩端都沒有資料時應回傳 nil")
+	}
+}
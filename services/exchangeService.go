@@ -2,21 +2,46 @@ package services
 
 import (
 	"encoding/json"
+	"final/models"
+	"final/notifications"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 )
 
 type ExchangeService struct {
 	APIKey  string
 	BaseURL string
+
+	rateHistoryPath string
+	rateAlertsPath  string
+	historyMutex    sync.Mutex // 保護 rate_history.json
+	alertMutex      sync.Mutex // 保護 rate_alerts.json
+	checkMutex      sync.Mutex // 避免匯率警報檢查重疊執行而重複通知
+	notifier        *notifications.Dispatcher
+
+	// 匯率每天只更新一次，短時間內重複查詢直接使用快取
+	cacheMutex sync.Mutex
+	rateCache  map[string]*cachedRates
+}
+
+type cachedRates struct {
+	response  ExchangeRateResponse
+	fetchedAt time.Time
 }
 
+// 匯率快取有效時間
+const exchangeCacheTTL = 1 * time.Hour
+
 func NewExchangeService(apiKey string) *ExchangeService {
 	return &ExchangeService{
-		APIKey:  apiKey,
-		BaseURL: "https://v6.exchangerate-api.com/v6",
+		APIKey:          apiKey,
+		BaseURL:         "https://v6.exchangerate-api.com/v6",
+		rateHistoryPath: rateHistoryDB,
+		rateAlertsPath:  rateAlertsDB,
+		rateCache:       make(map[string]*cachedRates),
 	}
 }
 
@@ -49,76 +74,102 @@ type ExchangeRateResult struct {
 
 // 獲取匯率
 func (s *ExchangeService) GetExchangeRates(baseCurrency string, targetCurrencies []string) (*ExchangeRateResult, error) {
+	apiResponse, fresh, err := s.fetchRates(baseCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	// 過濾需要的貨幣
+	rates := make(map[string]float64)
+	if len(targetCurrencies) > 0 {
+		for _, currency := range targetCurrencies {
+			if rate, exists := apiResponse.ConversionRates[currency]; exists {
+				rates[currency] = rate
+			}
+		}
+	} else {
+		// 如果沒有指定貨幣，返回所有
+		for currency, rate := range apiResponse.ConversionRates {
+			rates[currency] = rate
+		}
+	}
+
+	// 記錄本次從 API 取得的完整匯率表作為歷史走勢資料，不受呼叫端篩選的貨幣影響
+	if fresh {
+		s.recordRates(baseCurrency, apiResponse.ConversionRates)
+	}
+
+	return &ExchangeRateResult{
+		BaseCurrency: baseCurrency,
+		Rates:        rates,
+		LastUpdated:  time.Unix(apiResponse.TimeLastUpdateUnix, 0),
+		NextUpdate:   time.Unix(apiResponse.TimeNextUpdateUnix, 0),
+	}, nil
+}
+
+// fetchRates 取得 baseCurrency 的完整匯率表，fresh 表示是否為新呼叫 API 取得
+func (s *ExchangeService) fetchRates(baseCurrency string) (*ExchangeRateResponse, bool, error) {
+	s.cacheMutex.Lock()
+	if cached, ok := s.rateCache[baseCurrency]; ok && time.Since(cached.fetchedAt) < exchangeCacheTTL {
+		s.cacheMutex.Unlock()
+		return &cached.response, false, nil
+	}
+	s.cacheMutex.Unlock()
+
 	url := fmt.Sprintf("%s/%s/latest/%s", s.BaseURL, s.APIKey, baseCurrency)
 
 	client := &http.Client{Timeout: 10 * time.Second}
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("創建匯率請求失敗: %v", err)
+		return nil, false, fmt.Errorf("創建匯率請求失敗: %v", err)
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("匯率API請求失敗: %v", err)
+		return nil, false, fmt.Errorf("匯率API請求失敗: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("匯率API錯誤: %s - %s", resp.Status, string(body))
+		return nil, false, fmt.Errorf("匯率API錯誤: %s - %s", resp.Status, string(body))
 	}
 
 	var apiResponse ExchangeRateResponse
 	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
-		return nil, fmt.Errorf("解析匯率響應失敗: %v", err)
+		return nil, false, fmt.Errorf("解析匯率響應失敗: %v", err)
 	}
 
 	if apiResponse.Result != "success" {
-		return nil, fmt.Errorf("匯率API返回錯誤: %s", apiResponse.Result)
+		return nil, false, fmt.Errorf("匯率API返回錯誤: %s", apiResponse.Result)
 	}
 
-	// 過濾需要的貨幣
-	rates := make(map[string]float64)
-	if len(targetCurrencies) > 0 {
-		for _, currency := range targetCurrencies {
-			if rate, exists := apiResponse.ConversionRates[currency]; exists {
-				rates[currency] = rate
-			}
-		}
-	} else {
-		// 如果沒有指定貨幣，返回所有
-		rates = apiResponse.ConversionRates
-	}
+	s.cacheMutex.Lock()
+	s.rateCache[baseCurrency] = &cachedRates{response: apiResponse, fetchedAt: time.Now()}
+	s.cacheMutex.Unlock()
 
-	return &ExchangeRateResult{
-		BaseCurrency: baseCurrency,
-		Rates:        rates,
-		LastUpdated:  time.Unix(apiResponse.TimeLastUpdateUnix, 0),
-		NextUpdate:   time.Unix(apiResponse.TimeNextUpdateUnix, 0),
-	}, nil
+	return &apiResponse, true, nil
 }
 
-// 貨幣轉換
-func (s *ExchangeService) ConvertCurrency(amount float64, fromCurrency, toCurrency string) (float64, error) {
-	rates, err := s.GetExchangeRates(fromCurrency, []string{toCurrency})
+// 貨幣轉換，結果依目標貨幣精度四捨五入
+func (s *ExchangeService) ConvertCurrency(amount models.Money, toCurrency string) (models.Money, error) {
+	rate, err := s.GetRate(amount.Currency, toCurrency)
 	if err != nil {
-		return 0, err
+		return models.Money{}, err
 	}
 
-	rate, exists := rates.Rates[toCurrency]
-	if !exists {
-		return 0, fmt.Errorf("不支援的貨幣轉換: %s -> %s", fromCurrency, toCurrency)
-	}
+	return amount.Convert(rate, toCurrency), nil
+}
 
-	return amount * rate, nil
+// supportedCurrencies 支援的貨幣，機器人的 /settings currency 也以此驗證
+var supportedCurrencies = []string{
+	"TWD", "USD", "EUR", "JPY", "GBP", "AUD", "CAD", "CHF", "CNY", "HKD",
+	"KRW", "SGD", "THB", "VND", "MYR", "IDR", "PHP", "INR", "BRL", "RUB",
 }
 
 // 獲取支援的貨幣列表
 func (s *ExchangeService) GetSupportedCurrencies() []string {
-	return []string{
-		"TWD", "USD", "EUR", "JPY", "GBP", "AUD", "CAD", "CHF", "CNY", "HKD",
-		"KRW", "SGD", "THB", "VND", "MYR", "IDR", "PHP", "INR", "BRL", "RUB",
-	}
+	return append([]string(nil), supportedCurrencies...)
 }
 
 // 驗證 API 金鑰
@@ -0,0 +1,437 @@
+package services
+
+import (
+	"encoding/json"
+	"final/models"
+	"final/notifications"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 匯率歷史與警報的檔案路徑
+const (
+	rateHistoryDB = "rate_history.json" // 匯率歷史紀錄 (JSON Array)
+	rateAlertsDB  = "rate_alerts.json"  // 匯率警報設定 (JSON Array)
+
+	// 同一組貨幣在這段時間內不重複記錄，避免頻繁查詢灌爆歷史檔
+	minRateRecordInterval = 10 * time.Minute
+)
+
+// MaxRateSeriesDays 匯率走勢最多查詢的天數，更早的歷史紀錄在寫入時刪除
+const MaxRateSeriesDays = 365
+
+// SetNotifier 設定通知分派器，匯率警報觸發時透過 RateAlert.Channel 指定的管道通知
+func (s *ExchangeService) SetNotifier(notifier *notifications.Dispatcher) {
+	s.notifier = notifier
+}
+
+// ---------------------------------------------------------
+// 匯率歷史
+// ---------------------------------------------------------
+
+// loadRateHistory 讀取所有匯率歷史紀錄（呼叫端需持有 historyMutex）
+func (s *ExchangeService) loadRateHistory() ([]models.RateRecord, error) {
+	file, err := os.Open(s.rateHistoryPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []models.RateRecord{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var history []models.RateRecord
+	if err := json.NewDecoder(file).Decode(&history); err != nil {
+		// 檔案為空或格式錯誤時視為沒有紀錄
+		return []models.RateRecord{}, nil
+	}
+	return history, nil
+}
+
+// recordRates 將一次查詢得到的匯率寫入歷史紀錄
+func (s *ExchangeService) recordRates(base string, rates map[string]float64) {
+	if s.rateHistoryPath == "" || len(rates) == 0 {
+		return
+	}
+
+	supported := make(map[string]bool)
+	for _, c := range s.GetSupportedCurrencies() {
+		supported[c] = true
+	}
+
+	s.historyMutex.Lock()
+	defer s.historyMutex.Unlock()
+
+	history, err := s.loadRateHistory()
+	if err != nil {
+		log.Printf("⚠️ 讀取匯率歷史失敗: %v", err)
+		return
+	}
+
+	// 刪除超出走勢查詢範圍的紀錄，避免歷史檔無限成長
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -MaxRateSeriesDays)
+	kept := history[:0]
+	for _, r := range history {
+		if !r.RecordedAt.Before(cutoff) {
+			kept = append(kept, r)
+		}
+	}
+	pruned := len(history) - len(kept)
+	history = kept
+
+	// 找出每組貨幣最後一次記錄的時間
+	lastRecorded := make(map[string]time.Time)
+	for _, r := range history {
+		key := r.From + "-" + r.To
+		if r.RecordedAt.After(lastRecorded[key]) {
+			lastRecorded[key] = r.RecordedAt
+		}
+	}
+
+	added := 0
+	for currency, rate := range rates {
+		// 未指定貨幣時 API 會回傳全部 160 多種，只保留支援的貨幣
+		if currency == base || !supported[currency] {
+			continue
+		}
+		if now.Sub(lastRecorded[base+"-"+currency]) < minRateRecordInterval {
+			continue
+		}
+		history = append(history, models.RateRecord{
+			From:       base,
+			To:         currency,
+			Rate:       rate,
+			RecordedAt: now,
+		})
+		added++
+	}
+
+	if added == 0 && pruned == 0 {
+		return
+	}
+
+	file, err := os.Create(s.rateHistoryPath)
+	if err != nil {
+		log.Printf("⚠️ 無法寫入匯率歷史: %v", err)
+		return
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(history); err != nil {
+		log.Printf("⚠️ 無法寫入匯率歷史: %v", err)
+		return
+	}
+	log.Printf("💾 已記錄 %d 筆 %s 匯率（刪除 %d 筆過期紀錄）", added, base, pruned)
+}
+
+// GetRateSeries 取得指定貨幣對在最近 days 天內的匯率走勢
+// 若只有反向紀錄（例如要 JPY->TWD 但只存了 TWD->JPY），會自動取倒數
+func (s *ExchangeService) GetRateSeries(from, to string, days int) (*models.RateSeries, error) {
+	from = strings.ToUpper(from)
+	to = strings.ToUpper(to)
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("貨幣代碼不可為空")
+	}
+	if days <= 0 {
+		days = 30
+	}
+	if days > MaxRateSeriesDays {
+		days = MaxRateSeriesDays
+	}
+
+	s.historyMutex.Lock()
+	history, err := s.loadRateHistory()
+	s.historyMutex.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("讀取匯率歷史失敗: %v", err)
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	series := &models.RateSeries{
+		From:   from,
+		To:     to,
+		Days:   days,
+		Points: []models.RatePoint{},
+	}
+
+	for _, r := range history {
+		if r.RecordedAt.Before(since) {
+			continue
+		}
+		switch {
+		case r.From == from && r.To == to:
+			series.Points = append(series.Points, models.RatePoint{Time: r.RecordedAt, Rate: r.Rate})
+		case r.From == to && r.To == from && r.Rate != 0:
+			series.Points = append(series.Points, models.RatePoint{Time: r.RecordedAt, Rate: 1 / r.Rate})
+		}
+	}
+
+	sort.Slice(series.Points, func(i, j int) bool {
+		return series.Points[i].Time.Before(series.Points[j].Time)
+	})
+
+	series.Count = len(series.Points)
+	if series.Count == 0 {
+		return series, nil
+	}
+
+	series.Min = series.Points[0].Rate
+	series.Max = series.Points[0].Rate
+	sum := 0.0
+	for _, p := range series.Points {
+		if p.Rate < series.Min {
+			series.Min = p.Rate
+		}
+		if p.Rate > series.Max {
+			series.Max = p.Rate
+		}
+		sum += p.Rate
+	}
+	series.Avg = sum / float64(series.Count)
+	series.Latest = series.Points[series.Count-1].Rate
+
+	return series, nil
+}
+
+// StartRateSnapshots 定期抓取 base 對所有支援貨幣的匯率，累積歷史資料
+func (s *ExchangeService) StartRateSnapshots(base string, interval time.Duration) {
+	go func() {
+		for {
+			if _, err := s.GetExchangeRates(base, s.GetSupportedCurrencies()); err != nil {
+				log.Printf("⚠️ 匯率快照失敗: %v", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+	log.Printf("📸 匯率快照排程已啟動 (%s, 每 %s)", base, interval)
+}
+
+// ---------------------------------------------------------
+// 匯率警報
+// ---------------------------------------------------------
+
+// loadRateAlerts 讀取所有匯率警報（呼叫端需持有 alertMutex）
+func (s *ExchangeService) loadRateAlerts() ([]models.RateAlert, error) {
+	file, err := os.Open(s.rateAlertsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []models.RateAlert{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var alerts []models.RateAlert
+	if err := json.NewDecoder(file).Decode(&alerts); err != nil {
+		return []models.RateAlert{}, nil
+	}
+	return alerts, nil
+}
+
+// saveRateAlerts 覆寫匯率警報檔案（呼叫端需持有 alertMutex）
+func (s *ExchangeService) saveRateAlerts(alerts []models.RateAlert) error {
+	file, err := os.Create(s.rateAlertsPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(alerts)
+}
+
+// rateAlertChannels 匯率警報可使用的通知管道（Discord 頻道、Telegram 聊天室）
+var rateAlertChannels = map[string]bool{"discord": true, "telegram": true}
+
+// CreateRateAlert 建立新的匯率警報
+func (s *ExchangeService) CreateRateAlert(alert models.RateAlert) (*models.RateAlert, error) {
+	alert.From = strings.ToUpper(alert.From)
+	alert.To = strings.ToUpper(alert.To)
+	if alert.From == "" || alert.To == "" || alert.Threshold <= 0 {
+		return nil, fmt.Errorf("缺少必要參數: from, to, threshold")
+	}
+	if alert.Direction == "" {
+		alert.Direction = models.RateAlertBelow
+	}
+	if alert.Direction != models.RateAlertBelow && alert.Direction != models.RateAlertAbove {
+		return nil, fmt.Errorf("無效的警報方向: %s (僅支援 below, above)", alert.Direction)
+	}
+	if alert.Channel == "" || alert.Target == "" {
+		return nil, fmt.Errorf("缺少通知管道: channel, target")
+	}
+	if !rateAlertChannels[alert.Channel] {
+		return nil, fmt.Errorf("不支援的通知管道: %s (僅支援 discord, telegram)", alert.Channel)
+	}
+	if s.notifier != nil {
+		if err := s.notifier.CheckChannel(alert.Channel); err != nil {
+			return nil, err
+		}
+	}
+
+	alert.ID = fmt.Sprintf("rate_%d", time.Now().UnixNano())
+	alert.IsActive = true
+	alert.CreatedAt = time.Now()
+
+	s.alertMutex.Lock()
+	defer s.alertMutex.Unlock()
+
+	alerts, err := s.loadRateAlerts()
+	if err != nil {
+		return nil, err
+	}
+	alerts = append(alerts, alert)
+	if err := s.saveRateAlerts(alerts); err != nil {
+		return nil, fmt.Errorf("儲存匯率警報失敗: %v", err)
+	}
+
+	log.Printf("🔔 已建立匯率警報 %s: %s->%s %s %.4f", alert.ID, alert.From, alert.To, alert.Direction, alert.Threshold)
+	return &alert, nil
+}
+
+// ListRateAlerts 列出所有匯率警報
+func (s *ExchangeService) ListRateAlerts() ([]models.RateAlert, error) {
+	s.alertMutex.Lock()
+	defer s.alertMutex.Unlock()
+	return s.loadRateAlerts()
+}
+
+// DeleteRateAlert 刪除指定的匯率警報
+func (s *ExchangeService) DeleteRateAlert(id string) error {
+	s.alertMutex.Lock()
+	defer s.alertMutex.Unlock()
+
+	alerts, err := s.loadRateAlerts()
+	if err != nil {
+		return err
+	}
+
+	for i, a := range alerts {
+		if a.ID == id {
+			alerts = append(alerts[:i], alerts[i+1:]...)
+			return s.saveRateAlerts(alerts)
+		}
+	}
+	return fmt.Errorf("找不到匯率警報: %s", id)
+}
+
+// CheckRateAlerts 檢查所有啟用中的警報，達到門檻即發送通知並停用
+func (s *ExchangeService) CheckRateAlerts() {
+	s.checkMutex.Lock()
+	defer s.checkMutex.Unlock()
+
+	// 查詢匯率與送出通知時不持有 alertMutex，避免阻塞建立與刪除警報
+	s.alertMutex.Lock()
+	alerts, err := s.loadRateAlerts()
+	s.alertMutex.Unlock()
+	if err != nil {
+		log.Printf("⚠️ 讀取匯率警報失敗: %v", err)
+		return
+	}
+
+	// 同一組貨幣只查一次
+	rateCache := make(map[string]float64)
+	checked := make(map[string]models.RateAlert)
+
+	for i := range alerts {
+		alert := &alerts[i]
+		if !alert.IsActive {
+			continue
+		}
+
+		key := alert.From + "-" + alert.To
+		rate, ok := rateCache[key]
+		if !ok {
+			rate, err = s.GetRate(alert.From, alert.To)
+			if err != nil {
+				log.Printf("⚠️ 匯率警報 %s 查詢失敗: %v", alert.ID, err)
+				continue
+			}
+			rateCache[key] = rate
+		}
+
+		if !alert.IsTriggeredBy(rate) {
+			continue
+		}
+
+		if err := s.notifyRateAlert(alert, rate); err != nil {
+			log.Printf("⚠️ 匯率警報 %s 通知失敗: %v", alert.ID, err)
+			continue
+		}
+
+		now := time.Now()
+		alert.IsActive = false
+		alert.TriggeredAt = &now
+		alert.TriggeredRate = rate
+		checked[alert.ID] = *alert
+		log.Printf("🔔 匯率警報 %s 已觸發: %s = %.4f", alert.ID, key, rate)
+	}
+
+	if len(checked) == 0 {
+		return
+	}
+
+	// 檢查期間警報可能被新增或刪除，重新讀取後只合併觸發的結果
+	s.alertMutex.Lock()
+	defer s.alertMutex.Unlock()
+
+	current, err := s.loadRateAlerts()
+	if err != nil {
+		log.Printf("⚠️ 讀取匯率警報失敗: %v", err)
+		return
+	}
+	if mergeCheckedRateAlerts(current, checked) == 0 {
+		return
+	}
+	if err := s.saveRateAlerts(current); err != nil {
+		log.Printf("⚠️ 儲存匯率警報失敗: %v", err)
+	}
+}
+
+// mergeCheckedRateAlerts 將觸發的警報（以 ID 對應）合併回最新的警報清單，回傳合併的筆數
+// 檢查期間已刪除的警報直接略過
+func mergeCheckedRateAlerts(current []models.RateAlert, checked map[string]models.RateAlert) int {
+	merged := 0
+	for i := range current {
+		c, ok := checked[current[i].ID]
+		if !ok {
+			continue
+		}
+		current[i].IsActive = false
+		current[i].TriggeredAt = c.TriggeredAt
+		current[i].TriggeredRate = c.TriggeredRate
+		merged++
+	}
+	return merged
+}
+
+// notifyRateAlert 透過警報指定的管道送出通知
+func (s *ExchangeService) notifyRateAlert(alert *models.RateAlert, rate float64) error {
+	if s.notifier == nil {
+		return fmt.Errorf("通知服務未啟用")
+	}
+	return s.notifier.Send(notifications.Notification{
+		Kind:      notifications.KindRateAlert,
+		Channel:   alert.Channel,
+		Target:    alert.Target,
+		RateAlert: &notifications.RateAlertEvent{Alert: *alert, Rate: rate},
+	})
+}
+
+// StartRateAlertChecker 在背景定期檢查匯率警報
+func (s *ExchangeService) StartRateAlertChecker(interval time.Duration) {
+	go func() {
+		for {
+			s.CheckRateAlerts()
+			time.Sleep(interval)
+		}
+	}()
+	log.Printf("🔔 匯率警報檢查已啟動 (每 %s)", interval)
+}
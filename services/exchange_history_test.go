@@ -0,0 +1,199 @@
+package services
+
+import (
+	"encoding/json"
+	"final/models"
+	"final/notifications"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRateHistory_PersistAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rate_history.json")
+	now := time.Now()
+
+	// 既有紀錄：一筆超過保留期限、一筆超出查詢範圍、兩筆 TWD->JPY
+	seed := []models.RateRecord{
+		{From: "USD", To: "TWD", Rate: 28, RecordedAt: now.AddDate(0, 0, -MaxRateSeriesDays-10)},
+		{From: "USD", To: "TWD", Rate: 30, RecordedAt: now.AddDate(0, 0, -40)},
+		{From: "TWD", To: "JPY", Rate: 4.5, RecordedAt: now.AddDate(0, 0, -3)},
+		{From: "TWD", To: "JPY", Rate: 5, RecordedAt: now.AddDate(0, 0, -1)},
+	}
+	data, _ := json.Marshal(seed)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewExchangeService("")
+	s.rateHistoryPath = path
+	s.recordRates("USD", map[string]float64{"USD": 1, "TWD": 32, "XAU": 0.0004})
+	// 同一組貨幣在間隔內不重複記錄
+	s.recordRates("USD", map[string]float64{"TWD": 33})
+
+	// 以新的服務實例重新讀取檔案
+	reloaded := NewExchangeService("")
+	reloaded.rateHistoryPath = path
+	reloaded.historyMutex.Lock()
+	history, err := reloaded.loadRateHistory()
+	reloaded.historyMutex.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 4 {
+		t.Fatalf("應有 4 筆紀錄（3 筆未過期 + USD->TWD），實際 %d 筆: %+v", len(history), history)
+	}
+	if last := history[3]; last.From != "USD" || last.To != "TWD" || last.Rate != 32 {
+		t.Errorf("新增的紀錄錯誤: %+v", last)
+	}
+
+	tests := []struct {
+		name       string
+		from, to   string
+		days       int
+		wantCount  int
+		wantLatest float64
+		wantMin    float64
+		wantMax    float64
+	}{
+		{"直接查詢", "twd", "jpy", 30, 2, 5, 4.5, 5},
+		{"反向紀錄取倒數", "JPY", "TWD", 30, 2, 0.2, 0.2, 1 / 4.5},
+		{"排除超出天數的紀錄", "USD", "TWD", 30, 1, 32, 32, 32},
+		{"天數預設為 30", "USD", "TWD", 0, 1, 32, 32, 32},
+		{"較長的天數", "USD", "TWD", 60, 2, 32, 30, 32},
+		{"超過保留期限", "USD", "TWD", MaxRateSeriesDays * 2, 2, 32, 30, 32},
+		{"沒有紀錄", "EUR", "GBP", 30, 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series, err := reloaded.GetRateSeries(tt.from, tt.to, tt.days)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if series.Count != tt.wantCount || len(series.Points) != tt.wantCount {
+				t.Fatalf("筆數 = %d, 預期 %d", series.Count, tt.wantCount)
+			}
+			approx := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+			if !approx(series.Latest, tt.wantLatest) || !approx(series.Min, tt.wantMin) || !approx(series.Max, tt.wantMax) {
+				t.Errorf("統計錯誤: latest=%v min=%v max=%v", series.Latest, series.Min, series.Max)
+			}
+			for i := 1; i < len(series.Points); i++ {
+				if series.Points[i].Time.Before(series.Points[i-1].Time) {
+					t.Error("數據點應依時間排序")
+				}
+			}
+		})
+	}
+
+	if _, err := reloaded.GetRateSeries("", "TWD", 30); err == nil {
+		t.Error("貨幣代碼為空應回傳錯誤")
+	}
+}
+
+func TestCreateRateAlert(t *testing.T) {
+	s := NewExchangeService("")
+	s.rateAlertsPath = filepath.Join(t.TempDir(), "rate_alerts.json")
+	notifier := notifications.NewDispatcher(nil, nil)
+	notifier.Register(notifications.NewDiscordChannel(nil, false))
+	notifier.Register(notifications.NewDiscordChannel(nil, true))
+	s.SetNotifier(notifier)
+
+	tests := []struct {
+		name    string
+		alert   models.RateAlert
+		wantErr bool
+	}{
+		{"Discord 頻道", models.RateAlert{From: "usd", To: "twd", Threshold: 30, Channel: "discord", Target: "123"}, false},
+		{"缺少通知管道", models.RateAlert{From: "USD", To: "TWD", Threshold: 30}, true},
+		{"未啟用的 telegram", models.RateAlert{From: "USD", To: "TWD", Threshold: 30, Channel: "telegram", Target: "123"}, true},
+		{"私訊管道", models.RateAlert{From: "USD", To: "TWD", Threshold: 30, Channel: "discord_dm", Target: "42"}, true},
+		{"webhook", models.RateAlert{From: "USD", To: "TWD", Threshold: 30, Channel: "webhook", Target: "http://169.254.169.254/"}, true},
+		{"無效的方向", models.RateAlert{From: "USD", To: "TWD", Threshold: 30, Direction: "sideways", Channel: "discord", Target: "123"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert, err := s.CreateRateAlert(tt.alert)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (alert.From != "USD" || alert.Direction != models.RateAlertBelow || !alert.IsActive) {
+				t.Errorf("建立的警報欄位錯誤: %+v", alert)
+			}
+		})
+	}
+
+	alerts, err := s.ListRateAlerts()
+	if err != nil || len(alerts) != 1 {
+		t.Errorf("應只保存 1 筆警報: %v %+v", err, alerts)
+	}
+}
+
+// recordingChannel 記錄送出的 target
+type recordingChannel struct {
+	name    string
+	targets []string
+}
+
+func (c *recordingChannel) Name() string { return c.name }
+
+func (c *recordingChannel) Send(target string, msg notifications.Message) error {
+	c.targets = append(c.targets, target)
+	return nil
+}
+
+func TestCheckRateAlerts(t *testing.T) {
+	s, _ := newTestExchangeService(t)
+	s.rateAlertsPath = filepath.Join(t.TempDir(), "rate_alerts.json")
+	discord := &recordingChannel{name: "discord"}
+	notifier := notifications.NewDispatcher(nil, nil)
+	notifier.Register(discord)
+	s.SetNotifier(notifier)
+
+	// USD->TWD 為 32.5
+	for _, alert := range []models.RateAlert{
+		{From: "USD", To: "TWD", Threshold: 33, Channel: "discord", Target: "below"},
+		{From: "USD", To: "TWD", Threshold: 33, Direction: models.RateAlertAbove, Channel: "discord", Target: "above"},
+	} {
+		if _, err := s.CreateRateAlert(alert); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.CheckRateAlerts()
+	s.CheckRateAlerts()
+	if len(discord.targets) != 1 || discord.targets[0] != "below" {
+		t.Errorf("只應通知一次跌破門檻的警報: %v", discord.targets)
+	}
+	alerts, _ := s.ListRateAlerts()
+	if a := alerts[0]; a.IsActive || a.TriggeredAt == nil || a.TriggeredRate != 32.5 {
+		t.Errorf("觸發的警報應停用並記錄匯率: %+v", a)
+	}
+	if a := alerts[1]; !a.IsActive || a.TriggeredAt != nil {
+		t.Errorf("未觸發的警報不應被修改: %+v", a)
+	}
+}
+
+func TestMergeCheckedRateAlerts(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	// 檢查期間 "deleted" 被刪除、"new" 是新建立的
+	current := []models.RateAlert{
+		{ID: "hit", IsActive: true},
+		{ID: "new", IsActive: true},
+	}
+	checked := map[string]models.RateAlert{
+		"hit":     {ID: "hit", IsActive: false, TriggeredAt: &now, TriggeredRate: 32.5},
+		"deleted": {ID: "deleted", IsActive: false, TriggeredAt: &now, TriggeredRate: 32.5},
+	}
+
+	if n := mergeCheckedRateAlerts(current, checked); n != 1 {
+		t.Errorf("合併 %d 筆, 預期 1 筆", n)
+	}
+	if a := current[0]; a.IsActive || a.TriggeredAt == nil || a.TriggeredRate != 32.5 {
+		t.Errorf("觸發的警報應停用並記錄匯率: %+v", a)
+	}
+	if a := current[1]; !a.IsActive || a.TriggeredAt != nil {
+		t.Errorf("檢查期間新建立的警報不應被修改: %+v", a)
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"final/models"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func newTestExchangeService(t *testing.T) (*ExchangeService, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path != "/test-key/latest/USD" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"result":"success","base_code":"USD","time_last_update_unix":1767225600,"conversion_rates":{"USD":1,"TWD":32.5,"JPY":150.25,"EUR":0.92,"XAU":0.0004}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	s := NewExchangeService("test-key")
+	s.BaseURL = server.URL
+	s.rateHistoryPath = filepath.Join(t.TempDir(), "rate_history.json")
+	return s, &calls
+}
+
+func TestGetExchangeRates_RecordsFullSnapshot(t *testing.T) {
+	s, calls := newTestExchangeService(t)
+
+	res, err := s.GetExchangeRates("USD", []string{"TWD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Rates) != 1 || res.Rates["TWD"] != 32.5 {
+		t.Errorf("應只回傳指定的貨幣: %v", res.Rates)
+	}
+
+	// 歷史紀錄應包含整張匯率表中支援的貨幣，而不只是呼叫端指定的 TWD
+	s.historyMutex.Lock()
+	history, err := s.loadRateHistory()
+	s.historyMutex.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := make(map[string]float64)
+	for _, r := range history {
+		if r.From != "USD" {
+			t.Errorf("基準貨幣錯誤: %+v", r)
+		}
+		recorded[r.To] = r.Rate
+	}
+	want := map[string]float64{"TWD": 32.5, "JPY": 150.25, "EUR": 0.92}
+	if len(recorded) != len(want) {
+		t.Errorf("歷史紀錄 = %v, 預期 %v", recorded, want)
+	}
+	for c, rate := range want {
+		if recorded[c] != rate {
+			t.Errorf("%s 匯率 = %v, 預期 %v", c, recorded[c], rate)
+		}
+	}
+
+	// 快取命中時不再呼叫 API，也不重複記錄
+	if _, err := s.GetExchangeRates("USD", []string{"JPY"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("第二次查詢應使用快取, 實際請求 %d 次", got)
+	}
+	s.historyMutex.Lock()
+	history, _ = s.loadRateHistory()
+	s.historyMutex.Unlock()
+	if len(history) != len(want) {
+		t.Errorf("快取命中不應新增紀錄, 共 %d 筆", len(history))
+	}
+}
+
+func TestConvertCurrency(t *testing.T) {
+	s, _ := newTestExchangeService(t)
+
+	tests := []struct {
+		name    string
+		amount  models.Money
+		to      string
+		want    models.Money
+		wantErr bool
+	}{
+		{"美元換台幣", models.NewMoney(100, "USD"), "TWD", models.NewMoney(3250, "TWD"), false},
+		{"依目標精度四捨五入", models.NewMoney(10.01, "USD"), "JPY", models.NewMoney(1504, "JPY"), false},
+		{"相同貨幣", models.NewMoney(12.34, "USD"), "USD", models.NewMoney(12.34, "USD"), false},
+		{"不支援的貨幣", models.NewMoney(100, "USD"), "ABC", models.Money{}, true},
+		{"API 查詢失敗", models.NewMoney(100, "GBP"), "TWD", models.Money{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.ConvertCurrency(tt.amount, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConvertCurrency() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ConvertCurrency() = %v, 預期 %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,21 +2,49 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
+const (
+	foursquareBaseURL    = "https://places-api.foursquare.com"
+	foursquareAPIVersion = "2025-06-17"
+)
+
+// 新版 Places API 只會回傳明確要求的欄位，rating、hours 等進階欄位必須列在 fields 參數中
+var (
+	foursquareSearchFields = []string{
+		"fsq_place_id", "name", "categories", "location", "latitude", "longitude",
+		"distance", "rating", "price", "hours", "tel", "website", "description",
+	}
+	foursquareDetailFields = []string{
+		"fsq_place_id", "name", "categories", "location", "latitude", "longitude",
+		"rating", "price", "hours", "tel", "website", "description",
+		"popularity", "photos", "tips",
+	}
+)
+
+// ErrAttractionNotFound 找不到指定的景點
+var ErrAttractionNotFound = errors.New("找不到景點")
+
 type FoursquareService struct {
-	apiKey string
-	client *http.Client
+	apiKey  string
+	baseURL string
+	client  *http.Client
 }
 
 func NewFoursquareService(apiKey string) *FoursquareService {
 	return &FoursquareService{
-		apiKey: apiKey,
-		client: &http.Client{},
+		apiKey:  apiKey,
+		baseURL: foursquareBaseURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
 	}
 }
 
@@ -36,6 +64,48 @@ type Attraction struct {
 	Website     string  `json:"website"`
 	Description string  `json:"description"`
 	Distance    float64 `json:"distance"`
+
+	Hours *AttractionHours `json:"hours,omitempty"`
+}
+
+// 景點營業時間
+type AttractionHours struct {
+	Display string                 `json:"display,omitempty"` // 例如 "Mon-Sun 09:00-22:00"
+	OpenNow bool                   `json:"open_now"`
+	Regular []AttractionOpenPeriod `json:"regular,omitempty"`
+}
+
+// 每週固定營業時段，Day 1 = 週一 ... 7 = 週日，時間為 HHMM
+type AttractionOpenPeriod struct {
+	Day   int    `json:"day"`
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// 景點照片
+type AttractionPhoto struct {
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	Thumbnail string `json:"thumbnail"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// 使用者評論
+type AttractionTip struct {
+	ID        string `json:"id"`
+	Text      string `json:"text"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// 景點詳細資料
+type AttractionDetails struct {
+	Attraction
+	Categories []string          `json:"categories"`
+	Popularity float64           `json:"popularity"` // 0 ~ 1，越高越熱門
+	Photos     []AttractionPhoto `json:"photos"`
+	Tips       []AttractionTip   `json:"tips"`
 }
 
 type SearchRequest struct {
@@ -43,38 +113,197 @@ type SearchRequest struct {
 	Longitude float64 `json:"longitude"`
 	Radius    int     `json:"radius"`
 	Query     string  `json:"query"`
-	Category  string  `json:"category"`
+	Category  string  `json:"category"`            // 類別 slug（例如 museum）、中英文名稱或 Foursquare ID，可用逗號分隔多個
+	Sort      string  `json:"sort,omitempty"`      // relevance, rating, popularity, distance（預設）
+	Limit     int     `json:"limit,omitempty"`     // 1 ~ 50，預設 20
+	Cursor    string  `json:"cursor,omitempty"`    // 上一頁回傳的 next_cursor
+	OpenNow   bool    `json:"open_now,omitempty"`  // 只顯示目前營業中的地點
+	MinPrice  int     `json:"min_price,omitempty"` // 價位 1（便宜）~ 4（昂貴），0 表示不限
+	MaxPrice  int     `json:"max_price,omitempty"`
 }
 
-// 搜索附近景點 - 使用新的端點和認證
-func (fs *FoursquareService) SearchNearby(req SearchRequest) ([]Attraction, error) {
-	// 使用新的端點
-	baseURL := "https://places-api.foursquare.com/places/search"
+// 景點搜尋的一頁結果
+type AttractionPage struct {
+	Attractions []Attraction `json:"attractions"`
+	NextCursor  string       `json:"next_cursor,omitempty"` // 空字串表示沒有下一頁
+}
 
-	// 構建查詢參數
-	params := url.Values{}
-	params.Add("ll", fmt.Sprintf("%f,%f", req.Latitude, req.Longitude))
+// 景點排序方式
+const (
+	AttractionSortRelevance  = "relevance"
+	AttractionSortRating     = "rating"
+	AttractionSortPopularity = "popularity"
+	AttractionSortDistance   = "distance"
+)
 
-	if req.Radius > 0 {
-		params.Add("radius", fmt.Sprintf("%d", req.Radius))
-	} else {
-		params.Add("radius", "5000")
+const (
+	defaultAttractionLimit = 20
+	maxAttractionLimit     = 50
+)
+
+// AttractionSortOptions 支援的排序方式
+var AttractionSortOptions = []string{
+	AttractionSortRelevance, AttractionSortRating, AttractionSortPopularity, AttractionSortDistance,
+}
+
+// Validate 檢查並補上預設值（排序、筆數、價位範圍），並把類別 slug 轉成 Foursquare ID
+func (req *SearchRequest) Validate() error {
+	if req.Category != "" {
+		ids, err := DefaultCategoryTaxonomy().ResolveIDs(req.Category)
+		if err != nil {
+			return err
+		}
+		req.Category = ids
 	}
 
-	params.Add("limit", "20")
-	params.Add("sort", "DISTANCE")
+	req.Sort = strings.ToLower(strings.TrimSpace(req.Sort))
+	if req.Sort == "" {
+		req.Sort = AttractionSortDistance
+	}
+	valid := false
+	for _, opt := range AttractionSortOptions {
+		if req.Sort == opt {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("不支援的排序方式: %s（可用 %s）", req.Sort, strings.Join(AttractionSortOptions, ", "))
+	}
 
-	if req.Query != "" {
-		params.Add("query", req.Query)
+	if req.Limit == 0 {
+		req.Limit = defaultAttractionLimit
 	}
-	if req.Category != "" {
-		params.Add("categories", req.Category)
+	if req.Limit < 1 || req.Limit > maxAttractionLimit {
+		return fmt.Errorf("limit 必須介於 1 到 %d", maxAttractionLimit)
+	}
+
+	for _, price := range []int{req.MinPrice, req.MaxPrice} {
+		if price < 0 || price > 4 {
+			return fmt.Errorf("價位必須介於 1 到 4")
+		}
+	}
+	if req.MinPrice > 0 && req.MaxPrice > 0 && req.MinPrice > req.MaxPrice {
+		return fmt.Errorf("min_price 不可大於 max_price")
+	}
+	return nil
+}
+
+// Places API 回傳的地點物件（搜尋與詳細資料共用）
+type fsqPlace struct {
+	FSQPlaceID string `json:"fsq_place_id"`
+	Name       string `json:"name"`
+	Categories []struct {
+		ID   string `json:"id"` // 現在是 BSON ID
+		Name string `json:"name"`
+	} `json:"categories"`
+	Location struct {
+		FormattedAddress string `json:"formatted_address"`
+		Locality         string `json:"locality"`
+		Region           string `json:"region"`
+		Country          string `json:"country"`
+	} `json:"location"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Distance  int     `json:"distance"`
+	Rating    float64 `json:"rating"`
+	Hours     struct {
+		Display string                 `json:"display"`
+		OpenNow bool                   `json:"open_now"`
+		Regular []AttractionOpenPeriod `json:"regular"`
+	} `json:"hours"`
+	Price       int     `json:"price"`
+	Tel         string  `json:"tel"`
+	Website     string  `json:"website"`
+	Description string  `json:"description"`
+	Popularity  float64 `json:"popularity"`
+	Photos      []struct {
+		ID        string `json:"id"`
+		CreatedAt string `json:"created_at"`
+		Prefix    string `json:"prefix"`
+		Suffix    string `json:"suffix"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+	} `json:"photos"`
+	Tips []struct {
+		ID        string `json:"id"`
+		CreatedAt string `json:"created_at"`
+		Text      string `json:"text"`
+	} `json:"tips"`
+}
+
+func (p fsqPlace) toAttraction() Attraction {
+	category := ""
+	if len(p.Categories) > 0 {
+		category = p.Categories[0].Name
+	}
+	var hours *AttractionHours
+	if p.Hours.Display != "" || len(p.Hours.Regular) > 0 {
+		hours = &AttractionHours{
+			Display: p.Hours.Display,
+			OpenNow: p.Hours.OpenNow,
+			Regular: p.Hours.Regular,
+		}
+	}
+	return Attraction{
+		ID:          p.FSQPlaceID,
+		Name:        p.Name,
+		Address:     p.Location.FormattedAddress,
+		City:        p.Location.Locality,
+		Country:     p.Location.Country,
+		Latitude:    p.Latitude,
+		Longitude:   p.Longitude,
+		Category:    category,
+		Rating:      p.Rating,
+		Price:       p.Price,
+		IsOpen:      p.Hours.OpenNow,
+		Phone:       p.Tel,
+		Website:     p.Website,
+		Description: p.Description,
+		Distance:    float64(p.Distance),
+		Hours:       hours,
+	}
+}
+
+func (p fsqPlace) toDetails() *AttractionDetails {
+	details := &AttractionDetails{
+		Attraction: p.toAttraction(),
+		Categories: make([]string, 0, len(p.Categories)),
+		Popularity: p.Popularity,
+		Photos:     make([]AttractionPhoto, 0, len(p.Photos)),
+		Tips:       make([]AttractionTip, 0, len(p.Tips)),
 	}
+	for _, c := range p.Categories {
+		details.Categories = append(details.Categories, c.Name)
+	}
+	// 照片網址由 prefix + 尺寸 + suffix 組成
+	for _, photo := range p.Photos {
+		details.Photos = append(details.Photos, AttractionPhoto{
+			ID:        photo.ID,
+			URL:       photo.Prefix + "original" + photo.Suffix,
+			Thumbnail: photo.Prefix + "300x300" + photo.Suffix,
+			Width:     photo.Width,
+			Height:    photo.Height,
+			CreatedAt: photo.CreatedAt,
+		})
+	}
+	for _, tip := range p.Tips {
+		details.Tips = append(details.Tips, AttractionTip{
+			ID:        tip.ID,
+			Text:      tip.Text,
+			CreatedAt: tip.CreatedAt,
+		})
+	}
+	return details
+}
 
-	// 構建完整 URL
-	fullURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
+// get 發送 Places API 請求並解析 JSON，回傳回應標頭（分頁游標在 Link 標頭中）
+func (fs *FoursquareService) get(path string, params url.Values, out interface{}) (http.Header, error) {
+	fullURL := fs.baseURL + path
+	if len(params) > 0 {
+		fullURL += "?" + params.Encode()
+	}
 
-	// 創建請求
 	httpReq, err := http.NewRequest("GET", fullURL, nil)
 	if err != nil {
 		return nil, err
@@ -83,13 +312,10 @@ func (fs *FoursquareService) SearchNearby(req SearchRequest) ([]Attraction, erro
 	// 設置新的 headers - 按照遷移指南
 	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", fs.apiKey)) // 新的認證格式
 	httpReq.Header.Set("Accept", "application/json")
-	httpReq.Header.Set("X-Places-Api-Version", "2025-06-17") // 新的版本號
+	httpReq.Header.Set("X-Places-Api-Version", foursquareAPIVersion) // 新的版本號
 
 	log.Printf("🔍 發送 Foursquare 新 API 請求: %s", fullURL)
-	log.Printf("🔑 Headers: Authorization=Bearer %s...", fs.apiKey[:20])
-	log.Printf("🔑 Headers: X-Places-Api-Version=2025-06-17")
 
-	// 發送請求
 	resp, err := fs.client.Do(httpReq)
 	if err != nil {
 		return nil, err
@@ -98,88 +324,140 @@ func (fs *FoursquareService) SearchNearby(req SearchRequest) ([]Attraction, erro
 
 	log.Printf("📡 Foursquare 新 API 回應狀態: %s", resp.Status)
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrAttractionNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
-		// 讀取錯誤回應主體
-		var errorResp map[string]interface{}
-		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err == nil {
-			log.Printf("❌ Foursquare API 錯誤詳情: %v", errorResp)
-		} else {
-			bodyBytes := make([]byte, 1024)
-			n, _ := resp.Body.Read(bodyBytes)
-			log.Printf("❌ Foursquare API 原始錯誤: %s", string(bodyBytes[:n]))
-		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		log.Printf("❌ Foursquare API 錯誤詳情: %s", string(body))
 		return nil, fmt.Errorf("Foursquare API error: %s", resp.Status)
 	}
 
-	// 解析回應 - 適應新的回應格式
-	var apiResponse struct {
-		Results []struct {
-			FSQPlaceID string `json:"fsq_place_id"` // 新的欄位名稱
-			Name       string `json:"name"`
-			Categories []struct {
-				ID   string `json:"id"` // 現在是 BSON ID
-				Name string `json:"name"`
-			} `json:"categories"`
-			Location struct {
-				FormattedAddress string `json:"formatted_address"`
-				Locality         string `json:"locality"`
-				Region           string `json:"region"`
-				Country          string `json:"country"`
-			} `json:"location"`
-			Latitude  float64 `json:"latitude"`  // 新的位置格式
-			Longitude float64 `json:"longitude"` // 新的位置格式
-			Distance  int     `json:"distance"`
-			Rating    float64 `json:"rating"`
-			Hours     struct {
-				OpenNow bool `json:"open_now"`
-			} `json:"hours"`
-			Price       int    `json:"price"`
-			Tel         string `json:"tel"`
-			Website     string `json:"website"`
-			Description string `json:"description"`
-		} `json:"results"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
 		log.Printf("❌ 解析 Foursquare 回應錯誤: %v", err)
 		return nil, err
 	}
+	return resp.Header, nil
+}
+
+// nextCursor 從 Link 標頭取出下一頁的 cursor，例如
+// <https://places-api.foursquare.com/places/search?cursor=abc&ll=...>; rel="next"
+func nextCursor(header http.Header) string {
+	// 網址本身可能含有逗號（ll=lat,lng），所以依 <...> 切分而不是直接以逗號分隔
+	for _, link := range header.Values("Link") {
+		for rest := link; ; {
+			start := strings.Index(rest, "<")
+			end := strings.Index(rest, ">")
+			if start < 0 || end < start {
+				break
+			}
+			target := rest[start+1 : end]
+			rest = rest[end+1:]
+
+			rel := rest
+			if next := strings.Index(rest, "<"); next >= 0 {
+				rel = rest[:next]
+			}
+			if !strings.Contains(rel, `rel="next"`) {
+				continue
+			}
+			if u, err := url.Parse(target); err == nil && u.Query().Get("cursor") != "" {
+				return u.Query().Get("cursor")
+			}
+		}
+	}
+	return ""
+}
+
+// 搜索附近景點，只回傳第一頁
+func (fs *FoursquareService) SearchNearby(req SearchRequest) ([]Attraction, error) {
+	page, err := fs.Search(req)
+	if err != nil {
+		return nil, err
+	}
+	return page.Attractions, nil
+}
+
+// Search 搜尋景點並支援排序、筆數、營業中與價位篩選，以及 cursor 分頁
+func (fs *FoursquareService) Search(req SearchRequest) (*AttractionPage, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	// 構建查詢參數
+	params := url.Values{}
+	params.Add("ll", fmt.Sprintf("%f,%f", req.Latitude, req.Longitude))
+
+	if req.Radius > 0 {
+		params.Add("radius", fmt.Sprintf("%d", req.Radius))
+	} else {
+		params.Add("radius", "5000")
+	}
+
+	params.Add("limit", strconv.Itoa(req.Limit))
+	params.Add("sort", strings.ToUpper(req.Sort))
+	params.Add("fields", strings.Join(foursquareSearchFields, ","))
+
+	if req.Query != "" {
+		params.Add("query", req.Query)
+	}
+	if req.Category != "" {
+		params.Add("categories", req.Category)
+	}
+	if req.Cursor != "" {
+		params.Add("cursor", req.Cursor)
+	}
+	if req.OpenNow {
+		params.Add("open_now", "true")
+	}
+	if req.MinPrice > 0 {
+		params.Add("min_price", strconv.Itoa(req.MinPrice))
+	}
+	if req.MaxPrice > 0 {
+		params.Add("max_price", strconv.Itoa(req.MaxPrice))
+	}
+
+	var apiResponse struct {
+		Results []fsqPlace `json:"results"`
+	}
+	header, err := fs.get("/places/search", params, &apiResponse)
+	if err != nil {
+		return nil, err
+	}
 
 	// 轉換為我們的模型
-	var attractions []Attraction
+	page := &AttractionPage{
+		Attractions: make([]Attraction, 0, len(apiResponse.Results)),
+		NextCursor:  nextCursor(header),
+	}
 	for _, result := range apiResponse.Results {
-		category := ""
-		if len(result.Categories) > 0 {
-			category = result.Categories[0].Name
-		}
+		page.Attractions = append(page.Attractions, result.toAttraction())
+	}
 
-		attraction := Attraction{
-			ID:          result.FSQPlaceID,
-			Name:        result.Name,
-			Address:     result.Location.FormattedAddress,
-			City:        result.Location.Locality,
-			Country:     result.Location.Country,
-			Latitude:    result.Latitude,
-			Longitude:   result.Longitude,
-			Category:    category,
-			Rating:      result.Rating,
-			Price:       result.Price,
-			IsOpen:      result.Hours.OpenNow,
-			Phone:       result.Tel,
-			Website:     result.Website,
-			Description: result.Description,
-			Distance:    float64(result.Distance),
-		}
-		attractions = append(attractions, attraction)
+	log.Printf("✅ 找到 %d 個景點", len(page.Attractions))
+	return page, nil
+}
+
+// GetPlaceDetails 取得單一景點的詳細資料（營業時間、評分、價位、照片、評論、熱門度）
+func (fs *FoursquareService) GetPlaceDetails(placeID string) (*AttractionDetails, error) {
+	placeID = strings.TrimSpace(placeID)
+	if placeID == "" {
+		return nil, fmt.Errorf("景點 ID 不可為空")
 	}
 
-	log.Printf("✅ 找到 %d 個景點", len(attractions))
-	return attractions, nil
+	params := url.Values{}
+	params.Add("fields", strings.Join(foursquareDetailFields, ","))
+
+	var place fsqPlace
+	if _, err := fs.get("/places/"+url.PathEscape(placeID), params, &place); err != nil {
+		return nil, err
+	}
+	return place.toDetails(), nil
 }
 
 // 驗證 API Key - 使用新端點
 func (fs *FoursquareService) ValidateAPIKey() error {
-	testURL := "https://places-api.foursquare.com/places/search?ll=25.0330,121.5654&limit=1"
+	testURL := fs.baseURL + "/places/search?ll=25.0330,121.5654&limit=1"
 
 	req, err := http.NewRequest("GET", testURL, nil)
 	if err != nil {
@@ -188,7 +466,7 @@ func (fs *FoursquareService) ValidateAPIKey() error {
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", fs.apiKey))
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("X-Places-Api-Version", "2025-06-17")
+	req.Header.Set("X-Places-Api-Version", foursquareAPIVersion)
 
 	resp, err := fs.client.Do(req)
 	if err != nil {
@@ -204,11 +482,7 @@ func (fs *FoursquareService) ValidateAPIKey() error {
 	return fmt.Errorf("API test failed with status: %s", resp.Status)
 }
 
-// 獲取熱門景點類別
+// 獲取熱門景點類別（分類表中的 slug）
 func (fs *FoursquareService) GetPopularCategories() []string {
-	return []string{
-		"13000", // Arts & Entertainment
-		"16000", // Landmarks & Outdoors
-		"10000", // Professional & Other Places
-	}
+	return []string{"landmarks", "arts", "dining"}
 }
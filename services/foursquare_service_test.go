@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestFoursquare(t *testing.T, handler http.HandlerFunc) *FoursquareService {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Authorization 錯誤: %q", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("X-Places-Api-Version") == "" {
+			t.Error("缺少 X-Places-Api-Version")
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	fs := NewFoursquareService("test-key")
+	fs.baseURL = server.URL
+	return fs
+}
+
+func TestFoursquare_SearchRequestsFields(t *testing.T) {
+	fs := newTestFoursquare(t, func(w http.ResponseWriter, r *http.Request) {
+		fields := r.URL.Query().Get("fields")
+		for _, f := range []string{"rating", "hours", "website", "description"} {
+			if !strings.Contains(fields, f) {
+				t.Errorf("搜尋應明確要求 %s 欄位, fields=%q", f, fields)
+			}
+		}
+		// 新版 API 只接受 BSON 類別 ID
+		if got := r.URL.Query().Get("categories"); got != "4bf58dd8d48988d131941735" {
+			t.Errorf("類別應轉成 BSON ID, categories=%q", got)
+		}
+		fmt.Fprint(w, `{"results":[{"fsq_place_id":"abc","name":"淺草寺","rating":9.1,"price":1,"website":"https://www.senso-ji.jp","hours":{"open_now":true},"categories":[{"name":"Temple"}]}]}`)
+	})
+
+	attractions, err := fs.SearchNearby(SearchRequest{Latitude: 35.71, Longitude: 139.79, Category: "spiritual"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attractions) != 1 || attractions[0].Rating != 9.1 || !attractions[0].IsOpen || attractions[0].Category != "Temple" {
+		t.Errorf("搜尋結果錯誤: %+v", attractions)
+	}
+}
+
+func TestFoursquare_GetPlaceDetails(t *testing.T) {
+	fs := newTestFoursquare(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/places/abc":
+			fields := r.URL.Query().Get("fields")
+			for _, f := range []string{"photos", "tips", "popularity", "hours"} {
+				if !strings.Contains(fields, f) {
+					t.Errorf("詳細資料應明確要求 %s 欄位, fields=%q", f, fields)
+				}
+			}
+			fmt.Fprint(w, `{
+				"fsq_place_id": "abc",
+				"name": "淺草寺",
+				"categories": [{"name": "Temple"}, {"name": "Landmark"}],
+				"rating": 9.1,
+				"price": 1,
+				"popularity": 0.98,
+				"hours": {"display": "Mon-Sun 06:00-17:00", "open_now": false, "regular": [{"day": 1, "open": "0600", "close": "1700"}]},
+				"photos": [{"id": "p1", "prefix": "https://fastly.4sqi.net/img/general/", "suffix": "/p1.jpg", "width": 1440, "height": 1920}],
+				"tips": [{"id": "t1", "text": "清晨人比較少", "created_at": "2024-03-01T00:00:00.000Z"}]
+			}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+		}
+	})
+
+	details, err := fs.GetPlaceDetails("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.Name != "淺草寺" || details.Popularity != 0.98 || len(details.Categories) != 2 {
+		t.Errorf("基本資料錯誤: %+v", details)
+	}
+	if details.Hours == nil || details.Hours.Display != "Mon-Sun 06:00-17:00" || len(details.Hours.Regular) != 1 {
+		t.Errorf("營業時間錯誤: %+v", details.Hours)
+	}
+	if len(details.Photos) != 1 || details.Photos[0].URL != "https://fastly.4sqi.net/img/general/original/p1.jpg" {
+		t.Errorf("照片網址錯誤: %+v", details.Photos)
+	}
+	if len(details.Tips) != 1 || details.Tips[0].Text != "清晨人比較少" {
+		t.Errorf("評論錯誤: %+v", details.Tips)
+	}
+
+	if _, err := fs.GetPlaceDetails("missing"); !errors.Is(err, ErrAttractionNotFound) {
+		t.Errorf("不存在的景點應回傳 ErrAttractionNotFound, 實際 %v", err)
+	}
+}
+
+func TestFoursquare_SearchOptionsAndCursor(t *testing.T) {
+	fs := newTestFoursquare(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		want := map[string]string{"sort": "RATING", "limit": "30", "open_now": "true", "min_price": "1", "max_price": "2", "cursor": "page1"}
+		for k, v := range want {
+			if q.Get(k) != v {
+				t.Errorf("參數 %s = %q, 預期 %q", k, q.Get(k), v)
+			}
+		}
+		w.Header().Set("Link", `<https://places-api.foursquare.com/places/search?cursor=page2&ll=35.7,139.8>; rel="next"`)
+		fmt.Fprint(w, `{"results":[{"fsq_place_id":"a","name":"A"},{"fsq_place_id":"b","name":"B"}]}`)
+	})
+
+	page, err := fs.Search(SearchRequest{Latitude: 35.7, Longitude: 139.8, Sort: "Rating", Limit: 30, Cursor: "page1", OpenNow: true, MinPrice: 1, MaxPrice: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Attractions) != 2 || page.NextCursor != "page2" {
+		t.Errorf("分頁結果錯誤: %+v", page)
+	}
+}
+
+func TestSearchRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     SearchRequest
+		wantErr bool
+	}{
+		{"預設值", SearchRequest{}, false},
+		{"不支援的排序", SearchRequest{Sort: "name"}, true},
+		{"筆數過多", SearchRequest{Limit: 51}, true},
+		{"價位超出範圍", SearchRequest{MaxPrice: 5}, true},
+		{"價位上下限顛倒", SearchRequest{MinPrice: 3, MaxPrice: 1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			err := req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (req.Sort != AttractionSortDistance || req.Limit != defaultAttractionLimit) {
+				t.Errorf("未補上預設值: %+v", req)
+			}
+		})
+	}
+}
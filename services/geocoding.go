@@ -0,0 +1,291 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"final/models"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	geocodeCacheDB = "geocode_cache.json"
+	// Nominatim 使用政策：每秒最多 1 次請求
+	nominatimMinInterval = time.Second
+	geocodeCacheTTL      = 30 * 24 * time.Hour
+	defaultGeocoderAgent = "GoSkyAlert/1.0 (+https://github.com/ken0324-maker/GoSkyAlert)"
+)
+
+var (
+	// ErrPlaceNotFound 查詢成功但沒有符合的地點
+	ErrPlaceNotFound = errors.New("找不到地點")
+	// ErrInvalidPlaceQuery 查詢的地名為空或座標超出範圍
+	ErrInvalidPlaceQuery = errors.New("無效的地點查詢")
+)
+
+// GeocodingService 透過 Nominatim (OpenStreetMap) 進行正向與反向地理編碼
+// 所有請求共用節流與快取，Discord、景點與天氣功能都應透過這個服務查詢座標
+type GeocodingService struct {
+	BaseURL   string
+	UserAgent string
+	Language  string // Accept-Language，決定回傳地名的語系
+
+	client      *http.Client
+	cachePath   string
+	minInterval time.Duration
+
+	cacheMutex sync.Mutex
+	cache      map[string]models.GeoPlace
+
+	throttleMutex sync.Mutex
+	lastRequest   time.Time
+}
+
+func NewGeocodingService(userAgent string) *GeocodingService {
+	if userAgent == "" {
+		userAgent = defaultGeocoderAgent
+	}
+	return &GeocodingService{
+		BaseURL:     "https://nominatim.openstreetmap.org",
+		UserAgent:   userAgent,
+		Language:    "zh-TW,en",
+		client:      &http.Client{Timeout: 10 * time.Second},
+		cachePath:   geocodeCacheDB,
+		minInterval: nominatimMinInterval,
+	}
+}
+
+// Nominatim jsonv2 回應
+type nominatimPlace struct {
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Type        string `json:"type"`
+	Address     struct {
+		City        string `json:"city"`
+		Town        string `json:"town"`
+		Village     string `json:"village"`
+		State       string `json:"state"`
+		Country     string `json:"country"`
+		CountryCode string `json:"country_code"`
+	} `json:"address"`
+	Error string `json:"error"`
+}
+
+func (p nominatimPlace) toGeoPlace() (*models.GeoPlace, error) {
+	lat, err := strconv.ParseFloat(p.Lat, 64)
+	if err != nil {
+		return nil, fmt.Errorf("無效的緯度: %q", p.Lat)
+	}
+	lng, err := strconv.ParseFloat(p.Lon, 64)
+	if err != nil {
+		return nil, fmt.Errorf("無效的經度: %q", p.Lon)
+	}
+
+	name := p.Name
+	if name == "" {
+		name = strings.TrimSpace(strings.Split(p.DisplayName, ",")[0])
+	}
+	city := p.Address.City
+	if city == "" {
+		city = p.Address.Town
+	}
+	if city == "" {
+		city = p.Address.Village
+	}
+	if city == "" {
+		city = p.Address.State
+	}
+
+	return &models.GeoPlace{
+		Latitude:    lat,
+		Longitude:   lng,
+		Name:        name,
+		DisplayName: p.DisplayName,
+		City:        city,
+		Country:     p.Address.Country,
+		CountryCode: strings.ToUpper(p.Address.CountryCode),
+		Type:        p.Type,
+	}, nil
+}
+
+// Geocode 由地名或地址查詢座標
+func (s *GeocodingService) Geocode(query string) (*models.GeoPlace, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("%w: 查詢地點不可為空", ErrInvalidPlaceQuery)
+	}
+
+	key := "q:" + strings.ToLower(query)
+	if place, ok := s.cached(key); ok {
+		return place, nil
+	}
+
+	params := url.Values{}
+	params.Add("q", query)
+	params.Add("format", "jsonv2")
+	params.Add("addressdetails", "1")
+	params.Add("limit", "1")
+
+	var results []nominatimPlace
+	if err := s.get("/search", params, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPlaceNotFound, query)
+	}
+
+	place, err := results[0].toGeoPlace()
+	if err != nil {
+		return nil, err
+	}
+	s.store(key, place)
+	return place, nil
+}
+
+// ResolvePlace 將 IATA 機場代碼、城市名稱或任意地名解析成座標
+// 機場代碼會先對應到城市（例如 NRT → Tokyo），以市中心作為景點搜尋的中心點
+func (s *GeocodingService) ResolvePlace(input string) (*models.GeoPlace, error) {
+	input = strings.TrimSpace(input)
+	code := strings.ToUpper(input)
+	if city, ok := models.AirportCityMap[code]; ok && len(code) == 3 {
+		place, err := s.Geocode(city)
+		if err != nil {
+			return nil, err
+		}
+		resolved := *place
+		resolved.Airport = code
+		return &resolved, nil
+	}
+	return s.Geocode(input)
+}
+
+// ReverseGeocode 由座標查詢地名
+func (s *GeocodingService) ReverseGeocode(lat, lng float64) (*models.GeoPlace, error) {
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return nil, fmt.Errorf("%w: 座標超出範圍 %f, %f", ErrInvalidPlaceQuery, lat, lng)
+	}
+
+	// 約 10 公尺的精度就足夠共用快取
+	key := fmt.Sprintf("r:%.4f,%.4f", lat, lng)
+	if place, ok := s.cached(key); ok {
+		return place, nil
+	}
+
+	params := url.Values{}
+	params.Add("lat", strconv.FormatFloat(lat, 'f', 6, 64))
+	params.Add("lon", strconv.FormatFloat(lng, 'f', 6, 64))
+	params.Add("format", "jsonv2")
+	params.Add("addressdetails", "1")
+
+	var result nominatimPlace
+	if err := s.get("/reverse", params, &result); err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("%w: %s", ErrPlaceNotFound, result.Error)
+	}
+
+	place, err := result.toGeoPlace()
+	if err != nil {
+		return nil, err
+	}
+	s.store(key, place)
+	return place, nil
+}
+
+// get 發送節流後的請求並解析 JSON
+func (s *GeocodingService) get(path string, params url.Values, out interface{}) error {
+	s.throttle()
+
+	req, err := http.NewRequest("GET", s.BaseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("創建請求失敗: %v", err)
+	}
+	req.Header.Set("User-Agent", s.UserAgent)
+	if s.Language != "" {
+		req.Header.Set("Accept-Language", s.Language)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("地理編碼請求失敗: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("地理編碼 API 錯誤: %s - %s", resp.Status, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析地理編碼響應失敗: %v", err)
+	}
+	return nil
+}
+
+// throttle 確保兩次請求之間至少間隔 minInterval
+func (s *GeocodingService) throttle() {
+	s.throttleMutex.Lock()
+	defer s.throttleMutex.Unlock()
+
+	if wait := s.minInterval - time.Since(s.lastRequest); wait > 0 {
+		time.Sleep(wait)
+	}
+	s.lastRequest = time.Now()
+}
+
+// loadCache 第一次使用時從檔案載入快取（呼叫端需持有 cacheMutex）
+func (s *GeocodingService) loadCache() {
+	if s.cache != nil {
+		return
+	}
+	s.cache = make(map[string]models.GeoPlace)
+
+	data, err := os.ReadFile(s.cachePath)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &s.cache); err != nil {
+		log.Printf("⚠️ 地理編碼快取解析失敗，將重新建立: %v", err)
+		s.cache = make(map[string]models.GeoPlace)
+	}
+}
+
+func (s *GeocodingService) cached(key string) (*models.GeoPlace, bool) {
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	s.loadCache()
+	place, ok := s.cache[key]
+	if !ok || time.Since(place.CachedAt) > geocodeCacheTTL {
+		return nil, false
+	}
+	return &place, true
+}
+
+func (s *GeocodingService) store(key string, place *models.GeoPlace) {
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	s.loadCache()
+	place.CachedAt = time.Now()
+	s.cache[key] = *place
+
+	data, err := json.MarshalIndent(s.cache, "", "  ")
+	if err != nil {
+		log.Printf("❌ 地理編碼快取序列化失敗: %v", err)
+		return
+	}
+	if err := os.WriteFile(s.cachePath, data, 0644); err != nil {
+		log.Printf("❌ 無法寫入地理編碼快取: %v", err)
+	}
+}
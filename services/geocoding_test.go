@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestGeocoder(t *testing.T, handler http.HandlerFunc) (*GeocodingService, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Header.Get("User-Agent") != "GoSkyAlert-test" {
+			t.Errorf("User-Agent 錯誤: %q", r.Header.Get("User-Agent"))
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	s := NewGeocodingService("GoSkyAlert-test")
+	s.BaseURL = server.URL
+	s.cachePath = filepath.Join(t.TempDir(), "geocode_cache.json")
+	s.minInterval = 50 * time.Millisecond
+	return s, &calls
+}
+
+func TestGeocode_CacheAndThrottle(t *testing.T) {
+	s, calls := newTestGeocoder(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search":
+			fmt.Fprint(w, `[{"lat":"35.6812","lon":"139.7671","name":"東京車站","display_name":"東京車站, 千代田區, 東京都, 日本","address":{"city":"千代田區","country":"日本","country_code":"jp"}}]`)
+		case "/reverse":
+			fmt.Fprint(w, `{"lat":"25.0339","lon":"121.5645","display_name":"台北101, 信義區, 臺北市, 臺灣","address":{"city":"臺北市","country":"臺灣","country_code":"tw"}}`)
+		}
+	})
+
+	place, err := s.Geocode("Tokyo Station")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if place.Name != "東京車站" || place.CountryCode != "JP" || place.Latitude != 35.6812 {
+		t.Errorf("解析結果錯誤: %+v", place)
+	}
+
+	// 大小寫不同也應命中快取
+	if _, err := s.Geocode("tokyo station"); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("第二次查詢應使用快取, 實際請求 %d 次", got)
+	}
+
+	start := time.Now()
+	place, err = s.ReverseGeocode(25.0339, 121.5645)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if place.Name != "台北101" || place.City != "臺北市" {
+		t.Errorf("反向查詢結果錯誤: %+v", place)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("連續請求應被節流, 只間隔了 %v", elapsed)
+	}
+
+	// 重新建立服務後應從檔案讀回快取
+	reloaded := NewGeocodingService("GoSkyAlert-test")
+	reloaded.BaseURL = "http://127.0.0.1:0"
+	reloaded.cachePath = s.cachePath
+	if _, err := reloaded.Geocode("Tokyo Station"); err != nil {
+		t.Errorf("應從快取檔讀取結果: %v", err)
+	}
+}
+
+func TestGeocode_NotFound(t *testing.T) {
+	s, _ := newTestGeocoder(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	if _, err := s.Geocode("不存在的地方"); !errors.Is(err, ErrPlaceNotFound) {
+		t.Errorf("找不到地點時應回傳 ErrPlaceNotFound, 實際 %v", err)
+	}
+	if _, err := s.ReverseGeocode(91, 0); !errors.Is(err, ErrInvalidPlaceQuery) {
+		t.Errorf("超出範圍的座標應回傳 ErrInvalidPlaceQuery, 實際 %v", err)
+	}
+}
+
+func TestGeocode_UpstreamError(t *testing.T) {
+	s, _ := newTestGeocoder(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	})
+	_, err := s.Geocode("Tokyo")
+	if err == nil || errors.Is(err, ErrPlaceNotFound) || errors.Is(err, ErrInvalidPlaceQuery) {
+		t.Errorf("上游錯誤不應視為查無結果: %v", err)
+	}
+}
+
+func TestResolvePlace_AirportCode(t *testing.T) {
+	var queries []string
+	s, _ := newTestGeocoder(t, func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("q"))
+		fmt.Fprint(w, `[{"lat":"34.6937","lon":"135.5023","name":"大阪市","display_name":"大阪市, 大阪府, 日本","address":{"city":"大阪市","country":"日本","country_code":"jp"}}]`)
+	})
+	s.minInterval = 0
+
+	place, err := s.ResolvePlace("kix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if place.Airport != "KIX" || place.Name != "大阪市" {
+		t.Errorf("機場代碼解析錯誤: %+v", place)
+	}
+
+	// 一般地名直接查詢，不會帶上機場代碼
+	place, err = s.ResolvePlace("Dotonbori")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if place.Airport != "" {
+		t.Errorf("地名不應標記機場: %+v", place)
+	}
+
+	want := []string{"Osaka", "Dotonbori"}
+	if len(queries) != len(want) || queries[0] != want[0] || queries[1] != want[1] {
+		t.Errorf("查詢字串 = %v, 預期 %v", queries, want)
+	}
+}
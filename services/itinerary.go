@@ -0,0 +1,470 @@
+package services
+
+import (
+	"errors"
+	"final/models"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxItineraryDays      = 7
+	defaultStopsPerDay    = 4
+	maxStopsPerDay        = 8
+	itinerarySearchRadius = 8000 // 公尺，以市中心為圓心
+
+	itineraryDayStart = 9 * 60  // 每天 09:00 出發
+	itineraryDayEnd   = 20 * 60 // 20:00 前結束最後一個景點
+	visitMinutes      = 90      // 每個景點停留時間
+
+	walkMetersPerMinute = 80 // 約 4.8 km/h
+	// 直線距離換算實際步行距離的繞路係數
+	walkDetourFactor = 1.3
+	kmeansIterations = 10
+)
+
+var (
+	// ErrInvalidItinerary 行程參數錯誤（目的地、天數、日期或興趣類別）
+	ErrInvalidItinerary = errors.New("無效的行程參數")
+	// ErrNoItineraryStops 目的地附近沒有符合興趣的景點
+	ErrNoItineraryStops = errors.New("找不到符合興趣的景點")
+)
+
+// 沒有指定興趣時的預設類別
+var defaultItineraryInterests = []string{"landmarks", "arts"}
+
+// ItineraryService 依景點、座標與營業時間規劃每日行程
+type ItineraryService struct {
+	foursquare *FoursquareService
+	geocoder   *GeocodingService
+}
+
+// NewItineraryService 建立行程規劃服務，兩個服務都必須啟用
+func NewItineraryService(foursquare *FoursquareService, geocoder *GeocodingService) *ItineraryService {
+	return &ItineraryService{foursquare: foursquare, geocoder: geocoder}
+}
+
+// normalizeItineraryRequest 檢查並補上預設值
+func normalizeItineraryRequest(req *models.ItineraryRequest) error {
+	req.Destination = strings.TrimSpace(req.Destination)
+	if req.Destination == "" {
+		return fmt.Errorf("%w: 缺少目的地", ErrInvalidItinerary)
+	}
+	if req.Days < 1 || req.Days > maxItineraryDays {
+		return fmt.Errorf("%w: 天數必須介於 1 到 %d", ErrInvalidItinerary, maxItineraryDays)
+	}
+	if req.PerDay == 0 {
+		req.PerDay = defaultStopsPerDay
+	}
+	if req.PerDay < 1 || req.PerDay > maxStopsPerDay {
+		return fmt.Errorf("%w: 每天景點數必須介於 1 到 %d", ErrInvalidItinerary, maxStopsPerDay)
+	}
+	if req.StartDate != "" {
+		if _, err := time.Parse("2006-01-02", req.StartDate); err != nil {
+			return fmt.Errorf("%w: 日期格式錯誤，請使用 YYYY-MM-DD", ErrInvalidItinerary)
+		}
+	}
+
+	var interests []string
+	for _, interest := range req.Interests {
+		interest = strings.TrimSpace(interest)
+		if interest == "" {
+			continue
+		}
+		if _, err := DefaultCategoryTaxonomy().ResolveIDs(interest); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidItinerary, err)
+		}
+		interests = append(interests, interest)
+	}
+	if len(interests) == 0 {
+		interests = defaultItineraryInterests
+	}
+	req.Interests = interests
+	return nil
+}
+
+// PlanItinerary 解析目的地、依興趣搜尋景點，再排成每日行程
+func (s *ItineraryService) PlanItinerary(req models.ItineraryRequest) (*models.TripItinerary, error) {
+	if err := normalizeItineraryRequest(&req); err != nil {
+		return nil, err
+	}
+	if s.foursquare == nil || s.geocoder == nil {
+		return nil, fmt.Errorf("行程規劃需要景點與地理編碼服務")
+	}
+
+	place, err := s.geocoder.ResolvePlace(req.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.searchCandidates(place, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: %s 附近沒有結果", ErrNoItineraryStops, place.Name)
+	}
+
+	return BuildItinerary(place, candidates, req), nil
+}
+
+// searchCandidates 依每個興趣類別搜尋熱門景點並去除重複
+func (s *ItineraryService) searchCandidates(place *models.GeoPlace, req models.ItineraryRequest) ([]Attraction, error) {
+	limit := req.Days * req.PerDay * 2
+	if limit > maxAttractionLimit {
+		limit = maxAttractionLimit
+	}
+
+	seen := make(map[string]bool)
+	var candidates []Attraction
+	for _, interest := range req.Interests {
+		page, err := s.foursquare.Search(SearchRequest{
+			Latitude:  place.Latitude,
+			Longitude: place.Longitude,
+			Radius:    itinerarySearchRadius,
+			Category:  interest,
+			Sort:      AttractionSortPopularity,
+			Limit:     limit,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("搜尋 %s 景點失敗: %v", interest, err)
+		}
+		for _, a := range page.Attractions {
+			if a.ID == "" || seen[a.ID] {
+				continue
+			}
+			seen[a.ID] = true
+			candidates = append(candidates, a)
+		}
+	}
+	log.Printf("🗺️ %s 行程候選景點 %d 個", place.Name, len(candidates))
+	return candidates, nil
+}
+
+// BuildItinerary 從候選景點挑出評分最高的幾個，依地理位置分到每天，
+// 再以考慮營業時間的最近鄰路線排出每天的順序（不呼叫任何外部 API）
+func BuildItinerary(origin *models.GeoPlace, candidates []Attraction, req models.ItineraryRequest) *models.TripItinerary {
+	itinerary := &models.TripItinerary{
+		Destination: req.Destination,
+		Place:       origin,
+		Interests:   req.Interests,
+		StartDate:   req.StartDate,
+		Days:        make([]models.ItineraryDay, 0, req.Days),
+	}
+
+	// 評分高的優先，評分相同保留搜尋（熱門度）順序
+	picked := append([]Attraction(nil), candidates...)
+	sort.SliceStable(picked, func(i, j int) bool {
+		return picked[i].Rating > picked[j].Rating
+	})
+	if limit := req.Days * req.PerDay; len(picked) > limit {
+		picked = picked[:limit]
+	}
+
+	start, hasDate := time.Time{}, false
+	if req.StartDate != "" {
+		if t, err := time.Parse("2006-01-02", req.StartDate); err == nil {
+			start, hasDate = t, true
+		}
+	}
+
+	clusters := clusterAttractions(picked, req.Days, req.PerDay)
+	for i, cluster := range clusters {
+		day := models.ItineraryDay{Day: i + 1, Stops: []models.ItineraryStop{}}
+		var date time.Time
+		if hasDate {
+			date = start.AddDate(0, 0, i)
+			day.Date = date.Format("2006-01-02")
+		}
+
+		stops, skipped := scheduleDay(origin, cluster, date, hasDate)
+		day.Stops = stops
+		for _, stop := range stops {
+			day.TotalWalkMeters += stop.WalkMeters
+			day.TotalWalkMinutes += stop.WalkMinutes
+		}
+		for _, a := range skipped {
+			itinerary.Unscheduled = append(itinerary.Unscheduled, a.Name)
+		}
+		itinerary.Days = append(itinerary.Days, day)
+	}
+	return itinerary
+}
+
+// distanceMeters 兩點間的大圓距離
+func distanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadius = 6371000.0
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return earthRadius * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// walkingDistance 估計步行距離（公尺）與時間（分鐘）
+func walkingDistance(lat1, lng1, lat2, lng2 float64) (int, int) {
+	meters := distanceMeters(lat1, lng1, lat2, lng2) * walkDetourFactor
+	return int(math.Round(meters)), int(math.Ceil(meters / walkMetersPerMinute))
+}
+
+// clusterAttractions 以 k-means 將景點分成 days 群，每群最多 perDay 個
+// 初始中心取評分最高的景點，之後每次挑離現有中心最遠的景點，結果可重現
+func clusterAttractions(attractions []Attraction, days, perDay int) [][]Attraction {
+	clusters := make([][]Attraction, days)
+	if len(attractions) == 0 {
+		return clusters
+	}
+
+	k := days
+	if len(attractions) < k {
+		k = len(attractions)
+	}
+	type point struct{ lat, lng float64 }
+	centroids := []point{{attractions[0].Latitude, attractions[0].Longitude}}
+	for len(centroids) < k {
+		best, bestDist := -1, -1.0
+		for i, a := range attractions {
+			nearest := math.MaxFloat64
+			for _, c := range centroids {
+				nearest = math.Min(nearest, distanceMeters(a.Latitude, a.Longitude, c.lat, c.lng))
+			}
+			if nearest > bestDist {
+				best, bestDist = i, nearest
+			}
+		}
+		centroids = append(centroids, point{attractions[best].Latitude, attractions[best].Longitude})
+	}
+
+	// 每群容量至少能放下所有景點
+	capacity := perDay
+	if need := (len(attractions) + k - 1) / k; need > capacity {
+		capacity = need
+	}
+
+	assignment := make([]int, len(attractions))
+	for iter := 0; iter < kmeansIterations; iter++ {
+		// 依距離由近到遠分配，已滿的群就改放下一個最近的群
+		type pair struct {
+			attraction, cluster int
+			dist                float64
+		}
+		pairs := make([]pair, 0, len(attractions)*k)
+		for i, a := range attractions {
+			for c, centroid := range centroids {
+				pairs = append(pairs, pair{i, c, distanceMeters(a.Latitude, a.Longitude, centroid.lat, centroid.lng)})
+			}
+		}
+		sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].dist < pairs[j].dist })
+
+		next := make([]int, len(attractions))
+		for i := range next {
+			next[i] = -1
+		}
+		sizes := make([]int, k)
+		for _, p := range pairs {
+			if next[p.attraction] >= 0 || sizes[p.cluster] >= capacity {
+				continue
+			}
+			next[p.attraction] = p.cluster
+			sizes[p.cluster]++
+		}
+
+		changed := iter == 0
+		for i := range next {
+			if next[i] != assignment[i] {
+				changed = true
+			}
+		}
+		assignment = next
+		if !changed {
+			break
+		}
+
+		// 重新計算中心
+		for c := range centroids {
+			var lat, lng float64
+			n := 0
+			for i, a := range attractions {
+				if assignment[i] == c {
+					lat += a.Latitude
+					lng += a.Longitude
+					n++
+				}
+			}
+			if n > 0 {
+				centroids[c] = point{lat / float64(n), lng / float64(n)}
+			}
+		}
+	}
+
+	for i, a := range attractions {
+		clusters[assignment[i]] = append(clusters[assignment[i]], a)
+	}
+	return clusters
+}
+
+// openWindow 一段營業時間（從午夜起算的分鐘數）
+type openWindow struct{ open, close int }
+
+// parseHHMM 解析 Foursquare 的 HHMM 時間，"+0200" 表示隔天
+func parseHHMM(s string) (int, bool) {
+	nextDay := strings.HasPrefix(s, "+")
+	s = strings.TrimPrefix(s, "+")
+	if len(s) != 4 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false
+	}
+	minutes := (n/100)*60 + n%100
+	if nextDay {
+		minutes += 24 * 60
+	}
+	return minutes, true
+}
+
+// openWindows 取得景點在某天的營業時段；沒有營業時間資料或不知道日期時視為整天開放，
+// 有資料但當天沒有任何時段則代表公休（回傳空切片）
+func openWindows(hours *AttractionHours, date time.Time, hasDate bool) []openWindow {
+	allDay := []openWindow{{0, 24 * 60}}
+	if hours == nil || len(hours.Regular) == 0 || !hasDate {
+		return allDay
+	}
+
+	// Foursquare 的 day：1 = 週一 ... 7 = 週日
+	day := int(date.Weekday())
+	if day == 0 {
+		day = 7
+	}
+	windows := []openWindow{}
+	for _, period := range hours.Regular {
+		if period.Day != day {
+			continue
+		}
+		openAt, ok1 := parseHHMM(period.Open)
+		closeAt, ok2 := parseHHMM(period.Close)
+		if !ok1 || !ok2 {
+			continue
+		}
+		if closeAt <= openAt {
+			closeAt += 24 * 60
+		}
+		windows = append(windows, openWindow{openAt, closeAt})
+	}
+	return windows
+}
+
+// earliestVisit 在 arrive 之後最早可以開始參觀的時間；當天排不進去時回傳 false
+func earliestVisit(windows []openWindow, arrive int) (int, bool) {
+	best, found := 0, false
+	for _, w := range windows {
+		start := arrive
+		if w.open > start {
+			start = w.open
+		}
+		if start+visitMinutes > w.close || start+visitMinutes > itineraryDayEnd {
+			continue
+		}
+		if !found || start < best {
+			best, found = start, true
+		}
+	}
+	return best, found
+}
+
+// scheduleDay 從住宿（市中心）出發，每次前往「步行加等待時間」最短且營業中的景點
+func scheduleDay(origin *models.GeoPlace, attractions []Attraction, date time.Time, hasDate bool) ([]models.ItineraryStop, []Attraction) {
+	stops := []models.ItineraryStop{}
+	remaining := append([]Attraction(nil), attractions...)
+	lat, lng := origin.Latitude, origin.Longitude
+	clock := itineraryDayStart
+
+	for len(remaining) > 0 {
+		best, bestStart := -1, 0
+		var bestMeters, bestWalk int
+		for i, a := range remaining {
+			meters, walk := walkingDistance(lat, lng, a.Latitude, a.Longitude)
+			start, ok := earliestVisit(openWindows(a.Hours, date, hasDate), clock+walk)
+			if !ok {
+				continue
+			}
+			if best < 0 || start < bestStart || (start == bestStart && meters < bestMeters) {
+				best, bestStart, bestMeters, bestWalk = i, start, meters, walk
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		a := remaining[best]
+		stop := models.ItineraryStop{
+			Order:       len(stops) + 1,
+			ID:          a.ID,
+			Name:        a.Name,
+			Category:    a.Category,
+			Address:     a.Address,
+			Latitude:    a.Latitude,
+			Longitude:   a.Longitude,
+			Rating:      a.Rating,
+			Arrive:      clockString(bestStart),
+			Depart:      clockString(bestStart + visitMinutes),
+			WalkMeters:  bestMeters,
+			WalkMinutes: bestWalk,
+			WaitMinutes: bestStart - clock - bestWalk,
+		}
+		if a.Hours != nil {
+			stop.Hours = a.Hours.Display
+		}
+		stops = append(stops, stop)
+
+		lat, lng = a.Latitude, a.Longitude
+		clock = bestStart + visitMinutes
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+	return stops, remaining
+}
+
+func clockString(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60%24, minutes%60)
+}
+
+// FormatItinerary 將行程整理成 Discord 可用的 Markdown 訊息，lang 為 botTexts 的語系
+func FormatItinerary(it *models.TripItinerary, lang string) string {
+	var b strings.Builder
+	name := it.Destination
+	if it.Place != nil && it.Place.Name != "" {
+		name = it.Place.Name
+	}
+	sep := botText(lang, "plan.list_sep")
+	b.WriteString(botText(lang, "plan.title", name, len(it.Days), strings.Join(it.Interests, sep)))
+
+	for _, day := range it.Days {
+		b.WriteString(fmt.Sprintf("\n📅 **Day %d**", day.Day))
+		if day.Date != "" {
+			b.WriteString(" " + day.Date)
+		}
+		if len(day.Stops) == 0 {
+			b.WriteString(botText(lang, "plan.free"))
+			continue
+		}
+		b.WriteString(botText(lang, "plan.walk", float64(day.TotalWalkMeters)/1000))
+		for _, stop := range day.Stops {
+			b.WriteString(fmt.Sprintf("%d. `%s-%s` **%s**", stop.Order, stop.Arrive, stop.Depart, stop.Name))
+			if stop.WalkMinutes > 0 {
+				b.WriteString(botText(lang, "plan.walk_minutes", stop.WalkMinutes))
+			}
+			b.WriteString("\n")
+		}
+	}
+	if len(it.Unscheduled) > 0 {
+		b.WriteString(botText(lang, "plan.unscheduled", strings.Join(it.Unscheduled, sep)))
+	}
+	return b.String()
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"final/models"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// 京都市中心附近兩群景點：東山（東側）與嵐山（西側）
+var kyotoCandidates = []Attraction{
+	{ID: "kiyomizu", Name: "清水寺", Latitude: 34.9949, Longitude: 135.7850, Rating: 9.5},
+	{ID: "yasaka", Name: "八坂神社", Latitude: 35.0037, Longitude: 135.7788, Rating: 9.0},
+	{ID: "ginkaku", Name: "銀閣寺", Latitude: 35.0270, Longitude: 135.7982, Rating: 8.8},
+	{ID: "bamboo", Name: "竹林小徑", Latitude: 35.0170, Longitude: 135.6713, Rating: 9.3},
+	{ID: "tenryu", Name: "天龍寺", Latitude: 35.0158, Longitude: 135.6738, Rating: 8.9},
+	{ID: "togetsu", Name: "渡月橋", Latitude: 35.0128, Longitude: 135.6778, Rating: 8.7},
+	{ID: "lowrated", Name: "評分最低", Latitude: 35.0116, Longitude: 135.7681, Rating: 5.0},
+}
+
+var kyotoCenter = &models.GeoPlace{Name: "京都市", Latitude: 35.0116, Longitude: 135.7681}
+
+func dayIDs(day models.ItineraryDay) map[string]bool {
+	ids := make(map[string]bool)
+	for _, stop := range day.Stops {
+		ids[stop.ID] = true
+	}
+	return ids
+}
+
+func TestBuildItinerary_ClustersByArea(t *testing.T) {
+	req := models.ItineraryRequest{Destination: "Kyoto", Days: 2, PerDay: 3, Interests: []string{"landmarks"}}
+	it := BuildItinerary(kyotoCenter, kyotoCandidates, req)
+
+	if len(it.Days) != 2 {
+		t.Fatalf("應有 2 天行程, 實際 %d", len(it.Days))
+	}
+	east := []string{"kiyomizu", "yasaka", "ginkaku"}
+	west := []string{"bamboo", "tenryu", "togetsu"}
+	for _, day := range it.Days {
+		ids := dayIDs(day)
+		if ids["lowrated"] {
+			t.Error("評分最低的景點超過名額，不應被排入")
+		}
+		group := east
+		if ids["bamboo"] {
+			group = west
+		}
+		for _, id := range group {
+			if !ids[id] {
+				t.Errorf("Day %d 應把同一區的景點排在一起, 缺少 %s: %+v", day.Day, id, day.Stops)
+			}
+		}
+		if day.TotalWalkMeters <= 0 {
+			t.Errorf("Day %d 應計算步行距離", day.Day)
+		}
+	}
+}
+
+func TestBuildItinerary_NearestNeighborAndSchedule(t *testing.T) {
+	req := models.ItineraryRequest{Destination: "Kyoto", Days: 1, PerDay: 3}
+	candidates := []Attraction{kyotoCandidates[2], kyotoCandidates[0], kyotoCandidates[1]}
+	it := BuildItinerary(kyotoCenter, candidates, req)
+
+	stops := it.Days[0].Stops
+	if len(stops) != 3 {
+		t.Fatalf("應排入 3 個景點, 實際 %+v", stops)
+	}
+	// 從市中心出發，最近的是八坂神社，接著清水寺，最後是較遠的銀閣寺
+	want := []string{"yasaka", "kiyomizu", "ginkaku"}
+	for i, id := range want {
+		if stops[i].ID != id || stops[i].Order != i+1 {
+			t.Errorf("第 %d 站 = %s, 預期 %s", i+1, stops[i].ID, id)
+		}
+	}
+	if stops[0].Arrive < "09:00" || stops[1].Arrive < stops[0].Depart {
+		t.Errorf("時間安排錯誤: %+v", stops)
+	}
+}
+
+func TestBuildItinerary_RespectsOpeningHours(t *testing.T) {
+	// 2026-03-02 是週一
+	req := models.ItineraryRequest{Destination: "Kyoto", Days: 1, PerDay: 3, StartDate: "2026-03-02"}
+	candidates := []Attraction{
+		// 最近但下午才開
+		{ID: "late", Name: "下午開放", Latitude: 35.0120, Longitude: 135.7690, Rating: 9,
+			Hours: &AttractionHours{Regular: []AttractionOpenPeriod{{Day: 1, Open: "1300", Close: "1800"}}}},
+		{ID: "early", Name: "整天開放", Latitude: 35.0150, Longitude: 135.7700, Rating: 8},
+		// 週一公休
+		{ID: "closed", Name: "週一公休", Latitude: 35.0130, Longitude: 135.7685, Rating: 7,
+			Hours: &AttractionHours{Regular: []AttractionOpenPeriod{{Day: 2, Open: "0900", Close: "1700"}}}},
+	}
+	it := BuildItinerary(kyotoCenter, candidates, req)
+
+	stops := it.Days[0].Stops
+	if len(stops) != 2 || stops[0].ID != "early" || stops[1].ID != "late" {
+		t.Fatalf("應先去整天開放的景點，再等下午開放的景點: %+v", stops)
+	}
+	if stops[1].Arrive != "13:00" || stops[1].WaitMinutes <= 0 {
+		t.Errorf("應等到 13:00 開門: %+v", stops[1])
+	}
+	if len(it.Unscheduled) != 1 || it.Unscheduled[0] != "週一公休" {
+		t.Errorf("公休的景點應列在未排入清單: %v", it.Unscheduled)
+	}
+	if it.Days[0].Date != "2026-03-02" {
+		t.Errorf("日期錯誤: %s", it.Days[0].Date)
+	}
+}
+
+func TestNormalizeItineraryRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.ItineraryRequest
+		wantErr bool
+	}{
+		{"預設興趣", models.ItineraryRequest{Destination: "KIX", Days: 2}, false},
+		{"缺少目的地", models.ItineraryRequest{Days: 2}, true},
+		{"天數過多", models.ItineraryRequest{Destination: "KIX", Days: 10}, true},
+		{"日期格式錯誤", models.ItineraryRequest{Destination: "KIX", Days: 1, StartDate: "2026/03/01"}, true},
+		{"未知興趣", models.ItineraryRequest{Destination: "KIX", Days: 1, Interests: []string{"unicorn"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			err := normalizeItineraryRequest(&req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrInvalidItinerary) {
+				t.Errorf("參數錯誤應回傳 ErrInvalidItinerary, 實際 %v", err)
+			}
+			if !tt.wantErr && (req.PerDay != defaultStopsPerDay || len(req.Interests) == 0) {
+				t.Errorf("未補上預設值: %+v", req)
+			}
+		})
+	}
+}
+
+// 上游服務失敗不可與參數錯誤或查無結果混淆，handler 才能回 502
+func TestPlanItinerary_ErrorKinds(t *testing.T) {
+	geocoder, _ := newTestGeocoder(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"lat":"34.6937","lon":"135.5023","name":"大阪市","address":{"country_code":"jp"}}]`)
+	})
+	failing := newTestFoursquare(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream down", http.StatusInternalServerError)
+	})
+	empty := newTestFoursquare(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"results":[]}`)
+	})
+
+	_, err := NewItineraryService(failing, geocoder).PlanItinerary(models.ItineraryRequest{Destination: "KIX", Days: 1})
+	if err == nil || errors.Is(err, ErrInvalidItinerary) || errors.Is(err, ErrNoItineraryStops) || errors.Is(err, ErrPlaceNotFound) {
+		t.Errorf("景點服務失敗應為上游錯誤: %v", err)
+	}
+	_, err = NewItineraryService(empty, geocoder).PlanItinerary(models.ItineraryRequest{Destination: "KIX", Days: 1})
+	if !errors.Is(err, ErrNoItineraryStops) {
+		t.Errorf("沒有景點應回傳 ErrNoItineraryStops, 實際 %v", err)
+	}
+}
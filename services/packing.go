@@ -0,0 +1,351 @@
+package services
+
+import (
+	"final/models"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// 衣物數量上限（超過一週以洗衣為前提）
+const maxClothingSets = 7
+
+type PackingService struct {
+	weather  *WeatherService
+	exchange *ExchangeService
+}
+
+// NewPackingService 建立行李清單服務，weather 與 exchange 皆可為 nil
+// 沒有天氣服務（或未設定金鑰）時不列出依天氣判斷的項目，沒有匯率服務時不附匯率
+func NewPackingService(weather *WeatherService, exchange *ExchangeService) *PackingService {
+	return &PackingService{weather: weather, exchange: exchange}
+}
+
+// resolveDestinationCity 目的地可以是機場代碼（NRT）或城市名稱（Tokyo）
+func resolveDestinationCity(destination string) string {
+	code := strings.ToUpper(strings.TrimSpace(destination))
+	if city, ok := models.AirportCityMap[code]; ok {
+		return city
+	}
+	for city := range models.CityCountryMap {
+		if strings.EqualFold(city, destination) {
+			return city
+		}
+	}
+	return strings.TrimSpace(destination)
+}
+
+// GeneratePackingList 依目的地天氣展望、停留天數與當地用電/貨幣產生分類行李清單
+func (s *PackingService) GeneratePackingList(req models.PackingRequest) (*models.PackingList, error) {
+	if req.Destination == "" || req.StartDate == "" {
+		return nil, fmt.Errorf("缺少目的地或出發日期")
+	}
+
+	city := resolveDestinationCity(req.Destination)
+
+	start, end, err := tripDateRange(req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &models.PackingList{
+		Destination: req.Destination,
+		City:        city,
+		StartDate:   start.Format("2006-01-02"),
+		EndDate:     end.Format("2006-01-02"),
+		Days:        int(end.Sub(start).Hours()/24) + 1,
+	}
+
+	// 沒有設定天氣 API 金鑰時不查詢天氣，只列出與天氣無關的項目
+	hasWeather := false
+	if s.weather != nil && s.weather.APIKey != "" {
+		outlook, err := s.weather.GetTripOutlook(city, req.StartDate, req.EndDate)
+		if err != nil {
+			log.Printf("⚠️ %s 沒有天氣資料，行李清單只列基本項目: %v", city, err)
+		}
+		if outlook != nil && outlook.ForecastDays+outlook.ClimateDays > 0 {
+			list.Weather = outlook
+			list.MinTemp = outlook.MinTemp
+			list.MaxTemp = outlook.MaxTemp
+			list.RainyDays = outlook.RainyDays
+			hasWeather = true
+		}
+	}
+
+	country, hasCountry := models.GetCountryByCity(city)
+	// 沒有指定出發地時以台灣為準
+	home, ok := models.GetCountryByAirportCode(strings.ToUpper(req.Origin))
+	if !ok {
+		home = models.Countries["TW"]
+	}
+	if hasCountry {
+		list.Country = country.Name
+		list.Power = powerInfo(country, home)
+		list.Currency = s.currencyInfo(country, home)
+	}
+
+	list.Categories = append(list.Categories, documentItems(country, hasCountry, home))
+	list.Categories = append(list.Categories, clothingItems(list.Days, list.MinTemp, list.MaxTemp, hasWeather))
+	if hasWeather {
+		if rain := rainItems(list.Weather); len(rain.Items) > 0 {
+			list.Categories = append(list.Categories, rain)
+		}
+	}
+	if hasWeather && list.MaxTemp >= 25 {
+		list.Categories = append(list.Categories, models.PackingCategory{
+			Key:   "sun",
+			Title: "防曬",
+			Items: []models.PackingItem{
+				{Name: "防曬乳", Note: fmt.Sprintf("最高溫約 %.0f°C", list.MaxTemp)},
+				{Name: "太陽眼鏡"},
+				{Name: "遮陽帽"},
+			},
+		})
+	}
+	list.Categories = append(list.Categories, electronicItems(list.Power))
+	list.Categories = append(list.Categories, healthItems(list.MinTemp, hasWeather))
+
+	return list, nil
+}
+
+func powerInfo(country, home models.CountryInfo) *models.PowerInfo {
+	info := &models.PowerInfo{
+		PlugTypes:    country.PlugTypes,
+		Voltage:      country.Voltage,
+		NeedsAdapter: true,
+	}
+	for _, plug := range country.PlugTypes {
+		for _, homePlug := range home.PlugTypes {
+			if plug == homePlug {
+				info.NeedsAdapter = false
+			}
+		}
+	}
+	// 100-127V 與 220-240V 兩大系統之間才需要變壓器
+	info.NeedsConverter = (country.Voltage >= 200) != (home.Voltage >= 200)
+	return info
+}
+
+func (s *PackingService) currencyInfo(country, home models.CountryInfo) *models.CurrencyInfo {
+	info := &models.CurrencyInfo{Code: country.Currency}
+	if country.Currency == home.Currency {
+		return info
+	}
+	info.HomeCurrency = home.Currency
+	if s.exchange != nil {
+		if rate, err := s.exchange.GetRate(country.Currency, home.Currency); err == nil {
+			info.Rate = rate
+		} else {
+			log.Printf("⚠️ 無法取得 %s→%s 匯率: %v", country.Currency, home.Currency, err)
+		}
+	}
+	return info
+}
+
+func documentItems(country models.CountryInfo, hasCountry bool, home models.CountryInfo) models.PackingCategory {
+	category := models.PackingCategory{
+		Key:   "documents",
+		Title: "證件與票券",
+		Items: []models.PackingItem{
+			{Name: "護照", Note: "確認效期超過 6 個月"},
+			{Name: "機票與訂房確認"},
+			{Name: "旅遊保險資料"},
+		},
+	}
+	if hasCountry && country.Currency != home.Currency {
+		category.Items = append(category.Items, models.PackingItem{Name: "當地貨幣現金", Note: country.Currency})
+	}
+	category.Items = append(category.Items, models.PackingItem{Name: "信用卡"})
+	return category
+}
+
+func clothingItems(days int, minTemp, maxTemp float64, hasWeather bool) models.PackingCategory {
+	sets := days + 1
+	if sets > maxClothingSets {
+		sets = maxClothingSets
+	}
+	tops := days
+	if tops > maxClothingSets {
+		tops = maxClothingSets
+	}
+
+	category := models.PackingCategory{
+		Key:   "clothing",
+		Title: "衣物",
+		Items: []models.PackingItem{
+			{Name: "內衣褲", Quantity: sets},
+			{Name: "襪子", Quantity: sets},
+			{Name: "睡衣"},
+			{Name: "好走的鞋子"},
+		},
+	}
+	if days > maxClothingSets {
+		category.Items = append(category.Items, models.PackingItem{Name: "洗衣袋與洗衣精", Note: "行程超過一週，建議在當地洗衣"})
+	}
+
+	if !hasWeather {
+		category.Items = append(category.Items,
+			models.PackingItem{Name: "上衣", Quantity: tops},
+			models.PackingItem{Name: "可穿脫的外套", Note: "沒有天氣資料，建議洋蔥式穿搭"},
+		)
+		return category
+	}
+
+	// 依行程中的最高溫與最低溫決定衣物層次
+	if maxTemp >= 26 {
+		category.Items = append(category.Items,
+			models.PackingItem{Name: "短袖上衣", Quantity: tops},
+			models.PackingItem{Name: "短褲或薄長褲"},
+		)
+	}
+	if minTemp < 22 {
+		category.Items = append(category.Items, models.PackingItem{Name: "長袖上衣", Quantity: (tops + 1) / 2})
+		category.Items = append(category.Items, models.PackingItem{Name: "長褲", Quantity: 2})
+	}
+	if minTemp < 15 {
+		category.Items = append(category.Items, models.PackingItem{Name: "毛衣或刷毛外套"})
+	}
+	if minTemp < 5 {
+		category.Items = append(category.Items,
+			models.PackingItem{Name: "羽絨外套", Note: fmt.Sprintf("最低溫約 %.0f°C", minTemp)},
+			models.PackingItem{Name: "發熱衣"},
+		)
+	}
+	if minTemp < 0 {
+		category.Items = append(category.Items,
+			models.PackingItem{Name: "手套"},
+			models.PackingItem{Name: "毛帽"},
+			models.PackingItem{Name: "圍巾"},
+		)
+	}
+	if maxTemp-minTemp >= 10 {
+		category.Items = append(category.Items, models.PackingItem{Name: "薄外套", Note: fmt.Sprintf("日夜溫差約 %.0f°C", maxTemp-minTemp)})
+	}
+	return category
+}
+
+func rainItems(outlook *models.WeatherOutlook) models.PackingCategory {
+	category := models.PackingCategory{Key: "rain", Title: "雨具"}
+	maxChance := 0
+	for _, day := range outlook.Days {
+		if day.ChanceOfRain > maxChance {
+			maxChance = day.ChanceOfRain
+		}
+	}
+	if outlook.RainyDays > 0 || maxChance >= 40 {
+		category.Items = append(category.Items, models.PackingItem{Name: "折疊傘", Note: fmt.Sprintf("最高降雨機率 %d%%", maxChance)})
+	}
+	if outlook.RainyDays >= 3 {
+		category.Items = append(category.Items,
+			models.PackingItem{Name: "防水外套", Note: fmt.Sprintf("約 %d 天可能下雨", outlook.RainyDays)},
+			models.PackingItem{Name: "防水鞋或備用鞋"},
+		)
+	}
+	return category
+}
+
+func electronicItems(power *models.PowerInfo) models.PackingCategory {
+	category := models.PackingCategory{
+		Key:   "electronics",
+		Title: "電子用品",
+		Items: []models.PackingItem{
+			{Name: "手機充電器"},
+			{Name: "行動電源", Note: "需放在隨身行李"},
+		},
+	}
+	if power == nil {
+		return category
+	}
+	if power.NeedsAdapter {
+		category.Items = append(category.Items, models.PackingItem{
+			Name: "轉接頭",
+			Note: fmt.Sprintf("當地插座 %s 型", strings.Join(power.PlugTypes, "/")),
+		})
+	}
+	if power.NeedsConverter {
+		category.Items = append(category.Items, models.PackingItem{
+			Name: "變壓器",
+			Note: fmt.Sprintf("當地電壓 %dV，請確認電器是否支援", power.Voltage),
+		})
+	}
+	return category
+}
+
+func healthItems(minTemp float64, hasWeather bool) models.PackingCategory {
+	category := models.PackingCategory{
+		Key:   "health",
+		Title: "健康與盥洗",
+		Items: []models.PackingItem{
+			{Name: "個人常備藥品"},
+			{Name: "牙刷與盥洗用品"},
+		},
+	}
+	if hasWeather && minTemp < 5 {
+		category.Items = append(category.Items, models.PackingItem{Name: "保濕乳液與護唇膏", Note: "天氣寒冷乾燥"})
+	}
+	if hasWeather && minTemp >= 22 {
+		category.Items = append(category.Items, models.PackingItem{Name: "防蚊液"})
+	}
+	return category
+}
+
+// 行李清單輸出格式
+const (
+	PackingFormatJSON     = "json"
+	PackingFormatMarkdown = "markdown"
+	PackingFormatText     = "text"
+)
+
+// FormatPackingList 將行李清單輸出為 Markdown 或純文字
+func FormatPackingList(list *models.PackingList, format string) string {
+	markdown := format == PackingFormatMarkdown
+	var b strings.Builder
+
+	place := list.City
+	if list.Country != "" {
+		place = fmt.Sprintf("%s（%s）", list.City, list.Country)
+	}
+	if markdown {
+		fmt.Fprintf(&b, "# 🧳 %s 行李清單\n\n", place)
+	} else {
+		fmt.Fprintf(&b, "%s 行李清單\n\n", place)
+	}
+
+	fmt.Fprintf(&b, "日期：%s ~ %s（%d 天）\n", list.StartDate, list.EndDate, list.Days)
+	if list.Weather != nil && list.Weather.ForecastDays+list.Weather.ClimateDays > 0 {
+		fmt.Fprintf(&b, "氣溫：%.0f ~ %.0f°C，約 %d 天可能下雨\n", list.MinTemp, list.MaxTemp, list.RainyDays)
+	}
+	if list.Power != nil {
+		fmt.Fprintf(&b, "插座：%s 型，%dV\n", strings.Join(list.Power.PlugTypes, "/"), list.Power.Voltage)
+	}
+	if list.Currency != nil {
+		if list.Currency.Rate > 0 {
+			fmt.Fprintf(&b, "貨幣：%s（1 %s ≈ %.4f %s）\n", list.Currency.Code, list.Currency.Code, list.Currency.Rate, list.Currency.HomeCurrency)
+		} else {
+			fmt.Fprintf(&b, "貨幣：%s\n", list.Currency.Code)
+		}
+	}
+
+	for _, category := range list.Categories {
+		if markdown {
+			fmt.Fprintf(&b, "\n## %s\n", category.Title)
+		} else {
+			fmt.Fprintf(&b, "\n【%s】\n", category.Title)
+		}
+		for _, item := range category.Items {
+			line := item.Name
+			if item.Quantity > 0 {
+				line += fmt.Sprintf(" x%d", item.Quantity)
+			}
+			if item.Note != "" {
+				line += "（" + item.Note + "）"
+			}
+			if markdown {
+				b.WriteString("- [ ] " + line + "\n")
+			} else {
+				b.WriteString("- " + line + "\n")
+			}
+		}
+	}
+	return b.String()
+}
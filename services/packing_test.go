@@ -0,0 +1,119 @@
+package services
+
+import (
+	"final/models"
+	"strings"
+	"testing"
+)
+
+func packingItemNames(list *models.PackingList) map[string]bool {
+	names := make(map[string]bool)
+	for _, category := range list.Categories {
+		for _, item := range category.Items {
+			names[item.Name] = true
+		}
+	}
+	return names
+}
+
+func TestGeneratePackingList(t *testing.T) {
+	// 出發日期超出預報範圍，天氣服務只會使用內建氣候平均；不帶匯率服務
+	weather, calls := newTestWeatherService(t)
+	svc := NewPackingService(weather, nil)
+
+	tests := []struct {
+		name          string
+		req           models.PackingRequest
+		wantItems     []string
+		notItems      []string
+		wantAdapter   bool
+		wantConverter bool
+	}{
+		{
+			name:          "冬天的札幌",
+			req:           models.PackingRequest{Origin: "TPE", Destination: "CTS", StartDate: "2027-01-10", EndDate: "2027-01-14"},
+			wantItems:     []string{"羽絨外套", "手套", "當地貨幣現金"},
+			notItems:      []string{"短袖上衣", "轉接頭"},
+			wantAdapter:   false,
+			wantConverter: false,
+		},
+		{
+			name:          "夏天的首爾",
+			req:           models.PackingRequest{Origin: "TPE", Destination: "Seoul", StartDate: "2027-07-10", EndDate: "2027-07-20"},
+			wantItems:     []string{"短袖上衣", "折疊傘", "轉接頭", "變壓器", "洗衣袋與洗衣精"},
+			notItems:      []string{"羽絨外套"},
+			wantAdapter:   true,
+			wantConverter: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := svc.GeneratePackingList(tt.req)
+			if err != nil {
+				t.Fatalf("[%s] 產生失敗: %v", tt.name, err)
+			}
+			names := packingItemNames(list)
+			for _, item := range tt.wantItems {
+				if !names[item] {
+					t.Errorf("[%s] 預期包含 %s", tt.name, item)
+				}
+			}
+			for _, item := range tt.notItems {
+				if names[item] {
+					t.Errorf("[%s] 不應包含 %s", tt.name, item)
+				}
+			}
+			if list.Power == nil || list.Power.NeedsAdapter != tt.wantAdapter || list.Power.NeedsConverter != tt.wantConverter {
+				t.Errorf("[%s] 用電資訊錯誤: %+v", tt.name, list.Power)
+			}
+		})
+	}
+
+	if *calls != 0 {
+		t.Errorf("超出預報範圍不應呼叫天氣 API, 實際 %d 次", *calls)
+	}
+	if _, err := svc.GeneratePackingList(models.PackingRequest{Destination: "NRT", StartDate: "2027/01/10"}); err == nil {
+		t.Error("無效日期應回傳錯誤")
+	}
+}
+
+func TestGeneratePackingList_NoWeatherKey(t *testing.T) {
+	req := models.PackingRequest{Origin: "TPE", Destination: "CTS", StartDate: "2027-01-10", EndDate: "2027-01-14"}
+	for name, weather := range map[string]*WeatherService{"沒有天氣服務": nil, "沒有金鑰": NewWeatherService("")} {
+		t.Run(name, func(t *testing.T) {
+			list, err := NewPackingService(weather, nil).GeneratePackingList(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if list.Weather != nil || list.Days != 5 {
+				t.Errorf("不應附天氣資料: weather=%+v days=%d", list.Weather, list.Days)
+			}
+			names := packingItemNames(list)
+			for _, item := range []string{"羽絨外套", "手套", "折疊傘", "防曬乳"} {
+				if names[item] {
+					t.Errorf("沒有天氣資料不應包含 %s", item)
+				}
+			}
+			if !names["護照"] || !names["手機充電器"] {
+				t.Errorf("仍應列出與天氣無關的項目: %v", names)
+			}
+		})
+	}
+}
+
+func TestFormatPackingList(t *testing.T) {
+	list, err := NewPackingService(nil, nil).GeneratePackingList(models.PackingRequest{Destination: "NRT", StartDate: "2027-04-01", EndDate: "2027-04-03"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	md := FormatPackingList(list, PackingFormatMarkdown)
+	if !strings.HasPrefix(md, "# ") || !strings.Contains(md, "- [ ] 護照") {
+		t.Errorf("Markdown 格式錯誤:\n%s", md)
+	}
+	text := FormatPackingList(list, PackingFormatText)
+	if strings.Contains(text, "#") || !strings.Contains(text, "【衣物】") {
+		t.Errorf("純文字格式錯誤:\n%s", text)
+	}
+}
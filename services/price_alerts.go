@@ -0,0 +1,429 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"final/models"
+	"final/notifications"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// 持續追蹤的警報在價格沒有再創新低時，至少間隔這麼久才再通知
+	priceWatchCooldown = 24 * time.Hour
+	// 每個通知目標每小時最多收到幾則價格通知，避免多筆追蹤同時觸發時洗版
+	maxAlertsPerTargetPerHour = 5
+	// 每位使用者最多可同時追蹤的航線數
+	maxWatchesPerOwner = 10
+	// 價格歷史中這段時間內的紀錄可直接使用，不必再查詢 Amadeus
+	recordedFareMaxAge = time.Hour
+	// 沒有指定出發日期時，預設追蹤 30 天後出發的航班（與價格追蹤的假設相同）
+	defaultAlertLeadDays = 30
+)
+
+// notifyLimiter 以滑動視窗限制每個通知目標的訊息數量，零值即可使用
+type notifyLimiter struct {
+	mu   sync.Mutex
+	sent map[string][]time.Time
+}
+
+// allow 回傳視窗內的通知數是否未達上限，不會扣除額度
+func (l *notifyLimiter) allow(key string, now time.Time, limit int, window time.Duration) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.sent == nil {
+		l.sent = make(map[string][]time.Time)
+	}
+	recent := l.sent[key][:0]
+	for _, t := range l.sent[key] {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	l.sent[key] = recent
+	return len(recent) < limit
+}
+
+// record 記錄一則已成功送出的通知
+func (l *notifyLimiter) record(key string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.sent == nil {
+		l.sent = make(map[string][]time.Time)
+	}
+	l.sent[key] = append(l.sent[key], now)
+}
+
+// SetNotifier 設定通知分派器；價格警報指定了管道就送到該處，否則依建立者的通知偏好
+func (s *AmadeusService) SetNotifier(notifier *notifications.Dispatcher) {
+	s.notifier = notifier
+}
+
+// loadPriceAlerts 讀取所有價格警報（呼叫端需持有 alertMutex）
+func (s *AmadeusService) loadPriceAlerts() ([]models.PriceAlert, error) {
+	file, err := os.Open(s.priceAlertsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []models.PriceAlert{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var alerts []models.PriceAlert
+	if err := json.NewDecoder(file).Decode(&alerts); err != nil {
+		return []models.PriceAlert{}, nil
+	}
+	for i := range alerts {
+		alerts[i].NormalizeCurrency()
+	}
+	return alerts, nil
+}
+
+// savePriceAlerts 覆寫價格警報檔案（呼叫端需持有 alertMutex）
+func (s *AmadeusService) savePriceAlerts(alerts []models.PriceAlert) error {
+	file, err := os.Create(s.priceAlertsPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(alerts)
+}
+
+// CreatePriceAlert 建立新的價格警報，航線格式為 TPE-NRT；未指定出發日期時追蹤 30 天後出發的航班
+func (s *AmadeusService) CreatePriceAlert(alert models.PriceAlert) (*models.PriceAlert, error) {
+	alert.Route = strings.ToUpper(strings.TrimSpace(alert.Route))
+	if _, _, ok := alert.RouteCodes(); !ok {
+		return nil, fmt.Errorf("無效的航線格式，請使用 TPE-NRT")
+	}
+	if alert.DepartureDate == "" {
+		alert.DepartureDate = time.Now().AddDate(0, 0, defaultAlertLeadDays).Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", alert.DepartureDate); err != nil {
+		return nil, fmt.Errorf("出發日期格式錯誤，請使用 YYYY-MM-DD")
+	}
+	alert.Currency = strings.ToUpper(alert.PriceCurrency())
+	alert.TargetPrice = alert.TargetPrice.WithCurrency(alert.Currency)
+	if alert.TargetPrice.Sign() <= 0 {
+		return nil, fmt.Errorf("目標價格必須大於 0")
+	}
+	if (alert.Channel == "") != (alert.Target == "") {
+		return nil, fmt.Errorf("通知管道需同時指定 channel 與 target")
+	}
+	if alert.Channel != "" && s.notifier != nil {
+		if err := s.notifier.CheckChannel(alert.Channel); err != nil {
+			return nil, err
+		}
+	}
+
+	alert.ID = fmt.Sprintf("price_%d", time.Now().UnixNano())
+	alert.IsActive = true
+	alert.CreatedAt = time.Now()
+	alert.TriggeredAt = nil
+	alert.TriggeredPrice = models.Money{}
+	alert.LastNotifiedAt = nil
+	alert.LastNotifiedPrice = models.Money{}
+
+	s.alertMutex.Lock()
+	defer s.alertMutex.Unlock()
+
+	alerts, err := s.loadPriceAlerts()
+	if err != nil {
+		return nil, err
+	}
+	if alert.Owner != "" {
+		owned := 0
+		for _, a := range alerts {
+			if a.Owner == alert.Owner && a.IsActive {
+				owned++
+			}
+		}
+		if owned >= maxWatchesPerOwner {
+			return nil, fmt.Errorf("最多只能同時追蹤 %d 條航線，請先取消部分追蹤", maxWatchesPerOwner)
+		}
+	}
+	alerts = append(alerts, alert)
+	if err := s.savePriceAlerts(alerts); err != nil {
+		return nil, fmt.Errorf("儲存價格警報失敗: %v", err)
+	}
+
+	log.Printf("🔔 已建立價格警報 %s: %s (%s) <= %s", alert.ID, alert.Route, alert.DepartureDate, alert.TargetPrice)
+	return &alert, nil
+}
+
+// ListPriceAlerts 列出所有價格警報
+func (s *AmadeusService) ListPriceAlerts() ([]models.PriceAlert, error) {
+	s.alertMutex.Lock()
+	defer s.alertMutex.Unlock()
+	return s.loadPriceAlerts()
+}
+
+// ListPriceAlertsByOwner 列出使用者啟用中的價格警報（依建立時間排序）
+func (s *AmadeusService) ListPriceAlertsByOwner(owner string) ([]models.PriceAlert, error) {
+	s.alertMutex.Lock()
+	defer s.alertMutex.Unlock()
+
+	alerts, err := s.loadPriceAlerts()
+	if err != nil {
+		return nil, err
+	}
+	owned := []models.PriceAlert{}
+	for _, a := range alerts {
+		if a.Owner == owner && a.IsActive {
+			owned = append(owned, a)
+		}
+	}
+	return owned, nil
+}
+
+// DeletePriceAlert 刪除指定的價格警報
+func (s *AmadeusService) DeletePriceAlert(id string) error {
+	return s.deletePriceAlert(id, func(models.PriceAlert) bool { return true })
+}
+
+// DeleteOwnedPriceAlert 只允許建立者刪除自己的價格警報
+func (s *AmadeusService) DeleteOwnedPriceAlert(owner, id string) error {
+	return s.deletePriceAlert(id, func(a models.PriceAlert) bool { return a.Owner == owner })
+}
+
+func (s *AmadeusService) deletePriceAlert(id string, allowed func(models.PriceAlert) bool) error {
+	s.alertMutex.Lock()
+	defer s.alertMutex.Unlock()
+
+	alerts, err := s.loadPriceAlerts()
+	if err != nil {
+		return err
+	}
+
+	for i, a := range alerts {
+		if a.ID == id && allowed(a) {
+			alerts = append(alerts[:i], alerts[i+1:]...)
+			return s.savePriceAlerts(alerts)
+		}
+	}
+	return fmt.Errorf("找不到價格警報: %s", id)
+}
+
+// recordedFare 從價格歷史找出 since 之後最新一筆同航線、同日期的最低價
+// 歷史以基準貨幣儲存，其他貨幣只能使用原始報價相同幣別的紀錄
+func recordedFare(history []models.SearchHistoryRecord, origin, dest, date, currency string, since time.Time) (models.Money, bool) {
+	var latest *models.SearchHistoryRecord
+	for i := range history {
+		h := &history[i]
+		if h.Origin != origin || h.Destination != dest || h.DepartureDate != date || h.RecordDate.Before(since) {
+			continue
+		}
+		if h.PriceCurrency() != currency && h.OriginalCurrency != currency {
+			continue
+		}
+		if latest == nil || h.RecordDate.After(latest.RecordDate) {
+			latest = h
+		}
+	}
+	if latest == nil {
+		return models.Money{}, false
+	}
+	if latest.PriceCurrency() == currency {
+		return latest.Price, true
+	}
+	return latest.OriginalPrice, true
+}
+
+// lowestFare 查詢航線在指定日期的最低價，價格歷史中有近期紀錄時直接使用
+// record 為 false 時只讀取，查詢結果不寫入價格歷史、也不發布 webhook 事件
+func (s *AmadeusService) lowestFare(origin, dest, date, currency string, record bool) (models.Money, error) {
+	if history, err := s.loadSearchHistory(); err == nil {
+		if price, ok := recordedFare(history, origin, dest, date, currency, time.Now().Add(-recordedFareMaxAge)); ok {
+			return price, nil
+		}
+	}
+
+	flights, _, err := s.searchFlights(models.SearchRequest{
+		Origin:        origin,
+		Destination:   dest,
+		DepartureDate: date,
+		Adults:        1,
+		Currency:      currency,
+	}, record)
+	if err != nil {
+		return models.Money{}, err
+	}
+	return lowestFlightPrice(flights)
+}
+
+// CheckPriceAlerts 檢查所有啟用中的價格警報，最低價達到目標即發送通知並停用
+// 持續追蹤的警報不停用，依 ShouldNotify 與每個目標的通知上限決定是否再通知；出發日已過的警報直接停用
+// 查價與通知可能很慢，只在讀取與合併結果時持有 alertMutex，不阻擋建立、列出與刪除警報
+func (s *AmadeusService) CheckPriceAlerts() {
+	s.checkMutex.Lock()
+	defer s.checkMutex.Unlock()
+
+	s.alertMutex.Lock()
+	alerts, err := s.loadPriceAlerts()
+	s.alertMutex.Unlock()
+	if err != nil {
+		log.Printf("⚠️ 讀取價格警報失敗: %v", err)
+		return
+	}
+
+	today := time.Now().Format("2006-01-02")
+	// 同一航線、日期與幣別只查一次
+	fareCache := make(map[string]models.Money)
+	checked := make(map[string]models.PriceAlert)
+
+	for i := range alerts {
+		alert := &alerts[i]
+		if !alert.IsActive {
+			continue
+		}
+		if alert.DepartureDate < today {
+			alert.IsActive = false
+			checked[alert.ID] = *alert
+			log.Printf("⌛ 價格警報 %s 的出發日已過，已停用", alert.ID)
+			continue
+		}
+
+		origin, dest, _ := alert.RouteCodes()
+		key := alert.Route + "|" + alert.DepartureDate + "|" + alert.PriceCurrency()
+		price, ok := fareCache[key]
+		if !ok {
+			price, err = s.lowestFare(origin, dest, alert.DepartureDate, alert.PriceCurrency(), true)
+			if err != nil {
+				log.Printf("⚠️ 價格警報 %s 查詢失敗: %v", alert.ID, err)
+				continue
+			}
+			fareCache[key] = price
+		}
+
+		now := time.Now()
+		triggered, err := alert.IsTriggeredBy(price)
+		if err == nil && triggered && alert.Repeat {
+			triggered, err = alert.ShouldNotify(price, now, priceWatchCooldown)
+		}
+		if err != nil {
+			log.Printf("⚠️ 價格警報 %s 無法比較價格: %v", alert.ID, err)
+			continue
+		}
+		if !triggered {
+			continue
+		}
+
+		if alert.Channel != "" || alert.Owner != "" {
+			target := alert.Channel + ":" + alert.Target
+			if alert.Channel == "" {
+				target = "user:" + alert.Owner
+			}
+			if !s.alertLimiter.allow(target, now, maxAlertsPerTargetPerHour, time.Hour) {
+				log.Printf("⏳ 價格警報 %s 的通知對象本小時已達上限，下次再通知", alert.ID)
+				continue
+			}
+			// 沒有指定管道、建立者也沒有設定偏好時，和以往一樣只記錄觸發
+			err := s.notifyPriceAlert(alert, price)
+			if err != nil && !errors.Is(err, notifications.ErrNoRoute) {
+				log.Printf("⚠️ 價格警報 %s 通知失敗: %v", alert.ID, err)
+				continue
+			}
+			// 只有成功送出的通知才計入上限，送出失敗或沒有管道都不扣額度
+			if err == nil {
+				s.alertLimiter.record(target, now)
+			}
+		}
+
+		s.publish(notifications.EventAlertTriggered, notifications.NewAlertTriggeredEvent(*alert, price))
+
+		if alert.Repeat {
+			alert.LastNotifiedAt = &now
+			alert.LastNotifiedPrice = price
+		} else {
+			alert.IsActive = false
+			alert.TriggeredAt = &now
+			alert.TriggeredPrice = price
+		}
+		checked[alert.ID] = *alert
+		log.Printf("🔔 價格警報 %s 已觸發: %s = %s", alert.ID, key, price)
+	}
+
+	if len(checked) == 0 {
+		return
+	}
+
+	// 檢查期間警報可能被新增或刪除，重新讀取後只合併這次檢查變更的欄位
+	s.alertMutex.Lock()
+	defer s.alertMutex.Unlock()
+
+	current, err := s.loadPriceAlerts()
+	if err != nil {
+		log.Printf("⚠️ 讀取價格警報失敗: %v", err)
+		return
+	}
+	if mergeCheckedAlerts(current, checked) == 0 {
+		return
+	}
+	if err := s.savePriceAlerts(current); err != nil {
+		log.Printf("⚠️ 儲存價格警報失敗: %v", err)
+	}
+}
+
+// mergeCheckedAlerts 將檢查結果（以 ID 對應）合併回最新的警報清單，回傳合併的筆數
+// 檢查期間已刪除的警報直接略過；只覆寫檢查會變更的欄位，保留其他修改
+func mergeCheckedAlerts(current []models.PriceAlert, checked map[string]models.PriceAlert) int {
+	merged := 0
+	for i := range current {
+		c, ok := checked[current[i].ID]
+		if !ok {
+			continue
+		}
+		alert := &current[i]
+		if !c.IsActive {
+			alert.IsActive = false
+		}
+		if c.TriggeredAt != nil {
+			alert.TriggeredAt = c.TriggeredAt
+			alert.TriggeredPrice = c.TriggeredPrice
+		}
+		if c.LastNotifiedAt != nil {
+			alert.LastNotifiedAt = c.LastNotifiedAt
+			alert.LastNotifiedPrice = c.LastNotifiedPrice
+		}
+		merged++
+	}
+	return merged
+}
+
+// notifyPriceAlert 透過警報指定的管道或建立者的通知偏好送出通知
+func (s *AmadeusService) notifyPriceAlert(alert *models.PriceAlert, price models.Money) error {
+	if s.notifier == nil {
+		if alert.Channel == "" {
+			return notifications.ErrNoRoute
+		}
+		return fmt.Errorf("通知服務未啟用")
+	}
+	return s.notifier.Send(notifications.Notification{
+		Kind:       notifications.KindPriceAlert,
+		Channel:    alert.Channel,
+		Target:     alert.Target,
+		UserID:     alert.Owner,
+		PriceAlert: &notifications.PriceAlertEvent{Alert: *alert, Price: price},
+	})
+}
+
+// StartPriceAlertChecker 在背景定期檢查價格警報
+func (s *AmadeusService) StartPriceAlertChecker(interval time.Duration) {
+	go func() {
+		for {
+			s.CheckPriceAlerts()
+			time.Sleep(interval)
+		}
+	}()
+	log.Printf("🔔 價格警報檢查已啟動 (每 %s)", interval)
+}
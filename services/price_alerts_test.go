@@ -0,0 +1,274 @@
+package services
+
+import (
+	"errors"
+	"final/models"
+	"final/notifications"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestPriceAlerts(t *testing.T) *AmadeusService {
+	s := NewAmadeusService(nil)
+	s.priceAlertsPath = filepath.Join(t.TempDir(), "price_alerts.json")
+	return s
+}
+
+func TestCreatePriceAlert(t *testing.T) {
+	tests := []struct {
+		name    string
+		alert   models.PriceAlert
+		wantErr bool
+	}{
+		{"網頁建立（無通知管道）", models.PriceAlert{Route: "tpe-nrt", DepartureDate: "2026-03-01", TargetPrice: models.NewMoney(8000, "")}, false},
+		{"Discord 頻道", models.PriceAlert{Route: "TPE-KIX", DepartureDate: "2026-03-01", TargetPrice: models.NewMoney(120.5, "USD"), Currency: "USD", Channel: "discord", Target: "123"}, false},
+		{"未指定出發日期", models.PriceAlert{Route: "TPE-OKA", TargetPrice: models.NewMoney(5000, "TWD")}, false},
+		{"航線格式錯誤", models.PriceAlert{Route: "TPE", DepartureDate: "2026-03-01", TargetPrice: models.NewMoney(8000, "TWD")}, true},
+		{"日期格式錯誤", models.PriceAlert{Route: "TPE-NRT", DepartureDate: "2026/03/01", TargetPrice: models.NewMoney(8000, "TWD")}, true},
+		{"目標價格為 0", models.PriceAlert{Route: "TPE-NRT", DepartureDate: "2026-03-01"}, true},
+		{"只有通知管道沒有目標", models.PriceAlert{Route: "TPE-NRT", DepartureDate: "2026-03-01", TargetPrice: models.NewMoney(8000, "TWD"), Channel: "discord"}, true},
+		{"未啟用的管道", models.PriceAlert{Route: "TPE-NRT", DepartureDate: "2026-03-01", TargetPrice: models.NewMoney(8000, "TWD"), Channel: "telegram", Target: "123"}, true},
+		{"不開放的 webhook 管道", models.PriceAlert{Route: "TPE-NRT", DepartureDate: "2026-03-01", TargetPrice: models.NewMoney(8000, "TWD"), Channel: "webhook", Target: "http://169.254.169.254/"}, true},
+	}
+
+	s := newTestPriceAlerts(t)
+	notifier := notifications.NewDispatcher(nil, nil)
+	notifier.Register(notifications.NewDiscordChannel(nil, false))
+	s.SetNotifier(notifier)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert, err := s.CreatePriceAlert(tt.alert)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (!alert.IsActive || alert.ID == "" || alert.TargetPrice.Currency != alert.PriceCurrency()) {
+				t.Errorf("建立的警報欄位錯誤: %+v", alert)
+			}
+		})
+	}
+
+	alerts, err := s.ListPriceAlerts()
+	if err != nil || len(alerts) != 3 {
+		t.Fatalf("應保存 3 筆警報: %v %+v", err, alerts)
+	}
+	if alerts[0].Route != "TPE-NRT" || alerts[0].TargetPrice != models.NewMoney(8000, "TWD") {
+		t.Errorf("航線應轉大寫、金額應以 TWD 讀回: %+v", alerts[0])
+	}
+	if alerts[1].TargetPrice != models.NewMoney(120.5, "USD") {
+		t.Errorf("USD 金額應保留小數: %v", alerts[1].TargetPrice)
+	}
+	if want := time.Now().AddDate(0, 0, defaultAlertLeadDays).Format("2006-01-02"); alerts[2].DepartureDate != want {
+		t.Errorf("未指定出發日期應預設為 %s: %q", want, alerts[2].DepartureDate)
+	}
+
+	if err := s.DeletePriceAlert(alerts[0].ID); err != nil {
+		t.Fatalf("刪除失敗: %v", err)
+	}
+	if err := s.DeletePriceAlert("missing"); err == nil {
+		t.Error("刪除不存在的警報應回傳錯誤")
+	}
+	if alerts, _ := s.ListPriceAlerts(); len(alerts) != 2 {
+		t.Errorf("刪除後應剩 2 筆: %+v", alerts)
+	}
+}
+
+func TestPriceAlert_IsTriggeredBy(t *testing.T) {
+	alert := models.PriceAlert{TargetPrice: models.NewMoney(8000, "TWD")}
+	tests := []struct {
+		price   models.Money
+		want    bool
+		wantErr bool
+	}{
+		{models.NewMoney(7999, "TWD"), true, false},
+		{models.NewMoney(8000, "TWD"), true, false},
+		{models.NewMoney(8001, "TWD"), false, false},
+		{models.Money{}, false, false},
+		{models.NewMoney(200, "USD"), false, true},
+	}
+	for _, tt := range tests {
+		got, err := alert.IsTriggeredBy(tt.price)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("IsTriggeredBy(%s) = %v, %v; 預期 %v, wantErr %v", tt.price, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPriceAlert_ShouldNotify(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	last := now.Add(-2 * time.Hour)
+	notified := models.PriceAlert{LastNotifiedAt: &last, LastNotifiedPrice: models.NewMoney(7500, "TWD")}
+
+	tests := []struct {
+		name    string
+		alert   models.PriceAlert
+		price   models.Money
+		now     time.Time
+		want    bool
+		wantErr bool
+	}{
+		{"第一次觸發", models.PriceAlert{}, models.NewMoney(7800, "TWD"), now, true, false},
+		{"價格再創新低", notified, models.NewMoney(7400, "TWD"), now, true, false},
+		{"同樣價格仍在冷卻中", notified, models.NewMoney(7500, "TWD"), now, false, false},
+		{"冷卻時間已過", notified, models.NewMoney(7600, "TWD"), now.Add(priceWatchCooldown), true, false},
+		{"幣別不同", notified, models.NewMoney(230, "USD"), now, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.alert.ShouldNotify(tt.price, tt.now, priceWatchCooldown)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("ShouldNotify = %v, %v; 預期 %v, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNotifyLimiter(t *testing.T) {
+	var l notifyLimiter
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		if !l.allow("discord_dm:u1", now, 2, time.Hour) {
+			t.Fatalf("第 %d 則應允許", i+1)
+		}
+		l.record("discord_dm:u1", now)
+	}
+	if l.allow("discord_dm:u1", now.Add(time.Minute), 2, time.Hour) {
+		t.Error("超過上限應拒絕")
+	}
+	if !l.allow("discord_dm:u2", now, 2, time.Hour) {
+		t.Error("不同對象應分開計算")
+	}
+	if !l.allow("discord_dm:u1", now.Add(time.Hour), 2, time.Hour) {
+		t.Error("視窗過後應再次允許")
+	}
+
+	// 只檢查不記錄（送出失敗）時不扣額度
+	for i := 0; i < 5; i++ {
+		if !l.allow("discord_dm:u3", now, 1, time.Hour) {
+			t.Fatalf("未送出的通知不應計入上限（第 %d 次）", i+1)
+		}
+	}
+}
+
+func TestPriceAlerts_Owner(t *testing.T) {
+	s := newTestPriceAlerts(t)
+	watch := models.PriceAlert{Route: "TPE-NRT", DepartureDate: "2026-03-01", TargetPrice: models.NewMoney(8000, "TWD"), Owner: "u1", Repeat: true, Channel: "discord_dm", Target: "u1"}
+
+	var first *models.PriceAlert
+	for i := 0; i < maxWatchesPerOwner; i++ {
+		a, err := s.CreatePriceAlert(watch)
+		if err != nil {
+			t.Fatalf("第 %d 筆追蹤建立失敗: %v", i+1, err)
+		}
+		if first == nil {
+			first = a
+		}
+	}
+	if _, err := s.CreatePriceAlert(watch); err == nil {
+		t.Error("超過每人追蹤上限應回傳錯誤")
+	}
+
+	other := watch
+	other.Owner, other.Target = "u2", "u2"
+	if _, err := s.CreatePriceAlert(other); err != nil {
+		t.Fatalf("其他使用者不受影響: %v", err)
+	}
+	if owned, _ := s.ListPriceAlertsByOwner("u2"); len(owned) != 1 {
+		t.Errorf("u2 應只有 1 筆追蹤: %+v", owned)
+	}
+
+	if err := s.DeleteOwnedPriceAlert("u2", first.ID); err == nil {
+		t.Error("不能刪除別人的追蹤")
+	}
+	if err := s.DeleteOwnedPriceAlert("u1", first.ID); err != nil {
+		t.Errorf("建立者應可刪除: %v", err)
+	}
+	if owned, _ := s.ListPriceAlertsByOwner("u1"); len(owned) != maxWatchesPerOwner-1 {
+		t.Errorf("刪除後 u1 應剩 %d 筆: %d", maxWatchesPerOwner-1, len(owned))
+	}
+}
+
+func TestMergeCheckedAlerts(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	price := models.NewMoney(7500, "TWD")
+	// 檢查期間 "paused" 被使用者停用、"deleted" 被刪除、"new" 是新建立的
+	current := []models.PriceAlert{
+		{ID: "once", IsActive: true},
+		{ID: "watch", IsActive: true, Repeat: true},
+		{ID: "paused", IsActive: false, Repeat: true},
+		{ID: "new", IsActive: true},
+	}
+	checked := map[string]models.PriceAlert{
+		"once":    {ID: "once", IsActive: false, TriggeredAt: &now, TriggeredPrice: price},
+		"watch":   {ID: "watch", IsActive: true, Repeat: true, LastNotifiedAt: &now, LastNotifiedPrice: price},
+		"paused":  {ID: "paused", IsActive: true, Repeat: true, LastNotifiedAt: &now, LastNotifiedPrice: price},
+		"deleted": {ID: "deleted", IsActive: false, TriggeredAt: &now},
+	}
+
+	if n := mergeCheckedAlerts(current, checked); n != 3 {
+		t.Errorf("合併 %d 筆, 預期 3 筆", n)
+	}
+	if a := current[0]; a.IsActive || a.TriggeredAt == nil || a.TriggeredPrice != price {
+		t.Errorf("單次警報應停用並記錄觸發價格: %+v", a)
+	}
+	if a := current[1]; !a.IsActive || a.LastNotifiedAt == nil || a.LastNotifiedPrice != price {
+		t.Errorf("持續追蹤應記錄通知價格: %+v", a)
+	}
+	if a := current[2]; a.IsActive || a.LastNotifiedAt == nil {
+		t.Errorf("檢查期間被停用的警報不應重新啟用: %+v", a)
+	}
+	if a := current[3]; !a.IsActive || a.TriggeredAt != nil || a.LastNotifiedAt != nil {
+		t.Errorf("檢查期間新建立的警報不應被修改: %+v", a)
+	}
+}
+
+func TestRecordedFare(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	history := []models.SearchHistoryRecord{
+		{Origin: "TPE", Destination: "NRT", DepartureDate: "2026-04-01", Price: models.NewMoney(9000, "TWD"), Currency: "TWD", RecordDate: now.Add(-3 * time.Hour)},
+		{Origin: "TPE", Destination: "NRT", DepartureDate: "2026-04-01", Price: models.NewMoney(8200, "TWD"), Currency: "TWD", RecordDate: now.Add(-10 * time.Minute),
+			OriginalPrice: models.NewMoney(260.5, "USD"), OriginalCurrency: "USD"},
+		{Origin: "TPE", Destination: "KIX", DepartureDate: "2026-04-01", Price: models.NewMoney(5000, "TWD"), Currency: "TWD", RecordDate: now},
+	}
+	since := now.Add(-recordedFareMaxAge)
+
+	if price, ok := recordedFare(history, "TPE", "NRT", "2026-04-01", "TWD", since); !ok || price != models.NewMoney(8200, "TWD") {
+		t.Errorf("應取一小時內最新的紀錄: %v %v", price, ok)
+	}
+	if price, ok := recordedFare(history, "TPE", "NRT", "2026-04-01", "USD", since); !ok || price != models.NewMoney(260.5, "USD") {
+		t.Errorf("非基準貨幣應使用原始報價: %v %v", price, ok)
+	}
+	if _, ok := recordedFare(history, "TPE", "NRT", "2026-04-01", "JPY", since); ok {
+		t.Error("沒有相同幣別的紀錄時應查詢 Amadeus")
+	}
+	if _, ok := recordedFare(history, "TPE", "NRT", "2026-05-01", "TWD", since); ok {
+		t.Error("不同日期不應使用")
+	}
+}
+
+func TestFlightPrices_MixedCurrencies(t *testing.T) {
+	// 沒有匯率服務時 USD 報價無法換算，整批保留原幣別
+	s := NewAmadeusService(nil)
+	flights := s.convertFlights([]models.Flight{
+		{ID: "1", Price: models.NewMoney(5000, "TWD"), Currency: "TWD"},
+		{ID: "2", Price: models.NewMoney(150, "USD"), Currency: "USD"},
+	}, "TWD")
+	if flights[1].Currency != "USD" || !flights[1].OriginalPrice.IsZero() {
+		t.Fatalf("換算失敗時不應部分換算: %+v", flights[1])
+	}
+
+	if _, err := lowestFlightPrice(flights); !errors.Is(err, models.ErrCurrencyMismatch) {
+		t.Errorf("lowestFlightPrice 應回傳 ErrCurrencyMismatch, 結果 %v", err)
+	}
+	if err := sortFlightsByPrice(flights); !errors.Is(err, models.ErrCurrencyMismatch) || flights[0].ID != "1" {
+		t.Errorf("幣別不一致時不應排序: %v %+v", err, flights)
+	}
+
+	same := []models.Flight{{ID: "a", Price: models.NewMoney(5000, "TWD")}, {ID: "b", Price: models.NewMoney(4000, "TWD")}}
+	if err := sortFlightsByPrice(same); err != nil || same[0].ID != "b" {
+		t.Errorf("同幣別應依價格排序: %v %+v", err, same)
+	}
+	if low, err := lowestFlightPrice(same); err != nil || low.Float64() != 4000 {
+		t.Errorf("lowestFlightPrice = %s, %v", low, err)
+	}
+}
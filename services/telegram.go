@@ -0,0 +1,276 @@
+package services
+
+import (
+	"final/commands"
+	"final/notifications"
+	"html"
+	"log"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// telegramPlatform 指令 Context 的平台名稱，也是警報通知管道的名稱
+	telegramPlatform = "telegram"
+	// getUpdates 長輪詢秒數
+	telegramPollTimeout = 30
+	// 取得更新失敗時等待多久再重試
+	telegramRetryDelay = 5 * time.Second
+)
+
+// TelegramBot Telegram 轉接層：以長輪詢接收指令與按鈕，交給共用的指令路由處理
+type TelegramBot struct {
+	*TravelBot
+	Client *notifications.TelegramService
+
+	router   *commands.Router
+	offset   int64
+	stop     chan struct{}
+	stopOnce sync.Once
+	// username 機器人帳號（getMe），群組中只回應 "/price@username" 形式的指令
+	username string
+
+	// handlers 處理中的更新，只在 Stop 時等待；stopped 後不再接新的更新
+	handlerMutex sync.Mutex
+	handlers     sync.WaitGroup
+	stopped      bool
+}
+
+func NewTelegramBot(token string, amadeus *AmadeusService, weather *WeatherService, exchange *ExchangeService, foursquare *FoursquareService, geocoder *GeocodingService) *TelegramBot {
+	client := notifications.NewTelegramService(token)
+	if client == nil {
+		return nil
+	}
+	bot := NewTravelBot(amadeus, weather, exchange, foursquare, geocoder)
+	return &TelegramBot{
+		TravelBot: bot,
+		Client:    client,
+		router:    NewCommandRouter(bot),
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start 取得機器人帳號、設定指令清單並在背景開始長輪詢
+func (t *TelegramBot) Start() {
+	if err := t.identify(); err != nil {
+		log.Printf("⚠️ 取得 Telegram 機器人帳號失敗，群組中指名機器人的指令將被忽略: %v", err)
+	}
+	if err := t.Client.SetMyCommands(telegramCommands(t.router)); err != nil {
+		log.Printf("⚠️ 設定 Telegram 指令清單失敗: %v", err)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-t.stop:
+				return
+			default:
+			}
+			if err := t.poll(telegramPollTimeout); err != nil {
+				log.Printf("⚠️ 取得 Telegram 更新失敗: %v", err)
+				select {
+				case <-t.stop:
+					return
+				case <-time.After(telegramRetryDelay):
+				}
+			}
+		}
+	}()
+	log.Println("🤖 Telegram Bot 已開始接收訊息！")
+}
+
+// Stop 停止輪詢並等待處理中的更新回覆完畢；進行中的長輪詢會在逾時後結束
+func (t *TelegramBot) Stop() {
+	t.stopOnce.Do(func() { close(t.stop) })
+
+	t.handlerMutex.Lock()
+	t.stopped = true
+	t.handlerMutex.Unlock()
+	t.handlers.Wait()
+}
+
+// identify 以 getMe 取得機器人的 username
+func (t *TelegramBot) identify() error {
+	me, err := t.Client.GetMe()
+	if err != nil {
+		return err
+	}
+	t.username = me.Username
+	return nil
+}
+
+// poll 取得一批更新並各自在背景處理，不等待回覆就以新的 offset 取下一批，
+// 慢的指令（查詢航班需要數秒）不會擋住其他使用者
+func (t *TelegramBot) poll(timeout int) error {
+	updates, err := t.Client.GetUpdates(t.offset, timeout)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range updates {
+		if !t.dispatch(u) {
+			break
+		}
+		if u.UpdateID >= t.offset {
+			t.offset = u.UpdateID + 1
+		}
+	}
+	return nil
+}
+
+// dispatch 在背景處理一則更新；已經 Stop 時回傳 false
+func (t *TelegramBot) dispatch(u notifications.TelegramUpdate) bool {
+	t.handlerMutex.Lock()
+	defer t.handlerMutex.Unlock()
+	if t.stopped {
+		return false
+	}
+	t.handlers.Add(1)
+	go func() {
+		defer t.handlers.Done()
+		t.handleUpdate(u)
+	}()
+	return true
+}
+
+func (t *TelegramBot) handleUpdate(u notifications.TelegramUpdate) {
+	switch {
+	case u.Message != nil:
+		t.handleMessage(u.Message)
+	case u.CallbackQuery != nil:
+		t.handleCallback(u.CallbackQuery)
+	}
+}
+
+func (t *TelegramBot) handleMessage(msg *notifications.TelegramMessage) {
+	if msg.From == nil || msg.From.IsBot {
+		return
+	}
+	cmd, _, ok := t.router.Match(msg.Text, "/", t.username)
+	if !ok {
+		return
+	}
+
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	if cmd.Slow {
+		t.Client.SendChatAction(chatID, "typing")
+	}
+	ctx := &commands.Context{Platform: telegramPlatform, Prefix: "/", BotName: t.username, UserID: strconv.FormatInt(msg.From.ID, 10), ChannelID: chatID}
+	reply, _ := t.router.RunText(ctx, msg.Text)
+	t.send(chatID, 0, reply)
+}
+
+func (t *TelegramBot) handleCallback(q *notifications.TelegramCallbackQuery) {
+	if err := t.Client.AnswerCallbackQuery(q.ID, ""); err != nil {
+		log.Printf("⚠️ 回應 Telegram 按鈕失敗: %v", err)
+	}
+	if q.Message == nil {
+		return
+	}
+
+	chatID := strconv.FormatInt(q.Message.Chat.ID, 10)
+	ctx := &commands.Context{Platform: telegramPlatform, Prefix: "/", UserID: strconv.FormatInt(q.From.ID, 10), ChannelID: chatID}
+	reply, ok := t.router.RunAction(ctx, q.Data)
+	if !ok {
+		return
+	}
+	messageID := int64(0)
+	if reply.Update {
+		messageID = q.Message.MessageID
+	}
+	t.send(chatID, messageID, reply)
+}
+
+// send 傳送回覆；messageID 非 0 時編輯該訊息（翻頁、排序）
+func (t *TelegramBot) send(chatID string, messageID int64, reply commands.Reply) {
+	if reply.Empty() {
+		return
+	}
+	msg := notifications.TelegramOutgoing{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        telegramHTML(truncateRunes(reply.PlainText(), notifications.TelegramMessageLimit-96)),
+		ParseMode:   "HTML",
+		ReplyMarkup: telegramKeyboard(reply.Rows),
+	}
+
+	var err error
+	if messageID != 0 {
+		err = t.Client.EditMessageText(msg)
+	} else {
+		_, err = t.Client.SendMessage(msg)
+	}
+	if err != nil {
+		log.Printf("❌ 傳送 Telegram 訊息失敗: %v", err)
+	}
+}
+
+// telegramCommands 依路由註冊的指令產生 setMyCommands 的清單
+func telegramCommands(router *commands.Router) []notifications.TelegramBotCommand {
+	cmds := make([]notifications.TelegramBotCommand, 0, len(router.Commands()))
+	for _, cmd := range router.Commands() {
+		cmds = append(cmds, notifications.TelegramBotCommand{Command: cmd.Name, Description: cmd.Description})
+	}
+	return cmds
+}
+
+// telegramKeyboard 將按鈕列轉成內嵌鍵盤；Telegram 沒有停用狀態，停用的按鈕直接省略，
+// 選單則每個選項一列按鈕
+func telegramKeyboard(rows []commands.Row) *notifications.InlineKeyboardMarkup {
+	var keyboard [][]notifications.InlineKeyboardButton
+	add := func(row []notifications.InlineKeyboardButton, label, emoji, data string) []notifications.InlineKeyboardButton {
+		if len(data) > notifications.TelegramCallbackDataLimit {
+			log.Printf("⚠️ 按鈕資料超過 Telegram 上限，已略過: %s", data)
+			return row
+		}
+		if emoji != "" {
+			label = emoji + " " + label
+		}
+		return append(row, notifications.InlineKeyboardButton{Text: label, CallbackData: data})
+	}
+
+	for _, r := range rows {
+		var buttons []notifications.InlineKeyboardButton
+		for _, b := range r.Buttons {
+			if !b.Disabled {
+				buttons = add(buttons, b.Label, b.Emoji, b.Data)
+			}
+		}
+		if len(buttons) > 0 {
+			keyboard = append(keyboard, buttons)
+		}
+		if m := r.Menu; m != nil {
+			for _, o := range m.Options {
+				if option := add(nil, o.Label, o.Emoji, m.Data+":"+o.Value); len(option) > 0 {
+					keyboard = append(keyboard, option)
+				}
+			}
+		}
+	}
+
+	if len(keyboard) == 0 {
+		return nil
+	}
+	return &notifications.InlineKeyboardMarkup{InlineKeyboard: keyboard}
+}
+
+var (
+	markdownBold = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	markdownCode = regexp.MustCompile("`([^`]+)`")
+)
+
+// telegramHTML 將回覆中使用的 Markdown（**粗體**、`程式碼`）轉成 Telegram 的 HTML 格式
+func telegramHTML(text string) string {
+	text = html.EscapeString(text)
+	text = markdownCode.ReplaceAllString(text, "<code>$1</code>")
+	return markdownBold.ReplaceAllString(text, "<b>$1</b>")
+}
+
+func truncateRunes(s string, limit int) string {
+	if runes := []rune(s); len(runes) > limit {
+		return string(runes[:limit]) + "…"
+	}
+	return s
+}
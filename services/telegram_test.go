@@ -0,0 +1,149 @@
+package services
+
+import (
+	"encoding/json"
+	"final/commands"
+	"final/notifications"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTelegramBot_Poll(t *testing.T) {
+	var mu sync.Mutex
+	sent := make(map[string][]map[string]interface{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		var params map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&params)
+		mu.Lock()
+		sent[method] = append(sent[method], params)
+		mu.Unlock()
+
+		if method == "getMe" {
+			w.Write([]byte(`{"ok":true,"result":{"id":7,"is_bot":true,"username":"GoSkyAlertBot"}}`))
+			return
+		}
+		if method == "getUpdates" {
+			w.Write([]byte(`{"ok":true,"result":[
+				{"update_id":10,"message":{"message_id":1,"from":{"id":42},"chat":{"id":42,"type":"private"},"text":"/start"}},
+				{"update_id":11,"message":{"message_id":2,"from":{"id":42},"chat":{"id":42,"type":"private"},"text":"/price@GoSkyAlertBot TPE"}},
+				{"update_id":12,"message":{"message_id":3,"from":{"id":42},"chat":{"id":42,"type":"private"},"text":"你好"}},
+				{"update_id":13,"message":{"message_id":5,"from":{"id":42},"chat":{"id":-100,"type":"group"},"text":"/price@OtherBot TPE"}},
+				{"update_id":14,"callback_query":{"id":"cb1","from":{"id":42},"message":{"message_id":4,"chat":{"id":42}},"data":"price:page:missing:1:price"}}
+			]}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":99,"chat":{"id":42}}}`))
+	}))
+	defer server.Close()
+
+	bot := NewTelegramBot("TEST:TOKEN", nil, nil, nil, nil, nil)
+	bot.Client.BaseURL = server.URL
+	if err := bot.identify(); err != nil || bot.username != "GoSkyAlertBot" {
+		t.Fatalf("getMe 失敗: %v %q", err, bot.username)
+	}
+	if err := bot.poll(0); err != nil {
+		t.Fatalf("poll 失敗: %v", err)
+	}
+	// poll 不等待回覆，Stop 會等處理中的更新完成
+	bot.Stop()
+	if bot.offset != 15 {
+		t.Errorf("offset 應推進到最後一則更新 +1，實際 %d", bot.offset)
+	}
+
+	var texts []string
+	for _, msg := range sent["sendMessage"] {
+		if msg["chat_id"] != "42" || msg["parse_mode"] != "HTML" {
+			t.Errorf("訊息參數錯誤: %+v", msg)
+		}
+		texts = append(texts, msg["text"].(string))
+	}
+	all := strings.Join(texts, "\n---\n")
+	if len(texts) != 3 {
+		t.Fatalf("應回覆 /start、/price 與過期按鈕共 3 則，非指令訊息與指名其他機器人的指令不回覆:\n%s", all)
+	}
+	for _, want := range []string{"<b>👋 GoSkyAlert 全能旅遊機器人</b>", "<code>/price &lt;destination&gt; &lt;date&gt; [origin]</code>", "缺少參數 date", "搜尋結果已過期"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("回覆缺少 %q:\n%s", want, all)
+		}
+	}
+	if len(sent["answerCallbackQuery"]) != 1 || len(sent["editMessageText"]) != 0 {
+		t.Errorf("按鈕應先回應 callback，過期時另發訊息而不是編輯: %+v", sent)
+	}
+}
+
+func TestTelegramBot_PollDoesNotWaitForHandlers(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/getUpdates") {
+			w.Write([]byte(`{"ok":true,"result":[{"update_id":1,"message":{"message_id":1,"from":{"id":42},"chat":{"id":42,"type":"private"},"text":"/start"}}]}`))
+			return
+		}
+		// 模擬很慢的回覆
+		<-release
+		w.Write([]byte(`{"ok":true,"result":{"message_id":99,"chat":{"id":42}}}`))
+	}))
+	defer server.Close()
+
+	bot := NewTelegramBot("TEST:TOKEN", nil, nil, nil, nil, nil)
+	bot.Client.BaseURL = server.URL
+
+	done := make(chan error, 1)
+	go func() { done <- bot.poll(0) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("poll 失敗: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("poll 不應等待處理中的指令回覆")
+	}
+
+	close(release)
+	bot.Stop()
+	// Stop 之後不再處理新的更新，offset 也不前進
+	if err := bot.poll(0); err != nil || bot.offset != 2 {
+		t.Errorf("Stop 後 offset = %d, err = %v", bot.offset, err)
+	}
+}
+
+func TestTelegramKeyboard(t *testing.T) {
+	r := testFlightResults("down")
+	keyboard := telegramKeyboard(priceRows("abc123", r, 0, flightSortPrice))
+	if keyboard == nil {
+		t.Fatal("應產生內嵌鍵盤")
+	}
+
+	// 第一頁的「上一頁」停用，Telegram 直接省略；選單每個選項一列
+	rows := keyboard.InlineKeyboard
+	if len(rows) != 1+flightsPerPage || len(rows[0]) != 3 || rows[0][0].Text != "▶️ 下一頁" {
+		t.Fatalf("鍵盤結構錯誤: %+v", rows)
+	}
+	if rows[1][0].CallbackData != "price:alert:abc123:0" {
+		t.Errorf("選單選項應帶上選單資料與值: %s", rows[1][0].CallbackData)
+	}
+	for _, row := range rows {
+		for _, b := range row {
+			if len(b.CallbackData) > notifications.TelegramCallbackDataLimit {
+				t.Errorf("callback_data 超過 64 bytes: %s", b.CallbackData)
+			}
+		}
+	}
+
+	long := []commands.Row{{Buttons: []commands.Button{{Label: "x", Data: strings.Repeat("a", 65)}}}}
+	if telegramKeyboard(long) != nil {
+		t.Error("超過上限的按鈕應略過")
+	}
+}
+
+func TestTelegramHTML(t *testing.T) {
+	got := telegramHTML("**A<B** 使用 `/watch <id>` & 完成")
+	want := "<b>A&lt;B</b> 使用 <code>/watch &lt;id&gt;</code> &amp; 完成"
+	if got != want {
+		t.Errorf("telegramHTML = %q, 預期 %q", got, want)
+	}
+}
@@ -5,33 +5,99 @@ import (
 	"final/models"
 	"fmt"
 	"io"
+	"log"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 )
 
 type WeatherService struct {
 	APIKey  string
 	BaseURL string
+	// 預報最多可取得的天數（WeatherAPI 付費方案最多 14 天，免費方案實際只回傳 3 天）
+	MaxForecastDays int
+	// 產生天氣描述用的規則引擎，nil 時使用預設規則
+	Advice *AdviceEngine
 }
 
+// WeatherAPI forecast.json 允許的最大天數
+const maxProviderForecastDays = 14
+
+// 單次行程展望最多計算的天數
+const maxOutlookDays = 30
+
 func NewWeatherService(apiKey string) *WeatherService {
 	return &WeatherService{
-		APIKey:  apiKey,
-		BaseURL: "http://api.weatherapi.com/v1",
+		APIKey:          apiKey,
+		BaseURL:         "http://api.weatherapi.com/v1",
+		MaxForecastDays: maxProviderForecastDays,
 	}
 }
 
-// GetWeather 獲取指定城市和日期的天氣資訊
+// today 取得今天的日期（以日期字串為準，避免時區造成的誤差）
+func today() time.Time {
+	t, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	return t
+}
+
+// forecastDaysFor 計算要涵蓋指定日期需要請求的預報天數
+func (s *WeatherService) forecastDaysFor(date string) int {
+	maxDays := s.MaxForecastDays
+	if maxDays <= 0 || maxDays > maxProviderForecastDays {
+		maxDays = maxProviderForecastDays
+	}
+
+	target, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return maxDays
+	}
+	days := int(target.Sub(today()).Hours()/24) + 1
+	if days < 1 {
+		return 1
+	}
+	if days > maxDays {
+		return maxDays
+	}
+	return days
+}
+
+// IsBeyondForecast 判斷日期是否超出預報範圍
+func (s *WeatherService) IsBeyondForecast(date string) bool {
+	target, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return false
+	}
+	maxDays := s.MaxForecastDays
+	if maxDays <= 0 || maxDays > maxProviderForecastDays {
+		maxDays = maxProviderForecastDays
+	}
+	return !target.Before(today().AddDate(0, 0, maxDays))
+}
+
+// GetWeather 獲取指定城市和日期的天氣資訊（預報天數會涵蓋到該日期為止）
 func (s *WeatherService) GetWeather(city, date string) (*models.WeatherResponse, error) {
+	return s.GetForecast(city, s.forecastDaysFor(date))
+}
+
+// GetForecast 獲取指定城市未來 days 天的預報
+func (s *WeatherService) GetForecast(city string, days int) (*models.WeatherResponse, error) {
+	if days < 1 {
+		days = 1
+	}
+	if days > maxProviderForecastDays {
+		days = maxProviderForecastDays
+	}
+
 	// 構建請求 URL
 	endpoint := "/forecast.json"
 	params := url.Values{}
 	params.Add("key", s.APIKey)
 	params.Add("q", city)
-	params.Add("days", "3") // 獲取3天預報
-	params.Add("aqi", "no")
-	params.Add("alerts", "no")
+	params.Add("days", strconv.Itoa(days))
+	params.Add("aqi", "yes")
+	params.Add("alerts", "yes")
 
 	requestURL := fmt.Sprintf("%s%s?%s", s.BaseURL, endpoint, params.Encode())
 
@@ -70,7 +136,7 @@ func (s *WeatherService) GetCurrentWeather(city string) (*models.WeatherResponse
 	params := url.Values{}
 	params.Add("key", s.APIKey)
 	params.Add("q", city)
-	params.Add("aqi", "no")
+	params.Add("aqi", "yes")
 
 	requestURL := fmt.Sprintf("%s%s?%s", s.BaseURL, endpoint, params.Encode())
 
@@ -120,8 +186,14 @@ func (s *WeatherService) ValidateAPIKey() error {
 }
 
 // GetWeatherSummary 獲取天氣摘要（用於航班顯示）
-// GetWeatherSummary 獲取天氣摘要（用於航班顯示）
+// 日期在預報範圍內使用預報；超出範圍時改用歷年同月的氣候平均，並標記 BeyondForecast
 func (s *WeatherService) GetWeatherSummary(city, date string) (*models.WeatherSummary, error) {
+	if s.IsBeyondForecast(date) {
+		if summary := climateSummary(city, date); summary != nil {
+			return summary, nil
+		}
+	}
+
 	weather, err := s.GetWeather(city, date)
 	if err != nil {
 		return nil, err
@@ -136,6 +208,13 @@ func (s *WeatherService) GetWeatherSummary(city, date string) (*models.WeatherSu
 		}
 	}
 
+	// 預報沒有涵蓋到這一天（例如免費方案只有 3 天），優先改用氣候平均
+	if targetForecast == nil {
+		if summary := climateSummary(city, date); summary != nil {
+			return summary, nil
+		}
+	}
+
 	summary := &models.WeatherSummary{
 		City:      city, // 這裡會顯示城市名稱
 		Date:      date,
@@ -144,6 +223,8 @@ func (s *WeatherService) GetWeatherSummary(city, date string) (*models.WeatherSu
 		Icon:      weather.Current.Condition.Icon,
 		Humidity:  weather.Current.Humidity,
 		WindSpeed: weather.Current.WindKph,
+		UV:        weather.Current.UV,
+		Source:    models.WeatherSourceCurrent,
 	}
 
 	// 如果有預報數據，使用預報數據
@@ -152,50 +233,197 @@ func (s *WeatherService) GetWeatherSummary(city, date string) (*models.WeatherSu
 		summary.Condition = targetForecast.Day.Condition.Text
 		summary.Icon = targetForecast.Day.Condition.Icon
 		summary.ChanceOfRain = targetForecast.Day.DailyChanceOfRain
+		summary.UV = targetForecast.Day.UV
+		summary.Source = models.WeatherSourceForecast
+	} else {
+		summary.BeyondForecast = true
+	}
+
+	// 空氣品質：優先使用當天預報，沒有時只在今明兩天使用目前觀測值
+	if targetForecast != nil && targetForecast.Day.AirQuality != nil {
+		summary.AirQuality = targetForecast.Day.AirQuality
+	} else if date <= today().AddDate(0, 0, 1).Format("2006-01-02") {
+		summary.AirQuality = weather.Current.AirQuality
+	}
+	if summary.AirQuality != nil && summary.AirQuality.USEPAIndex > 0 {
+		summary.AQILevel = models.AQILevel(summary.AirQuality.USEPAIndex)
+	}
+	if summary.UV > 0 {
+		summary.UVLevel = models.UVLevel(summary.UV)
 	}
 
+	summary.Description = s.generateWeatherDescription(summary)
 	return summary, nil
 }
 
-// generateWeatherDescription 生成天氣描述
-func (s *WeatherService) generateWeatherDescription(condition string, tempC float64, chanceOfRain int) string {
-	description := condition
+// climateSummary 以氣候平均值組成天氣摘要，沒有資料時回傳 nil
+func climateSummary(city, date string) *models.WeatherSummary {
+	normal, ok := GetClimateNormalForDate(city, date)
+	if !ok {
+		return nil
+	}
 
-	if tempC < 10 {
-		description += "，天氣寒冷"
-	} else if tempC > 30 {
-		description += "，天氣炎熱"
-	} else if tempC >= 10 && tempC <= 25 {
-		description += "，天氣舒適"
+	return &models.WeatherSummary{
+		City:           city,
+		Date:           date,
+		AvgTemp:        normal.AvgTempC,
+		Condition:      fmt.Sprintf("歷年同期平均 %.0f~%.0f°C", normal.AvgLowC, normal.AvgHighC),
+		ChanceOfRain:   climateChanceOfRain(normal),
+		Description:    fmt.Sprintf("超出預報範圍，依歷年 %d 月平均：月降雨約 %.0f mm、%d 天有雨", normal.Month, normal.PrecipMM, normal.RainyDays),
+		Source:         models.WeatherSourceClimate,
+		BeyondForecast: true,
 	}
+}
 
-	if chanceOfRain > 50 {
-		description += "，降雨機率高"
-	} else if chanceOfRain > 20 {
-		description += "，可能有降雨"
+// tripDateRange 解析行程的出發與回程日期（未填回程日期視為單日），最多涵蓋 maxOutlookDays 天
+func tripDateRange(startDate, endDate string) (time.Time, time.Time, error) {
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("無效的出發日期: %s", startDate)
+	}
+	end := start
+	if endDate != "" {
+		end, err = time.Parse("2006-01-02", endDate)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("無效的回程日期: %s", endDate)
+		}
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("回程日期不可早於出發日期")
+	}
+	if end.Sub(start).Hours()/24 >= maxOutlookDays {
+		end = start.AddDate(0, 0, maxOutlookDays-1)
+	}
+	return start, end, nil
+}
+
+// GetTripOutlook 取得從 startDate 到 endDate（含）每一天的天氣展望
+// 預報範圍內使用預報，超出範圍的日期改用歷年同月的氣候平均
+func (s *WeatherService) GetTripOutlook(city, startDate, endDate string) (*models.WeatherOutlook, error) {
+	start, end, err := tripDateRange(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	outlook := &models.WeatherOutlook{
+		City:      city,
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+	}
+
+	// 只有行程的第一天落在預報範圍內才需要呼叫 API；沒有金鑰時只使用氣候平均
+	forecastByDate := make(map[string]models.ForecastDay)
+	if s.APIKey != "" && !s.IsBeyondForecast(outlook.StartDate) {
+		weather, err := s.GetWeather(city, outlook.EndDate)
+		if err != nil {
+			log.Printf("⚠️ 取得 %s 預報失敗，改用氣候平均: %v", city, err)
+		} else {
+			for _, day := range weather.Forecast.Forecastday {
+				forecastByDate[day.Date] = day
+				if day.Date > outlook.ForecastHorizon {
+					outlook.ForecastHorizon = day.Date
+				}
+			}
+		}
+	}
+
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		day := models.DailyOutlook{Date: date}
+
+		if fc, ok := forecastByDate[date]; ok {
+			day.Source = models.WeatherSourceForecast
+			day.MaxTemp = fc.Day.MaxTempC
+			day.MinTemp = fc.Day.MinTempC
+			day.AvgTemp = fc.Day.AvgTempC
+			day.Condition = fc.Day.Condition.Text
+			day.Icon = fc.Day.Condition.Icon
+			day.ChanceOfRain = fc.Day.DailyChanceOfRain
+			day.PrecipMM = fc.Day.TotalPrecipMM
+			outlook.ForecastDays++
+		} else if normal, ok := GetClimateNormal(city, int(d.Month())); ok {
+			day.Source = models.WeatherSourceClimate
+			day.BeyondForecast = true
+			day.MaxTemp = normal.AvgHighC
+			day.MinTemp = normal.AvgLowC
+			day.AvgTemp = normal.AvgTempC
+			day.Condition = "歷年同期平均"
+			day.ChanceOfRain = climateChanceOfRain(normal)
+			day.PrecipMM = math.Round(normal.PrecipMM/30*10) / 10
+			outlook.ClimateDays++
+		} else {
+			day.Source = models.WeatherSourceUnavailable
+			day.BeyondForecast = true
+		}
+
+		if day.Source != models.WeatherSourceUnavailable {
+			first := outlook.ForecastDays+outlook.ClimateDays == 1
+			if first || day.MinTemp < outlook.MinTemp {
+				outlook.MinTemp = day.MinTemp
+			}
+			if first || day.MaxTemp > outlook.MaxTemp {
+				outlook.MaxTemp = day.MaxTemp
+			}
+			if day.ChanceOfRain > 50 {
+				outlook.RainyDays++
+			}
+		}
+
+		outlook.Days = append(outlook.Days, day)
+	}
+
+	if outlook.ForecastDays == 0 && outlook.ClimateDays == 0 {
+		return outlook, fmt.Errorf("沒有 %s 的預報或氣候資料", city)
+	}
+
+	return outlook, nil
+}
+
+// generateWeatherDescription 生成天氣描述（規則見 advice_rules.json 的 weather 範圍）
+func (s *WeatherService) generateWeatherDescription(summary *models.WeatherSummary) string {
+	engine := s.Advice
+	if engine == nil {
+		engine = DefaultAdviceEngine()
+	}
+
+	facts := AdviceFacts{
+		"temp": summary.AvgTemp,
+		"rain": float64(summary.ChanceOfRain),
+	}
+	if summary.UV > 0 {
+		facts["uv"] = summary.UV
+	}
+	if summary.AirQuality != nil && summary.AirQuality.USEPAIndex > 0 {
+		facts["aqi"] = float64(summary.AirQuality.USEPAIndex)
+	}
+
+	description := summary.Condition
+	items := engine.Evaluate(AdviceScopeWeather, facts, AdviceLangZH)
+	for _, item := range items {
+		description += "，" + item.Message
 	}
 
 	return description
 }
 
 // IsWeatherSuitableForTravel 判斷天氣是否適合旅行
+// 以目前天氣與今明兩天的警特報評分，風險達「高」以上即視為不適合
 func (s *WeatherService) IsWeatherSuitableForTravel(weather *models.WeatherResponse) bool {
 	if weather == nil {
 		return true // 如果沒有天氣數據，默認適合旅行
 	}
 
-	// 檢查當前天氣條件
+	factors := riskFactors{}
 	current := weather.Current
-	if current.Condition.Text == "Heavy rain" ||
-		current.Condition.Text == "Thunderstorm" ||
-		current.Condition.Text == "Snow" {
-		return false
-	}
-
-	// 檢查風速是否過大
-	if current.WindKph > 50 {
-		return false
-	}
-
-	return true
+	scoreConditions(riskConditions{
+		Code:    current.Condition.Code,
+		GustKph: current.GustKph,
+		WindKph: current.WindKph,
+		VisKM:   current.VisKM,
+	}, factors)
+	scoreAlerts(weather.Alerts.Alert, time.Now(), factors)
+
+	score, _ := factors.total()
+	level := models.RiskLevelFor(score)
+	return level != models.RiskLevelHigh && level != models.RiskLevelSevere
 }
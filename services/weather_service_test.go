@@ -0,0 +1,128 @@
+package services
+
+import (
+	"encoding/json"
+	"final/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func dayOffset(n int) string {
+	return today().AddDate(0, 0, n).Format("2006-01-02")
+}
+
+func TestForecastHorizon(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxDays    int
+		date       string
+		wantDays   int
+		wantBeyond bool
+	}{
+		{"今天", 3, dayOffset(0), 1, false},
+		{"明天", 3, dayOffset(1), 2, false},
+		{"預報最後一天", 3, dayOffset(2), 3, false},
+		{"剛超出預報範圍", 3, dayOffset(3), 3, true},
+		{"過去的日期", 3, dayOffset(-5), 1, false},
+		{"無效日期", 3, "2026/03/01", 3, false},
+		{"未設定時使用供應商上限", 0, dayOffset(13), 14, false},
+		{"供應商上限的隔天", 0, dayOffset(14), 14, true},
+		{"設定超過供應商上限", 30, dayOffset(20), 14, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &WeatherService{MaxForecastDays: tt.maxDays}
+			if got := s.forecastDaysFor(tt.date); got != tt.wantDays {
+				t.Errorf("forecastDaysFor(%s) = %d, 預期 %d", tt.date, got, tt.wantDays)
+			}
+			if got := s.IsBeyondForecast(tt.date); got != tt.wantBeyond {
+				t.Errorf("IsBeyondForecast(%s) = %v, 預期 %v", tt.date, got, tt.wantBeyond)
+			}
+		})
+	}
+}
+
+// newTestWeatherService 模擬免費方案：不論請求幾天，最多只回傳從今天起 3 天的預報
+func newTestWeatherService(t *testing.T) (*WeatherService, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+		var resp models.WeatherResponse
+		for i := 0; i < days && i < 3; i++ {
+			var day models.ForecastDay
+			day.Date = dayOffset(i)
+			day.Day.MaxTempC = 20 + float64(i)
+			day.Day.MinTempC = 10
+			day.Day.DailyChanceOfRain = 80
+			resp.Forecast.Forecastday = append(resp.Forecast.Forecastday, day)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	s := NewWeatherService("test-key")
+	s.BaseURL = server.URL
+	s.MaxForecastDays = 3
+	return s, &calls
+}
+
+func TestGetTripOutlook_ForecastBoundary(t *testing.T) {
+	tests := []struct {
+		name         string
+		city         string
+		start, end   string
+		noKey        bool
+		wantCalls    int32
+		wantForecast int
+		wantClimate  int
+		wantHorizon  string
+		wantErr      bool
+	}{
+		{"跨越預報範圍", "Tokyo", dayOffset(1), dayOffset(4), false, 1, 2, 2, dayOffset(2), false},
+		{"全部在預報範圍內", "Tokyo", dayOffset(0), dayOffset(2), false, 1, 3, 0, dayOffset(2), false},
+		{"出發日剛超出預報範圍不呼叫 API", "Tokyo", dayOffset(3), dayOffset(5), false, 0, 0, 3, "", false},
+		{"沒有金鑰只用氣候平均", "Tokyo", dayOffset(0), dayOffset(1), true, 0, 0, 2, "", false},
+		{"單日行程", "Tokyo", dayOffset(2), "", false, 1, 1, 0, dayOffset(2), false},
+		{"超過展望上限截斷為 30 天", "Tokyo", dayOffset(10), dayOffset(60), false, 0, 0, maxOutlookDays, "", false},
+		{"沒有氣候資料的城市", "Atlantis", dayOffset(5), dayOffset(6), false, 0, 0, 0, "", true},
+		{"回程早於出發", "Tokyo", dayOffset(3), dayOffset(1), false, 0, 0, 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, calls := newTestWeatherService(t)
+			if tt.noKey {
+				s.APIKey = ""
+			}
+
+			outlook, err := s.GetTripOutlook(tt.city, tt.start, tt.end)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetTripOutlook() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(calls); got != tt.wantCalls {
+				t.Errorf("API 請求 %d 次, 預期 %d 次", got, tt.wantCalls)
+			}
+			if tt.wantErr {
+				return
+			}
+			if outlook.ForecastDays != tt.wantForecast || outlook.ClimateDays != tt.wantClimate {
+				t.Errorf("預報 %d 天、氣候平均 %d 天, 預期 %d / %d", outlook.ForecastDays, outlook.ClimateDays, tt.wantForecast, tt.wantClimate)
+			}
+			if outlook.ForecastHorizon != tt.wantHorizon {
+				t.Errorf("ForecastHorizon = %q, 預期 %q", outlook.ForecastHorizon, tt.wantHorizon)
+			}
+			if len(outlook.Days) != tt.wantForecast+tt.wantClimate {
+				t.Fatalf("共 %d 天", len(outlook.Days))
+			}
+			for _, day := range outlook.Days {
+				beyond := day.Date > tt.wantHorizon
+				if day.BeyondForecast != beyond || (day.Source == models.WeatherSourceForecast) == beyond {
+					t.Errorf("%s 標記錯誤: source=%s beyond=%v", day.Date, day.Source, day.BeyondForecast)
+				}
+			}
+		})
+	}
+}
@@ -37,29 +37,6 @@ class FlightSearchApp {
         });
     }
 
-   async geocodeLocation(query) {
-        try {
-            console.log('🗺️ 地理編碼搜尋:', query);
-            // 使用免費的 Nominatim API (OpenStreetMap)
-            const response = await fetch(`https://nominatim.openstreetmap.org/search?format=json&q=${encodeURIComponent(query)}&limit=1`);
-            const data = await response.json();
-            
-            if (data && data.length > 0) {
-                console.log('📍 找到位置:', data[0].display_name);
-                return {
-                    lat: parseFloat(data[0].lat),
-                    lng: parseFloat(data[0].lon),
-                    displayName: data[0].display_name
-                };
-            }
-            console.warn('❌ 找不到位置:', query);
-            return null;
-        } catch (error) {
-            console.error('地理編碼錯誤:', error);
-            return null;
-        }
-    }
-
     // 初始化景點搜尋功能
     initAttractionsSearch() {
         const searchBtn = document.getElementById('searchAttractionsBtn');
@@ -88,12 +65,46 @@ class FlightSearchApp {
                 });
             }
             
+            this.loadAttractionCategories();
             console.log('✅ 景點搜尋功能初始化完成');
         } else {
             console.error('❌ 找不到景點搜尋按鈕');
         }
     }
 
+    // 從後端載入階層式景點類別，填入類別下拉選單
+    async loadAttractionCategories() {
+        const categorySelect = document.getElementById('attractionCategory');
+        if (!categorySelect) return;
+
+        try {
+            const response = await fetch('/api/attractions/categories?lang=zh-TW');
+            const data = await response.json();
+            if (!data.success || !Array.isArray(data.categories)) return;
+
+            categorySelect.innerHTML = '<option value="">所有類別</option>';
+            data.categories.forEach(category => {
+                const group = document.createElement('optgroup');
+                group.label = category.name;
+
+                const all = document.createElement('option');
+                all.value = category.slug;
+                all.textContent = `全部${category.name}`;
+                group.appendChild(all);
+
+                (category.children || []).forEach(child => {
+                    const option = document.createElement('option');
+                    option.value = child.slug;
+                    option.textContent = child.name;
+                    group.appendChild(option);
+                });
+                categorySelect.appendChild(group);
+            });
+        } catch (error) {
+            console.warn('⚠️ 景點類別載入失敗，使用預設選項:', error);
+        }
+    }
+
     // 處理景點搜尋 - 使用地理編碼版本
     async handleAttractionsSearch() {
         console.log('🔍 開始搜尋景點...');
@@ -126,19 +137,9 @@ class FlightSearchApp {
         this.hideAttractionsResults();
 
         try {
-            // 第一步：地理編碼，將地名轉為經緯度
-            const geocodeResult = await this.geocodeLocation(locationQuery);
-            
-            if (!geocodeResult) {
-                throw new Error(`找不到地點 "${locationQuery}"，請嘗試更明確的名稱`);
-            }
-
-            console.log('🎯 地理編碼結果:', geocodeResult);
-            
-            // 第二步：使用經緯度搜尋景點
+            // 地名、城市或機場代碼都交給後端解析成座標
             const params = new URLSearchParams({
-                lat: geocodeResult.lat.toString(),
-                lng: geocodeResult.lng.toString(),
+                place: locationQuery,
                 radius: radiusSelect ? radiusSelect.value : '1000'
             });
 
@@ -155,6 +156,9 @@ class FlightSearchApp {
             const response = await fetch(apiUrl);
             console.log('📡 API 回應狀態:', response.status);
             
+            if (response.status === 404) {
+                throw new Error(`找不到地點 "${locationQuery}"，請嘗試更明確的名稱`);
+            }
             if (!response.ok) {
                 const errorText = await response.text();
                 throw new Error(`HTTP錯誤: ${response.status} - ${errorText}`);
@@ -169,7 +173,7 @@ class FlightSearchApp {
                 // 在結果中顯示地點名稱
                 const meta = data.meta || { 
                     radius: radiusSelect ? radiusSelect.value : '1000',
-                    location: geocodeResult.displayName
+                    location: locationQuery
                 };
                 this.displayAttractionsResults(data.data, meta);
             } else {
@@ -671,12 +675,58 @@ class FlightSearchApp {
                     console.error(`❌ 創建航班卡片 ${index + 1} 失敗:`, error);
                 }
             });
+
+            // 目的地推薦景點（非同步載入，不影響航班顯示）
+            this.loadDestinationAttractions(data.data.meta?.destination);
         }
 
         this.showElement('results');
         console.log('✅ 結果顯示完成');
     }
 
+    // 載入目的地附近的推薦景點
+    async loadDestinationAttractions(destination) {
+        const section = document.getElementById('destinationAttractions');
+        const listElement = document.getElementById('destinationAttractionsList');
+        if (!section || !listElement) return;
+
+        section.classList.add('hidden');
+        listElement.innerHTML = '';
+        if (!destination) return;
+
+        try {
+            const params = new URLSearchParams({ airport: destination, radius: '5000' });
+            const response = await fetch(`/api/attractions/search?${params}`);
+            if (!response.ok) {
+                console.warn('⚠️ 目的地景點載入失敗:', response.status);
+                return;
+            }
+
+            const data = await response.json();
+            if (!data.success || !Array.isArray(data.data) || data.data.length === 0) return;
+
+            const location = data.meta?.location || destination;
+            document.getElementById('destinationAttractionsTitle').textContent = `${location} 推薦景點`;
+            data.data.slice(0, 6).forEach(attraction => {
+                listElement.appendChild(this.createAttractionCard(attraction));
+            });
+            section.classList.remove('hidden');
+        } catch (error) {
+            console.error('❌ 目的地景點載入錯誤:', error);
+        }
+    }
+
+    formatUV(weather) {
+        if (!weather.uv) return '-';
+        return `${weather.uv} (${weather.uv_level || ''})`;
+    }
+
+    formatAirQuality(weather) {
+        const aq = weather.air_quality;
+        if (!aq || !aq['us-epa-index']) return '-';
+        return `${weather.aqi_level} · PM2.5 ${Math.round(aq.pm2_5)} · PM10 ${Math.round(aq.pm10)}`;
+    }
+
     // 顯示天氣資訊 - 修正名稱
     displayWeatherInfo(weatherInfo) {
         console.log('🌤️ 顯示天氣資訊:', weatherInfo);
@@ -689,6 +739,8 @@ class FlightSearchApp {
             document.getElementById('originHumidity').textContent = origin.humidity;
             document.getElementById('originWind').textContent = origin.wind_speed;
             document.getElementById('originRain').textContent = origin.chance_of_rain || 0;
+            document.getElementById('originUV').textContent = this.formatUV(origin);
+            document.getElementById('originAQI').textContent = this.formatAirQuality(origin);
             
             const originCityElement = document.querySelector('#originWeather h4');
             if (originCityElement) {
@@ -710,6 +762,8 @@ class FlightSearchApp {
             document.getElementById('destinationHumidity').textContent = destination.humidity;
             document.getElementById('destinationWind').textContent = destination.wind_speed;
             document.getElementById('destinationRain').textContent = destination.chance_of_rain || 0;
+            document.getElementById('destinationUV').textContent = this.formatUV(destination);
+            document.getElementById('destinationAQI').textContent = this.formatAirQuality(destination);
             
             const destinationCityElement = document.querySelector('#destinationWeather h4');
             if (destinationCityElement) {